@@ -3,7 +3,9 @@ package v1
 import (
 	"fmt"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"net/url"
+	"time"
 )
 
 // Default hosts for remote git servers
@@ -13,16 +15,28 @@ const (
 
 	GitlabDefaultAPIUrl = "https://gitlab.com"
 	GitlabDefaultHost   = "https://gitlab.com"
+
+	GiteaDefaultAPIUrl = "https://gitea.com"
+	GiteaDefaultHost   = "https://gitea.com"
+
+	BitbucketDefaultAPIUrl = "https://api.bitbucket.org/2.0"
+	BitbucketDefaultHost   = "https://bitbucket.org"
+
+	AzureDevOpsDefaultAPIUrl         = "https://dev.azure.com"
+	AzureDevOpsDefaultHost           = "https://dev.azure.com"
+	AzureDevOpsDefaultIdentityAPIUrl = "https://vssps.dev.azure.com"
 )
 
 // GitConfig is a git repository where the IntegrationConfig to be configured
 type GitConfig struct {
 	// Type for git remote server
-	// +kubebuilder:validation:Enum=github;gitlab
+	// +kubebuilder:validation:Enum=github;gitlab;gitea;bitbucket-server;bitbucket;azure-devops;gerrit
 	Type GitType `json:"type"`
 
 	// Repository name of git repository (in <org>/<repo> form, e.g., tmax-cloud/cicd-operator)
-	// +kubebuilder:validation:Pattern=.+/.+
+	// For azure-devops type, it should be in <organization>/<project>/<repo> form
+	// For gerrit type, it is a project name, which does not need to contain '/'
+	// +kubebuilder:validation:MinLength=1
 	Repository string `json:"repository"`
 
 	// APIUrl for api server (e.g., https://api.github.com for github type),
@@ -31,7 +45,62 @@ type GitConfig struct {
 	APIUrl string `json:"apiUrl,omitempty"`
 
 	// Token
-	Token GitToken `json:"token"`
+	// Either Token or GitHubApp (only for github type) should be specified
+	Token GitToken `json:"token,omitempty"`
+
+	// GitHubApp is a credential of GitHub App, used instead of the Token for github type
+	GitHubApp *GitHubAppConfig `json:"githubApp,omitempty"`
+
+	// Trigger is a way of receiving events from the remote git server. Defaults to webhook
+	// Use poll for the git servers which cannot send webhooks to the operator (e.g., behind a firewall)
+	// +kubebuilder:validation:Enum=webhook;poll
+	Trigger GitTrigger `json:"trigger,omitempty"`
+
+	// PollInterval is an interval of polling the remote git server, used only for poll trigger (e.g., 30s, 5m). Defaults to 1m
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
+
+	// HTTP is a configuration of HTTP connections to the API server
+	HTTP *GitHTTPConfig `json:"http,omitempty"`
+}
+
+// GitHTTPConfig is a configuration of HTTP connections to the API server
+type GitHTTPConfig struct {
+	// CABundle refers a ConfigMap key which contains PEM-encoded CA certificates, for the API server using a private CA
+	CABundle *corev1.ConfigMapKeySelector `json:"caBundle,omitempty"`
+
+	// InsecureSkipVerify skips verifying the API server's certificate. Not recommended
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+
+	// ProxyURL is a URL of the proxy server for the API server (e.g., http://proxy.my.domain:3128)
+	ProxyURL string `json:"proxyUrl,omitempty"`
+
+	// Timeout is a timeout of each request to the API server (e.g., 10s). Defaults to 30s
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// GitTrigger is a way of receiving events from the remote git server
+type GitTrigger string
+
+// Git triggers
+const (
+	GitTriggerWebhook = GitTrigger("webhook")
+	GitTriggerPoll    = GitTrigger("poll")
+)
+
+// DefaultPollInterval is a default interval of polling the remote git server
+const DefaultPollInterval = time.Minute
+
+// IsPolling returns if the remote git server is polled, instead of sending webhooks
+func (config *GitConfig) IsPolling() bool {
+	return config.Trigger == GitTriggerPoll
+}
+
+// GetPollInterval returns the interval of polling the remote git server
+func (config *GitConfig) GetPollInterval() time.Duration {
+	if config.PollInterval == nil || config.PollInterval.Duration <= 0 {
+		return DefaultPollInterval
+	}
+	return config.PollInterval.Duration
 }
 
 // GetGitHost gets git host
@@ -39,6 +108,8 @@ func (config *GitConfig) GetGitHost() (string, error) {
 	gitURL := config.GetAPIUrl()
 	if gitURL == GithubDefaultAPIUrl {
 		gitURL = GithubDefaultHost
+	} else if gitURL == BitbucketDefaultAPIUrl {
+		gitURL = BitbucketDefaultHost
 	}
 	gitU, err := url.Parse(gitURL)
 	if err != nil {
@@ -54,6 +125,12 @@ func (config *GitConfig) GetAPIUrl() string {
 		return GithubDefaultAPIUrl
 	} else if config.Type == GitTypeGitLab && config.APIUrl == "" {
 		return GitlabDefaultAPIUrl
+	} else if config.Type == GitTypeGitea && config.APIUrl == "" {
+		return GiteaDefaultAPIUrl
+	} else if config.Type == GitTypeBitbucket && config.APIUrl == "" {
+		return BitbucketDefaultAPIUrl
+	} else if config.Type == GitTypeAzureDevOps && config.APIUrl == "" {
+		return AzureDevOpsDefaultAPIUrl
 	}
 	return config.APIUrl
 }
@@ -72,6 +149,20 @@ type GitTokenFrom struct {
 	SecretKeyRef corev1.SecretKeySelector `json:"secretKeyRef"`
 }
 
+// GitHubAppConfig is a credential of GitHub App
+// Installation access tokens are issued using the App's private key and are used for accessing the repository
+type GitHubAppConfig struct {
+	// AppID is an ID of the GitHub App
+	// +kubebuilder:validation:Minimum=1
+	AppID int64 `json:"appId"`
+
+	// InstallationID is an ID of the App's installation. If not specified, the installation for the repository is used
+	InstallationID int64 `json:"installationId,omitempty"`
+
+	// PrivateKey refers secret key which contains the App's private key (in PEM format)
+	PrivateKey corev1.SecretKeySelector `json:"privateKey"`
+}
+
 // GitType is a type of remote git server
 type GitType string
 
@@ -79,4 +170,10 @@ type GitType string
 const (
 	GitTypeGitHub = GitType("github")
 	GitTypeGitLab = GitType("gitlab")
+	GitTypeGitea  = GitType("gitea")
+
+	GitTypeBitbucketServer = GitType("bitbucket-server")
+	GitTypeBitbucket       = GitType("bitbucket")
+	GitTypeAzureDevOps     = GitType("azure-devops")
+	GitTypeGerrit          = GitType("gerrit")
 )
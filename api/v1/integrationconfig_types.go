@@ -22,6 +22,7 @@ import (
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
 	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	"github.com/tmax-cloud/cicd-operator/internal/configs"
+	"time"
 
 	"github.com/operator-framework/operator-lib/status"
 	corev1 "k8s.io/api/core/v1"
@@ -33,9 +34,21 @@ import (
 // Condition keys for IntegrationConfig
 const (
 	IntegrationConfigConditionWebhookRegistered = status.ConditionType("webhook-registered")
+	IntegrationConfigConditionWebhookStale      = status.ConditionType("webhook-stale")
 	IntegrationConfigConditionReady             = status.ConditionType("ready")
+	IntegrationConfigConditionJobsValid         = status.ConditionType("jobs-valid")
 )
 
+// AnnotationRotateWebhookSecret is an annotation key for rotating the webhook secret
+// Its value is a grace period (e.g., 30m), during which the previous secret is also accepted. Defaults to 1h
+const AnnotationRotateWebhookSecret = "cicd.tmax.io/rotate-webhook-secret"
+
+// DefaultWebhookSecretGracePeriod is a default grace period of the previous webhook secret
+const DefaultWebhookSecretGracePeriod = time.Hour
+
+// DefaultSkipCIMarkers are default markers which skip the jobs
+var DefaultSkipCIMarkers = []string{"[skip ci]", "[ci skip]"}
+
 // IntegrationConfigSpec defines the desired state of IntegrationConfig
 type IntegrationConfigSpec struct {
 	// Git config for target repository
@@ -55,15 +68,43 @@ type IntegrationConfigSpec struct {
 
 	// PodTemplate for the TaskRun pods. Same as tekton's pod template. Refer to https://github.com/tektoncd/pipeline/blob/master/docs/podtemplates.md
 	PodTemplate *pod.Template `json:"podTemplate,omitempty"`
+
+	// SkipCIMarkers are the markers which skip the jobs, if they are in the head commit message of a push or the title of a pull request
+	// Defaults to [skip ci] and [ci skip]
+	SkipCIMarkers []string `json:"skipCIMarkers,omitempty"`
+
+	// TrustedContributors is a policy deciding whose pull requests are tested without /ok-to-test comment
+	// If it's not set, every pull request is tested
+	TrustedContributors *TrustedContributors `json:"trustedContributors,omitempty"`
 }
 
-// IntegrationConfigJobs categorizes jobs into two types (pre-submit and post-submit)
+// TrustedContributors decides whose pull requests are tested without /ok-to-test comment
+// A user is trusted if any of the conditions is met
+type TrustedContributors struct {
+	// OrgMembers trusts the members of the organization (GitHub) or the group (GitLab) the repository belongs to
+	OrgMembers bool `json:"orgMembers,omitempty"`
+
+	// Collaborators trusts the users who can write to the repository
+	Collaborators bool `json:"collaborators,omitempty"`
+
+	// Users are the names of the trusted users
+	Users []string `json:"users,omitempty"`
+}
+
+// IntegrationConfigJobs categorizes jobs into four types (pre-submit, post-submit, periodic and release)
 type IntegrationConfigJobs struct {
 	// PreSubmit jobs are for pull-request events
 	PreSubmit Jobs `json:"preSubmit,omitempty"`
 
 	// PostSubmit jobs are for push events (including tag events)
 	PostSubmit Jobs `json:"postSubmit,omitempty"`
+
+	// Periodic jobs run on cron schedules, for the head commits of the branches
+	// The periodic jobs of the same cron and branch run together in an IntegrationJob
+	Periodic PeriodicJobs `json:"periodic,omitempty"`
+
+	// Release jobs are for release events (GitHub releases are published or GitLab releases are created)
+	Release Jobs `json:"release,omitempty"`
 }
 
 // IntegrationConfigStatus defines the observed state of IntegrationConfig
@@ -71,6 +112,53 @@ type IntegrationConfigStatus struct {
 	// Conditions of IntegrationConfig
 	Conditions status.Conditions `json:"conditions"`
 	Secrets    string            `json:"secrets,omitempty"`
+
+	// PreviousSecrets is a webhook secret before the rotation, which is accepted until PreviousSecretsExpiresAt
+	PreviousSecrets string `json:"previousSecrets,omitempty"`
+
+	// PreviousSecretsExpiresAt is a time when the PreviousSecrets expires
+	PreviousSecretsExpiresAt *metav1.Time `json:"previousSecretsExpiresAt,omitempty"`
+
+	// WebhookURL is the url of the webhook registered to the remote git server
+	WebhookURL string `json:"webhookURL,omitempty"`
+
+	// WebhookEvents are the events which the registered webhook is subscribed to
+	WebhookEvents []string `json:"webhookEvents,omitempty"`
+
+	// LastWebhookVerificationTime is the last time when the registered webhook is verified
+	LastWebhookVerificationTime *metav1.Time `json:"lastWebhookVerificationTime,omitempty"`
+
+	// PollState is the state of the remote git server observed by the last poll. It's set only for poll trigger
+	PollState *PollState `json:"pollState,omitempty"`
+}
+
+// PollState is a state of the remote git server observed by polling, compared with the next poll to detect the changes
+type PollState struct {
+	// PolledAt is the last time when the remote git server is polled
+	PolledAt metav1.Time `json:"polledAt"`
+
+	// Branches are the head commit shas of the branches, keyed by the branch names
+	Branches map[string]string `json:"branches,omitempty"`
+
+	// Tags are the commit shas of the tags, keyed by the tag names
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// PullRequests are the open pull requests
+	PullRequests []PolledPullRequest `json:"pullRequests,omitempty"`
+}
+
+// PolledPullRequest is an open pull request observed by polling
+type PolledPullRequest struct {
+	ID int `json:"id"`
+
+	// Head is the name of the head branch
+	Head string `json:"head"`
+
+	// Base is the name of the base branch
+	Base string `json:"base"`
+
+	// Sha is the head commit sha
+	Sha string `json:"sha"`
 }
 
 // +kubebuilder:object:root=true
@@ -114,17 +202,49 @@ func (i *IntegrationConfig) GetToken(c client.Client) (string, error) {
 	}
 
 	// Get from secret
-	secretName := tokenStruct.ValueFrom.SecretKeyRef.Name
-	secretKey := tokenStruct.ValueFrom.SecretKeyRef.Key
-	secret := &corev1.Secret{}
-	if err := c.Get(context.Background(), types.NamespacedName{Name: secretName, Namespace: i.Namespace}, secret); err != nil {
+	token, err := i.getSecretValue(c, tokenStruct.ValueFrom.SecretKeyRef)
+	if err != nil {
 		return "", err
 	}
-	token, ok := secret.Data[secretKey]
+	return string(token), nil
+}
+
+// GetGitHubAppPrivateKey fetches GitHub App's private key from IntegrationConfig
+func (i *IntegrationConfig) GetGitHubAppPrivateKey(c client.Client) ([]byte, error) {
+	if i.Spec.Git.GitHubApp == nil {
+		return nil, fmt.Errorf("githubApp is not configured")
+	}
+	return i.getSecretValue(c, i.Spec.Git.GitHubApp.PrivateKey)
+}
+
+func (i *IntegrationConfig) getSecretValue(c client.Client, selector corev1.SecretKeySelector) ([]byte, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(context.Background(), types.NamespacedName{Name: selector.Name, Namespace: i.Namespace}, secret); err != nil {
+		return nil, err
+	}
+	value, ok := secret.Data[selector.Key]
 	if !ok {
-		return "", fmt.Errorf("token secret/key %s/%s not valid", secretName, secretKey)
+		return nil, fmt.Errorf("token secret/key %s/%s not valid", selector.Name, selector.Key)
 	}
-	return string(token), nil
+	return value, nil
+}
+
+// GetWebhookSecrets returns the webhook secrets to be accepted
+// The previous secret is also accepted during its grace period after the rotation
+func (i *IntegrationConfig) GetWebhookSecrets() []string {
+	secrets := []string{i.Status.Secrets}
+	if i.Status.PreviousSecrets != "" && i.Status.PreviousSecretsExpiresAt != nil && time.Now().Before(i.Status.PreviousSecretsExpiresAt.Time) {
+		secrets = append(secrets, i.Status.PreviousSecrets)
+	}
+	return secrets
+}
+
+// GetSkipCIMarkers returns the markers which skip the jobs
+func (i *IntegrationConfig) GetSkipCIMarkers() []string {
+	if i.Spec.SkipCIMarkers == nil {
+		return DefaultSkipCIMarkers
+	}
+	return i.Spec.SkipCIMarkers
 }
 
 // GetServiceAccountName returns the name of the related ServiceAccount
@@ -33,6 +33,13 @@ const (
 	IntegrationJobStateRunning   = IntegrationJobState("Running")
 	IntegrationJobStateCompleted = IntegrationJobState("Completed")
 	IntegrationJobStateFailed    = IntegrationJobState("Failed")
+	IntegrationJobStateCanceled  = IntegrationJobState("Canceled")
+)
+
+// Reasons of the IntegrationJob cancellation
+const (
+	IntegrationJobCancelReasonSuperseded        = "Superseded"
+	IntegrationJobCancelReasonPullRequestClosed = "PullRequestClosed"
 )
 
 // IntegrationJobSpec defines the desired state of IntegrationJob
@@ -54,6 +61,35 @@ type IntegrationJobSpec struct {
 
 	// PodTemplate for the TaskRun pods. Same as tekton's pod template
 	PodTemplate *pod.Template `json:"podTemplate,omitempty"`
+
+	// Cancel is a request to cancel the IntegrationJob, if it is pending or running
+	Cancel *IntegrationJobCancel `json:"cancel,omitempty"`
+
+	// Parameters are set to the job containers as environment variables
+	// They are given when the IntegrationJob is run manually, via the run api of the IntegrationConfig
+	Parameters []IntegrationJobParameter `json:"parameters,omitempty"`
+}
+
+// IntegrationJobParameter is a key/value parameter of the IntegrationJob
+type IntegrationJobParameter struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// IntegrationJobCancel describes who or what canceled the IntegrationJob
+type IntegrationJobCancel struct {
+	// Reason is why the IntegrationJob is canceled (Superseded or PullRequestClosed)
+	Reason string `json:"reason"`
+
+	// SupersededBy is a name of the IntegrationJob superseding this one
+	// It is empty if no IntegrationJob is created for the new commit
+	SupersededBy string `json:"supersededBy,omitempty"`
+
+	// Sha is a sha of the new head commit superseding this one
+	Sha string `json:"sha,omitempty"`
+
+	// Sender is a git user who triggered the cancellation
+	Sender *IntegrationJobSender `json:"sender,omitempty"`
 }
 
 // IntegrationJobConfigRef refers to the IntegrationConfig
@@ -71,6 +107,10 @@ type IntegrationJobRefs struct {
 	// Link is a full url of the repository
 	Link string `json:"link"`
 
+	// CloneURL is a url for cloning the repository
+	// It is set only if the repository cannot be cloned from <server url>/<repository>
+	CloneURL string `json:"cloneUrl,omitempty"`
+
 	// Sender is a git user who triggered the webhook
 	Sender *IntegrationJobSender `json:"sender"`
 
@@ -80,6 +120,9 @@ type IntegrationJobRefs struct {
 
 	// Pull represents pull request head commit
 	Pull *IntegrationJobRefsPull `json:"pull,omitempty"`
+
+	// Release is the release which triggered the release jobs
+	Release *IntegrationJobRefsRelease `json:"release,omitempty"`
 }
 
 // IntegrationJobSender is a git user who triggered the IntegrationJob
@@ -109,6 +152,15 @@ type IntegrationJobRefsPullAuthor struct {
 	Name string `json:"name"`
 }
 
+// IntegrationJobRefsRelease refers to the release
+type IntegrationJobRefsRelease struct {
+	Tag  string `json:"tag"`
+	Name string `json:"name,omitempty"`
+	// Prerelease is whether the release is a pre-release (GitHub) or an upcoming release (GitLab)
+	Prerelease bool   `json:"prerelease,omitempty"`
+	Link       string `json:"link,omitempty"`
+}
+
 // IntegrationJobStatus defines the observed state of IntegrationJob
 type IntegrationJobStatus struct {
 	// State is a current state of the IntegrationJob
@@ -156,6 +208,24 @@ func init() {
 	SchemeBuilder.Register(&IntegrationJob{}, &IntegrationJobList{})
 }
 
+// GetMessage returns a message describing the cancellation
+func (c *IntegrationJobCancel) GetMessage() string {
+	switch c.Reason {
+	case IntegrationJobCancelReasonSuperseded:
+		msg := "Superseded by a new commit"
+		if c.Sha != "" {
+			msg = fmt.Sprintf("Superseded by a new commit %s", c.Sha)
+		}
+		if c.SupersededBy != "" {
+			msg += fmt.Sprintf(" (IntegrationJob %s)", c.SupersededBy)
+		}
+		return msg
+	case IntegrationJobCancelReasonPullRequestClosed:
+		return "Pull request is closed"
+	}
+	return "Canceled"
+}
+
 // SetDefaults sets default values for the status
 func (s *IntegrationJobStatus) SetDefaults() error {
 	if s.State == "" {
@@ -16,6 +16,10 @@ const (
 	JobTypePreSubmit = JobType("preSubmit")
 	// JobTypePostSubmit is a post-submit type (push or tag-push)
 	JobTypePostSubmit = JobType("postSubmit")
+	// JobTypePeriodic is a periodic type (cron schedule)
+	JobTypePeriodic = JobType("periodic")
+	// JobTypeRelease is a release type (release is published)
+	JobTypeRelease = JobType("release")
 )
 
 // CommitStatusState is a state of git commit status
@@ -115,6 +119,24 @@ type JobWhen struct {
 
 	Tag     []string `json:"tag,omitempty"`
 	SkipTag []string `json:"skipTag,omitempty"`
+
+	// Paths are glob patterns of the files. The job runs only if any of the changed files matches them
+	// '*' matches any characters except '/' and '**' matches any characters including '/'
+	Paths []string `json:"paths,omitempty"`
+	// SkipPaths are glob patterns of the files. The job is skipped if all the changed files match them
+	SkipPaths []string `json:"skipPaths,omitempty"`
+
+	// Labels are names of the pull request labels. The job runs only for pull requests having any of them
+	Labels []string `json:"labels,omitempty"`
+	// SkipLabels are names of the pull request labels. The job is skipped for pull requests having any of them
+	SkipLabels []string `json:"skipLabels,omitempty"`
+
+	// SkipDraft skips the job for draft pull requests. The job runs when the pull request is marked as ready for review
+	SkipDraft bool `json:"skipDraft,omitempty"`
+
+	// Expression is a boolean expression over the event. The job runs only if it is evaluated to true
+	// e.g., type == "push" && ref.startsWith("refs/tags/v") || "run-e2e" in labels
+	Expression string `json:"expression,omitempty"`
 }
 
 // JobStatus is a current status for each job
@@ -170,3 +192,26 @@ func (j *Jobs) GetGraph() (structs.Graph, error) {
 
 	return graph, nil
 }
+
+// PeriodicJob is a job which runs periodically, for the head commit of a branch
+type PeriodicJob struct {
+	Job `json:",inline"`
+
+	// Cron is a cron spec of the job's schedule, e.g., "0 0 * * *", "@daily" or "@every 12h"
+	Cron string `json:"cron"`
+
+	// Branch is a branch whose head commit is used for the job
+	Branch string `json:"branch"`
+}
+
+// PeriodicJobs is an array of PeriodicJob
+type PeriodicJobs []PeriodicJob
+
+// GetJobs returns the jobs of the periodic jobs
+func (p PeriodicJobs) GetJobs() Jobs {
+	var jobs Jobs
+	for _, job := range p {
+		jobs = append(jobs, job.Job)
+	}
+	return jobs
+}
@@ -0,0 +1,276 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/operator-framework/operator-lib/status"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition keys for OrgIntegrationConfig
+const (
+	OrgIntegrationConfigConditionReady = status.ConditionType("ready")
+)
+
+// LabelOrgIntegrationConfig is a label key of the IntegrationConfigs generated by an OrgIntegrationConfig
+const LabelOrgIntegrationConfig = JobLabelPrefix + "org-integration-config"
+
+// DefaultDiscoveryInterval is a default interval of discovering the repositories of an organization
+const DefaultDiscoveryInterval = 10 * time.Minute
+
+// maxGeneratedNameLength is the maximum length of the generated IntegrationConfigs' names
+// IntegrationJobs are named as <IntegrationConfig name>-xxxxx-yyyyy and used as label values (at most 63 characters) of
+// the PipelineRuns, so 12 characters are left for the suffix
+const maxGeneratedNameLength = 51
+
+// OrgIntegrationConfigSpec defines the desired state of OrgIntegrationConfig
+type OrgIntegrationConfigSpec struct {
+	// Git config for the organization (github) or the group (gitlab)
+	Git OrgGitConfig `json:"git"`
+
+	// Repositories selects the repositories of the organization, for which IntegrationConfigs are generated
+	// Every repository (except for the archived ones) is selected if it's not specified
+	Repositories RepositorySelector `json:"repositories,omitempty"`
+
+	// Template is a template of the IntegrationConfigs generated for the selected repositories
+	Template IntegrationConfigTemplate `json:"template"`
+
+	// DiscoveryInterval is an interval of discovering the repositories of the organization (e.g., 5m). Defaults to 10m
+	DiscoveryInterval *metav1.Duration `json:"discoveryInterval,omitempty"`
+}
+
+// OrgGitConfig is a git organization (github) or a group (gitlab) where the OrgIntegrationConfig to be configured
+type OrgGitConfig struct {
+	// Type for git remote server
+	// +kubebuilder:validation:Enum=github;gitlab
+	Type GitType `json:"type"`
+
+	// Organization is a name of GitHub organization or a full path of GitLab group (e.g., my-group/my-subgroup)
+	// +kubebuilder:validation:MinLength=1
+	Organization string `json:"organization"`
+
+	// APIUrl for api server (e.g., https://api.github.com for github type), for the case where the git server is self-hosted
+	APIUrl string `json:"apiUrl,omitempty"`
+
+	// Token for accessing the organization's repositories. It should be able to list the repositories and register webhooks
+	Token GitToken `json:"token"`
+
+	// Trigger is a way of receiving events from the remote git server. Defaults to webhook
+	// +kubebuilder:validation:Enum=webhook;poll
+	Trigger GitTrigger `json:"trigger,omitempty"`
+
+	// PollInterval is an interval of polling the remote git server, used only for poll trigger (e.g., 30s, 5m). Defaults to 1m
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
+
+	// HTTP is a configuration of HTTP connections to the API server
+	HTTP *GitHTTPConfig `json:"http,omitempty"`
+}
+
+// RepositorySelector selects repositories of an organization
+type RepositorySelector struct {
+	// Names are regular expressions of the repositories' full names (e.g., tmax-cloud/cicd-.*) to be selected
+	Names []string `json:"names,omitempty"`
+
+	// SkipNames are regular expressions of the repositories' full names to be skipped
+	SkipNames []string `json:"skipNames,omitempty"`
+
+	// Topics selects the repositories having any of the topics
+	Topics []string `json:"topics,omitempty"`
+}
+
+// IntegrationConfigTemplate is a template of IntegrationConfig, except for the git config
+type IntegrationConfigTemplate struct {
+	// Secrets are the list of secret names which are included in service account
+	Secrets []corev1.LocalObjectReference `json:"secrets,omitempty"`
+
+	// Workspaces list
+	Workspaces []tektonv1beta1.WorkspaceBinding `json:"workspaces,omitempty"`
+
+	// Jobs specify the tasks to be executed
+	Jobs IntegrationConfigJobs `json:"jobs"`
+
+	// PodTemplate for the TaskRun pods. Same as tekton's pod template. Refer to https://github.com/tektoncd/pipeline/blob/master/docs/podtemplates.md
+	PodTemplate *pod.Template `json:"podTemplate,omitempty"`
+
+	// SkipCIMarkers are the markers which skip the jobs, if they are in the head commit message of a push or the title of a pull request
+	// Defaults to [skip ci] and [ci skip]
+	SkipCIMarkers []string `json:"skipCIMarkers,omitempty"`
+
+	// TrustedContributors is a policy deciding whose pull requests are tested without /ok-to-test comment
+	// If it's not set, every pull request is tested
+	TrustedContributors *TrustedContributors `json:"trustedContributors,omitempty"`
+}
+
+// OrgIntegrationConfigStatus defines the observed state of OrgIntegrationConfig
+type OrgIntegrationConfigStatus struct {
+	// Conditions of OrgIntegrationConfig
+	Conditions status.Conditions `json:"conditions"`
+
+	// Repositories are the discovered repositories, for which IntegrationConfigs are generated
+	Repositories []DiscoveredRepository `json:"repositories,omitempty"`
+
+	// LastDiscoveryTime is the last time when the repositories are discovered
+	LastDiscoveryTime *metav1.Time `json:"lastDiscoveryTime,omitempty"`
+}
+
+// DiscoveredRepository is a repository discovered by OrgIntegrationConfig
+type DiscoveredRepository struct {
+	// Name is a full name of the repository
+	Name string `json:"name"`
+
+	// IntegrationConfig is a name of the IntegrationConfig generated for the repository
+	IntegrationConfig string `json:"integrationConfig"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// OrgIntegrationConfig is the Schema for the orgintegrationconfigs API
+// It generates IntegrationConfigs for the repositories of an organization (github) or a group (gitlab)
+// +kubebuilder:resource:shortName="oic"
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="ready")].status`
+// +kubebuilder:printcolumn:name="Organization",type=string,JSONPath=`.spec.git.organization`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Creation time"
+type OrgIntegrationConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OrgIntegrationConfigSpec   `json:"spec"`
+	Status OrgIntegrationConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// OrgIntegrationConfigList contains a list of OrgIntegrationConfig
+type OrgIntegrationConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OrgIntegrationConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OrgIntegrationConfig{}, &OrgIntegrationConfigList{})
+}
+
+// GetDiscoveryInterval returns the interval of discovering the repositories
+func (o *OrgIntegrationConfig) GetDiscoveryInterval() time.Duration {
+	if o.Spec.DiscoveryInterval == nil || o.Spec.DiscoveryInterval.Duration <= 0 {
+		return DefaultDiscoveryInterval
+	}
+	return o.Spec.DiscoveryInterval.Duration
+}
+
+// GitConfig returns a GitConfig for the repository of the organization
+func (g *OrgGitConfig) GitConfig(repository string) GitConfig {
+	return GitConfig{
+		Type:         g.Type,
+		Repository:   repository,
+		APIUrl:       g.APIUrl,
+		Token:        *g.Token.DeepCopy(),
+		Trigger:      g.Trigger,
+		PollInterval: g.PollInterval.DeepCopy(),
+		HTTP:         g.HTTP.DeepCopy(),
+	}
+}
+
+// GenerateIntegrationConfig generates an IntegrationConfig for the repository, from the template
+func (o *OrgIntegrationConfig) GenerateIntegrationConfig(repository string) *IntegrationConfig {
+	template := o.Spec.Template.DeepCopy()
+	return &IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetGeneratedIntegrationConfigName(o.Name, repository),
+			Namespace: o.Namespace,
+			Labels:    map[string]string{LabelOrgIntegrationConfig: o.Name},
+		},
+		Spec: IntegrationConfigSpec{
+			Git:                 o.Spec.Git.GitConfig(repository),
+			Secrets:             template.Secrets,
+			Workspaces:          template.Workspaces,
+			Jobs:                template.Jobs,
+			PodTemplate:         template.PodTemplate,
+			SkipCIMarkers:       template.SkipCIMarkers,
+			TrustedContributors: template.TrustedContributors,
+		},
+	}
+}
+
+// invalidNameChars matches the characters which cannot be used for the names of the objects
+var invalidNameChars = regexp.MustCompile("[^a-z0-9-]+")
+
+// GetGeneratedIntegrationConfigName returns the name of the IntegrationConfig generated for the repository
+// Long names are truncated and suffixed with a hash of the repository name, so that the names of the IntegrationJobs can
+// be used as label values
+func GetGeneratedIntegrationConfigName(orgConfigName, repository string) string {
+	name := fmt.Sprintf("%s-%s", orgConfigName, strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(repository), "-"), "-"))
+	if len(name) <= maxGeneratedNameLength {
+		return name
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(repository)))[:10]
+	return strings.TrimRight(name[:maxGeneratedNameLength-len(hash)-1], "-") + "-" + hash
+}
+
+// Select returns if the repository is selected
+func (s *RepositorySelector) Select(name string, topics []string) (bool, error) {
+	if len(s.Names) > 0 {
+		matched, err := matchAny(s.Names, name)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if len(s.SkipNames) > 0 {
+		matched, err := matchAny(s.SkipNames, name)
+		if err != nil || matched {
+			return false, err
+		}
+	}
+
+	if len(s.Topics) > 0 {
+		for _, t := range s.Topics {
+			for _, topic := range topics {
+				if t == topic {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func matchAny(patterns []string, str string) (bool, error) {
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return false, err
+		}
+		if re.MatchString(str) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -153,10 +154,40 @@ func (in *ApprovalStatus) DeepCopy() *ApprovalStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiscoveredRepository) DeepCopyInto(out *DiscoveredRepository) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiscoveredRepository.
+func (in *DiscoveredRepository) DeepCopy() *DiscoveredRepository {
+	if in == nil {
+		return nil
+	}
+	out := new(DiscoveredRepository)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitConfig) DeepCopyInto(out *GitConfig) {
 	*out = *in
 	in.Token.DeepCopyInto(&out.Token)
+	if in.GitHubApp != nil {
+		in, out := &in.GitHubApp, &out.GitHubApp
+		*out = new(GitHubAppConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PollInterval != nil {
+		in, out := &in.PollInterval, &out.PollInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(GitHTTPConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitConfig.
@@ -169,6 +200,47 @@ func (in *GitConfig) DeepCopy() *GitConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitHTTPConfig) DeepCopyInto(out *GitHTTPConfig) {
+	*out = *in
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = new(corev1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitHTTPConfig.
+func (in *GitHTTPConfig) DeepCopy() *GitHTTPConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GitHTTPConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitHubAppConfig) DeepCopyInto(out *GitHubAppConfig) {
+	*out = *in
+	in.PrivateKey.DeepCopyInto(&out.PrivateKey)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitHubAppConfig.
+func (in *GitHubAppConfig) DeepCopy() *GitHubAppConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GitHubAppConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitToken) DeepCopyInto(out *GitToken) {
 	*out = *in
@@ -249,6 +321,20 @@ func (in *IntegrationConfigJobs) DeepCopyInto(out *IntegrationConfigJobs) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Periodic != nil {
+		in, out := &in.Periodic, &out.Periodic
+		*out = make(PeriodicJobs, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Release != nil {
+		in, out := &in.Release, &out.Release
+		*out = make(Jobs, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IntegrationConfigJobs.
@@ -315,6 +401,16 @@ func (in *IntegrationConfigSpec) DeepCopyInto(out *IntegrationConfigSpec) {
 		*out = new(pod.Template)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SkipCIMarkers != nil {
+		in, out := &in.SkipCIMarkers, &out.SkipCIMarkers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TrustedContributors != nil {
+		in, out := &in.TrustedContributors, &out.TrustedContributors
+		*out = new(TrustedContributors)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IntegrationConfigSpec.
@@ -337,6 +433,24 @@ func (in *IntegrationConfigStatus) DeepCopyInto(out *IntegrationConfigStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PreviousSecretsExpiresAt != nil {
+		in, out := &in.PreviousSecretsExpiresAt, &out.PreviousSecretsExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.WebhookEvents != nil {
+		in, out := &in.WebhookEvents, &out.WebhookEvents
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastWebhookVerificationTime != nil {
+		in, out := &in.LastWebhookVerificationTime, &out.LastWebhookVerificationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PollState != nil {
+		in, out := &in.PollState, &out.PollState
+		*out = new(PollState)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IntegrationConfigStatus.
@@ -349,6 +463,49 @@ func (in *IntegrationConfigStatus) DeepCopy() *IntegrationConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IntegrationConfigTemplate) DeepCopyInto(out *IntegrationConfigTemplate) {
+	*out = *in
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Workspaces != nil {
+		in, out := &in.Workspaces, &out.Workspaces
+		*out = make([]v1beta1.WorkspaceBinding, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Jobs.DeepCopyInto(&out.Jobs)
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		*out = new(pod.Template)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SkipCIMarkers != nil {
+		in, out := &in.SkipCIMarkers, &out.SkipCIMarkers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TrustedContributors != nil {
+		in, out := &in.TrustedContributors, &out.TrustedContributors
+		*out = new(TrustedContributors)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IntegrationConfigTemplate.
+func (in *IntegrationConfigTemplate) DeepCopy() *IntegrationConfigTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(IntegrationConfigTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IntegrationJob) DeepCopyInto(out *IntegrationJob) {
 	*out = *in
@@ -376,6 +533,26 @@ func (in *IntegrationJob) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IntegrationJobCancel) DeepCopyInto(out *IntegrationJobCancel) {
+	*out = *in
+	if in.Sender != nil {
+		in, out := &in.Sender, &out.Sender
+		*out = new(IntegrationJobSender)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IntegrationJobCancel.
+func (in *IntegrationJobCancel) DeepCopy() *IntegrationJobCancel {
+	if in == nil {
+		return nil
+	}
+	out := new(IntegrationJobCancel)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IntegrationJobConfigRef) DeepCopyInto(out *IntegrationJobConfigRef) {
 	*out = *in
@@ -423,6 +600,21 @@ func (in *IntegrationJobList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IntegrationJobParameter) DeepCopyInto(out *IntegrationJobParameter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IntegrationJobParameter.
+func (in *IntegrationJobParameter) DeepCopy() *IntegrationJobParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(IntegrationJobParameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IntegrationJobRefs) DeepCopyInto(out *IntegrationJobRefs) {
 	*out = *in
@@ -437,6 +629,11 @@ func (in *IntegrationJobRefs) DeepCopyInto(out *IntegrationJobRefs) {
 		*out = new(IntegrationJobRefsPull)
 		**out = **in
 	}
+	if in.Release != nil {
+		in, out := &in.Release, &out.Release
+		*out = new(IntegrationJobRefsRelease)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IntegrationJobRefs.
@@ -495,6 +692,21 @@ func (in *IntegrationJobRefsPullAuthor) DeepCopy() *IntegrationJobRefsPullAuthor
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IntegrationJobRefsRelease) DeepCopyInto(out *IntegrationJobRefsRelease) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IntegrationJobRefsRelease.
+func (in *IntegrationJobRefsRelease) DeepCopy() *IntegrationJobRefsRelease {
+	if in == nil {
+		return nil
+	}
+	out := new(IntegrationJobRefsRelease)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IntegrationJobSender) DeepCopyInto(out *IntegrationJobSender) {
 	*out = *in
@@ -534,6 +746,16 @@ func (in *IntegrationJobSpec) DeepCopyInto(out *IntegrationJobSpec) {
 		*out = new(pod.Template)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Cancel != nil {
+		in, out := &in.Cancel, &out.Cancel
+		*out = new(IntegrationJobCancel)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]IntegrationJobParameter, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IntegrationJobSpec.
@@ -716,6 +938,26 @@ func (in *JobWhen) DeepCopyInto(out *JobWhen) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SkipPaths != nil {
+		in, out := &in.SkipPaths, &out.SkipPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SkipLabels != nil {
+		in, out := &in.SkipLabels, &out.SkipLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobWhen.
@@ -834,6 +1076,262 @@ func (in *NotificationMethods) DeepCopy() *NotificationMethods {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrgGitConfig) DeepCopyInto(out *OrgGitConfig) {
+	*out = *in
+	in.Token.DeepCopyInto(&out.Token)
+	if in.PollInterval != nil {
+		in, out := &in.PollInterval, &out.PollInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(GitHTTPConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrgGitConfig.
+func (in *OrgGitConfig) DeepCopy() *OrgGitConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OrgGitConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrgIntegrationConfig) DeepCopyInto(out *OrgIntegrationConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrgIntegrationConfig.
+func (in *OrgIntegrationConfig) DeepCopy() *OrgIntegrationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OrgIntegrationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OrgIntegrationConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrgIntegrationConfigList) DeepCopyInto(out *OrgIntegrationConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OrgIntegrationConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrgIntegrationConfigList.
+func (in *OrgIntegrationConfigList) DeepCopy() *OrgIntegrationConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(OrgIntegrationConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OrgIntegrationConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrgIntegrationConfigSpec) DeepCopyInto(out *OrgIntegrationConfigSpec) {
+	*out = *in
+	in.Git.DeepCopyInto(&out.Git)
+	in.Repositories.DeepCopyInto(&out.Repositories)
+	in.Template.DeepCopyInto(&out.Template)
+	if in.DiscoveryInterval != nil {
+		in, out := &in.DiscoveryInterval, &out.DiscoveryInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrgIntegrationConfigSpec.
+func (in *OrgIntegrationConfigSpec) DeepCopy() *OrgIntegrationConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OrgIntegrationConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrgIntegrationConfigStatus) DeepCopyInto(out *OrgIntegrationConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(status.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]DiscoveredRepository, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastDiscoveryTime != nil {
+		in, out := &in.LastDiscoveryTime, &out.LastDiscoveryTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrgIntegrationConfigStatus.
+func (in *OrgIntegrationConfigStatus) DeepCopy() *OrgIntegrationConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OrgIntegrationConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeriodicJob) DeepCopyInto(out *PeriodicJob) {
+	*out = *in
+	in.Job.DeepCopyInto(&out.Job)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeriodicJob.
+func (in *PeriodicJob) DeepCopy() *PeriodicJob {
+	if in == nil {
+		return nil
+	}
+	out := new(PeriodicJob)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in PeriodicJobs) DeepCopyInto(out *PeriodicJobs) {
+	{
+		in := &in
+		*out = make(PeriodicJobs, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeriodicJobs.
+func (in PeriodicJobs) DeepCopy() PeriodicJobs {
+	if in == nil {
+		return nil
+	}
+	out := new(PeriodicJobs)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PollState) DeepCopyInto(out *PollState) {
+	*out = *in
+	in.PolledAt.DeepCopyInto(&out.PolledAt)
+	if in.Branches != nil {
+		in, out := &in.Branches, &out.Branches
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PullRequests != nil {
+		in, out := &in.PullRequests, &out.PullRequests
+		*out = make([]PolledPullRequest, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PollState.
+func (in *PollState) DeepCopy() *PollState {
+	if in == nil {
+		return nil
+	}
+	out := new(PollState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolledPullRequest) DeepCopyInto(out *PolledPullRequest) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolledPullRequest.
+func (in *PolledPullRequest) DeepCopy() *PolledPullRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(PolledPullRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositorySelector) DeepCopyInto(out *RepositorySelector) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SkipNames != nil {
+		in, out := &in.SkipNames, &out.SkipNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Topics != nil {
+		in, out := &in.Topics, &out.Topics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositorySelector.
+func (in *RepositorySelector) DeepCopy() *RepositorySelector {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositorySelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TektonTask) DeepCopyInto(out *TektonTask) {
 	*out = *in
@@ -886,3 +1384,23 @@ func (in *TektonTaskParam) DeepCopy() *TektonTaskParam {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrustedContributors) DeepCopyInto(out *TrustedContributors) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrustedContributors.
+func (in *TrustedContributors) DeepCopy() *TrustedContributors {
+	if in == nil {
+		return nil
+	}
+	out := new(TrustedContributors)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -155,15 +155,16 @@ func (r *ConfigReconciler) Reconcile(cm *corev1.ConfigMap) error {
 
 func (r *ConfigReconciler) reconcileConfig(cm *corev1.ConfigMap) error {
 	vars := map[string]operatorConfig{
-		"maxPipelineRun":            {Type: cfgTypeInt, IntVal: &configs.MaxPipelineRun, IntDefault: 5},         // Max PipelineRun count
-		"enableMail":                {Type: cfgTypeBool, BoolVal: &configs.EnableMail, BoolDefault: false},      // Enable Mail
-		"externalHostName":          {Type: cfgTypeString, StringVal: &configs.ExternalHostName},                // External Hostname
-		"reportRedirectUriTemplate": {Type: cfgTypeString, StringVal: &configs.ReportRedirectURITemplate},       // RedirectUriTemplate for report access
-		"smtpHost":                  {Type: cfgTypeString, StringVal: &configs.SMTPHost},                        // SMTP Host
-		"smtpUserSecret":            {Type: cfgTypeString, StringVal: &configs.SMTPUserSecret},                  // SMTP Cred
-		"collectPeriod":             {Type: cfgTypeInt, IntVal: &configs.CollectPeriod, IntDefault: 120},        // GC period
-		"integrationJobTTL":         {Type: cfgTypeInt, IntVal: &configs.IntegrationJobTTL, IntDefault: 120},    // GC threshold
-		"ingressClass":              {Type: cfgTypeString, StringVal: &configs.IngressClass, StringDefault: ""}, // Ingress class
+		"maxPipelineRun":            {Type: cfgTypeInt, IntVal: &configs.MaxPipelineRun, IntDefault: 5},                   // Max PipelineRun count
+		"enableMail":                {Type: cfgTypeBool, BoolVal: &configs.EnableMail, BoolDefault: false},                // Enable Mail
+		"externalHostName":          {Type: cfgTypeString, StringVal: &configs.ExternalHostName},                          // External Hostname
+		"reportRedirectUriTemplate": {Type: cfgTypeString, StringVal: &configs.ReportRedirectURITemplate},                 // RedirectUriTemplate for report access
+		"smtpHost":                  {Type: cfgTypeString, StringVal: &configs.SMTPHost},                                  // SMTP Host
+		"smtpUserSecret":            {Type: cfgTypeString, StringVal: &configs.SMTPUserSecret},                            // SMTP Cred
+		"collectPeriod":             {Type: cfgTypeInt, IntVal: &configs.CollectPeriod, IntDefault: 120},                  // GC period
+		"integrationJobTTL":         {Type: cfgTypeInt, IntVal: &configs.IntegrationJobTTL, IntDefault: 120},              // GC threshold
+		"ingressClass":              {Type: cfgTypeString, StringVal: &configs.IngressClass, StringDefault: ""},           // Ingress class
+		"allowSha1WebhookSignature": {Type: cfgTypeBool, BoolVal: &configs.AllowSHA1WebhookSignature, BoolDefault: false}, // Accept SHA-1 webhook signatures
 	}
 
 	getVars(cm.Data, vars)
@@ -20,10 +20,18 @@ import (
 	"context"
 	"fmt"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/operator-framework/operator-lib/status"
 	"github.com/tmax-cloud/cicd-operator/internal/utils"
+	"github.com/tmax-cloud/cicd-operator/pkg/expression"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"github.com/tmax-cloud/cicd-operator/pkg/git/github"
+	"gopkg.in/robfig/cron.v2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -39,8 +47,28 @@ const (
 	finalizer         = "cicd.tmax.io/finalizer"
 	gitSecretHostKey  = "tekton.dev/git-0"
 	gitSecretUserName = "tmax-cicd-bot"
+
+	bitbucketGitSecretUserName = "x-token-auth"
+	githubAppGitSecretUserName = "x-access-token"
+
+	// webhookVerificationPeriod is a period of verifying the webhook registered to the remote git server
+	webhookVerificationPeriod = 10 * time.Minute
+
+	// webhookRetryPeriod is a period of retrying the failed webhook registration
+	webhookRetryPeriod = time.Minute
+
+	// githubAppTokenResyncPeriod is a period of refreshing the git secret for GitHub App,
+	// as the installation access tokens expire in an hour
+	githubAppTokenResyncPeriod = 10 * time.Minute
+
+	// webhookSecretRotatedReason is a reason of webhook-registered condition, set until the webhook is registered again
+	// with the rotated secret
+	webhookSecretRotatedReason = "webhookSecretRotated"
 )
 
+// repositoryPattern is a pattern of the repository names in <org>/<repo> form
+var repositoryPattern = regexp.MustCompile(`.+/.+`)
+
 // IntegrationConfigReconciler reconciles a IntegrationConfig object
 type IntegrationConfigReconciler struct {
 	client.Client
@@ -60,6 +88,7 @@ func (r *IntegrationConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 	instance := &cicdv1.IntegrationConfig{}
 	if err := r.Client.Get(ctx, req.NamespacedName, instance); err != nil {
 		if errors.IsNotFound(err) {
+			git.EvictHTTPClient(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		log.Error(err, "")
@@ -95,12 +124,32 @@ func (r *IntegrationConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 		return ctrl.Result{}, nil
 	}
 
+	// Validate repository
+	if err := validateRepository(instance); err != nil {
+		cond.Status = corev1.ConditionFalse
+		cond.Reason = "InvalidRepository"
+		cond.Message = err.Error()
+		return ctrl.Result{}, nil
+	}
+
+	// Rotate secret, if requested
+	secretRotated, err := r.rotateWebhookSecret(instance)
+	if err != nil {
+		log.Error(err, "")
+		cond.Reason = "CannotRotateWebhookSecret"
+		cond.Message = err.Error()
+		return ctrl.Result{}, nil
+	}
+
 	// Set secret
 	secretChanged := r.setSecretString(instance)
 
 	// Set webhook registered
 	webhookConditionChanged := r.setWebhookRegisteredCond(instance)
 
+	// Set jobs valid
+	jobsValidConditionChanged := setJobsValidCond(instance)
+
 	// Set ready
 	readyConditionChanged := r.setReadyCond(instance)
 
@@ -121,7 +170,7 @@ func (r *IntegrationConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 	}
 
 	// If conditions changed, update status
-	if secretChanged || webhookConditionChanged || readyConditionChanged {
+	if secretRotated || secretChanged || webhookConditionChanged || jobsValidConditionChanged || readyConditionChanged {
 		p := client.MergeFrom(original)
 		if err := r.Client.Status().Patch(ctx, instance, p); err != nil {
 			log.Error(err, "")
@@ -129,7 +178,34 @@ func (r *IntegrationConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 		}
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeuePeriod(instance)}, nil
+}
+
+// requeuePeriod returns the period after which the IntegrationConfig should be reconciled again, 0 if it's not needed
+func requeuePeriod(instance *cicdv1.IntegrationConfig) time.Duration {
+	var period time.Duration
+
+	// Previous webhook secret should be cleaned up after it expires
+	if instance.Status.PreviousSecretsExpiresAt != nil {
+		period = time.Until(instance.Status.PreviousSecretsExpiresAt.Time) + time.Second
+	}
+
+	// Installation access tokens of GitHub App should be refreshed before they expire
+	if instance.Spec.Git.GitHubApp != nil && (period == 0 || githubAppTokenResyncPeriod < period) {
+		period = githubAppTokenResyncPeriod
+	}
+
+	// Registered webhook should be verified periodically, and failed registration should be retried
+	// Requests to the git server are not retried in place, so they're retried by requeueing
+	verificationPeriod := webhookVerificationPeriod
+	if !instance.Status.Conditions.IsTrueFor(cicdv1.IntegrationConfigConditionWebhookRegistered) {
+		verificationPeriod = webhookRetryPeriod
+	}
+	if !instance.Spec.Git.IsPolling() && (period == 0 || verificationPeriod < period) {
+		period = verificationPeriod
+	}
+
+	return period
 }
 
 // SetupWithManager sets IntegrationConfigReconciler to the manager
@@ -171,7 +247,7 @@ func (r *IntegrationConfigReconciler) handleFinalizer(instance, original *cicdv1
 				r.Log.Error(err, "")
 			}
 			for _, h := range hookList {
-				if h.URL == instance.GetWebhookServerAddress() {
+				if isWebhookOf(instance, h.URL) {
 					r.Log.Info("Deleting webhook " + h.URL)
 					if err := gitCli.DeleteWebhook(h.ID); err != nil {
 						r.Log.Error(err, "")
@@ -180,6 +256,8 @@ func (r *IntegrationConfigReconciler) handleFinalizer(instance, original *cicdv1
 			}
 		}
 
+		git.EvictHTTPClient(types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace})
+
 		// Delete finalizer
 		if len(instance.Finalizers) == 1 {
 			instance.Finalizers = nil
@@ -212,9 +290,75 @@ func (r *IntegrationConfigReconciler) setSecretString(instance *cicdv1.Integrati
 	return secretChanged
 }
 
-// Set webhook-registered condition, return if it's changed or not
+// rotateWebhookSecret rotates the webhook secret if it's requested by the annotation, return if the secrets are changed or not
+// The previous secret is accepted during the grace period, and the webhook is re-registered with the new secret by
+// setWebhookRegisteredCond. The annotation is removed before the new secret is generated, so that the secret is rotated
+// only once for a request, even if persisting the new secret fails. Then the rotation should be requested again
+func (r *IntegrationConfigReconciler) rotateWebhookSecret(instance *cicdv1.IntegrationConfig) (bool, error) {
+	changed := false
+
+	gracePeriodStr, requested := instance.Annotations[cicdv1.AnnotationRotateWebhookSecret]
+	if requested && instance.Status.Secrets != "" {
+		gracePeriod := cicdv1.DefaultWebhookSecretGracePeriod
+		if gracePeriodStr != "" {
+			d, err := time.ParseDuration(gracePeriodStr)
+			if err != nil {
+				return false, fmt.Errorf("annotation %s is not a valid duration: %s", cicdv1.AnnotationRotateWebhookSecret, err.Error())
+			}
+			gracePeriod = d
+		}
+
+		// Remove the annotation, not to rotate again
+		// The optimistic lock prevents rotating again for a stale IntegrationConfig whose annotation is already removed
+		original := instance.DeepCopy()
+		delete(instance.Annotations, cicdv1.AnnotationRotateWebhookSecret)
+		if err := r.Client.Patch(context.Background(), instance, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+			return false, err
+		}
+
+		r.Log.Info(fmt.Sprintf("Rotating webhook secret of %s/%s", instance.Namespace, instance.Name))
+
+		original = instance.DeepCopy()
+		instance.Status.PreviousSecrets = instance.Status.Secrets
+		instance.Status.PreviousSecretsExpiresAt = &metav1.Time{Time: time.Now().Add(gracePeriod)}
+		instance.Status.Secrets = utils.RandomString(20)
+		changed = true
+
+		// Let the webhook be registered again with the new secret
+		if !instance.Spec.Git.IsPolling() {
+			instance.Status.Conditions.SetCondition(status.Condition{
+				Type:    cicdv1.IntegrationConfigConditionWebhookRegistered,
+				Status:  corev1.ConditionFalse,
+				Reason:  webhookSecretRotatedReason,
+				Message: "webhook secret is rotated",
+			})
+		}
+
+		// Persist the new secret
+		if err := r.Client.Status().Patch(context.Background(), instance, client.MergeFrom(original)); err != nil {
+			instance.Status = *original.Status.DeepCopy()
+			return false, fmt.Errorf("cannot persist the rotated webhook secret, request the rotation again: %s", err.Error())
+		}
+	}
+
+	// Clean up the expired previous secret
+	if instance.Status.PreviousSecretsExpiresAt != nil && !time.Now().Before(instance.Status.PreviousSecretsExpiresAt.Time) {
+		instance.Status.PreviousSecrets = ""
+		instance.Status.PreviousSecretsExpiresAt = nil
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// isWebhookOf returns if the webhook url is registered for the IntegrationConfig
+func isWebhookOf(instance *cicdv1.IntegrationConfig, url string) bool {
+	return url == instance.GetWebhookServerAddress() || (instance.Status.WebhookURL != "" && url == instance.Status.WebhookURL)
+}
+
+// Set webhook-registered and webhook-stale conditions, return if they're changed or not
+// The registered webhook is verified periodically, and is repaired if it's drifted from the IntegrationConfig
 func (r *IntegrationConfigReconciler) setWebhookRegisteredCond(instance *cicdv1.IntegrationConfig) bool {
-	webhookConditionChanged := false
 	webhookRegistered := instance.Status.Conditions.GetCondition(cicdv1.IntegrationConfigConditionWebhookRegistered)
 	if webhookRegistered == nil {
 		webhookRegistered = &status.Condition{
@@ -223,48 +367,228 @@ func (r *IntegrationConfigReconciler) setWebhookRegisteredCond(instance *cicdv1.
 		}
 	}
 
-	// Register only if the condition is false
-	if webhookRegistered.IsFalse() {
+	// Webhook is not needed for poll trigger
+	if instance.Spec.Git.IsPolling() {
 		webhookRegistered.Status = corev1.ConditionFalse
-		webhookRegistered.Reason = ""
-		webhookRegistered.Message = ""
+		webhookRegistered.Reason = "pollTrigger"
+		webhookRegistered.Message = "webhook is not registered, as the remote git server is polled"
+		return instance.Status.Conditions.SetCondition(*webhookRegistered)
+	}
 
-		gitCli, err := utils.GetGitCli(instance, r.Client)
-		if err != nil {
-			webhookRegistered.Reason = "invalidGitType"
-			webhookRegistered.Message = fmt.Sprintf("git type %s is not supported", instance.Spec.Git.Type)
-		} else {
-			addr := instance.GetWebhookServerAddress()
-			isUnique := true
-			r.Log.Info("Registering webhook " + addr)
-			entries, err := gitCli.ListWebhook()
-			if err != nil {
-				webhookRegistered.Reason = "webhookRegisterFailed"
-				webhookRegistered.Message = err.Error()
+	// Register if the condition is false, and verify if the verification period is passed
+	now := time.Now()
+	wasRegistered := webhookRegistered.IsTrue()
+	secretRotated := webhookRegistered.Reason == webhookSecretRotatedReason
+	events := webhookEventStrings(instance)
+	if wasRegistered && !webhookVerificationDue(instance, now) && reflect.DeepEqual(events, instance.Status.WebhookEvents) {
+		return false
+	}
+
+	webhookStale := instance.Status.Conditions.GetCondition(cicdv1.IntegrationConfigConditionWebhookStale)
+	if webhookStale == nil {
+		webhookStale = &status.Condition{
+			Type:   cicdv1.IntegrationConfigConditionWebhookStale,
+			Status: corev1.ConditionFalse,
+		}
+	}
+
+	changed := false
+	gitCli, err := utils.GetGitCli(instance, r.Client)
+	if err != nil {
+		webhookRegistered.Status = corev1.ConditionFalse
+		webhookRegistered.Reason = "invalidGitType"
+		webhookRegistered.Message = fmt.Sprintf("git type %s is not supported", instance.Spec.Git.Type)
+	} else {
+		driftReason, driftMessage, err := r.syncWebhook(instance, gitCli, wasRegistered, secretRotated)
+		switch {
+		case err != nil && secretRotated:
+			// Keep the reason, to replace the webhook registered with the previous secret on the next reconciliation
+			r.Log.Error(err, "")
+			webhookRegistered.Status = corev1.ConditionFalse
+			webhookRegistered.Message = fmt.Sprintf("webhook secret is rotated, but the webhook cannot be registered again: %s", err.Error())
+		case err != nil:
+			r.Log.Error(err, "")
+			webhookRegistered.Status = corev1.ConditionFalse
+			webhookRegistered.Reason = "webhookRegisterFailed"
+			webhookRegistered.Message = err.Error()
+		default:
+			webhookRegistered.Status = corev1.ConditionTrue
+			webhookRegistered.Reason = ""
+			webhookRegistered.Message = ""
+		}
+
+		switch {
+		case driftReason != "" && err != nil:
+			webhookStale.Status = corev1.ConditionTrue
+			webhookStale.Reason = status.ConditionReason(driftReason)
+			webhookStale.Message = fmt.Sprintf("%s, but cannot be repaired: %s", driftMessage, err.Error())
+		case driftReason != "":
+			webhookStale.Status = corev1.ConditionFalse
+			webhookStale.Reason = "webhookRepaired"
+			webhookStale.Message = fmt.Sprintf("%s, and is repaired", driftMessage)
+		case err == nil && webhookStale.IsTrue():
+			webhookStale.Status = corev1.ConditionFalse
+			webhookStale.Reason = ""
+			webhookStale.Message = ""
+		}
+
+		// Failed ones are retried on the next reconciliation, regardless of the verification period
+		if err == nil {
+			instance.Status.LastWebhookVerificationTime = &metav1.Time{Time: now}
+			instance.Status.WebhookEvents = events
+			changed = true
+		}
+	}
+
+	if instance.Status.Conditions.SetCondition(*webhookRegistered) {
+		changed = true
+	}
+	if instance.Status.Conditions.SetCondition(*webhookStale) {
+		changed = true
+	}
+	return changed
+}
+
+// webhookVerificationDue returns if the registered webhook should be verified
+func webhookVerificationDue(instance *cicdv1.IntegrationConfig, now time.Time) bool {
+	last := instance.Status.LastWebhookVerificationTime
+	return last == nil || !now.Before(last.Add(webhookVerificationPeriod))
+}
+
+// webhookEventStrings returns the events which the webhook should be subscribed to
+// The webhook is verified again if they're changed
+func webhookEventStrings(instance *cicdv1.IntegrationConfig) []string {
+	var events []string
+	for _, ev := range git.WebhookEvents(instance) {
+		events = append(events, string(ev))
+	}
+	return events
+}
+
+// syncWebhook verifies the webhooks registered to the remote git server, and repairs them if they're drifted
+// If the secret is rotated, the webhooks are registered again with the new secret
+// It returns the reason and the message of the drift, empty if the webhook is up to date
+func (r *IntegrationConfigReconciler) syncWebhook(instance *cicdv1.IntegrationConfig, gitCli git.Client, wasRegistered, secretRotated bool) (string, string, error) {
+	addr := instance.GetWebhookServerAddress()
+	entries, err := gitCli.ListWebhook()
+	if err != nil {
+		return "", "", err
+	}
+
+	var driftReason, driftMessage string
+	drift := func(reason, message string) {
+		if driftReason == "" {
+			driftReason, driftMessage = reason, message
+		}
+	}
+
+	found := false
+	for _, e := range entries {
+		switch {
+		case e.URL == addr && found:
+			drift("webhookDuplicated", "webhook is registered more than once")
+		case e.URL == addr && secretRotated:
+			// Registered with the previous secret
+		case e.URL == addr:
+			detector, ok := gitCli.(git.WebhookDriftDetector)
+			if !ok {
+				found = true
+				continue
 			}
-			for _, e := range entries {
-				if addr == e.URL {
-					webhookRegistered.Reason = "webhookRegisterFailed"
-					webhookRegistered.Message = "same webhook has already registered"
-					isUnique = false
-					break
-				}
+			reason, message := git.WebhookDrift(detector.ExpectedWebhook(addr), e)
+			if reason == "" {
+				found = true
+				continue
 			}
-			if isUnique {
-				if err := gitCli.RegisterWebhook(addr); err != nil {
-					webhookRegistered.Reason = "webhookRegisterFailed"
-					webhookRegistered.Message = err.Error()
-				} else {
-					webhookRegistered.Status = corev1.ConditionTrue
-					webhookRegistered.Reason = ""
-					webhookRegistered.Message = ""
+			drift(reason, message)
+		case instance.Status.WebhookURL != "" && e.URL == instance.Status.WebhookURL:
+			// The webhook server address is changed (e.g., ExternalHostName is changed)
+			drift("webhookURLChanged", fmt.Sprintf("webhook url %s is different from %s", e.URL, addr))
+		default:
+			continue
+		}
+
+		r.Log.Info("Deleting webhook " + e.URL)
+		if err := gitCli.DeleteWebhook(e.ID); err != nil {
+			return driftReason, driftMessage, err
+		}
+	}
+
+	if !found {
+		if wasRegistered {
+			drift("webhookMissing", "webhook is not registered")
+		}
+		r.Log.Info("Registering webhook " + addr)
+		if err := gitCli.RegisterWebhook(addr); err != nil {
+			return driftReason, driftMessage, err
+		}
+	}
+	instance.Status.WebhookURL = addr
+
+	return driftReason, driftMessage, nil
+}
+
+// validateRepository validates the form of the repository name, which is <org>/<repo> for the git types except gerrit
+// Gerrit project names do not need to contain '/'
+func validateRepository(instance *cicdv1.IntegrationConfig) error {
+	if instance.Spec.Git.Type == cicdv1.GitTypeGerrit || repositoryPattern.MatchString(instance.Spec.Git.Repository) {
+		return nil
+	}
+	return fmt.Errorf("repository %s should be in <org>/<repo> form", instance.Spec.Git.Repository)
+}
+
+// setJobsValidCond sets jobs-valid condition, by validating the when conditions and the cron specs of the jobs
+// It returns if the condition is changed or not
+func setJobsValidCond(instance *cicdv1.IntegrationConfig) bool {
+	cond := status.Condition{
+		Type:   cicdv1.IntegrationConfigConditionJobsValid,
+		Status: corev1.ConditionTrue,
+	}
+	if errs := validateJobs(instance); len(errs) > 0 {
+		cond.Status = corev1.ConditionFalse
+		cond.Reason = "InvalidJobs"
+		cond.Message = strings.Join(errs, ", ")
+	}
+	return instance.Status.Conditions.SetCondition(cond)
+}
+
+// validateJobs validates the regular expressions and the expressions of the jobs' when conditions,
+// which otherwise make the jobs silently skipped, and the cron specs of the periodic jobs
+func validateJobs(instance *cicdv1.IntegrationConfig) []string {
+	var errs []string
+	validateWhen := func(jobType cicdv1.JobType, job *cicdv1.Job) {
+		if job.When == nil {
+			return
+		}
+		for _, patterns := range [][]string{job.When.Branch, job.When.SkipBranch, job.When.Tag, job.When.SkipTag} {
+			for _, pattern := range patterns {
+				if _, err := regexp.Compile(pattern); err != nil {
+					errs = append(errs, fmt.Sprintf("%s job %s has an invalid regular expression %q: %s", jobType, job.Name, pattern, err.Error()))
 				}
 			}
 		}
-		webhookConditionChanged = instance.Status.Conditions.SetCondition(*webhookRegistered)
+		if job.When.Expression != "" {
+			if _, err := expression.Compile(job.When.Expression); err != nil {
+				errs = append(errs, fmt.Sprintf("%s job %s has an invalid expression: %s", jobType, job.Name, err.Error()))
+			}
+		}
 	}
 
-	return webhookConditionChanged
+	for i := range instance.Spec.Jobs.PreSubmit {
+		validateWhen(cicdv1.JobTypePreSubmit, &instance.Spec.Jobs.PreSubmit[i])
+	}
+	for i := range instance.Spec.Jobs.PostSubmit {
+		validateWhen(cicdv1.JobTypePostSubmit, &instance.Spec.Jobs.PostSubmit[i])
+	}
+	for i := range instance.Spec.Jobs.Release {
+		validateWhen(cicdv1.JobTypeRelease, &instance.Spec.Jobs.Release[i])
+	}
+	for _, job := range instance.Spec.Jobs.Periodic {
+		if _, err := cron.Parse(job.Cron); err != nil {
+			errs = append(errs, fmt.Sprintf("%s job %s has an invalid cron spec %q: %s", cicdv1.JobTypePeriodic, job.Name, job.Cron, err.Error()))
+		}
+	}
+	return errs
 }
 
 // Set ready condition, return if it's changed or not
@@ -277,9 +601,9 @@ func (r *IntegrationConfigReconciler) setReadyCond(instance *cicdv1.IntegrationC
 		}
 	}
 
-	// For now, only checked is if webhook-registered is true & secrets are set
+	// For now, only checked is if webhook-registered is true (or the remote git server is polled) & secrets are set
 	webhookRegistered := instance.Status.Conditions.GetCondition(cicdv1.IntegrationConfigConditionWebhookRegistered)
-	if instance.Status.Secrets != "" && webhookRegistered != nil && webhookRegistered.Status == corev1.ConditionTrue {
+	if instance.Status.Secrets != "" && (instance.Spec.Git.IsPolling() || (webhookRegistered != nil && webhookRegistered.Status == corev1.ConditionTrue)) {
 		ready.Status = corev1.ConditionTrue
 	}
 	readyConditionChanged := instance.Status.Conditions.SetCondition(*ready)
@@ -347,22 +671,51 @@ func (r *IntegrationConfigReconciler) updateGitSecret(instance *cicdv1.Integrati
 	secret.Type = corev1.SecretTypeBasicAuth
 
 	// check and set token
-	token, err := instance.GetToken(r.Client)
+	userName, token, err := r.getGitCredential(instance)
 	if err != nil {
 		return false, err
 	}
 	if secret.Data == nil {
 		needPatch = true
 		secret.Data = map[string][]byte{}
-	} else if string(secret.Data[corev1.BasicAuthUsernameKey]) != gitSecretUserName || string(secret.Data[corev1.BasicAuthPasswordKey]) != token {
+	} else if string(secret.Data[corev1.BasicAuthUsernameKey]) != userName || string(secret.Data[corev1.BasicAuthPasswordKey]) != token {
 		needPatch = true
 	}
-	secret.Data[corev1.BasicAuthUsernameKey] = []byte(gitSecretUserName)
+	secret.Data[corev1.BasicAuthUsernameKey] = []byte(userName)
 	secret.Data[corev1.BasicAuthPasswordKey] = []byte(token)
 
 	return needPatch, nil
 }
 
+// getGitCredential returns user name and password (token) for cloning the repository
+func (r *IntegrationConfigReconciler) getGitCredential(instance *cicdv1.IntegrationConfig) (string, string, error) {
+	// GitHub App's installation access token
+	if instance.Spec.Git.Type == cicdv1.GitTypeGitHub && instance.Spec.Git.GitHubApp != nil {
+		token, _, err := github.GetInstallationToken(instance, r.Client)
+		if err != nil {
+			return "", "", err
+		}
+		return githubAppGitSecretUserName, token, nil
+	}
+
+	token, err := instance.GetToken(r.Client)
+	if err != nil {
+		return "", "", err
+	}
+	userName := gitSecretUserName
+	if instance.Spec.Git.Type == cicdv1.GitTypeBitbucket {
+		// Bitbucket requires x-token-auth for access tokens
+		userName = bitbucketGitSecretUserName
+	}
+	if instance.Spec.Git.Type == cicdv1.GitTypeBitbucket || instance.Spec.Git.Type == cicdv1.GitTypeGerrit {
+		// Token in a form of <user name>:<password> requires the real user name
+		if tokens := strings.SplitN(token, ":", 2); len(tokens) == 2 {
+			userName, token = tokens[0], tokens[1]
+		}
+	}
+	return userName, token, nil
+}
+
 // Create service account for pipeline run
 func (r *IntegrationConfigReconciler) createServiceAccount(instance *cicdv1.IntegrationConfig) error {
 	// Get and check if values are set right
@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/internal/configs"
+	gitfake "github.com/tmax-cloud/cicd-operator/pkg/git/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestIntegrationConfigReconciler_setWebhookRegisteredCond(t *testing.T) {
+	configs.ExternalHostName = "cicd.tmax.co.kr"
+
+	srv := gitfake.NewGitHubServer(&gitfake.Repository{Name: "tmax-cloud/cicd-operator"}, "test-token")
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+	r := &IntegrationConfigReconciler{Client: fake.NewFakeClientWithScheme(s), Log: ctrl.Log, Scheme: s}
+
+	instance := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Git: cicdv1.GitConfig{
+				Type:       cicdv1.GitTypeGitHub,
+				Repository: "tmax-cloud/cicd-operator",
+				APIUrl:     srv.URL,
+				Token:      cicdv1.GitToken{Value: "test-token"},
+			},
+			Jobs: cicdv1.IntegrationConfigJobs{
+				PreSubmit: cicdv1.Jobs{{Container: corev1.Container{Name: "test"}}},
+			},
+		},
+		Status: cicdv1.IntegrationConfigStatus{Secrets: "test-secret"},
+	}
+	expire := func() {
+		instance.Status.LastWebhookVerificationTime = &metav1.Time{Time: time.Now().Add(-webhookVerificationPeriod)}
+	}
+	type condition struct{ status, reason string }
+	stale := func() condition {
+		c := instance.Status.Conditions.GetCondition(cicdv1.IntegrationConfigConditionWebhookStale)
+		return condition{string(c.Status), string(c.Reason)}
+	}
+
+	// Registration
+	assert.Equal(t, true, r.setWebhookRegisteredCond(instance))
+	assert.Equal(t, true, instance.Status.Conditions.IsTrueFor(cicdv1.IntegrationConfigConditionWebhookRegistered))
+	assert.Equal(t, condition{"False", ""}, stale())
+	assert.Equal(t, "http://cicd.tmax.co.kr/webhook/default/test-ic", instance.Status.WebhookURL)
+	hooks := srv.Repository().Hooks
+	assert.Equal(t, 1, len(hooks))
+	assert.Equal(t, instance.Status.WebhookURL, hooks[0].URL)
+
+	// Not verified before the verification period
+	srv.ResetRequests()
+	assert.Equal(t, false, r.setWebhookRegisteredCond(instance))
+	assert.Equal(t, 0, len(srv.Requests()))
+
+	// Deleted webhook
+	srv.Update(func(repo *gitfake.Repository) { repo.Hooks = nil })
+	expire()
+	assert.Equal(t, true, r.setWebhookRegisteredCond(instance))
+	assert.Equal(t, condition{"False", "webhookRepaired"}, stale())
+	assert.Equal(t, 1, len(srv.Repository().Hooks))
+
+	// Modified webhook
+	srv.Update(func(repo *gitfake.Repository) {
+		repo.Hooks[0].Events = []string{"push"}
+		repo.Hooks[0].Secret = ""
+	})
+	expire()
+	r.setWebhookRegisteredCond(instance)
+	assert.Equal(t, condition{"False", "webhookRepaired"}, stale())
+	hooks = srv.Repository().Hooks
+	assert.Equal(t, 1, len(hooks))
+	assert.Equal(t, []string{"pull_request", "issue_comment", "pull_request_review", "pull_request_review_comment"}, hooks[0].Events)
+	assert.Equal(t, "test-secret", hooks[0].Secret)
+
+	// Changed jobs, before the verification period
+	instance.Spec.Jobs.PostSubmit = cicdv1.Jobs{{Container: corev1.Container{Name: "test"}}}
+	assert.Equal(t, true, r.setWebhookRegisteredCond(instance))
+	hooks = srv.Repository().Hooks
+	assert.Equal(t, 1, len(hooks))
+	assert.Equal(t, []string{"pull_request", "issue_comment", "pull_request_review", "pull_request_review_comment", "push"}, hooks[0].Events)
+	assert.Equal(t, []string{"pull_request", "issue_comment", "push"}, instance.Status.WebhookEvents)
+
+	// Changed external host name
+	configs.ExternalHostName = "cicd-new.tmax.co.kr"
+	expire()
+	r.setWebhookRegisteredCond(instance)
+	hooks = srv.Repository().Hooks
+	assert.Equal(t, 1, len(hooks))
+	assert.Equal(t, "http://cicd-new.tmax.co.kr/webhook/default/test-ic", hooks[0].URL)
+	assert.Equal(t, hooks[0].URL, instance.Status.WebhookURL)
+
+	// Cannot verify the webhook
+	instance.Spec.Git.Token.Value = "wrong-token"
+	expire()
+	verifiedAt := instance.Status.LastWebhookVerificationTime
+	assert.Equal(t, true, r.setWebhookRegisteredCond(instance))
+	cond := instance.Status.Conditions.GetCondition(cicdv1.IntegrationConfigConditionWebhookRegistered)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, "webhookRegisterFailed", string(cond.Reason))
+	assert.Equal(t, verifiedAt, instance.Status.LastWebhookVerificationTime)
+
+	// Recovered
+	instance.Spec.Git.Token.Value = "test-token"
+	r.setWebhookRegisteredCond(instance)
+	assert.Equal(t, true, instance.Status.Conditions.IsTrueFor(cicdv1.IntegrationConfigConditionWebhookRegistered))
+	assert.Equal(t, 1, len(srv.Repository().Hooks))
+}
+
+func TestIntegrationConfigReconciler_rotateWebhookSecret(t *testing.T) {
+	configs.ExternalHostName = "cicd.tmax.co.kr"
+
+	srv := gitfake.NewGitHubServer(&gitfake.Repository{Name: "tmax-cloud/cicd-operator"}, "test-token")
+	defer srv.Close()
+
+	instance := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test-ic",
+			Namespace:       "default",
+			ResourceVersion: "1",
+			Annotations:     map[string]string{cicdv1.AnnotationRotateWebhookSecret: "1h"},
+		},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Git: cicdv1.GitConfig{
+				Type:       cicdv1.GitTypeGitHub,
+				Repository: "tmax-cloud/cicd-operator",
+				APIUrl:     srv.URL,
+				Token:      cicdv1.GitToken{Value: "test-token"},
+			},
+			Jobs: cicdv1.IntegrationConfigJobs{
+				PreSubmit: cicdv1.Jobs{{Container: corev1.Container{Name: "test"}}},
+			},
+		},
+		Status: cicdv1.IntegrationConfigStatus{Secrets: "test-secret"},
+	}
+
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+	r := &IntegrationConfigReconciler{Client: fake.NewFakeClientWithScheme(s, instance.DeepCopy()), Log: ctrl.Log, Scheme: s}
+	// Registered with the previous secret
+	original := instance.DeepCopy()
+	r.setWebhookRegisteredCond(instance)
+	assert.Equal(t, "test-secret", srv.Repository().Hooks[0].Secret)
+
+	// Rotation is persisted, without deleting the webhook
+	srv.ResetRequests()
+	changed, err := r.rotateWebhookSecret(instance)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, changed)
+	assert.Equal(t, 0, len(srv.Requests()))
+	assert.Equal(t, 1, len(srv.Repository().Hooks))
+
+	stored := &cicdv1.IntegrationConfig{}
+	assert.Equal(t, nil, r.Client.Get(context.Background(), types.NamespacedName{Name: "test-ic", Namespace: "default"}, stored))
+	_, annotated := stored.Annotations[cicdv1.AnnotationRotateWebhookSecret]
+	assert.Equal(t, false, annotated)
+	assert.Equal(t, "test-secret", stored.Status.PreviousSecrets)
+	assert.Equal(t, instance.Status.Secrets, stored.Status.Secrets)
+	assert.NotEqual(t, "test-secret", stored.Status.Secrets)
+
+	// Rotated only once for a request, even for a stale IntegrationConfig still annotated
+	rotated := stored.Status.Secrets
+	changed, err = r.rotateWebhookSecret(instance)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, changed)
+	stale := original.DeepCopy()
+	_, err = r.rotateWebhookSecret(stale)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, nil, r.Client.Get(context.Background(), types.NamespacedName{Name: "test-ic", Namespace: "default"}, stored))
+	assert.Equal(t, rotated, stored.Status.Secrets)
+	assert.Equal(t, "test-secret", stored.Status.PreviousSecrets)
+
+	// Webhook is registered again with the new secret
+	r.setWebhookRegisteredCond(instance)
+	assert.Equal(t, true, instance.Status.Conditions.IsTrueFor(cicdv1.IntegrationConfigConditionWebhookRegistered))
+	hooks := srv.Repository().Hooks
+	assert.Equal(t, 1, len(hooks))
+	assert.Equal(t, instance.Status.Secrets, hooks[0].Secret)
+}
+
+func TestSetJobsValidCond(t *testing.T) {
+	instance := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Jobs: cicdv1.IntegrationConfigJobs{
+				PreSubmit: cicdv1.Jobs{
+					{Container: corev1.Container{Name: "test"}, When: &cicdv1.JobWhen{Branch: []string{"master"}, Expression: `"run-e2e" in labels`}},
+				},
+				Periodic: cicdv1.PeriodicJobs{
+					{Job: cicdv1.Job{Container: corev1.Container{Name: "nightly"}}, Cron: "@daily", Branch: "master"},
+				},
+				Release: cicdv1.Jobs{
+					{Container: corev1.Container{Name: "publish"}, When: &cicdv1.JobWhen{Tag: []string{"v.*"}}},
+				},
+			},
+		},
+	}
+
+	// Valid
+	assert.Equal(t, true, setJobsValidCond(instance))
+	assert.Equal(t, true, instance.Status.Conditions.IsTrueFor(cicdv1.IntegrationConfigConditionJobsValid))
+	assert.Equal(t, false, setJobsValidCond(instance))
+
+	// Invalid
+	instance.Spec.Jobs.PreSubmit[0].When.SkipBranch = []string{"feat/(.*"}
+	instance.Spec.Jobs.PreSubmit[0].When.Expression = `labels == "run-e2e"`
+	instance.Spec.Jobs.Periodic[0].Cron = "every day"
+	instance.Spec.Jobs.Release[0].When.Tag = []string{"v(.*"}
+	assert.Equal(t, true, setJobsValidCond(instance))
+	cond := instance.Status.Conditions.GetCondition(cicdv1.IntegrationConfigConditionJobsValid)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, "InvalidJobs", string(cond.Reason))
+	assert.Equal(t, 4, len(validateJobs(instance)))
+}
+
+func TestValidateRepository(t *testing.T) {
+	tc := map[string]struct {
+		gitType    cicdv1.GitType
+		repository string
+		valid      bool
+	}{
+		"github":         {gitType: cicdv1.GitTypeGitHub, repository: "tmax-cloud/cicd-operator", valid: true},
+		"githubNoOrg":    {gitType: cicdv1.GitTypeGitHub, repository: "cicd-operator", valid: false},
+		"azureDevOps":    {gitType: cicdv1.GitTypeAzureDevOps, repository: "tmax-cloud/cicd/cicd-operator", valid: true},
+		"gerrit":         {gitType: cicdv1.GitTypeGerrit, repository: "cicd-operator", valid: true},
+		"gerritNested":   {gitType: cicdv1.GitTypeGerrit, repository: "tmax-cloud/cicd-operator", valid: true},
+		"gitlabEmptyOrg": {gitType: cicdv1.GitTypeGitLab, repository: "/cicd-operator", valid: false},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			instance := &cicdv1.IntegrationConfig{Spec: cicdv1.IntegrationConfigSpec{Git: cicdv1.GitConfig{Type: c.gitType, Repository: c.repository}}}
+			assert.Equal(t, c.valid, validateRepository(instance) == nil)
+		})
+	}
+}
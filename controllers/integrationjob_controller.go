@@ -23,6 +23,7 @@ import (
 	"github.com/tmax-cloud/cicd-operator/pkg/pipelinemanager"
 	"github.com/tmax-cloud/cicd-operator/pkg/scheduler"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -91,6 +92,15 @@ func (r *integrationJobReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 		return ctrl.Result{}, nil
 	}
 
+	// Cancel if it's requested
+	if instance.Spec.Cancel != nil {
+		if err := r.cancel(instance, original); err != nil {
+			log.Error(err, "")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
 	// Skip if it's ended
 	if instance.Status.CompletionTime != nil {
 		return ctrl.Result{}, nil
@@ -188,6 +198,44 @@ func (r *integrationJobReconciler) handleFinalizer(instance, original *cicdv1.In
 	return false, nil
 }
 
+// cancel cancels the PipelineRun and sets the IntegrationJob's state to Canceled
+// The PipelineRun is canceled even if the IntegrationJob is already canceled, in case it is scheduled concurrently
+func (r *integrationJobReconciler) cancel(instance, original *cicdv1.IntegrationJob) error {
+	ctx := context.Background()
+
+	pr := &tektonv1beta1.PipelineRun{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: pipelinemanager.Name(instance), Namespace: instance.Namespace}, pr); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+	} else if pr.Status.CompletionTime == nil && !pr.IsCancelled() {
+		prOriginal := pr.DeepCopy()
+		pr.Spec.Status = tektonv1beta1.PipelineRunSpecStatusCancelled
+		if err := r.Client.Patch(ctx, pr, client.MergeFrom(prOriginal)); err != nil {
+			return err
+		}
+	}
+
+	if instance.Status.CompletionTime != nil {
+		return nil
+	}
+
+	// Notify state change to scheduler, to remove it from the pool
+	defer r.scheduler.Notify(instance)
+
+	now := metav1.Now()
+	instance.Status.State = cicdv1.IntegrationJobStateCanceled
+	instance.Status.Message = instance.Spec.Cancel.GetMessage()
+	instance.Status.CompletionTime = &now
+	for i := range instance.Status.Jobs {
+		if instance.Status.Jobs[i].CompletionTime == nil && instance.Status.Jobs[i].StartTime != nil {
+			instance.Status.Jobs[i].CompletionTime = &now
+		}
+	}
+
+	return r.Client.Status().Patch(ctx, instance, client.MergeFrom(original))
+}
+
 func (r *integrationJobReconciler) patchStatus(instance *cicdv1.IntegrationJob, original *cicdv1.IntegrationJob, message string) {
 	instance.Status.State = cicdv1.IntegrationJobStateFailed
 	instance.Status.Message = message
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/pipelinemanager"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeScheduler struct {
+	notified []cicdv1.IntegrationJobState
+}
+
+func (f *fakeScheduler) Notify(job *cicdv1.IntegrationJob) {
+	f.notified = append(f.notified, job.Status.State)
+}
+
+func TestIntegrationJobReconciler_ReconcileCancel(t *testing.T) {
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+	utilruntime.Must(tektonv1beta1.AddToScheme(s))
+
+	now := metav1.Now()
+	instance := &cicdv1.IntegrationJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ij", Namespace: "default", Finalizers: []string{finalizer}},
+		Spec: cicdv1.IntegrationJobSpec{
+			ID: "test-id",
+			Cancel: &cicdv1.IntegrationJobCancel{
+				Reason:       cicdv1.IntegrationJobCancelReasonSuperseded,
+				SupersededBy: "test-ij-2",
+				Sha:          "0123456789012345678901234567890123456789",
+			},
+		},
+		Status: cicdv1.IntegrationJobStatus{
+			State:     cicdv1.IntegrationJobStateRunning,
+			StartTime: &now,
+			Jobs:      []cicdv1.JobStatus{{Name: "test", StartTime: &now}, {Name: "test-2"}},
+		},
+	}
+	pr := &tektonv1beta1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: pipelinemanager.Name(instance), Namespace: "default"}}
+
+	cli := fake.NewFakeClientWithScheme(s, instance, pr)
+	sch := &fakeScheduler{}
+	r := &integrationJobReconciler{Client: cli, Log: ctrl.Log, Scheme: s, scheduler: sch}
+
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}})
+	assert.Equal(t, nil, err)
+
+	resInstance := &cicdv1.IntegrationJob{}
+	assert.Equal(t, nil, cli.Get(context.Background(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, resInstance))
+	assert.Equal(t, cicdv1.IntegrationJobStateCanceled, resInstance.Status.State)
+	assert.Equal(t, "Superseded by a new commit 0123456789012345678901234567890123456789 (IntegrationJob test-ij-2)", resInstance.Status.Message)
+	assert.T(t, resInstance.Status.CompletionTime != nil)
+	assert.T(t, resInstance.Status.Jobs[0].CompletionTime != nil)
+	assert.T(t, resInstance.Status.Jobs[1].CompletionTime == nil)
+	assert.Equal(t, []cicdv1.IntegrationJobState{cicdv1.IntegrationJobStateCanceled}, sch.notified)
+
+	resPr := &tektonv1beta1.PipelineRun{}
+	assert.Equal(t, nil, cli.Get(context.Background(), types.NamespacedName{Name: pr.Name, Namespace: pr.Namespace}, resPr))
+	assert.Equal(t, tektonv1beta1.PipelineRunSpecStatus(tektonv1beta1.PipelineRunSpecStatusCancelled), resPr.Spec.Status)
+
+	// Already canceled
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(sch.notified))
+}
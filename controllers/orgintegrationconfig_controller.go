@@ -0,0 +1,218 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"github.com/operator-framework/operator-lib/status"
+	"github.com/tmax-cloud/cicd-operator/internal/utils"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+)
+
+// OrgIntegrationConfigReconciler reconciles a OrgIntegrationConfig object
+type OrgIntegrationConfigReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=cicd.tmax.io,resources=orgintegrationconfigs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cicd.tmax.io,resources=orgintegrationconfigs/status,verbs=get;update;patch
+
+// Reconcile discovers the repositories of the organization and generates IntegrationConfigs for them
+func (r *OrgIntegrationConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("orgintegrationconfig", req.NamespacedName)
+
+	instance := &cicdv1.OrgIntegrationConfig{}
+	if err := r.Client.Get(ctx, req.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "")
+		return ctrl.Result{}, err
+	}
+	original := instance.DeepCopy()
+
+	cond := instance.Status.Conditions.GetCondition(cicdv1.OrgIntegrationConfigConditionReady)
+	if cond == nil {
+		cond = &status.Condition{
+			Type:   cicdv1.OrgIntegrationConfigConditionReady,
+			Status: corev1.ConditionFalse,
+		}
+	}
+
+	defer func() {
+		instance.Status.Conditions.SetCondition(*cond)
+		p := client.MergeFrom(original)
+		if err := r.Client.Status().Patch(ctx, instance, p); err != nil {
+			log.Error(err, "")
+		}
+	}()
+
+	// Repositories are discovered periodically, regardless of the result
+	result := ctrl.Result{RequeueAfter: instance.GetDiscoveryInterval()}
+
+	repos, err := r.discoverRepositories(instance)
+	if err != nil {
+		log.Error(err, "")
+		cond.Status = corev1.ConditionFalse
+		cond.Reason = "CannotDiscoverRepositories"
+		cond.Message = err.Error()
+		return result, nil
+	}
+	now := metav1.Now()
+	instance.Status.LastDiscoveryTime = &now
+
+	discovered, err := r.syncIntegrationConfigs(instance, repos)
+	instance.Status.Repositories = discovered
+	if err != nil {
+		log.Error(err, "")
+		cond.Status = corev1.ConditionFalse
+		cond.Reason = "CannotSyncIntegrationConfigs"
+		cond.Message = err.Error()
+		return result, nil
+	}
+
+	cond.Status = corev1.ConditionTrue
+	cond.Reason = ""
+	cond.Message = ""
+
+	return result, nil
+}
+
+// discoverRepositories lists the repositories of the organization, selected by the selector
+func (r *OrgIntegrationConfigReconciler) discoverRepositories(instance *cicdv1.OrgIntegrationConfig) ([]git.OrganizationRepository, error) {
+	// Git client for the organization, not for a specific repository
+	gitCli, err := utils.GetGitCli(&cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: instance.Name, Namespace: instance.Namespace},
+		Spec:       cicdv1.IntegrationConfigSpec{Git: instance.Spec.Git.GitConfig("")},
+	}, r.Client)
+	if err != nil {
+		return nil, err
+	}
+	orgCli, ok := gitCli.(git.OrganizationClient)
+	if !ok {
+		return nil, fmt.Errorf("git type %s does not support organizations", instance.Spec.Git.Type)
+	}
+
+	repos, err := orgCli.ListOrganizationRepositories(instance.Spec.Git.Organization)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []git.OrganizationRepository
+	for _, repo := range repos {
+		if repo.Archived {
+			continue
+		}
+		ok, err := instance.Spec.Repositories.Select(repo.Name, repo.Topics)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			selected = append(selected, repo)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Name < selected[j].Name
+	})
+
+	return selected, nil
+}
+
+// syncIntegrationConfigs creates/updates IntegrationConfigs for the repositories, and deletes the ones for the other repositories
+// It returns the repositories for which IntegrationConfigs are generated
+func (r *OrgIntegrationConfigReconciler) syncIntegrationConfigs(instance *cicdv1.OrgIntegrationConfig, repos []git.OrganizationRepository) ([]cicdv1.DiscoveredRepository, error) {
+	ctx := context.Background()
+
+	icList := &cicdv1.IntegrationConfigList{}
+	if err := r.Client.List(ctx, icList, client.InNamespace(instance.Namespace), client.MatchingLabels{cicdv1.LabelOrgIntegrationConfig: instance.Name}); err != nil {
+		return nil, err
+	}
+	existing := map[string]*cicdv1.IntegrationConfig{}
+	for i := range icList.Items {
+		existing[icList.Items[i].Name] = &icList.Items[i]
+	}
+
+	var discovered []cicdv1.DiscoveredRepository
+	generated := map[string]string{}
+	for _, repo := range repos {
+		desired := instance.GenerateIntegrationConfig(repo.Name)
+		if other, dup := generated[desired.Name]; dup {
+			r.Log.Info(fmt.Sprintf("Skipping repository %s, as its IntegrationConfig name %s is same as the one for %s", repo.Name, desired.Name, other))
+			continue
+		}
+		generated[desired.Name] = repo.Name
+
+		cur, exist := existing[desired.Name]
+		if !exist {
+			r.Log.Info(fmt.Sprintf("Creating IntegrationConfig %s for repository %s", desired.Name, repo.Name))
+			if err := controllerutil.SetControllerReference(instance, desired, r.Scheme); err != nil {
+				return discovered, err
+			}
+			if err := r.Client.Create(ctx, desired); err != nil {
+				return discovered, err
+			}
+		} else if !equality.Semantic.DeepEqual(cur.Spec, desired.Spec) {
+			r.Log.Info(fmt.Sprintf("Updating IntegrationConfig %s for repository %s", desired.Name, repo.Name))
+			p := client.MergeFrom(cur.DeepCopy())
+			cur.Spec = desired.Spec
+			if err := r.Client.Patch(ctx, cur, p); err != nil {
+				return discovered, err
+			}
+		}
+		delete(existing, desired.Name)
+
+		discovered = append(discovered, cicdv1.DiscoveredRepository{Name: repo.Name, IntegrationConfig: desired.Name})
+	}
+
+	// Delete IntegrationConfigs for the repositories which are not selected anymore
+	for _, ic := range existing {
+		r.Log.Info(fmt.Sprintf("Deleting IntegrationConfig %s for repository %s", ic.Name, ic.Spec.Git.Repository))
+		if err := r.Client.Delete(ctx, ic); err != nil && !errors.IsNotFound(err) {
+			return discovered, err
+		}
+	}
+
+	return discovered, nil
+}
+
+// SetupWithManager sets OrgIntegrationConfigReconciler to the manager
+// Status updates are ignored, not to discover the repositories every time the status is updated
+func (r *OrgIntegrationConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&cicdv1.OrgIntegrationConfig{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&cicdv1.IntegrationConfig{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	gitfake "github.com/tmax-cloud/cicd-operator/pkg/git/fake"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestOrgIntegrationConfigReconciler_Reconcile(t *testing.T) {
+	srv := gitfake.NewGitHubServer(&gitfake.Repository{
+		Name:         "tmax-cloud/cicd-operator",
+		Organization: "tmax-cloud",
+		OrganizationRepositories: []git.OrganizationRepository{
+			{Name: "tmax-cloud/cicd-operator", Topics: []string{"ci"}},
+			{Name: "tmax-cloud/cicd-dashboard", Topics: []string{"ci"}},
+			{Name: "tmax-cloud/cicd-archived", Topics: []string{"ci"}, Archived: true},
+			{Name: "tmax-cloud/hypercloud", Topics: []string{"ci"}},
+			{Name: "tmax-cloud/cicd-docs"},
+		},
+	}, "test-token")
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+
+	instance := &cicdv1.OrgIntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-oic", Namespace: "default"},
+		Spec: cicdv1.OrgIntegrationConfigSpec{
+			Git: cicdv1.OrgGitConfig{
+				Type:         cicdv1.GitTypeGitHub,
+				Organization: "tmax-cloud",
+				APIUrl:       srv.URL,
+				Token:        cicdv1.GitToken{Value: "test-token"},
+			},
+			Repositories: cicdv1.RepositorySelector{
+				Names:  []string{"tmax-cloud/cicd-.*"},
+				Topics: []string{"ci"},
+			},
+			Template: cicdv1.IntegrationConfigTemplate{
+				Jobs: cicdv1.IntegrationConfigJobs{
+					PreSubmit: cicdv1.Jobs{{Container: corev1.Container{Name: "test", Image: "alpine"}}},
+				},
+			},
+		},
+	}
+	// Left-over IntegrationConfig for a repository which is not selected anymore
+	stale := instance.GenerateIntegrationConfig("tmax-cloud/old-repo")
+
+	cli := fake.NewFakeClientWithScheme(s, instance, stale)
+	r := &OrgIntegrationConfigReconciler{Client: cli, Log: ctrl.Log, Scheme: s}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}}
+	result, err := r.Reconcile(req)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, cicdv1.DefaultDiscoveryInterval, result.RequeueAfter)
+
+	// Status
+	resInstance := &cicdv1.OrgIntegrationConfig{}
+	assert.Equal(t, nil, cli.Get(context.Background(), req.NamespacedName, resInstance))
+	assert.Equal(t, corev1.ConditionTrue, resInstance.Status.Conditions.GetCondition(cicdv1.OrgIntegrationConfigConditionReady).Status)
+	assert.Equal(t, []cicdv1.DiscoveredRepository{
+		{Name: "tmax-cloud/cicd-dashboard", IntegrationConfig: "test-oic-tmax-cloud-cicd-dashboard"},
+		{Name: "tmax-cloud/cicd-operator", IntegrationConfig: "test-oic-tmax-cloud-cicd-operator"},
+	}, resInstance.Status.Repositories)
+	assert.T(t, resInstance.Status.LastDiscoveryTime != nil)
+
+	// Generated IntegrationConfigs
+	icList := &cicdv1.IntegrationConfigList{}
+	assert.Equal(t, nil, cli.List(context.Background(), icList, client.MatchingLabels{cicdv1.LabelOrgIntegrationConfig: instance.Name}))
+	assert.Equal(t, 2, len(icList.Items))
+	for _, ic := range icList.Items {
+		assert.Equal(t, srv.URL, ic.Spec.Git.APIUrl)
+		assert.Equal(t, "test", ic.Spec.Jobs.PreSubmit[0].Name)
+		assert.Equal(t, 1, len(ic.OwnerReferences))
+		assert.Equal(t, instance.Name, ic.OwnerReferences[0].Name)
+	}
+	assert.T(t, errors.IsNotFound(cli.Get(context.Background(), types.NamespacedName{Name: stale.Name, Namespace: stale.Namespace}, &cicdv1.IntegrationConfig{})))
+
+	// Template is updated
+	resInstance.Spec.Template.Jobs.PreSubmit[0].Image = "busybox"
+	assert.Equal(t, nil, cli.Update(context.Background(), resInstance))
+	_, err = r.Reconcile(req)
+	assert.Equal(t, nil, err)
+	ic := &cicdv1.IntegrationConfig{}
+	assert.Equal(t, nil, cli.Get(context.Background(), types.NamespacedName{Name: "test-oic-tmax-cloud-cicd-operator", Namespace: "default"}, ic))
+	assert.Equal(t, "busybox", ic.Spec.Jobs.PreSubmit[0].Image)
+
+	// Unsupported organization
+	assert.Equal(t, nil, cli.Get(context.Background(), req.NamespacedName, resInstance))
+	resInstance.Spec.Git.Organization = "unknown"
+	assert.Equal(t, nil, cli.Update(context.Background(), resInstance))
+	_, err = r.Reconcile(req)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, cli.Get(context.Background(), req.NamespacedName, resInstance))
+	cond := resInstance.Status.Conditions.GetCondition(cicdv1.OrgIntegrationConfigConditionReady)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, "CannotDiscoverRepositories", string(cond.Reason))
+}
+
+func TestGetGeneratedIntegrationConfigName(t *testing.T) {
+	assert.Equal(t, "test-oic-tmax-cloud-cicd-operator", cicdv1.GetGeneratedIntegrationConfigName("test-oic", "tmax-cloud/cicd-operator"))
+	assert.Equal(t, "test-oic-my-group-sub-my-repo", cicdv1.GetGeneratedIntegrationConfigName("test-oic", "My-Group/sub/my_repo"))
+
+	long := cicdv1.GetGeneratedIntegrationConfigName("test-oic", "tmax-cloud/a-very-long-repository-name-which-exceeds-the-limit-of-label-values")
+	assert.Equal(t, 51, len(long))
+	assert.T(t, long != cicdv1.GetGeneratedIntegrationConfigName("test-oic", "tmax-cloud/a-very-long-repository-name-which-exceeds-the-limit-of-label-values-2"))
+}
@@ -40,4 +40,8 @@ var (
 
 	// IngressClass is a class for ingress instance
 	IngressClass string
+
+	// AllowSHA1WebhookSignature is whether to accept SHA-1 signatures (X-Hub-Signature) of webhooks,
+	// for the git servers which do not send SHA-256 signatures
+	AllowSHA1WebhookSignature bool
 )
@@ -9,6 +9,11 @@ import (
 
 	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
 	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"github.com/tmax-cloud/cicd-operator/pkg/git/azuredevops"
+	"github.com/tmax-cloud/cicd-operator/pkg/git/bitbucket"
+	"github.com/tmax-cloud/cicd-operator/pkg/git/bitbucketserver"
+	"github.com/tmax-cloud/cicd-operator/pkg/git/gerrit"
+	"github.com/tmax-cloud/cicd-operator/pkg/git/gitea"
 	"github.com/tmax-cloud/cicd-operator/pkg/git/github"
 	"github.com/tmax-cloud/cicd-operator/pkg/git/gitlab"
 )
@@ -29,6 +34,16 @@ func GetGitCli(cfg *cicdv1.IntegrationConfig, cli client.Client) (git.Client, er
 		return &github.Client{IntegrationConfig: cfg, K8sClient: cli}, nil
 	case cicdv1.GitTypeGitLab:
 		return &gitlab.Client{IntegrationConfig: cfg, K8sClient: cli}, nil
+	case cicdv1.GitTypeGitea:
+		return &gitea.Client{IntegrationConfig: cfg, K8sClient: cli}, nil
+	case cicdv1.GitTypeBitbucketServer:
+		return &bitbucketserver.Client{IntegrationConfig: cfg, K8sClient: cli}, nil
+	case cicdv1.GitTypeBitbucket:
+		return &bitbucket.Client{IntegrationConfig: cfg, K8sClient: cli}, nil
+	case cicdv1.GitTypeAzureDevOps:
+		return &azuredevops.Client{IntegrationConfig: cfg, K8sClient: cli}, nil
+	case cicdv1.GitTypeGerrit:
+		return &gerrit.Client{IntegrationConfig: cfg, K8sClient: cli}, nil
 	default:
 		return nil, fmt.Errorf("git type %s is not supported", cfg.Spec.Git.Type)
 	}
@@ -28,6 +28,8 @@ import (
 	"github.com/tmax-cloud/cicd-operator/pkg/collector"
 	"github.com/tmax-cloud/cicd-operator/pkg/dispatcher"
 	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"github.com/tmax-cloud/cicd-operator/pkg/periodic"
+	"github.com/tmax-cloud/cicd-operator/pkg/poller"
 	"github.com/tmax-cloud/cicd-operator/pkg/server"
 	"io"
 	rbac "k8s.io/api/rbac/v1"
@@ -125,6 +127,14 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "IntegrationConfig")
 		os.Exit(1)
 	}
+	if err = (&controllers.OrgIntegrationConfigReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("OrgIntegrationConfig"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "OrgIntegrationConfig")
+		os.Exit(1)
+	}
 
 	if err = controllers.NewIntegrationJobReconciler(mgr.GetClient(), mgr.GetScheme(), ctrl.Log.WithName("controllers").WithName("IntegrationJob")).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "IntegrationJob")
@@ -176,10 +186,22 @@ func main() {
 	// Create and start webhook server
 	srv := server.New(mgr.GetClient(), mgr.GetConfig())
 	// Add plugins for webhook
-	server.AddPlugin([]git.EventType{git.EventTypePullRequest, git.EventTypePush}, &dispatcher.Dispatcher{Client: mgr.GetClient()})
+	server.AddPlugin([]git.EventType{git.EventTypePullRequest, git.EventTypePush, git.EventTypeRelease}, &dispatcher.Dispatcher{Client: mgr.GetClient()})
 	server.AddPlugin([]git.EventType{git.EventTypeIssueComment}, chatops.New(mgr.GetClient()))
 	go srv.Start()
 
+	// Start poller for the IntegrationConfigs which cannot receive webhooks, only on the leader
+	if err := mgr.Add(poller.New(mgr.GetClient(), server.HandleEvent)); err != nil {
+		setupLog.Error(err, "unable to add poller")
+		os.Exit(1)
+	}
+
+	// Start periodic trigger for the periodic jobs, only on the leader
+	if err := mgr.Add(periodic.New(mgr.GetClient())); err != nil {
+		setupLog.Error(err, "unable to add periodic trigger")
+		os.Exit(1)
+	}
+
 	// Start API aggregation server
 	apiServer := apiserver.New(mgr.GetScheme())
 	go apiServer.Start()
@@ -16,9 +16,10 @@ import (
 
 // APIGroup and versions
 const (
-	APIGroup     = "cicdapi.tmax.io"
-	APIVersion   = "v1"
-	approvalKind = "approvals"
+	APIGroup              = "cicdapi.tmax.io"
+	APIVersion            = "v1"
+	approvalKind          = "approvals"
+	integrationConfigKind = "integrationconfigs"
 )
 
 var logger = logf.Log.WithName("approve-apis")
@@ -45,7 +46,10 @@ func AddV1Apis(parent wrapper.RouterWrapper, cli client.Client) error {
 		return err
 	}
 
-	return addApprovalApis(namespaceWrapper, cli)
+	if err := addApprovalApis(namespaceWrapper, cli); err != nil {
+		return err
+	}
+	return addIntegrationConfigApis(namespaceWrapper, cli)
 }
 
 func versionHandler(w http.ResponseWriter, _ *http.Request) {
@@ -63,6 +67,10 @@ func versionHandler(w http.ResponseWriter, _ *http.Request) {
 			Name:       fmt.Sprintf("%s/reject", approvalKind),
 			Namespaced: true,
 		},
+		{
+			Name:       fmt.Sprintf("%s/run", integrationConfigKind),
+			Namespaced: true,
+		},
 	}
 
 	_ = utils.RespondJSON(w, apiResourceList)
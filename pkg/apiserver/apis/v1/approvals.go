@@ -13,7 +13,7 @@ func addApprovalApis(parent wrapper.RouterWrapper, cli client.Client) error {
 		return err
 	}
 
-	approvalWrapper.Router().Use(authorize)
+	approvalWrapper.Router().Use(authorize("update"))
 
 	if err := addApproveApis(approvalWrapper, cli); err != nil {
 		return err
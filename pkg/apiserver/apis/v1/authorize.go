@@ -18,23 +18,26 @@ const (
 	extrasHeader = "X-Remote-Extra-"
 )
 
-func authorize(h http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
-			_ = utils.RespondError(w, http.StatusUnauthorized, "is not https or there is no peer certificate")
-			return
-		}
-
-		if err := reviewAccess(req); err != nil {
-			_ = utils.RespondError(w, http.StatusForbidden, err.Error())
-			return
-		}
-
-		h.ServeHTTP(w, req)
-	})
+// authorize returns a middleware which authorizes the requests to the subresources, by SubjectAccessReview of the verb
+func authorize(verb string) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+				_ = utils.RespondError(w, http.StatusUnauthorized, "is not https or there is no peer certificate")
+				return
+			}
+
+			if err := reviewAccess(req, verb); err != nil {
+				_ = utils.RespondError(w, http.StatusForbidden, err.Error())
+				return
+			}
+
+			h.ServeHTTP(w, req)
+		})
+	}
 }
 
-func reviewAccess(req *http.Request) error {
+func reviewAccess(req *http.Request, verb string) error {
 	userName, err := getUserName(req.Header)
 	if err != nil {
 		return err
@@ -50,15 +53,16 @@ func reviewAccess(req *http.Request) error {
 	// URL : /apis/cicdapi.tmax.io/v1/namespaces/default/approvals/test-approval/approve
 	subPaths := strings.Split(req.URL.Path, "/")
 	if len(subPaths) != 9 {
-		return fmt.Errorf("URL should be in form of '/apis/cicdapi.tmax.io/v1/namespaces/<namespace>/approvals/<approval-name>/[approve|reject]'")
+		return fmt.Errorf("URL should be in form of '/apis/cicdapi.tmax.io/v1/namespaces/<namespace>/<resource>/<name>/<subresource>'")
 	}
+	resource := subPaths[6]
+	name := subPaths[7]
 	subResource := subPaths[8]
 
 	vars := mux.Vars(req)
 
 	ns, nsExist := vars["namespace"]
-	approvalName, nameExist := vars["approvalName"]
-	if !nsExist || !nameExist {
+	if !nsExist {
 		return fmt.Errorf("url is malformed")
 	}
 
@@ -68,13 +72,13 @@ func reviewAccess(req *http.Request) error {
 			Groups: userGroups,
 			Extra:  userExtras,
 			ResourceAttributes: &authorization.ResourceAttributes{
-				Name:        approvalName,
+				Name:        name,
 				Namespace:   ns,
 				Group:       APIGroup,
 				Version:     APIVersion,
-				Resource:    approvalKind,
+				Resource:    resource,
 				Subresource: subResource,
-				Verb:        "update",
+				Verb:        verb,
 			},
 		},
 	}
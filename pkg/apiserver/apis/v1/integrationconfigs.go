@@ -0,0 +1,19 @@
+package v1
+
+import (
+	"fmt"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tmax-cloud/cicd-operator/internal/wrapper"
+)
+
+func addIntegrationConfigApis(parent wrapper.RouterWrapper, cli client.Client) error {
+	integrationConfigWrapper := wrapper.New(fmt.Sprintf("/%s/{integrationConfigName}", integrationConfigKind), nil, nil)
+	if err := parent.Add(integrationConfigWrapper); err != nil {
+		return err
+	}
+
+	integrationConfigWrapper.Router().Use(authorize("create"))
+
+	return addRunApis(integrationConfigWrapper, cli)
+}
@@ -0,0 +1,280 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/internal/utils"
+	"github.com/tmax-cloud/cicd-operator/internal/wrapper"
+	"github.com/tmax-cloud/cicd-operator/pkg/dispatcher"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=cicdapi.tmax.io,resources=integrationconfigs/run,verbs=create
+
+var (
+	shaPattern       = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+	parameterPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// runReqBody is a request body of the run api
+type runReqBody struct {
+	// Ref is a branch or a tag to run the jobs for, e.g., master, refs/heads/master or refs/tags/v0.1.0
+	Ref string `json:"ref"`
+
+	// Sha is a commit to run the jobs for. Defaults to the head commit of the ref
+	Sha string `json:"sha,omitempty"`
+
+	// Type is a type of the jobs to run. Defaults to postSubmit
+	Type cicdv1.JobType `json:"type,omitempty"`
+
+	// Parameters are set to the job containers as environment variables
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// runError is an error of the run api, with a http status code
+type runError struct {
+	code int
+	msg  string
+}
+
+func (e *runError) Error() string {
+	return e.msg
+}
+
+// addRunApis adds run api
+func addRunApis(parent wrapper.RouterWrapper, cli client.Client) error {
+	runWrapper := wrapper.New("/run", []string{http.MethodPost}, runHandler)
+	if err := parent.Add(runWrapper); err != nil {
+		return err
+	}
+
+	k8sCliLock.Lock()
+	defer k8sCliLock.Unlock()
+	if k8sClient == nil {
+		k8sClient = cli
+	}
+
+	return nil
+}
+
+func runHandler(w http.ResponseWriter, req *http.Request) {
+	reqID := utils.RandomString(10)
+	log := logger.WithValues("request", reqID)
+
+	// Get ns/integrationConfigName
+	vars := mux.Vars(req)
+
+	ns, nsExist := vars["namespace"]
+	configName, nameExist := vars["integrationConfigName"]
+	if !nsExist || !nameExist {
+		_ = utils.RespondError(w, http.StatusBadRequest, "url is malformed")
+		return
+	}
+
+	// Get run request
+	userReq := &runReqBody{}
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(userReq); err != nil {
+		log.Info(err.Error())
+		_ = utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("req: %s, body is not in json form or is malformed, err : %s", reqID, err.Error()))
+		return
+	}
+	if err := validateRunRequest(userReq); err != nil {
+		log.Info(err.Error())
+		_ = utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("req: %s, %s", reqID, err.Error()))
+		return
+	}
+
+	// Get user
+	user, err := getUserName(req.Header)
+	if err != nil {
+		log.Info(err.Error())
+		_ = utils.RespondError(w, http.StatusUnauthorized, fmt.Sprintf("req: %s, forbidden user, err : %s", reqID, err.Error()))
+		return
+	}
+
+	// Get corresponding IntegrationConfig object
+	if k8sClient == nil {
+		msg := fmt.Errorf("req: %s, k8sClient is not ready", reqID)
+		log.Info(msg.Error())
+		_ = utils.RespondError(w, http.StatusInternalServerError, msg.Error())
+		return
+	}
+
+	cfg := &cicdv1.IntegrationConfig{}
+	if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: configName, Namespace: ns}, cfg); err != nil {
+		log.Info(err.Error())
+		_ = utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("req: %s, no IntegrationConfig %s/%s is found", reqID, ns, configName))
+		return
+	}
+
+	job, err := generateRunJob(k8sClient, cfg, userReq, user)
+	if err != nil {
+		log.Info(err.Error())
+		code := http.StatusInternalServerError
+		if runErr, ok := err.(*runError); ok {
+			code = runErr.code
+		}
+		_ = utils.RespondError(w, code, fmt.Sprintf("req: %s, %s", reqID, err.Error()))
+		return
+	}
+
+	if err := k8sClient.Create(context.Background(), job); err != nil {
+		log.Error(err, "")
+		_ = utils.RespondError(w, http.StatusInternalServerError, fmt.Sprintf("req: %s, cannot create IntegrationJob, err : %s", reqID, err.Error()))
+		return
+	}
+	log.Info(fmt.Sprintf("IntegrationJob %s/%s is created by %s", job.Namespace, job.Name, user))
+
+	_ = utils.RespondJSON(w, job)
+}
+
+func validateRunRequest(runReq *runReqBody) error {
+	if runReq.Ref == "" {
+		return fmt.Errorf("ref should be specified")
+	}
+	if runReq.Sha != "" && !shaPattern.MatchString(runReq.Sha) {
+		return fmt.Errorf("sha %s is not a valid commit sha", runReq.Sha)
+	}
+	switch runReq.Type {
+	case "", cicdv1.JobTypePreSubmit, cicdv1.JobTypePostSubmit, cicdv1.JobTypePeriodic:
+	default:
+		return fmt.Errorf("type should be one of %s, %s and %s", cicdv1.JobTypePreSubmit, cicdv1.JobTypePostSubmit, cicdv1.JobTypePeriodic)
+	}
+	for name := range runReq.Parameters {
+		if !parameterPattern.MatchString(name) {
+			return fmt.Errorf("parameter name %s is not a valid environment variable name", name)
+		}
+		if name == "CI" || strings.HasPrefix(name, "CI_") {
+			return fmt.Errorf("parameter name %s is reserved", name)
+		}
+	}
+	return nil
+}
+
+// generateRunJob generates an IntegrationJob for the run request, sent by the user
+func generateRunJob(cli client.Client, cfg *cicdv1.IntegrationConfig, runReq *runReqBody, user string) (*cicdv1.IntegrationJob, error) {
+	gitCli, err := utils.GetGitCli(cfg, cli)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := gitCli.GetRepository()
+	if err != nil {
+		return nil, err
+	}
+	sender := &git.User{Name: user}
+
+	var job *cicdv1.IntegrationJob
+	switch runReq.Type {
+	case cicdv1.JobTypePreSubmit:
+		pr, err := findPullRequest(gitCli, runReq.Ref)
+		if err != nil {
+			return nil, err
+		}
+		if runReq.Sha != "" {
+			pr.Head.Sha = runReq.Sha
+		}
+		job, err = dispatcher.GeneratePreSubmit(pr, repo, sender, cfg, cli)
+		if err != nil {
+			return nil, err
+		}
+	case cicdv1.JobTypePeriodic:
+		ref, sha, err := resolveRef(gitCli, runReq.Ref, runReq.Sha)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(ref, "refs/heads/") {
+			return nil, &runError{code: http.StatusBadRequest, msg: fmt.Sprintf("ref %s is not a branch", runReq.Ref)}
+		}
+		branch := strings.TrimPrefix(ref, "refs/heads/")
+		var jobs []cicdv1.Job
+		for _, j := range cfg.Spec.Jobs.Periodic {
+			if j.Branch == branch {
+				jobs = append(jobs, j.Job)
+			}
+		}
+		job = dispatcher.GeneratePeriodic(branch, sha, jobs, repo, cfg)
+	default:
+		ref, sha, err := resolveRef(gitCli, runReq.Ref, runReq.Sha)
+		if err != nil {
+			return nil, err
+		}
+		job, err = dispatcher.GeneratePostSubmit(&git.Push{Ref: ref, Sha: sha, Sender: *sender}, repo, sender, cfg, cli)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if job == nil {
+		return nil, &runError{code: http.StatusBadRequest, msg: fmt.Sprintf("no job is configured to run for %s", runReq.Ref)}
+	}
+
+	dispatcher.IdentifyRandomly(job, cfg)
+	job.Spec.Refs.Sender = &cicdv1.IntegrationJobSender{Name: user}
+	for name, value := range runReq.Parameters {
+		job.Spec.Parameters = append(job.Spec.Parameters, cicdv1.IntegrationJobParameter{Name: name, Value: value})
+	}
+	sort.Slice(job.Spec.Parameters, func(i, j int) bool {
+		return job.Spec.Parameters[i].Name < job.Spec.Parameters[j].Name
+	})
+	return job, nil
+}
+
+// resolveRef resolves the ref to a full ref (refs/heads/<branch> or refs/tags/<tag>) and its head commit sha
+// sha is returned as it is, if it is not empty
+// A short ref (without refs/heads/ or refs/tags/ prefix) is looked up from the branches first, then from the tags
+func resolveRef(gitCli git.Client, ref, sha string) (string, string, error) {
+	name := strings.TrimPrefix(strings.TrimPrefix(ref, "refs/heads/"), "refs/tags/")
+
+	if !strings.HasPrefix(ref, "refs/tags/") {
+		branch, err := gitCli.GetBranch(name)
+		if err != nil {
+			return "", "", err
+		}
+		if branch != nil {
+			if sha == "" {
+				sha = branch.Sha
+			}
+			return "refs/heads/" + name, sha, nil
+		}
+	}
+
+	if !strings.HasPrefix(ref, "refs/heads/") {
+		tag, err := gitCli.GetTag(name)
+		if err != nil {
+			return "", "", err
+		}
+		if tag != nil {
+			if sha == "" {
+				sha = tag.Sha
+			}
+			return "refs/tags/" + name, sha, nil
+		}
+	}
+
+	return "", "", &runError{code: http.StatusBadRequest, msg: fmt.Sprintf("ref %s is not found", ref)}
+}
+
+// findPullRequest finds an open pull request whose head branch is the ref
+func findPullRequest(gitCli git.Client, ref string) (*git.PullRequest, error) {
+	branch := strings.TrimPrefix(ref, "refs/heads/")
+	prs, err := gitCli.ListOpenPullRequests()
+	if err != nil {
+		return nil, err
+	}
+	for i := range prs {
+		if prs[i].Head.Ref == branch {
+			return &prs[i], nil
+		}
+	}
+	return nil, &runError{code: http.StatusBadRequest, msg: fmt.Sprintf("no open pull request is found for %s", ref)}
+}
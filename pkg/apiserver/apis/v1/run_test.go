@@ -0,0 +1,179 @@
+package v1
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	gitfake "github.com/tmax-cloud/cicd-operator/pkg/git/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testMasterSha = "0123456789012345678901234567890123456789"
+	testTagSha    = "1123456789012345678901234567890123456789"
+	testPullSha   = "2123456789012345678901234567890123456789"
+	testGivenSha  = "3123456789012345678901234567890123456789"
+)
+
+func TestValidateRunRequest(t *testing.T) {
+	tc := map[string]struct {
+		req           runReqBody
+		expectedError bool
+	}{
+		"valid":            {req: runReqBody{Ref: "master", Sha: "0123abc", Type: cicdv1.JobTypePeriodic, Parameters: map[string]string{"TARGET": "e2e"}}},
+		"noRef":            {req: runReqBody{}, expectedError: true},
+		"invalidSha":       {req: runReqBody{Ref: "master", Sha: "not-a-sha"}, expectedError: true},
+		"invalidType":      {req: runReqBody{Ref: "master", Type: "unknown"}, expectedError: true},
+		"invalidParameter": {req: runReqBody{Ref: "master", Parameters: map[string]string{"1-TARGET": "e2e"}}, expectedError: true},
+		"reservedParam":    {req: runReqBody{Ref: "master", Parameters: map[string]string{"CI_HEAD_SHA": "abc"}}, expectedError: true},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			err := validateRunRequest(&c.req)
+			assert.Equal(t, c.expectedError, err != nil)
+		})
+	}
+}
+
+func TestGenerateRunJob(t *testing.T) {
+	srv := gitfake.NewGitHubServer(&gitfake.Repository{
+		Name:     "tmax-cloud/cicd-operator",
+		Branches: []git.Ref{{Name: "master", Sha: testMasterSha}, {Name: "feat/a", Sha: testPullSha}},
+		Tags:     []git.Ref{{Name: "v0.1.0", Sha: testTagSha}},
+		PullRequests: []git.PullRequest{
+			{ID: 3, Title: "Feature A", State: git.PullRequestStateOpen, Base: git.Base{Ref: "master"}, Head: git.Head{Ref: "feat/a", Sha: testPullSha}},
+		},
+	}, "test-token")
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+	cli := fake.NewFakeClientWithScheme(s)
+
+	cfg := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Git: cicdv1.GitConfig{
+				Type:       cicdv1.GitTypeGitHub,
+				Repository: "tmax-cloud/cicd-operator",
+				APIUrl:     srv.URL,
+				Token:      cicdv1.GitToken{Value: "test-token"},
+			},
+			Jobs: cicdv1.IntegrationConfigJobs{
+				PreSubmit: cicdv1.Jobs{{Container: corev1.Container{Name: "test"}}},
+				PostSubmit: cicdv1.Jobs{
+					{Container: corev1.Container{Name: "build"}},
+					{Container: corev1.Container{Name: "release"}, When: &cicdv1.JobWhen{Tag: []string{"v.*"}}},
+				},
+				Periodic: cicdv1.PeriodicJobs{
+					{Job: cicdv1.Job{Container: corev1.Container{Name: "nightly"}}, Cron: "@daily", Branch: "master"},
+				},
+			},
+		},
+	}
+
+	tc := map[string]struct {
+		req runReqBody
+
+		expectedErrorCode int
+		expectedType      cicdv1.JobType
+		expectedJobs      []string
+		expectedRef       string
+		expectedSha       string
+		expectedPull      int
+		expectedParams    []cicdv1.IntegrationJobParameter
+	}{
+		"branch": {
+			req:            runReqBody{Ref: "master", Parameters: map[string]string{"TARGET": "e2e", "DEBUG": "true"}},
+			expectedType:   cicdv1.JobTypePostSubmit,
+			expectedJobs:   []string{"build"},
+			expectedRef:    "refs/heads/master",
+			expectedSha:    testMasterSha,
+			expectedParams: []cicdv1.IntegrationJobParameter{{Name: "DEBUG", Value: "true"}, {Name: "TARGET", Value: "e2e"}},
+		},
+		"branchSha": {
+			req:          runReqBody{Ref: "refs/heads/master", Sha: testGivenSha, Type: cicdv1.JobTypePostSubmit},
+			expectedType: cicdv1.JobTypePostSubmit,
+			expectedJobs: []string{"build"},
+			expectedRef:  "refs/heads/master",
+			expectedSha:  testGivenSha,
+		},
+		"tag": {
+			req:          runReqBody{Ref: "v0.1.0"},
+			expectedType: cicdv1.JobTypePostSubmit,
+			expectedJobs: []string{"build", "release"},
+			expectedRef:  "refs/tags/v0.1.0",
+			expectedSha:  testTagSha,
+		},
+		"pullRequest": {
+			req:          runReqBody{Ref: "feat/a", Type: cicdv1.JobTypePreSubmit},
+			expectedType: cicdv1.JobTypePreSubmit,
+			expectedJobs: []string{"test"},
+			expectedRef:  "master",
+			expectedSha:  testPullSha,
+			expectedPull: 3,
+		},
+		"periodic": {
+			req:          runReqBody{Ref: "master", Type: cicdv1.JobTypePeriodic},
+			expectedType: cicdv1.JobTypePeriodic,
+			expectedJobs: []string{"nightly"},
+			expectedRef:  "refs/heads/master",
+			expectedSha:  testMasterSha,
+		},
+		"refNotFound": {
+			req:               runReqBody{Ref: "not-exist"},
+			expectedErrorCode: http.StatusBadRequest,
+		},
+		"pullRequestNotFound": {
+			req:               runReqBody{Ref: "master", Type: cicdv1.JobTypePreSubmit},
+			expectedErrorCode: http.StatusBadRequest,
+		},
+		"periodicTag": {
+			req:               runReqBody{Ref: "refs/tags/v0.1.0", Type: cicdv1.JobTypePeriodic},
+			expectedErrorCode: http.StatusBadRequest,
+		},
+		"noJobs": {
+			req:               runReqBody{Ref: "feat/a", Type: cicdv1.JobTypePeriodic},
+			expectedErrorCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			job, err := generateRunJob(cli, cfg, &c.req, "admin@tmax.co.kr")
+			if c.expectedErrorCode != 0 {
+				assert.T(t, err != nil)
+				runErr, ok := err.(*runError)
+				assert.T(t, ok)
+				assert.Equal(t, c.expectedErrorCode, runErr.code)
+				return
+			}
+			assert.Equal(t, nil, err)
+
+			assert.Equal(t, c.expectedType, job.Spec.ConfigRef.Type)
+			var jobs []string
+			for _, j := range job.Spec.Jobs {
+				jobs = append(jobs, j.Name)
+			}
+			assert.Equal(t, c.expectedJobs, jobs)
+			assert.Equal(t, c.expectedRef, job.Spec.Refs.Base.Ref)
+			if c.expectedPull != 0 {
+				assert.Equal(t, c.expectedPull, job.Spec.Refs.Pull.ID)
+				assert.Equal(t, c.expectedSha, job.Spec.Refs.Pull.Sha)
+			} else {
+				assert.T(t, job.Spec.Refs.Pull == nil)
+				assert.Equal(t, c.expectedSha, job.Spec.Refs.Base.Sha)
+			}
+			assert.Equal(t, &cicdv1.IntegrationJobSender{Name: "admin@tmax.co.kr"}, job.Spec.Refs.Sender)
+			assert.Equal(t, c.expectedParams, job.Spec.Parameters)
+		})
+	}
+}
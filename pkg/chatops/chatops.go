@@ -4,9 +4,12 @@ import (
 	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
 	"github.com/tmax-cloud/cicd-operator/pkg/git"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"strings"
 )
 
+var log = logf.Log.WithName("chatops")
+
 // chatOps triggers tests/retests via comments
 type chatOps struct {
 	client   client.Client
@@ -22,6 +25,7 @@ func New(c client.Client) *chatOps {
 
 	co.registerCommandHandler(commandTypeTest, co.handleTestCommand)
 	co.registerCommandHandler(commandTypeRetest, co.handleRetestCommand)
+	co.registerCommandHandler(commandTypeOkToTest, co.handleOkToTestCommand)
 
 	return co
 }
@@ -8,8 +8,9 @@ import (
 type commandType string
 
 const (
-	commandTypeTest   = commandType("test")
-	commandTypeRetest = commandType("retest")
+	commandTypeTest     = commandType("test")
+	commandTypeRetest   = commandType("retest")
+	commandTypeOkToTest = commandType("ok-to-test")
 )
 
 // command is a structure extracted by the comment body
@@ -31,7 +31,7 @@ func (c *chatOps) handleTestCommand(command command, webhook *git.Webhook, confi
 	}
 
 	// Generate IntegrationJob for the PullRequest
-	job, err := dispatcher.GeneratePreSubmit(issueComment.Issue.PullRequest, &webhook.Repo, &issueComment.Sender, config)
+	job, err := dispatcher.GeneratePreSubmit(issueComment.Issue.PullRequest, &webhook.Repo, &issueComment.Sender, config, c.client)
 	if err != nil {
 		return err
 	}
@@ -50,6 +50,7 @@ func (c *chatOps) handleTestCommand(command command, webhook *git.Webhook, confi
 	}
 
 	// Create it
+	dispatcher.IdentifyRandomly(job, config)
 	if err := c.client.Create(context.Background(), job); err != nil {
 		return err
 	}
@@ -74,7 +75,7 @@ func (c *chatOps) handleRetestCommand(_ command, webhook *git.Webhook, config *c
 	}
 
 	// Generate IntegrationJob for the PullRequest
-	job, err := dispatcher.GeneratePreSubmit(issueComment.Issue.PullRequest, &webhook.Repo, &issueComment.Sender, config)
+	job, err := dispatcher.GeneratePreSubmit(issueComment.Issue.PullRequest, &webhook.Repo, &issueComment.Sender, config, c.client)
 	if err != nil {
 		return err
 	}
@@ -84,6 +85,60 @@ func (c *chatOps) handleRetestCommand(_ command, webhook *git.Webhook, config *c
 	}
 
 	// Create it
+	dispatcher.IdentifyRandomly(job, config)
+	if err := c.client.Create(context.Background(), job); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// handleOkToTestCommand handles '/ok-to-test' command
+// A trusted contributor allows the pull request of an untrusted contributor to be tested
+func (c *chatOps) handleOkToTestCommand(_ command, webhook *git.Webhook, config *cicdv1.IntegrationConfig) error {
+	issueComment := webhook.IssueComment
+	pr := issueComment.Issue.PullRequest
+	// Do nothing if it's not pull request's comment, it's closed or the trusted contributors policy is not set
+	if pr == nil || pr.State != git.PullRequestStateOpen || config.Spec.TrustedContributors == nil {
+		return nil
+	}
+
+	gitCli, err := utils.GetGitCli(config, c.client)
+	if err != nil {
+		return err
+	}
+
+	// Authorize or exit
+	trusted, err := dispatcher.IsTrustedContributor(config, gitCli, issueComment.Sender)
+	if err != nil {
+		return err
+	}
+	if !trusted {
+		return gitCli.RegisterComment(git.IssueTypePullRequest, pr.ID, generateUserUnauthorizedForOkToTestComment(issueComment.Sender.Name, config.Spec.Git.Repository))
+	}
+
+	// Label the PR, so that the jobs are run for its new commits as well
+	// The jobs are run anyway, even if it cannot be labeled
+	if labelCli, ok := gitCli.(git.LabelClient); ok {
+		if err := labelCli.AddLabel(pr.ID, dispatcher.LabelOkToTest); err != nil {
+			log.Error(err, "cannot label the pull request as ok-to-test", "repository", config.Spec.Git.Repository, "pullRequest", pr.ID)
+		} else if err := labelCli.RemoveLabel(pr.ID, dispatcher.LabelNeedsOkToTest); err != nil {
+			log.Error(err, "cannot remove needs-ok-to-test label from the pull request", "repository", config.Spec.Git.Repository, "pullRequest", pr.ID)
+		}
+	}
+
+	// Generate IntegrationJob for the PullRequest
+	job, err := dispatcher.GeneratePreSubmit(pr, &webhook.Repo, &issueComment.Sender, config, c.client)
+	if err != nil {
+		return err
+	}
+
+	if job == nil {
+		return nil
+	}
+
+	// Create it
+	dispatcher.IdentifyRandomly(job, config)
 	if err := c.client.Create(context.Background(), job); err != nil {
 		return err
 	}
@@ -96,15 +151,28 @@ func (c *chatOps) authorizeUserForTest(cfg *cicdv1.IntegrationConfig, webhook *g
 	issueComment := webhook.IssueComment
 
 	// Check if it's PR's author
-	if issueComment.Sender.ID == issueComment.Issue.PullRequest.Sender.ID {
+	isAuthor := issueComment.Sender.ID == issueComment.Issue.PullRequest.Sender.ID
+	if isAuthor && cfg.Spec.TrustedContributors == nil {
 		return nil
 	}
 
-	// Check if it's repo's maintainer
 	g, err := utils.GetGitCli(cfg, c.client)
 	if err != nil {
 		return err
 	}
+
+	// The author of the PR waiting for /ok-to-test comment cannot trigger the tests
+	if isAuthor {
+		needsOkToTest, err := dispatcher.NeedsOkToTest(cfg, g, issueComment.Issue.PullRequest)
+		if err != nil {
+			return err
+		}
+		if !needsOkToTest {
+			return nil
+		}
+	}
+
+	// Check if it's repo's maintainer
 	ok, err := g.CanUserWriteToRepo(issueComment.Sender)
 	if err != nil {
 		return err
@@ -173,5 +241,15 @@ func generateUserUnauthorizedForTestComment(user, repo string) string {
 		"If you want to trigger the test, you need to...\n"+
 		"- Be author of the pull request\n"+
 		"- (For GitHub) Have write permission on the repository\n"+
-		"- (For GitLab) Be Developer, Maintainer, or Owner\n", user, repo)
+		"- (For GitLab) Be Developer, Maintainer, or Owner\n"+
+		"- (For Gitea) Have write permission on the repository\n"+
+		"- (For Bitbucket Server) Have write permission on the repository or the project\n"+
+		"- (For Bitbucket) Have write or admin permission on the repository\n"+
+		"- (For Azure DevOps) Have Contribute permission on the repository\n"+
+		"- (For Gerrit) Have Submit permission on the HEAD branch of the project\n", user, repo)
+}
+
+func generateUserUnauthorizedForOkToTestComment(user, repo string) string {
+	return fmt.Sprintf("User `%s` is not allowed to comment `/ok-to-test` for the repository `%s`\n\n"+
+		"Only the trusted contributors, configured by `trustedContributors` of the IntegrationConfig, can allow the pull request to be tested\n", user, repo)
 }
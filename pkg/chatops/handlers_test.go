@@ -4,7 +4,9 @@ import (
 	"context"
 	"github.com/bmizerany/assert"
 	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/dispatcher"
 	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	gitfake "github.com/tmax-cloud/cicd-operator/pkg/git/fake"
 	"github.com/tmax-cloud/cicd-operator/pkg/server"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -188,3 +190,66 @@ func buildTestWebhook() *git.Webhook {
 		},
 	}
 }
+
+func TestChatOps_HandleOkToTest(t *testing.T) {
+	maintainer := gitfake.User{ID: 1, Name: "maintainer", CanWrite: true}
+	srv := gitfake.NewGitHubServer(&gitfake.Repository{
+		Name:  "tmax-cloud/cicd-operator",
+		Users: []gitfake.User{maintainer, {ID: testUserID, Name: testUserName}},
+		PullRequests: []git.PullRequest{
+			{ID: 1, State: git.PullRequestStateOpen, Labels: []git.IssueLabel{{Name: dispatcher.LabelNeedsOkToTest}}},
+		},
+	}, "test-token")
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+
+	ic := buildTestJobs()
+	ic.Spec.Git = cicdv1.GitConfig{
+		Type:       cicdv1.GitTypeGitHub,
+		Repository: "tmax-cloud/cicd-operator",
+		APIUrl:     srv.URL,
+		Token:      cicdv1.GitToken{Value: "test-token"},
+	}
+	ic.Spec.TrustedContributors = &cicdv1.TrustedContributors{Collaborators: true}
+	wh := buildTestWebhook()
+	wh.IssueComment.Issue.PullRequest.ID = 1
+
+	fakeCli := fake.NewFakeClientWithScheme(s, ic)
+	chatOps := New(fakeCli)
+	countJobs := func() int {
+		ijList := &cicdv1.IntegrationJobList{}
+		assert.Equal(t, nil, fakeCli.List(context.Background(), ijList))
+		return len(ijList.Items)
+	}
+
+	// The untrusted author cannot test nor allow the test
+	for _, command := range []string{"/retest", "/ok-to-test"} {
+		wh.IssueComment.Comment.Body = command
+		assert.Equal(t, nil, chatOps.Handle(wh, ic))
+		assert.Equal(t, 0, countJobs())
+	}
+	assert.Equal(t, 2, len(srv.Repository().Comments))
+
+	// A trusted contributor allows the test
+	wh.IssueComment.Sender = git.User{ID: maintainer.ID, Name: maintainer.Name}
+	assert.Equal(t, nil, chatOps.Handle(wh, ic))
+	assert.Equal(t, 1, countJobs())
+	assert.Equal(t, []git.IssueLabel{{Name: dispatcher.LabelOkToTest}}, srv.Repository().PullRequests[0].Labels)
+
+	// The author can retest the pull request which is ok to test
+	wh.IssueComment.Sender = wh.IssueComment.Issue.PullRequest.Sender
+	wh.IssueComment.Issue.PullRequest.Labels = []git.IssueLabel{{Name: dispatcher.LabelOkToTest}}
+	wh.IssueComment.Comment.Body = "/retest"
+	assert.Equal(t, nil, chatOps.Handle(wh, ic))
+	assert.Equal(t, 2, countJobs())
+
+	// The jobs are run even if the pull request cannot be labeled
+	wh.IssueComment.Sender = git.User{ID: maintainer.ID, Name: maintainer.Name}
+	wh.IssueComment.Issue.PullRequest.ID = 2
+	wh.IssueComment.Issue.PullRequest.Labels = nil
+	wh.IssueComment.Comment.Body = "/ok-to-test"
+	assert.Equal(t, nil, chatOps.Handle(wh, ic))
+	assert.Equal(t, 3, countJobs())
+}
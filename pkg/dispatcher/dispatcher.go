@@ -2,81 +2,326 @@ package dispatcher
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
 	"github.com/tmax-cloud/cicd-operator/internal/utils"
+	"github.com/tmax-cloud/cicd-operator/pkg/expression"
 	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+var log = logf.Log.WithName("dispatcher")
+
+// skippedMessage is a description of the commit statuses of the skipped jobs
+const skippedMessage = "skipped"
+
+// periodicSender is a sender of the periodic IntegrationJobs, which are not triggered by any user
+const periodicSender = "cicd-operator"
+
 // Dispatcher dispatches IntegrationJob when webhook is called
 // A kind of 'plugin' for webhook handler
 type Dispatcher struct {
 	Client client.Client
 }
 
-// Handle handles pull-request and push events
+// Handle handles pull-request, push and release events
 func (d Dispatcher) Handle(webhook *git.Webhook, config *cicdv1.IntegrationConfig) error {
 	var job *cicdv1.IntegrationJob
 	var err error
 	pr := webhook.PullRequest
 	push := webhook.Push
-	if pr == nil && push == nil {
-		return fmt.Errorf("pull request and push struct is nil")
+	release := webhook.Release
+	if pr == nil && push == nil && release == nil {
+		return fmt.Errorf("pull request, push and release struct is nil")
 	}
 
 	if webhook.EventType == git.EventTypePullRequest && pr != nil {
 		if pr.Action == git.PullRequestActionOpen || pr.Action == git.PullRequestActionSynchronize || pr.Action == git.PullRequestActionReOpen {
-			job, err = GeneratePreSubmit(pr, &webhook.Repo, &pr.Sender, config)
+			job, err = GeneratePreSubmit(pr, &webhook.Repo, &pr.Sender, config, d.Client)
+			if err != nil {
+				return err
+			}
+		} else if pr.Action == git.PullRequestActionReadyForReview && pr.State == git.PullRequestStateOpen {
+			// Only the jobs skipped while the pull request was a draft are run
+			job, err = GeneratePreSubmit(pr, &webhook.Repo, &pr.Sender, config, d.Client)
 			if err != nil {
 				return err
 			}
+			if job != nil {
+				job.Spec.Jobs = filterDraftSkipped(job.Spec.Jobs)
+				if len(job.Spec.Jobs) == 0 {
+					job = nil
+				}
+			}
+		} else if (pr.Action == git.PullRequestActionLabeled || pr.Action == git.PullRequestActionUnlabeled) && pr.State == git.PullRequestStateOpen && isLabelTrigger(config.Spec.Jobs.PreSubmit, pr) {
+			// Only the jobs enabled by the label change are run
+			job, err = GeneratePreSubmit(pr, &webhook.Repo, &pr.Sender, config, d.Client)
+			if err != nil {
+				return err
+			}
+			if job != nil {
+				job.Spec.Jobs = filterLabelTriggered(job.Spec.Jobs, pr)
+				if len(job.Spec.Jobs) == 0 {
+					job = nil
+				}
+			}
 		}
 	} else if webhook.EventType == git.EventTypePush && push != nil {
-		job, err = GeneratePostSubmit(push, &webhook.Repo, &push.Sender, config)
+		job, err = GeneratePostSubmit(push, &webhook.Repo, &push.Sender, config, d.Client)
+		if err != nil {
+			return err
+		}
+	} else if webhook.EventType == git.EventTypeRelease && release != nil {
+		job, err = GenerateRelease(release, &webhook.Repo, config)
 		if err != nil {
 			return err
 		}
 	}
 
-	if job == nil {
-		return nil
+	// Jobs for the pull requests of the untrusted contributors wait for /ok-to-test comment
+	if job != nil && webhook.EventType == git.EventTypePullRequest {
+		okToTest, err := d.okToTest(pr, config)
+		if err != nil {
+			return err
+		}
+		if !okToTest {
+			job = nil
+		}
+	}
+
+	if job != nil {
+		identify(job, webhook, config)
+		// Jobs are reported as skipped, not to block the branch protection
+		if hasSkipCIMarker(webhook, config.GetSkipCIMarkers()) {
+			if err := d.reportSkipped(job, config); err != nil {
+				return err
+			}
+			job = nil
+		} else if err := d.createJob(job); err != nil {
+			return err
+		}
+	}
+
+	// Cancel the IntegrationJobs superseded by the new commit, or the ones of the closed pull request
+	if webhook.EventType == git.EventTypePullRequest && pr != nil {
+		sender := &cicdv1.IntegrationJobSender{Name: pr.Sender.Name, Email: pr.Sender.Email}
+		switch pr.Action {
+		case git.PullRequestActionSynchronize:
+			cancel := &cicdv1.IntegrationJobCancel{Reason: cicdv1.IntegrationJobCancelReasonSuperseded, Sha: pr.Head.Sha, Sender: sender}
+			if job != nil {
+				cancel.SupersededBy = job.Name
+			}
+			return d.cancelPreSubmits(config, pr, cancel)
+		case git.PullRequestActionClose:
+			return d.cancelPreSubmits(config, pr, &cicdv1.IntegrationJobCancel{Reason: cicdv1.IntegrationJobCancelReasonPullRequestClosed, Sender: sender})
+		}
+	}
+
+	return nil
+}
+
+// identify derives the identity of the IntegrationJob from the IntegrationConfig, the event, the commit sha and the job type,
+// so that a replayed event does not create a duplicated IntegrationJob
+// The delivery ID distinguishes the events which are the same otherwise, e.g., adding the same label again
+func identify(job *cicdv1.IntegrationJob, webhook *git.Webhook, config *cicdv1.IntegrationConfig) {
+	fields := []string{config.Namespace, config.Name, string(config.UID), string(webhook.EventType), webhook.DeliveryID, string(job.Spec.ConfigRef.Type)}
+	sha := job.Spec.Refs.Base.Sha
+	pullRequestID := 0
+	if pr := webhook.PullRequest; webhook.EventType == git.EventTypePullRequest && pr != nil {
+		fields = append(fields, string(pr.Action), strconv.Itoa(pr.ID))
+		sha = job.Spec.Refs.Pull.Sha
+		pullRequestID = job.Spec.Refs.Pull.ID
+	} else if push := webhook.Push; push != nil {
+		fields = append(fields, push.Ref, push.Before)
+	} else if release := webhook.Release; release != nil {
+		fields = append(fields, release.Tag)
+	}
+	fields = append(fields, sha)
+
+	hash := sha1.Sum([]byte(strings.Join(fields, "\n")))
+	setID(job, config, hex.EncodeToString(hash[:])[:20], sha, pullRequestID)
+}
+
+// IdentifyRandomly gives a random identity to the IntegrationJob which is not created for a webhook event,
+// e.g., the ones triggered by the chat-ops commands, the periodic triggers or the run requests
+func IdentifyRandomly(job *cicdv1.IntegrationJob, config *cicdv1.IntegrationConfig) {
+	sha := job.Spec.Refs.Base.Sha
+	pullRequestID := 0
+	if pull := job.Spec.Refs.Pull; pull != nil {
+		sha = pull.Sha
+		pullRequestID = pull.ID
+	}
+	setID(job, config, utils.RandomString(20), sha, pullRequestID)
+}
+
+// setID sets the id of the IntegrationJob and the metadata derived from it
+func setID(job *cicdv1.IntegrationJob, config *cicdv1.IntegrationConfig, jobID, sha string, pullRequestID int) {
+	job.ObjectMeta = generateMeta(config.Name, config.Namespace, job.Spec.ConfigRef.Type, sha, jobID, pullRequestID)
+	job.Spec.ID = jobID
+}
+
+// createJob creates the IntegrationJob, unless it's already created for the replayed event
+// The name contains only a part of the id, so an existing IntegrationJob of another id is a name collision, not a replay
+func (d Dispatcher) createJob(job *cicdv1.IntegrationJob) error {
+	err := d.Client.Create(context.Background(), job)
+	if err == nil || !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	existing := &cicdv1.IntegrationJob{}
+	if err := d.Client.Get(context.Background(), types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, existing); err != nil {
+		return err
+	}
+	if existing.Spec.ID != job.Spec.ID {
+		return fmt.Errorf("IntegrationJob %s/%s of id %s already exists, name collides with id %s", job.Namespace, job.Name, existing.Spec.ID, job.Spec.ID)
+	}
+	log.Info("IntegrationJob is already created for the event", "namespace", job.Namespace, "name", job.Name)
+	return nil
+}
+
+// okToTest checks if the pull request can be tested, by the trusted contributors policy
+// If not, it asks for /ok-to-test comment when the pull request is opened or reopened
+func (d Dispatcher) okToTest(pr *git.PullRequest, config *cicdv1.IntegrationConfig) (bool, error) {
+	if config.Spec.TrustedContributors == nil {
+		return true, nil
+	}
+	gitCli, err := utils.GetGitCli(config, d.Client)
+	if err != nil {
+		return false, err
+	}
+	needsOkToTest, err := NeedsOkToTest(config, gitCli, pr)
+	if err != nil {
+		return false, err
+	}
+	if !needsOkToTest {
+		return true, nil
+	}
+	if pr.Action == git.PullRequestActionOpen || pr.Action == git.PullRequestActionReOpen {
+		if err := requestOkToTest(config, gitCli, pr); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// cancelPreSubmits requests to cancel the pending or running pre-submit IntegrationJobs of the pull request,
+// except the ones for the head commit of the pull request
+func (d Dispatcher) cancelPreSubmits(config *cicdv1.IntegrationConfig, pr *git.PullRequest, cancel *cicdv1.IntegrationJobCancel) error {
+	ijList := &cicdv1.IntegrationJobList{}
+	if err := d.Client.List(context.Background(), ijList, client.InNamespace(config.Namespace), client.MatchingLabels{
+		cicdv1.JobLabelConfig:      config.Name,
+		cicdv1.JobLabelType:        string(cicdv1.JobTypePreSubmit),
+		cicdv1.JobLabelPullRequest: strconv.Itoa(pr.ID),
+	}); err != nil {
+		return err
+	}
+
+	for i := range ijList.Items {
+		ij := &ijList.Items[i]
+		if ij.Status.CompletionTime != nil || ij.Spec.Cancel != nil {
+			continue
+		}
+		if cancel.Reason == cicdv1.IntegrationJobCancelReasonSuperseded && ij.Spec.Refs.Pull != nil && ij.Spec.Refs.Pull.Sha == pr.Head.Sha {
+			continue
+		}
+		log.Info(fmt.Sprintf("Canceling IntegrationJob %s/%s (%s)", ij.Namespace, ij.Name, cancel.Reason))
+		original := ij.DeepCopy()
+		ij.Spec.Cancel = cancel.DeepCopy()
+		if err := d.Client.Patch(context.Background(), ij, client.MergeFrom(original)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasSkipCIMarker checks if the pull request title or the head commit message of the push has any of the markers
+// Releases are never skipped
+func hasSkipCIMarker(webhook *git.Webhook, markers []string) bool {
+	var text string
+	if webhook.EventType == git.EventTypePullRequest && webhook.PullRequest != nil {
+		text = webhook.PullRequest.Title
+	} else if webhook.EventType == git.EventTypePush && webhook.Push != nil {
+		text = webhook.Push.Message
+	} else {
+		return false
+	}
+	text = strings.ToLower(text)
+	for _, marker := range markers {
+		if marker != "" && strings.Contains(text, strings.ToLower(marker)) {
+			return true
+		}
 	}
+	return false
+}
 
-	if err := d.Client.Create(context.Background(), job); err != nil {
+// reportSkipped sets the commit statuses (or check runs) of the jobs to success, without running them
+func (d Dispatcher) reportSkipped(job *cicdv1.IntegrationJob, config *cicdv1.IntegrationConfig) error {
+	gitCli, err := utils.GetGitCli(config, d.Client)
+	if err != nil {
 		return err
 	}
+	checkRunCli, ok := gitCli.(git.CheckRunClient)
+	useCheckRun := ok && checkRunCli.CheckRunEnabled()
 
+	for _, j := range job.Spec.Jobs {
+		log.Info(fmt.Sprintf("Skipping job %s for %s", j.Name, config.Spec.Git.Repository))
+		if useCheckRun {
+			err = checkRunCli.SetCheckRun(job, &cicdv1.JobStatus{Name: j.Name, State: cicdv1.CommitStatusStateSuccess, Message: skippedMessage}, "")
+		} else {
+			err = gitCli.SetCommitStatus(job, j.Name, git.CommitStatusState(cicdv1.CommitStatusStateSuccess), skippedMessage, "")
+		}
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // GeneratePreSubmit generates IntegrationJob for pull request event
-func GeneratePreSubmit(pr *git.PullRequest, repo *git.Repository, sender *git.User, config *cicdv1.IntegrationConfig) (*cicdv1.IntegrationJob, error) {
+// The IntegrationJob is not identified yet, i.e., it has no name and id
+// cli is used to list the files changed by the pull request, only if any job has path filters
+func GeneratePreSubmit(pr *git.PullRequest, repo *git.Repository, sender *git.User, config *cicdv1.IntegrationConfig, cli client.Client) (*cicdv1.IntegrationJob, error) {
 	jobs, err := filter(config.Spec.Jobs.PreSubmit, git.EventTypePullRequest, pr.Base.Ref)
 	if err != nil {
 		return nil, err
 	}
+	jobs = filterLabels(jobs, pr.Labels)
+	jobs = filterDraft(jobs, pr.Draft)
+	changedFiles := listChangedFiles(jobs, config, cli, func(gitCli git.Client) ([]string, error) {
+		// Pull request files API is not limited to 300 files, unlike comparing commits
+		if prFilesCli, ok := gitCli.(git.PullRequestFilesClient); ok {
+			return prFilesCli.ListPullRequestChangedFiles(pr.ID)
+		}
+		return gitCli.ListChangedFiles(pr.Base.Ref, pr.Head.Sha)
+	})
+	jobs = filterPaths(jobs, changedFiles)
+	jobs = filterExpressions(jobs, newPullRequestEvent(pr, sender, pr.Labels, changedFiles))
+	jobs = resolveAfter(jobs, config.Spec.Jobs.PreSubmit)
 	if len(jobs) < 1 {
 		return nil, nil
 	}
-	jobID := utils.RandomString(20)
 	return &cicdv1.IntegrationJob{
-		ObjectMeta: generateMeta(config.Name, config.Namespace, pr.Head.Sha, jobID),
 		Spec: cicdv1.IntegrationJobSpec{
 			ConfigRef: cicdv1.IntegrationJobConfigRef{
 				Name: config.Name,
 				Type: cicdv1.JobTypePreSubmit,
 			},
-			ID:         jobID,
 			Jobs:       jobs,
 			Workspaces: config.Spec.Workspaces,
 			Refs: cicdv1.IntegrationJobRefs{
 				Repository: repo.Name,
 				Link:       repo.URL,
+				CloneURL:   repo.CloneURL,
 				Sender: &cicdv1.IntegrationJobSender{
 					Name:  sender.Name,
 					Email: sender.Email,
@@ -101,28 +346,40 @@ func GeneratePreSubmit(pr *git.PullRequest, repo *git.Repository, sender *git.Us
 }
 
 // GeneratePostSubmit generates IntegrationJob for push event
-func GeneratePostSubmit(push *git.Push, repo *git.Repository, sender *git.User, config *cicdv1.IntegrationConfig) (*cicdv1.IntegrationJob, error) {
+// The IntegrationJob is not identified yet, i.e., it has no name and id
+// cli is used to list the files changed by the push, only if any job has path filters
+func GeneratePostSubmit(push *git.Push, repo *git.Repository, sender *git.User, config *cicdv1.IntegrationConfig, cli client.Client) (*cicdv1.IntegrationJob, error) {
 	jobs, err := filter(config.Spec.Jobs.PostSubmit, git.EventTypePush, push.Ref)
 	if err != nil {
 		return nil, err
 	}
+	// Push events do not have labels
+	jobs = filterLabels(jobs, nil)
+	// Changed files of a tag push or a newly created branch are unknown, so every job runs
+	var changedFiles []string
+	if !strings.HasPrefix(push.Ref, "refs/tags/") && !isZeroSha(push.Before) {
+		changedFiles = listChangedFiles(jobs, config, cli, func(gitCli git.Client) ([]string, error) {
+			return gitCli.ListChangedFiles(push.Before, push.Sha)
+		})
+	}
+	jobs = filterPaths(jobs, changedFiles)
+	jobs = filterExpressions(jobs, newPushEvent(push, sender, changedFiles))
+	jobs = resolveAfter(jobs, config.Spec.Jobs.PostSubmit)
 	if len(jobs) < 1 {
 		return nil, nil
 	}
-	jobID := utils.RandomString(20)
 	return &cicdv1.IntegrationJob{
-		ObjectMeta: generateMeta(config.Name, config.Namespace, push.Sha, jobID),
 		Spec: cicdv1.IntegrationJobSpec{
 			ConfigRef: cicdv1.IntegrationJobConfigRef{
 				Name: config.Name,
 				Type: cicdv1.JobTypePostSubmit,
 			},
-			ID:         jobID,
 			Jobs:       jobs,
 			Workspaces: config.Spec.Workspaces,
 			Refs: cicdv1.IntegrationJobRefs{
 				Repository: repo.Name,
 				Link:       repo.URL,
+				CloneURL:   repo.CloneURL,
 				Sender: &cicdv1.IntegrationJobSender{
 					Name:  sender.Name,
 					Email: sender.Email,
@@ -138,15 +395,109 @@ func GeneratePostSubmit(push *git.Push, repo *git.Repository, sender *git.User,
 	}, nil
 }
 
-func generateMeta(cfgName, cfgNamespace, sha, jobID string) metav1.ObjectMeta {
-	return metav1.ObjectMeta{
+// GenerateRelease generates IntegrationJob for release event
+// The IntegrationJob is not identified yet, i.e., it has no name and id
+// Release jobs are filtered as the post-submit jobs of the tag push, except the path filters
+func GenerateRelease(release *git.Release, repo *git.Repository, config *cicdv1.IntegrationConfig) (*cicdv1.IntegrationJob, error) {
+	ref := "refs/tags/" + release.Tag
+	jobs, err := filter(config.Spec.Jobs.Release, git.EventTypePush, ref)
+	if err != nil {
+		return nil, err
+	}
+	// Release events do not have labels
+	jobs = filterLabels(jobs, nil)
+	jobs = filterExpressions(jobs, newReleaseEvent(release))
+	jobs = resolveAfter(jobs, config.Spec.Jobs.Release)
+	if len(jobs) < 1 {
+		return nil, nil
+	}
+	job := &cicdv1.IntegrationJob{
+		Spec: cicdv1.IntegrationJobSpec{
+			ConfigRef: cicdv1.IntegrationJobConfigRef{
+				Name: config.Name,
+				Type: cicdv1.JobTypeRelease,
+			},
+			Jobs:       jobs,
+			Workspaces: config.Spec.Workspaces,
+			Refs: cicdv1.IntegrationJobRefs{
+				Repository: repo.Name,
+				Link:       repo.URL,
+				CloneURL:   repo.CloneURL,
+				Base: cicdv1.IntegrationJobRefsBase{
+					Ref:  ref,
+					Link: repo.URL,
+					Sha:  release.Sha,
+				},
+				Release: &cicdv1.IntegrationJobRefsRelease{
+					Tag:        release.Tag,
+					Name:       release.Name,
+					Prerelease: release.Prerelease,
+					Link:       release.URL,
+				},
+			},
+			PodTemplate: config.Spec.PodTemplate,
+		},
+	}
+	// GitLab does not report who created the release
+	if release.Sender.Name != "" {
+		job.Spec.Refs.Sender = &cicdv1.IntegrationJobSender{
+			Name:  release.Sender.Name,
+			Email: release.Sender.Email,
+		}
+	}
+	return job, nil
+}
+
+// GeneratePeriodic generates IntegrationJob for the periodic jobs, scheduled for the head commit (sha) of the branch
+// The IntegrationJob is not identified yet, i.e., it has no name and id
+// The after fields of the jobs can only refer to the jobs in the same IntegrationJob
+func GeneratePeriodic(branch, sha string, jobs []cicdv1.Job, repo *git.Repository, config *cicdv1.IntegrationConfig) *cicdv1.IntegrationJob {
+	if len(jobs) < 1 {
+		return nil
+	}
+	jobs = resolveAfter(cicdv1.Jobs(jobs).DeepCopy(), jobs)
+	return &cicdv1.IntegrationJob{
+		Spec: cicdv1.IntegrationJobSpec{
+			ConfigRef: cicdv1.IntegrationJobConfigRef{
+				Name: config.Name,
+				Type: cicdv1.JobTypePeriodic,
+			},
+			Jobs:       jobs,
+			Workspaces: config.Spec.Workspaces,
+			Refs: cicdv1.IntegrationJobRefs{
+				Repository: repo.Name,
+				Link:       repo.URL,
+				CloneURL:   repo.CloneURL,
+				Sender: &cicdv1.IntegrationJobSender{
+					Name: periodicSender,
+				},
+				Base: cicdv1.IntegrationJobRefsBase{
+					Ref:  "refs/heads/" + branch,
+					Link: repo.URL,
+					Sha:  sha,
+				},
+			},
+			PodTemplate: config.Spec.PodTemplate,
+		},
+	}
+}
+
+// generateMeta generates the metadata of the IntegrationJob
+// pullRequestID is set as a label only for the pre-submit jobs
+func generateMeta(cfgName, cfgNamespace string, jobType cicdv1.JobType, sha, jobID string, pullRequestID int) metav1.ObjectMeta {
+	meta := metav1.ObjectMeta{
 		Name:      fmt.Sprintf("%s-%s-%s", cfgName, sha[:5], jobID[:5]),
 		Namespace: cfgNamespace,
 		Labels: map[string]string{
 			cicdv1.JobLabelConfig: cfgName,
+			cicdv1.JobLabelType:   string(jobType),
 			cicdv1.JobLabelID:     jobID,
 		},
 	}
+	if jobType == cicdv1.JobTypePreSubmit {
+		meta.Labels[cicdv1.JobLabelPullRequest] = strconv.Itoa(pullRequestID)
+	}
+	return meta
 }
 
 func filter(cand []cicdv1.Job, evType git.EventType, ref string) ([]cicdv1.Job, error) {
@@ -192,30 +543,16 @@ func filterTags(jobs []cicdv1.Job, incomingTag string) ([]cicdv1.Job, error) {
 		// Always run if no tag/skipTag is specified
 		if tags == nil && skipTags == nil {
 			filteredJobs = append(filteredJobs, job)
+			continue
 		}
 
 		if incomingTag == "" {
 			continue
 		}
 
-		if tags != nil && skipTags == nil {
-			for _, tag := range tags {
-				if match := matchString(incomingTag, tag); match {
-					filteredJobs = append(filteredJobs, job)
-					break
-				}
-			}
-		} else if skipTags != nil && tags == nil {
-			isInValidJob := false
-			for _, tag := range skipTags {
-				if match := matchString(incomingTag, tag); match {
-					isInValidJob = true
-					break
-				}
-			}
-			if !isInValidJob {
-				filteredJobs = append(filteredJobs, job)
-			}
+		// Run if the tag matches any of tags (if specified) and matches none of skipTags
+		if (tags == nil || matchAnyString(incomingTag, tags)) && !matchAnyString(incomingTag, skipTags) {
+			filteredJobs = append(filteredJobs, job)
 		}
 	}
 	return filteredJobs, nil
@@ -235,35 +572,31 @@ func filterBranches(jobs []cicdv1.Job, incomingBranch string) ([]cicdv1.Job, err
 		// Always run if no branch/skipBranch is specified
 		if branches == nil && skipBranches == nil {
 			filteredJobs = append(filteredJobs, job)
+			continue
 		}
 
 		if incomingBranch == "" {
 			continue
 		}
 
-		if branches != nil && skipBranches == nil {
-			for _, branch := range branches {
-				if match := matchString(incomingBranch, branch); match {
-					filteredJobs = append(filteredJobs, job)
-					break
-				}
-			}
-		} else if skipBranches != nil && branches == nil {
-			isInValidJob := false
-			for _, branch := range skipBranches {
-				if match := matchString(incomingBranch, branch); match {
-					isInValidJob = true
-					break
-				}
-			}
-			if !isInValidJob {
-				filteredJobs = append(filteredJobs, job)
-			}
+		// Run if the branch matches any of branches (if specified) and matches none of skipBranches
+		if (branches == nil || matchAnyString(incomingBranch, branches)) && !matchAnyString(incomingBranch, skipBranches) {
+			filteredJobs = append(filteredJobs, job)
 		}
 	}
 	return filteredJobs, nil
 }
 
+// matchAnyString checks if the incoming string matches any of the regular expressions
+func matchAnyString(incoming string, targets []string) bool {
+	for _, target := range targets {
+		if matchString(incoming, target) {
+			return true
+		}
+	}
+	return false
+}
+
 func matchString(incoming, target string) bool {
 	re, err := regexp.Compile(target)
 	if err != nil {
@@ -271,3 +604,293 @@ func matchString(incoming, target string) bool {
 	}
 	return re.MatchString(incoming)
 }
+
+// isLabelTrigger checks if the labels added (or removed) by the pull request event make any job runnable
+func isLabelTrigger(jobs []cicdv1.Job, pr *git.PullRequest) bool {
+	previous := previousLabels(pr)
+	for _, job := range jobs {
+		if isLabelTriggered(job, pr, previous) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterLabelTriggered filters only the jobs made runnable by the labels added (or removed) by the pull request event
+func filterLabelTriggered(jobs []cicdv1.Job, pr *git.PullRequest) []cicdv1.Job {
+	previous := previousLabels(pr)
+	var filteredJobs []cicdv1.Job
+	for _, job := range jobs {
+		if isLabelTriggered(job, pr, previous) {
+			filteredJobs = append(filteredJobs, job)
+		}
+	}
+	return resolveAfter(filteredJobs, jobs)
+}
+
+// isLabelTriggered checks if the job runs with the current labels of the pull request, but did not run with the previous
+// labels, by its labels, skipLabels and expression
+// Changed files are not considered, as they are not changed by the labels
+func isLabelTriggered(job cicdv1.Job, pr *git.PullRequest, previous []git.IssueLabel) bool {
+	if job.When == nil || (job.When.Labels == nil && job.When.SkipLabels == nil && job.When.Expression == "") {
+		return false
+	}
+	runs := func(labels []git.IssueLabel) bool {
+		jobs := filterLabels([]cicdv1.Job{job}, labels)
+		return len(filterExpressions(jobs, newPullRequestEvent(pr, &pr.Sender, labels, nil))) > 0
+	}
+	return runs(pr.Labels) && !runs(previous)
+}
+
+// previousLabels returns the labels of the pull request before the labeled/unlabeled action
+func previousLabels(pr *git.PullRequest) []git.IssueLabel {
+	changed := map[string]bool{}
+	for _, l := range pr.LabelChanged {
+		changed[l.Name] = true
+	}
+	var labels []git.IssueLabel
+	for _, l := range pr.Labels {
+		if !changed[l.Name] {
+			labels = append(labels, l)
+		}
+	}
+	if pr.Action == git.PullRequestActionUnlabeled {
+		labels = append(labels, pr.LabelChanged...)
+	}
+	return labels
+}
+
+// filterLabels filters the jobs by the labels of the pull request
+// A job runs if the pull request has any of its labels (if specified) and has none of its skipLabels
+func filterLabels(jobs []cicdv1.Job, labels []git.IssueLabel) []cicdv1.Job {
+	var filteredJobs []cicdv1.Job
+	for _, job := range jobs {
+		if job.When == nil || (job.When.Labels == nil && job.When.SkipLabels == nil) {
+			filteredJobs = append(filteredJobs, job)
+			continue
+		}
+		matched, skipped := false, false
+		for _, l := range labels {
+			matched = matched || containsString(job.When.Labels, l.Name)
+			skipped = skipped || containsString(job.When.SkipLabels, l.Name)
+		}
+		if (job.When.Labels == nil || matched) && !skipped {
+			filteredJobs = append(filteredJobs, job)
+		}
+	}
+	return filteredJobs
+}
+
+// filterDraft filters out the jobs skipping draft pull requests, if the pull request is a draft
+func filterDraft(jobs []cicdv1.Job, draft bool) []cicdv1.Job {
+	if !draft {
+		return jobs
+	}
+	var filteredJobs []cicdv1.Job
+	for _, job := range jobs {
+		if job.When != nil && job.When.SkipDraft {
+			continue
+		}
+		filteredJobs = append(filteredJobs, job)
+	}
+	return filteredJobs
+}
+
+// filterDraftSkipped filters only the jobs skipping draft pull requests
+func filterDraftSkipped(jobs []cicdv1.Job) []cicdv1.Job {
+	var filteredJobs []cicdv1.Job
+	for _, job := range jobs {
+		if job.When != nil && job.When.SkipDraft {
+			filteredJobs = append(filteredJobs, job)
+		}
+	}
+	return resolveAfter(filteredJobs, jobs)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// listChangedFiles lists the changed files by the list function, only if any job has path filters (or expressions referring to them)
+// It returns nil if the files are not needed or cannot be listed completely, so that the jobs are not filtered by the paths
+func listChangedFiles(jobs []cicdv1.Job, config *cicdv1.IntegrationConfig, cli client.Client, list func(git.Client) ([]string, error)) []string {
+	needed := false
+	for _, job := range jobs {
+		if job.When != nil && (job.When.Paths != nil || job.When.SkipPaths != nil || expressionUsesFiles(job.When.Expression)) {
+			needed = true
+			break
+		}
+	}
+	if !needed || cli == nil {
+		return nil
+	}
+
+	gitCli, err := utils.GetGitCli(config, cli)
+	if err != nil {
+		log.Error(err, "")
+		return nil
+	}
+	files, err := list(gitCli)
+	if err != nil {
+		log.Error(err, fmt.Sprintf("cannot list changed files of %s/%s, not filtering jobs by paths", config.Namespace, config.Name))
+		return nil
+	}
+	if files == nil {
+		files = []string{}
+	}
+	return files
+}
+
+// filterPaths filters the jobs by the changed files
+// A job runs if any changed file matches its paths (if specified) and does not match its skipPaths
+// Jobs are not filtered if changedFiles is nil, i.e., the changed files are unknown
+func filterPaths(jobs []cicdv1.Job, changedFiles []string) []cicdv1.Job {
+	if changedFiles == nil {
+		return jobs
+	}
+
+	var filteredJobs []cicdv1.Job
+	for _, job := range jobs {
+		if job.When == nil || (job.When.Paths == nil && job.When.SkipPaths == nil) {
+			filteredJobs = append(filteredJobs, job)
+			continue
+		}
+		for _, file := range changedFiles {
+			if (job.When.Paths == nil || matchAnyPath(file, job.When.Paths)) && !matchAnyPath(file, job.When.SkipPaths) {
+				filteredJobs = append(filteredJobs, job)
+				break
+			}
+		}
+	}
+	return filteredJobs
+}
+
+// filterExpressions filters the jobs by the expressions of their when conditions
+// Jobs with invalid expressions never run. The errors are also reported in the IntegrationConfig's status
+func filterExpressions(jobs []cicdv1.Job, ev *expression.Event) []cicdv1.Job {
+	var filteredJobs []cicdv1.Job
+	for _, job := range jobs {
+		if job.When == nil || job.When.Expression == "" {
+			filteredJobs = append(filteredJobs, job)
+			continue
+		}
+		expr, err := expression.Compile(job.When.Expression)
+		if err != nil {
+			log.Error(err, fmt.Sprintf("invalid expression of job %s", job.Name))
+			continue
+		}
+		if expr.Evaluate(ev) {
+			filteredJobs = append(filteredJobs, job)
+		}
+	}
+	return filteredJobs
+}
+
+// expressionUsesFiles checks if the expression refers to the changed files
+func expressionUsesFiles(expr string) bool {
+	if expr == "" {
+		return false
+	}
+	compiled, err := expression.Compile(expr)
+	return err == nil && compiled.UsesFiles()
+}
+
+// newPullRequestEvent generates an expression event of the pull request
+func newPullRequestEvent(pr *git.PullRequest, sender *git.User, labels []git.IssueLabel, changedFiles []string) *expression.Event {
+	ev := &expression.Event{
+		Type:   string(git.EventTypePullRequest),
+		Ref:    "refs/heads/" + pr.Base.Ref,
+		Base:   expression.Ref{Ref: pr.Base.Ref},
+		Head:   expression.Ref{Ref: pr.Head.Ref, Sha: pr.Head.Sha},
+		Sender: expression.Sender{Name: sender.Name, Email: sender.Email},
+		Title:  pr.Title,
+		Files:  changedFiles,
+	}
+	for _, l := range labels {
+		ev.Labels = append(ev.Labels, l.Name)
+	}
+	return ev
+}
+
+// newPushEvent generates an expression event of the push
+func newPushEvent(push *git.Push, sender *git.User, changedFiles []string) *expression.Event {
+	return &expression.Event{
+		Type:   string(git.EventTypePush),
+		Ref:    push.Ref,
+		Base:   expression.Ref{Ref: push.Ref, Sha: push.Before},
+		Head:   expression.Ref{Ref: push.Ref, Sha: push.Sha},
+		Sender: expression.Sender{Name: sender.Name, Email: sender.Email},
+		Files:  changedFiles,
+	}
+}
+
+// newReleaseEvent generates an expression event of the release
+// The name of the release is exposed as the title
+func newReleaseEvent(release *git.Release) *expression.Event {
+	ref := "refs/tags/" + release.Tag
+	return &expression.Event{
+		Type:   string(git.EventTypeRelease),
+		Ref:    ref,
+		Head:   expression.Ref{Ref: ref, Sha: release.Sha},
+		Sender: expression.Sender{Name: release.Sender.Name, Email: release.Sender.Email},
+		Title:  release.Name,
+	}
+}
+
+// resolveAfter replaces the filtered-out jobs in the after fields with their own after jobs,
+// so that the order of the remaining jobs is kept
+func resolveAfter(jobs []cicdv1.Job, cand []cicdv1.Job) []cicdv1.Job {
+	remaining := map[string]bool{}
+	for _, job := range jobs {
+		remaining[job.Name] = true
+	}
+	candAfter := map[string][]string{}
+	for _, job := range cand {
+		candAfter[job.Name] = job.After
+	}
+
+	var resolve func(names []string, visited map[string]bool) []string
+	resolve = func(names []string, visited map[string]bool) []string {
+		var result []string
+		for _, name := range names {
+			if visited[name] {
+				continue
+			}
+			visited[name] = true
+			if remaining[name] {
+				result = append(result, name)
+				continue
+			}
+			result = append(result, resolve(candAfter[name], visited)...)
+		}
+		return result
+	}
+
+	for i := range jobs {
+		if jobs[i].After == nil {
+			continue
+		}
+		jobs[i].After = resolve(jobs[i].After, map[string]bool{})
+	}
+	return jobs
+}
+
+// matchAnyPath checks if the path matches any of the glob patterns
+func matchAnyPath(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if expression.MatchPath(path, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// isZeroSha checks if the sha is empty or all-zero, which means the ref did not exist
+func isZeroSha(sha string) bool {
+	return strings.Trim(sha, "0") == ""
+}
@@ -0,0 +1,749 @@
+package dispatcher
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	gitfake "github.com/tmax-cloud/cicd-operator/pkg/git/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testHeadSha = "0123456789012345678901234567890123456789"
+	testBaseSha = "9876543210987654321098765432109876543210"
+)
+
+func TestGeneratePreSubmit(t *testing.T) {
+	srv := gitfake.NewGitHubServer(&gitfake.Repository{
+		Name:         "tmax-cloud/cicd-operator",
+		PullRequests: []git.PullRequest{{ID: 3, State: git.PullRequestStateOpen, Head: git.Head{Sha: testHeadSha}}, {ID: 4, State: git.PullRequestStateOpen, Head: git.Head{Sha: testBaseSha}}},
+		ChangedFiles: map[string][]string{
+			testHeadSha: {"docs/README.md", "pkg/git/git.go"},
+		},
+	}, "test-token")
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+	cli := fake.NewFakeClientWithScheme(s)
+
+	newJob := func(name string, when *cicdv1.JobWhen, after ...string) cicdv1.Job {
+		return cicdv1.Job{Container: corev1.Container{Name: name}, When: when, After: after}
+	}
+	config := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Git: cicdv1.GitConfig{
+				Type:       cicdv1.GitTypeGitHub,
+				Repository: "tmax-cloud/cicd-operator",
+				APIUrl:     srv.URL,
+				Token:      cicdv1.GitToken{Value: "test-token"},
+			},
+			Jobs: cicdv1.IntegrationConfigJobs{
+				PreSubmit: cicdv1.Jobs{
+					newJob("lint", nil),
+					newJob("build-ui", &cicdv1.JobWhen{Paths: []string{"ui/**"}}, "lint"),
+					newJob("test-ui", nil, "build-ui"),
+					newJob("test-go", &cicdv1.JobWhen{Paths: []string{"**/*.go"}}, "lint"),
+					newJob("docs", &cicdv1.JobWhen{SkipPaths: []string{"**/*.go"}}),
+					newJob("e2e", &cicdv1.JobWhen{SkipPaths: []string{"docs/**", "pkg/**"}}),
+				},
+			},
+		},
+	}
+	pr := &git.PullRequest{ID: 3, Base: git.Base{Ref: "master"}, Head: git.Head{Ref: "feat/a", Sha: testHeadSha}}
+	repo := &git.Repository{Name: "tmax-cloud/cicd-operator"}
+
+	jobNames := func(jobs []cicdv1.Job) map[string][]string {
+		names := map[string][]string{}
+		for _, j := range jobs {
+			names[j.Name] = j.After
+		}
+		return names
+	}
+
+	// Filtered by the changed files
+	ij, err := GeneratePreSubmit(pr, repo, &git.User{Name: "test"}, config, cli)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string][]string{
+		"lint":    nil,
+		"test-ui": {"lint"},
+		"test-go": {"lint"},
+		"docs":    nil,
+	}, jobNames(ij.Spec.Jobs))
+	assert.Equal(t, "/repos/tmax-cloud/cicd-operator/pulls/3/files", srv.Requests()[0].Path)
+
+	// Not filtered if the changed files cannot be listed
+	pr.ID = 5
+	ij, err = GeneratePreSubmit(pr, repo, &git.User{Name: "test"}, config, cli)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 6, len(ij.Spec.Jobs))
+
+	// Changed files of a push are listed by comparing the commits
+	srv.ResetRequests()
+	push := &git.Push{Ref: "refs/heads/master", Before: testBaseSha, Sha: testHeadSha}
+	config.Spec.Jobs.PostSubmit = config.Spec.Jobs.PreSubmit
+	ij, err = GeneratePostSubmit(push, repo, &git.User{Name: "test"}, config, cli)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 4, len(ij.Spec.Jobs))
+	assert.Equal(t, "/repos/tmax-cloud/cicd-operator/compare/"+testBaseSha+"..."+testHeadSha, srv.Requests()[0].Path)
+
+	// Changed files are not listed for a newly created branch
+	srv.ResetRequests()
+	push = &git.Push{Ref: "refs/heads/master", Before: "0000000000000000000000000000000000000000", Sha: testHeadSha}
+	ij, err = GeneratePostSubmit(push, repo, &git.User{Name: "test"}, config, cli)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 6, len(ij.Spec.Jobs))
+	assert.Equal(t, 0, len(srv.Requests()))
+}
+
+func TestDispatcher_HandleLabels(t *testing.T) {
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+	d := Dispatcher{Client: fake.NewFakeClientWithScheme(s)}
+
+	config := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Jobs: cicdv1.IntegrationConfigJobs{
+				PreSubmit: cicdv1.Jobs{
+					{Container: corev1.Container{Name: "test"}},
+					{Container: corev1.Container{Name: "e2e"}, When: &cicdv1.JobWhen{Labels: []string{"run-e2e"}}},
+					{Container: corev1.Container{Name: "lint"}, When: &cicdv1.JobWhen{SkipLabels: []string{"skip-lint"}}},
+				},
+				PostSubmit: cicdv1.Jobs{
+					{Container: corev1.Container{Name: "e2e"}, When: &cicdv1.JobWhen{Labels: []string{"run-e2e"}}},
+					{Container: corev1.Container{Name: "lint"}, When: &cicdv1.JobWhen{SkipLabels: []string{"skip-lint"}}},
+				},
+			},
+		},
+	}
+
+	tc := map[string]struct {
+		action       git.PullRequestAction
+		labels       []string
+		labelChanged []string
+		expectedJobs []string
+	}{
+		"openedWithoutLabels":  {action: git.PullRequestActionOpen, expectedJobs: []string{"test", "lint"}},
+		"openedWithLabels":     {action: git.PullRequestActionOpen, labels: []string{"run-e2e", "skip-lint"}, expectedJobs: []string{"test", "e2e"}},
+		"labeledTrigger":       {action: git.PullRequestActionLabeled, labels: []string{"run-e2e"}, labelChanged: []string{"run-e2e"}, expectedJobs: []string{"e2e"}},
+		"labeledOther":         {action: git.PullRequestActionLabeled, labels: []string{"kind/bug"}, labelChanged: []string{"kind/bug"}},
+		"labeledSkip":          {action: git.PullRequestActionLabeled, labels: []string{"skip-lint"}, labelChanged: []string{"skip-lint"}},
+		"unlabeledSkipTrigger": {action: git.PullRequestActionUnlabeled, labelChanged: []string{"skip-lint"}, expectedJobs: []string{"lint"}},
+		"unlabeledTrigger":     {action: git.PullRequestActionUnlabeled, labelChanged: []string{"run-e2e"}},
+	}
+
+	toLabels := func(names []string) []git.IssueLabel {
+		var labels []git.IssueLabel
+		for _, n := range names {
+			labels = append(labels, git.IssueLabel{Name: n})
+		}
+		return labels
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			ijList := &cicdv1.IntegrationJobList{}
+			assert.Equal(t, nil, d.Client.List(context.Background(), ijList))
+			for i := range ijList.Items {
+				assert.Equal(t, nil, d.Client.Delete(context.Background(), &ijList.Items[i]))
+			}
+
+			pr := &git.PullRequest{ID: 3, State: git.PullRequestStateOpen, Action: c.action, Base: git.Base{Ref: "master"},
+				Head: git.Head{Ref: "feat/a", Sha: testHeadSha}, Labels: toLabels(c.labels), LabelChanged: toLabels(c.labelChanged)}
+			assert.Equal(t, nil, d.Handle(&git.Webhook{EventType: git.EventTypePullRequest, PullRequest: pr}, config))
+
+			assert.Equal(t, nil, d.Client.List(context.Background(), ijList))
+			if c.expectedJobs == nil {
+				assert.Equal(t, 0, len(ijList.Items))
+				return
+			}
+			assert.Equal(t, 1, len(ijList.Items))
+			var jobs []string
+			for _, j := range ijList.Items[0].Spec.Jobs {
+				jobs = append(jobs, j.Name)
+			}
+			assert.Equal(t, c.expectedJobs, jobs)
+		})
+	}
+
+	// Push events do not have labels
+	ij, err := GeneratePostSubmit(&git.Push{Ref: "refs/heads/master", Sha: testHeadSha}, &git.Repository{}, &git.User{}, config, nil)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(ij.Spec.Jobs))
+	assert.Equal(t, "lint", ij.Spec.Jobs[0].Name)
+}
+
+func TestFilterLabelTriggered(t *testing.T) {
+	jobs := []cicdv1.Job{
+		{Container: corev1.Container{Name: "test"}},
+		{Container: corev1.Container{Name: "e2e"}, When: &cicdv1.JobWhen{Labels: []string{"run-e2e", "run-all"}}},
+		{Container: corev1.Container{Name: "e2e-report"}, After: []string{"e2e"}},
+		{Container: corev1.Container{Name: "lint"}, When: &cicdv1.JobWhen{Labels: []string{"run-all"}}},
+	}
+	names := func(jobs []cicdv1.Job) []string {
+		var result []string
+		for _, j := range jobs {
+			result = append(result, j.Name)
+		}
+		return result
+	}
+
+	// Only the jobs enabled by the added label are run, not the jobs depending on them
+	pr := &git.PullRequest{Action: git.PullRequestActionLabeled, Labels: []git.IssueLabel{{Name: "run-e2e"}}, LabelChanged: []git.IssueLabel{{Name: "run-e2e"}}}
+	assert.Equal(t, []string{"e2e"}, names(filterLabelTriggered(filterLabels(jobs, pr.Labels), pr)))
+
+	// Jobs which were already runnable are not run again
+	pr = &git.PullRequest{Action: git.PullRequestActionLabeled, Labels: []git.IssueLabel{{Name: "run-e2e"}, {Name: "run-all"}}, LabelChanged: []git.IssueLabel{{Name: "run-all"}}}
+	assert.Equal(t, []string{"lint"}, names(filterLabelTriggered(filterLabels(jobs, pr.Labels), pr)))
+}
+
+func TestDispatcher_HandleDraft(t *testing.T) {
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+	d := Dispatcher{Client: fake.NewFakeClientWithScheme(s)}
+
+	config := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Jobs: cicdv1.IntegrationConfigJobs{
+				PreSubmit: cicdv1.Jobs{
+					{Container: corev1.Container{Name: "lint"}},
+					{Container: corev1.Container{Name: "build"}, When: &cicdv1.JobWhen{SkipDraft: true}, After: []string{"lint"}},
+					{Container: corev1.Container{Name: "e2e"}, When: &cicdv1.JobWhen{SkipDraft: true}, After: []string{"build"}},
+				},
+			},
+		},
+	}
+
+	tc := map[string]struct {
+		action       git.PullRequestAction
+		draft        bool
+		expectedJobs map[string][]string
+	}{
+		"draftOpened":    {action: git.PullRequestActionOpen, draft: true, expectedJobs: map[string][]string{"lint": nil}},
+		"opened":         {action: git.PullRequestActionOpen, expectedJobs: map[string][]string{"lint": nil, "build": {"lint"}, "e2e": {"build"}}},
+		"readyForReview": {action: git.PullRequestActionReadyForReview, expectedJobs: map[string][]string{"build": nil, "e2e": {"build"}}},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			ijList := &cicdv1.IntegrationJobList{}
+			assert.Equal(t, nil, d.Client.List(context.Background(), ijList))
+			for i := range ijList.Items {
+				assert.Equal(t, nil, d.Client.Delete(context.Background(), &ijList.Items[i]))
+			}
+
+			pr := &git.PullRequest{ID: 3, State: git.PullRequestStateOpen, Action: c.action, Draft: c.draft, Base: git.Base{Ref: "master"}, Head: git.Head{Ref: "feat/a", Sha: testHeadSha}}
+			assert.Equal(t, nil, d.Handle(&git.Webhook{EventType: git.EventTypePullRequest, PullRequest: pr}, config))
+
+			assert.Equal(t, nil, d.Client.List(context.Background(), ijList))
+			assert.Equal(t, 1, len(ijList.Items))
+			jobs := map[string][]string{}
+			for _, j := range ijList.Items[0].Spec.Jobs {
+				jobs[j.Name] = j.After
+			}
+			assert.Equal(t, c.expectedJobs, jobs)
+		})
+	}
+}
+
+func TestDispatcher_HandleSkipCI(t *testing.T) {
+	srv := gitfake.NewGitHubServer(&gitfake.Repository{Name: "tmax-cloud/cicd-operator"}, "test-token")
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+	d := Dispatcher{Client: fake.NewFakeClientWithScheme(s)}
+
+	config := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Git: cicdv1.GitConfig{
+				Type:       cicdv1.GitTypeGitHub,
+				Repository: "tmax-cloud/cicd-operator",
+				APIUrl:     srv.URL,
+				Token:      cicdv1.GitToken{Value: "test-token"},
+			},
+			Jobs: cicdv1.IntegrationConfigJobs{
+				PreSubmit:  cicdv1.Jobs{{Container: corev1.Container{Name: "test"}}, {Container: corev1.Container{Name: "lint"}}},
+				PostSubmit: cicdv1.Jobs{{Container: corev1.Container{Name: "release"}}},
+			},
+		},
+	}
+	repo := git.Repository{Name: "tmax-cloud/cicd-operator"}
+
+	tc := map[string]struct {
+		webhook          *git.Webhook
+		markers          []string
+		expectedSkipped  bool
+		expectedStatuses []gitfake.Status
+	}{
+		"prTitle": {
+			webhook: &git.Webhook{EventType: git.EventTypePullRequest, Repo: repo, PullRequest: &git.PullRequest{ID: 3, Title: "Fix typo [Skip CI]", State: git.PullRequestStateOpen,
+				Action: git.PullRequestActionOpen, Base: git.Base{Ref: "master"}, Head: git.Head{Ref: "feat/a", Sha: testHeadSha}}},
+			expectedSkipped: true,
+			expectedStatuses: []gitfake.Status{
+				{Context: "test", State: git.CommitStatusState(cicdv1.CommitStatusStateSuccess), Description: "skipped"},
+				{Context: "lint", State: git.CommitStatusState(cicdv1.CommitStatusStateSuccess), Description: "skipped"},
+			},
+		},
+		"pushMessage": {
+			webhook:         &git.Webhook{EventType: git.EventTypePush, Repo: repo, Push: &git.Push{Ref: "refs/heads/master", Sha: testHeadSha, Message: "Update docs\n\n[ci skip]"}},
+			expectedSkipped: true,
+			expectedStatuses: []gitfake.Status{
+				{Context: "release", State: git.CommitStatusState(cicdv1.CommitStatusStateSuccess), Description: "skipped"},
+			},
+		},
+		"customMarker": {
+			webhook:         &git.Webhook{EventType: git.EventTypePush, Repo: repo, Push: &git.Push{Ref: "refs/heads/master", Sha: testHeadSha, Message: "Update docs [skip ci]"}},
+			markers:         []string{"[no ci]"},
+			expectedSkipped: false,
+		},
+		"noMarker": {
+			webhook:         &git.Webhook{EventType: git.EventTypePush, Repo: repo, Push: &git.Push{Ref: "refs/heads/master", Sha: testHeadSha, Message: "Update docs"}},
+			expectedSkipped: false,
+		},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			ijList := &cicdv1.IntegrationJobList{}
+			assert.Equal(t, nil, d.Client.List(context.Background(), ijList))
+			for i := range ijList.Items {
+				assert.Equal(t, nil, d.Client.Delete(context.Background(), &ijList.Items[i]))
+			}
+			srv.Update(func(repo *gitfake.Repository) { repo.Statuses = map[string][]gitfake.Status{} })
+
+			config.Spec.SkipCIMarkers = c.markers
+			assert.Equal(t, nil, d.Handle(c.webhook, config))
+
+			assert.Equal(t, nil, d.Client.List(context.Background(), ijList))
+			if !c.expectedSkipped {
+				assert.Equal(t, 1, len(ijList.Items))
+				assert.Equal(t, 0, len(srv.Repository().Statuses[testHeadSha]))
+				return
+			}
+			assert.Equal(t, 0, len(ijList.Items))
+			assert.Equal(t, c.expectedStatuses, srv.Repository().Statuses[testHeadSha])
+		})
+	}
+}
+
+func TestDispatcher_HandleCancel(t *testing.T) {
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+	d := Dispatcher{Client: fake.NewFakeClientWithScheme(s)}
+
+	config := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Jobs: cicdv1.IntegrationConfigJobs{
+				PreSubmit: cicdv1.Jobs{{Container: corev1.Container{Name: "test"}}},
+			},
+		},
+	}
+	pr := func(action git.PullRequestAction, sha string) *git.Webhook {
+		return &git.Webhook{EventType: git.EventTypePullRequest, PullRequest: &git.PullRequest{ID: 3, State: git.PullRequestStateOpen, Action: action,
+			Sender: git.User{Name: "test-user"}, Base: git.Base{Ref: "master"}, Head: git.Head{Ref: "feat/a", Sha: sha}}}
+	}
+	listJobs := func() map[string]*cicdv1.IntegrationJob {
+		ijList := &cicdv1.IntegrationJobList{}
+		assert.Equal(t, nil, d.Client.List(context.Background(), ijList))
+		jobs := map[string]*cicdv1.IntegrationJob{}
+		for i := range ijList.Items {
+			jobs[ijList.Items[i].Spec.Refs.Pull.Sha] = &ijList.Items[i]
+		}
+		return jobs
+	}
+
+	// Other pull request's job
+	other := pr(git.PullRequestActionOpen, "1111111111111111111111111111111111111111")
+	other.PullRequest.ID = 4
+	assert.Equal(t, nil, d.Handle(other, config))
+
+	assert.Equal(t, nil, d.Handle(pr(git.PullRequestActionOpen, testBaseSha), config))
+	assert.Equal(t, nil, d.Handle(pr(git.PullRequestActionSynchronize, testHeadSha), config))
+	jobs := listJobs()
+	assert.Equal(t, 3, len(jobs))
+	assert.Equal(t, &cicdv1.IntegrationJobCancel{
+		Reason:       cicdv1.IntegrationJobCancelReasonSuperseded,
+		SupersededBy: jobs[testHeadSha].Name,
+		Sha:          testHeadSha,
+		Sender:       &cicdv1.IntegrationJobSender{Name: "test-user"},
+	}, jobs[testBaseSha].Spec.Cancel)
+	assert.T(t, jobs[testHeadSha].Spec.Cancel == nil)
+	assert.T(t, jobs["1111111111111111111111111111111111111111"].Spec.Cancel == nil)
+
+	// Completed jobs are not canceled
+	completed := jobs[testHeadSha].DeepCopy()
+	now := metav1.Now()
+	completed.Status.CompletionTime = &now
+	assert.Equal(t, nil, d.Client.Status().Update(context.Background(), completed))
+	assert.Equal(t, nil, d.Handle(pr(git.PullRequestActionClose, testHeadSha), config))
+	jobs = listJobs()
+	assert.T(t, jobs[testHeadSha].Spec.Cancel == nil)
+	assert.Equal(t, cicdv1.IntegrationJobCancelReasonSuperseded, jobs[testBaseSha].Spec.Cancel.Reason)
+
+	// Closed pull request
+	assert.Equal(t, nil, d.Handle(pr(git.PullRequestActionReOpen, testHeadSha), config))
+	assert.Equal(t, nil, d.Handle(pr(git.PullRequestActionClose, testHeadSha), config))
+	ijList := &cicdv1.IntegrationJobList{}
+	assert.Equal(t, nil, d.Client.List(context.Background(), ijList))
+	canceled := 0
+	for _, ij := range ijList.Items {
+		if ij.Spec.Cancel != nil && ij.Spec.Cancel.Reason == cicdv1.IntegrationJobCancelReasonPullRequestClosed {
+			canceled++
+		}
+	}
+	assert.Equal(t, 1, canceled)
+}
+
+func TestFilter(t *testing.T) {
+	jobs := []cicdv1.Job{
+		{Container: corev1.Container{Name: "always"}},
+		{Container: corev1.Container{Name: "branch"}, When: &cicdv1.JobWhen{Branch: []string{"^release/"}, SkipBranch: []string{"-rc$"}}},
+		{Container: corev1.Container{Name: "skipBranch"}, When: &cicdv1.JobWhen{SkipBranch: []string{"^release/"}}},
+		{Container: corev1.Container{Name: "tag"}, When: &cicdv1.JobWhen{Tag: []string{"^v"}, SkipTag: []string{"-rc"}}},
+	}
+
+	tc := map[string]struct {
+		ref          string
+		expectedJobs []string
+	}{
+		"release":   {ref: "refs/heads/release/v1", expectedJobs: []string{"always", "branch"}},
+		"releaseRC": {ref: "refs/heads/release/v1-rc", expectedJobs: []string{"always"}},
+		"master":    {ref: "refs/heads/master", expectedJobs: []string{"always", "skipBranch"}},
+		"tag":       {ref: "refs/tags/v0.1.0", expectedJobs: []string{"always", "tag"}},
+		"tagRC":     {ref: "refs/tags/v0.1.0-rc", expectedJobs: []string{"always"}},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			filtered, err := filter(jobs, git.EventTypePush, c.ref)
+			assert.Equal(t, nil, err)
+			var names []string
+			for _, j := range filtered {
+				names = append(names, j.Name)
+			}
+			assert.Equal(t, c.expectedJobs, names)
+		})
+	}
+}
+
+func TestDispatcher_HandleExpression(t *testing.T) {
+	srv := gitfake.NewGitHubServer(&gitfake.Repository{
+		Name:         "tmax-cloud/cicd-operator",
+		PullRequests: []git.PullRequest{{ID: 3, State: git.PullRequestStateOpen, Head: git.Head{Sha: testHeadSha}}},
+		ChangedFiles: map[string][]string{
+			testHeadSha: {"docs/README.md"},
+		},
+	}, "test-token")
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+	d := Dispatcher{Client: fake.NewFakeClientWithScheme(s)}
+
+	newJob := func(name, expr string) cicdv1.Job {
+		return cicdv1.Job{Container: corev1.Container{Name: name}, When: &cicdv1.JobWhen{Expression: expr}}
+	}
+	config := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Git: cicdv1.GitConfig{
+				Type:       cicdv1.GitTypeGitHub,
+				Repository: "tmax-cloud/cicd-operator",
+				APIUrl:     srv.URL,
+				Token:      cicdv1.GitToken{Value: "test-token"},
+			},
+			Jobs: cicdv1.IntegrationConfigJobs{
+				PreSubmit: cicdv1.Jobs{
+					{Container: corev1.Container{Name: "test"}},
+					newJob("e2e", `"run-e2e" in labels || title.contains("[e2e]")`),
+					newJob("docs", `changed("docs/**")`),
+					newJob("code", `changed("**/*.go")`),
+					newJob("bot", `sender.name.endsWith("-bot")`),
+					newJob("invalid", `branch =~ "feat/(.*"`),
+				},
+				PostSubmit: cicdv1.Jobs{
+					newJob("release", `type == "push" && tag =~ "^v[0-9]+"`),
+					newJob("master", `branch == "master"`),
+				},
+			},
+		},
+	}
+
+	tc := map[string]struct {
+		pr           *git.PullRequest
+		push         *git.Push
+		expectedJobs []string
+	}{
+		"pullRequest": {
+			pr:           &git.PullRequest{Title: "Update docs", Action: git.PullRequestActionOpen, Sender: git.User{Name: "renovate-bot"}},
+			expectedJobs: []string{"test", "docs", "bot"},
+		},
+		"pullRequestLabeled": {
+			pr:           &git.PullRequest{Title: "Update docs", Action: git.PullRequestActionLabeled, Labels: []git.IssueLabel{{Name: "run-e2e"}}, LabelChanged: []git.IssueLabel{{Name: "run-e2e"}}},
+			expectedJobs: []string{"e2e"},
+		},
+		"pullRequestLabeledOther": {
+			pr: &git.PullRequest{Title: "Update docs [e2e]", Action: git.PullRequestActionLabeled, Labels: []git.IssueLabel{{Name: "run-e2e"}}, LabelChanged: []git.IssueLabel{{Name: "run-e2e"}}},
+		},
+		"tagPush": {
+			push:         &git.Push{Ref: "refs/tags/v0.1.0", Sha: testHeadSha},
+			expectedJobs: []string{"release"},
+		},
+		"branchPush": {
+			push:         &git.Push{Ref: "refs/heads/master", Before: testBaseSha, Sha: testHeadSha},
+			expectedJobs: []string{"master"},
+		},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			ijList := &cicdv1.IntegrationJobList{}
+			assert.Equal(t, nil, d.Client.List(context.Background(), ijList))
+			for i := range ijList.Items {
+				assert.Equal(t, nil, d.Client.Delete(context.Background(), &ijList.Items[i]))
+			}
+
+			wh := &git.Webhook{Repo: git.Repository{Name: "tmax-cloud/cicd-operator"}}
+			if c.pr != nil {
+				c.pr.ID = 3
+				c.pr.State = git.PullRequestStateOpen
+				c.pr.Base = git.Base{Ref: "master"}
+				c.pr.Head = git.Head{Ref: "feat/a", Sha: testHeadSha}
+				wh.EventType = git.EventTypePullRequest
+				wh.PullRequest = c.pr
+			} else {
+				wh.EventType = git.EventTypePush
+				wh.Push = c.push
+			}
+			assert.Equal(t, nil, d.Handle(wh, config))
+
+			assert.Equal(t, nil, d.Client.List(context.Background(), ijList))
+			if c.expectedJobs == nil {
+				assert.Equal(t, 0, len(ijList.Items))
+				return
+			}
+			assert.Equal(t, 1, len(ijList.Items))
+			var jobs []string
+			for _, j := range ijList.Items[0].Spec.Jobs {
+				jobs = append(jobs, j.Name)
+			}
+			assert.Equal(t, c.expectedJobs, jobs)
+		})
+	}
+}
+
+func TestDispatcher_HandleReplay(t *testing.T) {
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+	d := Dispatcher{Client: fake.NewFakeClientWithScheme(s)}
+
+	config := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Jobs: cicdv1.IntegrationConfigJobs{
+				PreSubmit:  cicdv1.Jobs{{Container: corev1.Container{Name: "test"}}},
+				PostSubmit: cicdv1.Jobs{{Container: corev1.Container{Name: "build"}}},
+			},
+		},
+	}
+	pullRequest := func(deliveryID string, action git.PullRequestAction) *git.Webhook {
+		return &git.Webhook{EventType: git.EventTypePullRequest, DeliveryID: deliveryID, PullRequest: &git.PullRequest{
+			ID: 3, Action: action, State: git.PullRequestStateOpen, Base: git.Base{Ref: "master"}, Head: git.Head{Ref: "feat/a", Sha: testHeadSha},
+		}}
+	}
+	push := func(deliveryID string) *git.Webhook {
+		return &git.Webhook{EventType: git.EventTypePush, DeliveryID: deliveryID, Push: &git.Push{Ref: "refs/heads/master", Before: testBaseSha, Sha: testHeadSha}}
+	}
+	countJobs := func() int {
+		ijList := &cicdv1.IntegrationJobList{}
+		assert.Equal(t, nil, d.Client.List(context.Background(), ijList))
+		return len(ijList.Items)
+	}
+
+	// Replays of the same delivery create only one IntegrationJob
+	assert.Equal(t, nil, d.Handle(pullRequest("delivery-1", git.PullRequestActionOpen), config))
+	assert.Equal(t, nil, d.Handle(pullRequest("delivery-1", git.PullRequestActionOpen), config))
+	assert.Equal(t, 1, countJobs())
+
+	// Other deliveries or other events create new ones
+	assert.Equal(t, nil, d.Handle(pullRequest("delivery-2", git.PullRequestActionOpen), config))
+	assert.Equal(t, nil, d.Handle(pullRequest("delivery-2", git.PullRequestActionReOpen), config))
+	assert.Equal(t, 3, countJobs())
+
+	// Events without delivery IDs are identified by their contents
+	assert.Equal(t, nil, d.Handle(push(""), config))
+	assert.Equal(t, nil, d.Handle(push(""), config))
+	assert.Equal(t, 4, countJobs())
+
+	// An existing IntegrationJob of another id is a name collision
+	ijList := &cicdv1.IntegrationJobList{}
+	assert.Equal(t, nil, d.Client.List(context.Background(), ijList, client.MatchingLabels{cicdv1.JobLabelType: string(cicdv1.JobTypePostSubmit)}))
+	collided := ijList.Items[0]
+	collided.Spec.ID = "collided-id"
+	assert.Equal(t, nil, d.Client.Update(context.Background(), &collided))
+	assert.NotEqual(t, nil, d.Handle(push(""), config))
+	assert.Equal(t, 4, countJobs())
+}
+
+func TestDispatcher_HandleOkToTest(t *testing.T) {
+	srv := gitfake.NewGitHubServer(&gitfake.Repository{
+		Name:         "tmax-cloud/cicd-operator",
+		Organization: "tmax-cloud",
+		Users: []gitfake.User{
+			{ID: 1, Name: "member", OrgMember: true},
+			{ID: 2, Name: "writer", CanWrite: true},
+			{ID: 3, Name: "external"},
+		},
+		PullRequests: []git.PullRequest{{ID: 3, State: git.PullRequestStateOpen}},
+	}, "test-token")
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+	d := Dispatcher{Client: fake.NewFakeClientWithScheme(s)}
+
+	config := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Git: cicdv1.GitConfig{
+				Type:       cicdv1.GitTypeGitHub,
+				Repository: "tmax-cloud/cicd-operator",
+				APIUrl:     srv.URL,
+				Token:      cicdv1.GitToken{Value: "test-token"},
+			},
+			Jobs: cicdv1.IntegrationConfigJobs{
+				PreSubmit: cicdv1.Jobs{{Container: corev1.Container{Name: "test"}}},
+			},
+			TrustedContributors: &cicdv1.TrustedContributors{OrgMembers: true, Collaborators: true, Users: []string{"allowed"}},
+		},
+	}
+
+	tc := map[string]struct {
+		author         string
+		action         git.PullRequestAction
+		labels         []git.IssueLabel
+		noPolicy       bool
+		expectedJob    bool
+		expectedLabels []git.IssueLabel
+	}{
+		"orgMember":     {author: "member", action: git.PullRequestActionOpen, expectedJob: true},
+		"collaborator":  {author: "writer", action: git.PullRequestActionOpen, expectedJob: true},
+		"allowlist":     {author: "allowed", action: git.PullRequestActionOpen, expectedJob: true},
+		"noPolicy":      {author: "external", action: git.PullRequestActionOpen, noPolicy: true, expectedJob: true},
+		"okToTest":      {author: "external", action: git.PullRequestActionSynchronize, labels: []git.IssueLabel{{Name: LabelOkToTest}}, expectedJob: true},
+		"untrusted":     {author: "external", action: git.PullRequestActionOpen, expectedLabels: []git.IssueLabel{{Name: LabelNeedsOkToTest}}},
+		"untrustedSync": {author: "external", action: git.PullRequestActionSynchronize},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			ijList := &cicdv1.IntegrationJobList{}
+			assert.Equal(t, nil, d.Client.List(context.Background(), ijList))
+			for i := range ijList.Items {
+				assert.Equal(t, nil, d.Client.Delete(context.Background(), &ijList.Items[i]))
+			}
+			srv.Update(func(repo *gitfake.Repository) {
+				repo.PullRequests[0].Labels = nil
+				repo.Comments = nil
+			})
+
+			cfg := config.DeepCopy()
+			if c.noPolicy {
+				cfg.Spec.TrustedContributors = nil
+			}
+			// The sender differs from the author, e.g., the pull request is synchronized by a maintainer
+			assert.Equal(t, nil, d.Handle(&git.Webhook{EventType: git.EventTypePullRequest, PullRequest: &git.PullRequest{
+				ID: 3, Action: c.action, State: git.PullRequestStateOpen, Labels: c.labels,
+				Sender: git.User{ID: 2, Name: "writer"}, Author: git.User{Name: c.author},
+				Base: git.Base{Ref: "master"}, Head: git.Head{Ref: "feat/a", Sha: testHeadSha},
+			}}, cfg))
+
+			assert.Equal(t, nil, d.Client.List(context.Background(), ijList))
+			if c.expectedJob {
+				assert.Equal(t, 1, len(ijList.Items))
+				return
+			}
+			assert.Equal(t, 0, len(ijList.Items))
+			assert.Equal(t, c.expectedLabels, srv.Repository().PullRequests[0].Labels)
+			assert.Equal(t, c.action == git.PullRequestActionOpen, len(srv.Repository().Comments) == 1)
+		})
+	}
+}
+
+func TestDispatcher_HandleRelease(t *testing.T) {
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+	d := Dispatcher{Client: fake.NewFakeClientWithScheme(s)}
+
+	config := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			SkipCIMarkers: []string{"[skip ci]"},
+			Jobs: cicdv1.IntegrationConfigJobs{
+				PostSubmit: cicdv1.Jobs{{Container: corev1.Container{Name: "build"}}},
+				Release: cicdv1.Jobs{
+					{Container: corev1.Container{Name: "publish"}, When: &cicdv1.JobWhen{Tag: []string{"v.*"}}},
+					{Container: corev1.Container{Name: "announce"}, When: &cicdv1.JobWhen{Expression: `!title.contains("rc")`}, After: []string{"publish"}},
+				},
+			},
+		},
+	}
+	release := func(tag, name string) *git.Webhook {
+		return &git.Webhook{EventType: git.EventTypeRelease, Repo: git.Repository{Name: "tmax-cloud/cicd-operator", URL: "https://github.com/tmax-cloud/cicd-operator"}, Release: &git.Release{
+			Sender: git.User{Name: "releaser"}, Tag: tag, Name: name + " [skip ci]", Prerelease: true, Sha: testHeadSha, URL: "https://github.com/tmax-cloud/cicd-operator/releases/tag/" + tag,
+		}}
+	}
+	listJobs := func() []cicdv1.IntegrationJob {
+		ijList := &cicdv1.IntegrationJobList{}
+		assert.Equal(t, nil, d.Client.List(context.Background(), ijList))
+		return ijList.Items
+	}
+
+	// Release jobs are filtered by the tag and the expressions, not skipped by the markers
+	assert.Equal(t, nil, d.Handle(release("v0.2.0-rc.1", "v0.2.0 rc.1"), config))
+	ijs := listJobs()
+	assert.Equal(t, 1, len(ijs))
+	assert.Equal(t, cicdv1.JobTypeRelease, ijs[0].Spec.ConfigRef.Type)
+	assert.Equal(t, string(cicdv1.JobTypeRelease), ijs[0].Labels[cicdv1.JobLabelType])
+	assert.Equal(t, 1, len(ijs[0].Spec.Jobs))
+	assert.Equal(t, "publish", ijs[0].Spec.Jobs[0].Name)
+	assert.Equal(t, cicdv1.IntegrationJobRefsBase{Ref: "refs/tags/v0.2.0-rc.1", Link: "https://github.com/tmax-cloud/cicd-operator", Sha: testHeadSha}, ijs[0].Spec.Refs.Base)
+	assert.Equal(t, &cicdv1.IntegrationJobRefsRelease{Tag: "v0.2.0-rc.1", Name: "v0.2.0 rc.1 [skip ci]", Prerelease: true, Link: "https://github.com/tmax-cloud/cicd-operator/releases/tag/v0.2.0-rc.1"}, ijs[0].Spec.Refs.Release)
+	assert.Equal(t, "releaser", ijs[0].Spec.Refs.Sender.Name)
+
+	// Neither the tag nor the expression is matched
+	assert.Equal(t, nil, d.Handle(release("nightly", "nightly rc"), config))
+	assert.Equal(t, 1, len(listJobs()))
+
+	// Replays are ignored
+	assert.Equal(t, nil, d.Handle(release("v0.2.0-rc.1", "v0.2.0 rc.1"), config))
+	assert.Equal(t, 1, len(listJobs()))
+
+	// GitLab releases do not have senders
+	wh := release("v0.2.0", "v0.2.0")
+	wh.Release.Sender = git.User{}
+	assert.Equal(t, nil, d.Handle(wh, config))
+	ijs = listJobs()
+	assert.Equal(t, 2, len(ijs))
+	for _, ij := range ijs {
+		if ij.Spec.Refs.Release.Tag != "v0.2.0" {
+			continue
+		}
+		assert.Equal(t, 2, len(ij.Spec.Jobs))
+		assert.Equal(t, (*cicdv1.IntegrationJobSender)(nil), ij.Spec.Refs.Sender)
+	}
+}
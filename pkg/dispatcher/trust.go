@@ -0,0 +1,96 @@
+package dispatcher
+
+import (
+	"fmt"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+)
+
+// Labels of the pull requests of the untrusted contributors
+const (
+	// LabelOkToTest is a label of the pull requests which are allowed to be tested by a trusted contributor
+	LabelOkToTest = "ok-to-test"
+	// LabelNeedsOkToTest is a label of the pull requests which wait for /ok-to-test comment
+	LabelNeedsOkToTest = "needs-ok-to-test"
+)
+
+// IsTrustedContributor checks if the user is trusted by the IntegrationConfig's trusted contributors policy
+// Every user is trusted if the policy is not set
+func IsTrustedContributor(config *cicdv1.IntegrationConfig, gitCli git.Client, user git.User) (bool, error) {
+	policy := config.Spec.TrustedContributors
+	if policy == nil {
+		return true, nil
+	}
+
+	for _, u := range policy.Users {
+		if u == user.Name {
+			return true, nil
+		}
+	}
+
+	if memberCli, ok := gitCli.(git.OrganizationMemberClient); ok && policy.OrgMembers {
+		isMember, err := memberCli.IsOrganizationMember(user)
+		if err != nil {
+			return false, err
+		}
+		if isMember {
+			return true, nil
+		}
+	}
+
+	if policy.Collaborators {
+		return gitCli.CanUserWriteToRepo(user)
+	}
+
+	return false, nil
+}
+
+// NeedsOkToTest checks if the pull request should wait for /ok-to-test comment to be tested,
+// i.e., its author is not trusted and it's not labeled as ok-to-test yet
+func NeedsOkToTest(config *cicdv1.IntegrationConfig, gitCli git.Client, pr *git.PullRequest) (bool, error) {
+	if config.Spec.TrustedContributors == nil || hasLabel(pr.Labels, LabelOkToTest) {
+		return false, nil
+	}
+	trusted, err := IsTrustedContributor(config, gitCli, PullRequestAuthor(pr))
+	if err != nil {
+		return false, err
+	}
+	return !trusted, nil
+}
+
+// PullRequestAuthor returns the author of the pull request
+func PullRequestAuthor(pr *git.PullRequest) git.User {
+	if pr.Author.Name != "" || pr.Author.ID != 0 {
+		return pr.Author
+	}
+	return pr.Sender
+}
+
+// requestOkToTest labels the pull request as needs-ok-to-test (if the git server supports labels),
+// and registers a comment explaining how to run the jobs
+// The label is only informative, so the comment is registered even if it cannot be labeled
+func requestOkToTest(config *cicdv1.IntegrationConfig, gitCli git.Client, pr *git.PullRequest) error {
+	if labelCli, ok := gitCli.(git.LabelClient); ok {
+		if err := labelCli.AddLabel(pr.ID, LabelNeedsOkToTest); err != nil {
+			log.Error(err, "cannot label the pull request as needs-ok-to-test", "repository", config.Spec.Git.Repository, "pullRequest", pr.ID)
+		}
+	}
+	return gitCli.RegisterComment(git.IssueTypePullRequest, pr.ID, generateNeedsOkToTestComment(PullRequestAuthor(pr).Name, config.Spec.Git.Repository))
+}
+
+func generateNeedsOkToTestComment(user, repo string) string {
+	return fmt.Sprintf("The jobs are not run for this pull request, as `%s` is not a trusted contributor of the repository `%s`\n\n"+
+		"A trusted contributor can run the jobs by commenting `/ok-to-test`.\n"+
+		"Once it's ok to test, the jobs are run for the new commits of the pull request as well, "+
+		"if the git server supports labels (i.e., `%s` label is set). Otherwise, it should be commented again.\n", user, repo, LabelOkToTest)
+}
+
+func hasLabel(labels []git.IssueLabel, label string) bool {
+	for _, l := range labels {
+		if l.Name == label {
+			return true
+		}
+	}
+	return false
+}
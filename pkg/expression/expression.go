@@ -0,0 +1,108 @@
+package expression
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Event is an event over which the expressions are evaluated
+type Event struct {
+	// Type is a type of the event, i.e., pull_request, push or release
+	Type string
+
+	// Ref is the pushed ref of the push event, the tag ref of the release, or the base branch ref of the pull request (e.g., refs/heads/master)
+	Ref string
+
+	// Base is the base of the pull request, or the ref before the push
+	Base Ref
+	// Head is the head of the pull request, or the pushed ref
+	Head Ref
+
+	// Sender is who triggered the event
+	Sender Sender
+
+	// Title is a title of the pull request, or a name of the release. Empty for the push events
+	Title string
+
+	// Labels are the labels of the pull request. Empty for the push events
+	Labels []string
+
+	// Files are the changed files. nil if they are unknown
+	Files []string
+}
+
+// Ref is a ref and a commit sha of the event
+type Ref struct {
+	Ref string
+	Sha string
+}
+
+// Sender is who triggered the event
+type Sender struct {
+	Name  string
+	Email string
+}
+
+// Expression is a compiled expression
+type Expression struct {
+	root      node
+	usesFiles bool
+}
+
+// Compile parses and type-checks the expression
+// All the errors, including the invalid regular expressions, are detected here, so that Evaluate never fails
+func Compile(expr string) (*Expression, error) {
+	p, err := newParser(expr)
+	if err != nil {
+		return nil, err
+	}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{root: root, usesFiles: p.usesFiles}, nil
+}
+
+// Evaluate evaluates the expression for the event
+func (e *Expression) Evaluate(ev *Event) bool {
+	return e.root.eval(ev).(bool)
+}
+
+// UsesFiles reports if the expression refers to the changed files
+func (e *Expression) UsesFiles() bool {
+	return e.usesFiles
+}
+
+// MatchPath checks if the path matches the glob pattern
+// '*' matches any characters except '/', '**' matches any characters including '/' and '?' matches a character except '/'
+func MatchPath(path, pattern string) bool {
+	return globToRegexp(pattern).MatchString(path)
+}
+
+func globToRegexp(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				// '**/' also matches the root directory
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					expr.WriteString("(.*/)?")
+				} else {
+					expr.WriteString(".*")
+				}
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
@@ -0,0 +1,127 @@
+package expression
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestCompile(t *testing.T) {
+	tc := map[string]struct {
+		expr          string
+		errorOccurs   bool
+		expectedFiles bool
+	}{
+		"comparison":      {expr: `type == "push" && branch != "master"`},
+		"methods":         {expr: `ref.startsWith("refs/tags/v") || sender.name.endsWith("-bot") || title.contains("WIP")`},
+		"in":              {expr: `"run-e2e" in labels && !(branch in ["master", 'release'])`},
+		"listContains":    {expr: `labels.contains("kind/bug")`},
+		"regex":           {expr: `tag =~ "^v[0-9]+" && matches(head.ref, "^feat/")`},
+		"changed":         {expr: `changed("docs/**") || "go.mod" in files`, expectedFiles: true},
+		"notBool":         {expr: `branch`, errorOccurs: true},
+		"unknownVariable": {expr: `author == "admin"`, errorOccurs: true},
+		"unknownField":    {expr: `sender.id == "1"`, errorOccurs: true},
+		"unknownFunction": {expr: `branch.lower() == "master"`, errorOccurs: true},
+		"typeMismatch":    {expr: `labels == "run-e2e"`, errorOccurs: true},
+		"argCount":        {expr: `startsWith(ref)`, errorOccurs: true},
+		"invalidRegex":    {expr: `branch =~ "feat/(.*"`, errorOccurs: true},
+		"nonLiteralRegex": {expr: `branch =~ title`, errorOccurs: true},
+		"unterminated":    {expr: `branch == "master`, errorOccurs: true},
+		"unexpectedToken": {expr: `branch == "master" "dev"`, errorOccurs: true},
+		"unexpectedChar":  {expr: `branch = "master"`, errorOccurs: true},
+		"notBoolOperand":  {expr: `!branch`, errorOccurs: true},
+		"emptyExpression": {expr: ``, errorOccurs: true},
+		"unclosedParen":   {expr: `(branch == "master"`, errorOccurs: true},
+		"listOfNonString": {expr: `branch in [true]`, errorOccurs: true},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			expr, err := Compile(c.expr)
+			if c.errorOccurs {
+				assert.T(t, err != nil)
+				return
+			}
+			assert.Equal(t, nil, err)
+			assert.Equal(t, c.expectedFiles, expr.UsesFiles())
+		})
+	}
+}
+
+func TestExpression_Evaluate(t *testing.T) {
+	pullRequest := &Event{
+		Type:   "pull_request",
+		Ref:    "refs/heads/master",
+		Base:   Ref{Ref: "master"},
+		Head:   Ref{Ref: "feat/a", Sha: "0123456789"},
+		Sender: Sender{Name: "renovate-bot", Email: "bot@tmax.co.kr"},
+		Title:  "[WIP] Add feature A",
+		Labels: []string{"kind/feature", "run-e2e"},
+		Files:  []string{"docs/README.md", "pkg/a/a.go"},
+	}
+	tagPush := &Event{
+		Type: "push",
+		Ref:  "refs/tags/v0.2.0",
+		Head: Ref{Ref: "refs/tags/v0.2.0", Sha: "0123456789"},
+	}
+
+	tc := map[string]struct {
+		expr     string
+		event    *Event
+		expected bool
+	}{
+		"type":              {expr: `type == "pull_request"`, event: pullRequest, expected: true},
+		"branch":            {expr: `branch == "master"`, event: pullRequest, expected: true},
+		"tagOfBranch":       {expr: `tag == ""`, event: pullRequest, expected: true},
+		"tag":               {expr: `tag =~ "^v0\\.2\\."`, event: tagPush, expected: true},
+		"branchOfTag":       {expr: `branch != ""`, event: tagPush, expected: false},
+		"headRef":           {expr: `head.ref.startsWith("feat/")`, event: pullRequest, expected: true},
+		"sender":            {expr: `sender.name.endsWith("-bot") && sender.email == "bot@tmax.co.kr"`, event: pullRequest, expected: true},
+		"title":             {expr: `title.contains("[WIP]")`, event: pullRequest, expected: true},
+		"label":             {expr: `"run-e2e" in labels`, event: pullRequest, expected: true},
+		"noLabel":           {expr: `labels.contains("run-e2e")`, event: tagPush, expected: false},
+		"listLiteral":       {expr: `base.ref in ["master", "release"]`, event: pullRequest, expected: true},
+		"not":               {expr: `!(branch == "master")`, event: pullRequest, expected: false},
+		"precedence":        {expr: `false && false || true`, event: pullRequest, expected: true},
+		"changed":           {expr: `changed("pkg/**/*.go")`, event: pullRequest, expected: true},
+		"notChanged":        {expr: `changed("api/**")`, event: pullRequest, expected: false},
+		"changedUnknown":    {expr: `changed("api/**")`, event: tagPush, expected: true},
+		"filesUnknown":      {expr: `"go.mod" in files`, event: tagPush, expected: false},
+		"matchesFunction":   {expr: `matches(ref, "^refs/tags/")`, event: tagPush, expected: true},
+		"stringEscape":      {expr: `'[WIP] Add feature A' == title`, event: pullRequest, expected: true},
+		"boolComparison":    {expr: `("run-e2e" in labels) == true`, event: pullRequest, expected: true},
+		"releaseOrPullTest": {expr: `type == "push" && ref.startsWith("refs/tags/v") || "run-e2e" in labels`, event: tagPush, expected: true},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			expr, err := Compile(c.expr)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, c.expected, expr.Evaluate(c.event))
+		})
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tc := map[string]struct {
+		path    string
+		pattern string
+		match   bool
+	}{
+		"exact":           {"README.md", "README.md", true},
+		"star":            {"main.go", "*.go", true},
+		"starNoSlash":     {"pkg/main.go", "*.go", false},
+		"doubleStar":      {"pkg/git/git.go", "**/*.go", true},
+		"doubleStarRoot":  {"main.go", "**/*.go", true},
+		"doubleStarDir":   {"ui/src/index.js", "ui/**", true},
+		"doubleStarOther": {"uis/index.js", "ui/**", false},
+		"question":        {"v1.go", "v?.go", true},
+		"dot":             {"mainxgo", "main.go", false},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.match, MatchPath(c.path, c.pattern))
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package expression
+
+import (
+	"regexp"
+	"strings"
+)
+
+// valueType is a type of the values of the expressions
+type valueType string
+
+const (
+	typeString = valueType("string")
+	typeBool   = valueType("bool")
+	typeList   = valueType("list")
+)
+
+// node is a node of the expression tree
+// eval returns a string, a bool or a []string, according to its type
+type node interface {
+	typ() valueType
+	eval(ev *Event) interface{}
+}
+
+// variable is a variable of the event
+type variable struct {
+	t   valueType
+	get func(ev *Event) interface{}
+}
+
+// variables are the variables which can be referred by the expressions
+var variables = map[string]variable{
+	"type":         {typeString, func(ev *Event) interface{} { return ev.Type }},
+	"ref":          {typeString, func(ev *Event) interface{} { return ev.Ref }},
+	"branch":       {typeString, func(ev *Event) interface{} { return trimRef(ev.Ref, "refs/heads/") }},
+	"tag":          {typeString, func(ev *Event) interface{} { return trimRef(ev.Ref, "refs/tags/") }},
+	"base.ref":     {typeString, func(ev *Event) interface{} { return ev.Base.Ref }},
+	"base.sha":     {typeString, func(ev *Event) interface{} { return ev.Base.Sha }},
+	"head.ref":     {typeString, func(ev *Event) interface{} { return ev.Head.Ref }},
+	"head.sha":     {typeString, func(ev *Event) interface{} { return ev.Head.Sha }},
+	"sender.name":  {typeString, func(ev *Event) interface{} { return ev.Sender.Name }},
+	"sender.email": {typeString, func(ev *Event) interface{} { return ev.Sender.Email }},
+	"title":        {typeString, func(ev *Event) interface{} { return ev.Title }},
+	"labels":       {typeList, func(ev *Event) interface{} { return ev.Labels }},
+	"files":        {typeList, func(ev *Event) interface{} { return ev.Files }},
+}
+
+// trimRef returns the name of the ref without the prefix, or an empty string if the ref does not have the prefix
+func trimRef(ref, prefix string) string {
+	if !strings.HasPrefix(ref, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(ref, prefix)
+}
+
+type literalNode struct {
+	t     valueType
+	value interface{}
+}
+
+func (n *literalNode) typ() valueType            { return n.t }
+func (n *literalNode) eval(_ *Event) interface{} { return n.value }
+
+type listNode struct {
+	items []node
+}
+
+func (n *listNode) typ() valueType { return typeList }
+func (n *listNode) eval(ev *Event) interface{} {
+	var list []string
+	for _, item := range n.items {
+		list = append(list, item.eval(ev).(string))
+	}
+	return list
+}
+
+type variableNode struct {
+	v variable
+}
+
+func (n *variableNode) typ() valueType             { return n.v.t }
+func (n *variableNode) eval(ev *Event) interface{} { return n.v.get(ev) }
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) typ() valueType             { return typeBool }
+func (n *notNode) eval(ev *Event) interface{} { return !n.operand.eval(ev).(bool) }
+
+type andNode struct {
+	left, right node
+}
+
+func (n *andNode) typ() valueType { return typeBool }
+func (n *andNode) eval(ev *Event) interface{} {
+	return n.left.eval(ev).(bool) && n.right.eval(ev).(bool)
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n *orNode) typ() valueType { return typeBool }
+func (n *orNode) eval(ev *Event) interface{} {
+	return n.left.eval(ev).(bool) || n.right.eval(ev).(bool)
+}
+
+type equalNode struct {
+	left, right node
+	negate      bool
+}
+
+func (n *equalNode) typ() valueType { return typeBool }
+func (n *equalNode) eval(ev *Event) interface{} {
+	return (n.left.eval(ev) == n.right.eval(ev)) != n.negate
+}
+
+type inNode struct {
+	element, list node
+}
+
+func (n *inNode) typ() valueType { return typeBool }
+func (n *inNode) eval(ev *Event) interface{} {
+	return containsString(n.list.eval(ev).([]string), n.element.eval(ev).(string))
+}
+
+type matchNode struct {
+	operand node
+	re      *regexp.Regexp
+}
+
+func (n *matchNode) typ() valueType { return typeBool }
+func (n *matchNode) eval(ev *Event) interface{} {
+	return n.re.MatchString(n.operand.eval(ev).(string))
+}
+
+// changedNode checks if any changed file matches the glob pattern
+// It is always true if the changed files are unknown, so that the job is not skipped by mistake
+type changedNode struct {
+	re *regexp.Regexp
+}
+
+func (n *changedNode) typ() valueType { return typeBool }
+func (n *changedNode) eval(ev *Event) interface{} {
+	if ev.Files == nil {
+		return true
+	}
+	for _, f := range ev.Files {
+		if n.re.MatchString(f) {
+			return true
+		}
+	}
+	return false
+}
+
+type stringFuncNode struct {
+	args []node
+	fn   func(s, arg string) bool
+}
+
+func (n *stringFuncNode) typ() valueType { return typeBool }
+func (n *stringFuncNode) eval(ev *Event) interface{} {
+	return n.fn(n.args[0].eval(ev).(string), n.args[1].eval(ev).(string))
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,432 @@
+package expression
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tokenKind is a kind of the tokens
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// operators are the operators and the delimiters, longer ones first
+var operators = []string{"&&", "||", "==", "!=", "=~", "!", "(", ")", "[", "]", ",", "."}
+
+// tokenize splits the expression into tokens
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			var value strings.Builder
+			start := i
+			i++
+			for ; i < len(expr) && expr[i] != c; i++ {
+				if expr[i] == '\\' && i+1 < len(expr) {
+					i++
+					switch expr[i] {
+					case 'n':
+						value.WriteByte('\n')
+					case 't':
+						value.WriteByte('\t')
+					default:
+						value.WriteByte(expr[i])
+					}
+					continue
+				}
+				value.WriteByte(expr[i])
+			}
+			if i >= len(expr) {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, value: value.String(), pos: start})
+		case isIdentChar(c, true):
+			start := i
+			for i < len(expr) && isIdentChar(expr[i], false) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, value: expr[start:i], pos: start})
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(expr[i:], op) {
+					tokens = append(tokens, token{kind: tokenOperator, value: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character '%c' at %d", c, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(expr)}), nil
+}
+
+func isIdentChar(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+// parser is a recursive descent parser of the expressions
+//
+//	expr    := and ('||' and)*
+//	and     := unary ('&&' unary)*
+//	unary   := '!' unary | compare
+//	compare := postfix (('==' | '!=' | '=~' | 'in') postfix)?
+//	postfix := primary ('.' ident '(' args ')')*
+//	primary := string | 'true' | 'false' | variable | ident '(' args ')' | '(' expr ')' | '[' args ']'
+type parser struct {
+	tokens []token
+	pos    int
+
+	usesFiles bool
+}
+
+func newParser(expr string) (*parser, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{tokens: tokens}, nil
+}
+
+func (p *parser) parse() (node, error) {
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(0); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected '%s' at %d", t.value, t.pos)
+	}
+	if n.typ() != typeBool {
+		return nil, fmt.Errorf("expression should be a bool, not a %s", n.typ())
+	}
+	return n, nil
+}
+
+func (p *parser) peek(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	t := p.peek(0)
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOperator(offset int, op string) bool {
+	t := p.peek(offset)
+	return t.kind == tokenOperator && t.value == op
+}
+
+func (p *parser) expect(op string) error {
+	t := p.next()
+	if t.kind != tokenOperator || t.value != op {
+		return fmt.Errorf("expected '%s' at %d", op, t.pos)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator(0, "||") {
+		pos := p.next().pos
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkTypes(pos, "||", typeBool, left, right); err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator(0, "&&") {
+		pos := p.next().pos
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkTypes(pos, "&&", typeBool, left, right); err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOperator(0, "!") {
+		pos := p.next().pos
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkTypes(pos, "!", typeBool, operand); err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek(0)
+	isIn := t.kind == tokenIdent && t.value == "in"
+	if !isIn && !(t.kind == tokenOperator && (t.value == "==" || t.value == "!=" || t.value == "=~")) {
+		return left, nil
+	}
+	p.next()
+	right, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case isIn:
+		if err := checkArgs(t.pos, "in", []node{left, right}, typeString, typeList); err != nil {
+			return nil, err
+		}
+		return &inNode{element: left, list: right}, nil
+	case t.value == "=~":
+		return newMatchNode(t.pos, "=~", left, right)
+	default:
+		if left.typ() != right.typ() || left.typ() == typeList {
+			return nil, fmt.Errorf("cannot compare %s with %s at %d", left.typ(), right.typ(), t.pos)
+		}
+		return &equalNode{left: left, right: right, negate: t.value == "!="}, nil
+	}
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	// Method calls, e.g., ref.startsWith("refs/tags/")
+	for p.isOperator(0, ".") {
+		p.next()
+		name := p.next()
+		if name.kind != tokenIdent {
+			return nil, fmt.Errorf("expected a function name at %d", name.pos)
+		}
+		args, err := p.parseArgs("(", ")")
+		if err != nil {
+			return nil, err
+		}
+		n, err = p.newCall(name, append([]node{n}, args...))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return &literalNode{t: typeString, value: t.value}, nil
+	case tokenIdent:
+		switch {
+		case t.value == "true" || t.value == "false":
+			return &literalNode{t: typeBool, value: t.value == "true"}, nil
+		case p.isOperator(0, "("):
+			args, err := p.parseArgs("(", ")")
+			if err != nil {
+				return nil, err
+			}
+			return p.newCall(t, args)
+		}
+		// Variables may have fields, e.g., sender.name, which are not followed by '('
+		name := t.value
+		for p.isOperator(0, ".") && p.peek(1).kind == tokenIdent && !p.isOperator(2, "(") {
+			p.next()
+			name += "." + p.next().value
+		}
+		v, exist := variables[name]
+		if !exist {
+			return nil, fmt.Errorf("unknown variable %s at %d", name, t.pos)
+		}
+		if name == "files" {
+			p.usesFiles = true
+		}
+		return &variableNode{v: v}, nil
+	case tokenOperator:
+		switch t.value {
+		case "(":
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		case "[":
+			p.pos--
+			items, err := p.parseArgs("[", "]")
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range items {
+				if item.typ() != typeString {
+					return nil, fmt.Errorf("list should only have strings, not %s at %d", item.typ(), t.pos)
+				}
+			}
+			return &listNode{items: items}, nil
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of the expression")
+	}
+	return nil, fmt.Errorf("unexpected '%s' at %d", t.value, t.pos)
+}
+
+// parseArgs parses comma-separated expressions, enclosed by open and close
+func (p *parser) parseArgs(open, close string) ([]node, error) {
+	if err := p.expect(open); err != nil {
+		return nil, err
+	}
+	var args []node
+	if p.isOperator(0, close) {
+		p.next()
+		return args, nil
+	}
+	for {
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.isOperator(0, ",") {
+			p.next()
+			continue
+		}
+		if err := p.expect(close); err != nil {
+			return nil, err
+		}
+		return args, nil
+	}
+}
+
+// newCall creates a node for the function call
+// Method calls are also handled here, having the receiver as the first argument
+func (p *parser) newCall(name token, args []node) (node, error) {
+	switch name.value {
+	case "startsWith":
+		if err := checkArgs(name.pos, name.value, args, typeString, typeString); err != nil {
+			return nil, err
+		}
+		return &stringFuncNode{args: args, fn: strings.HasPrefix}, nil
+	case "endsWith":
+		if err := checkArgs(name.pos, name.value, args, typeString, typeString); err != nil {
+			return nil, err
+		}
+		return &stringFuncNode{args: args, fn: strings.HasSuffix}, nil
+	case "contains":
+		if len(args) == 2 && args[0].typ() == typeList {
+			if err := checkArgs(name.pos, name.value, args, typeList, typeString); err != nil {
+				return nil, err
+			}
+			return &inNode{element: args[1], list: args[0]}, nil
+		}
+		if err := checkArgs(name.pos, name.value, args, typeString, typeString); err != nil {
+			return nil, err
+		}
+		return &stringFuncNode{args: args, fn: strings.Contains}, nil
+	case "matches":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s expects 2 arguments, not %d at %d", name.value, len(args), name.pos)
+		}
+		return newMatchNode(name.pos, name.value, args[0], args[1])
+	case "changed":
+		if err := checkArgs(name.pos, name.value, args, typeString); err != nil {
+			return nil, err
+		}
+		pattern, ok := args[0].(*literalNode)
+		if !ok {
+			return nil, fmt.Errorf("%s expects a string literal at %d", name.value, name.pos)
+		}
+		p.usesFiles = true
+		return &changedNode{re: globToRegexp(pattern.value.(string))}, nil
+	}
+	return nil, fmt.Errorf("unknown function %s at %d", name.value, name.pos)
+}
+
+// newMatchNode creates a node matching the operand with the regular expression
+// The regular expression should be a string literal, so that it is compiled only once and its errors are detected in advance
+func newMatchNode(pos int, op string, operand, pattern node) (node, error) {
+	if err := checkArgs(pos, op, []node{operand, pattern}, typeString, typeString); err != nil {
+		return nil, err
+	}
+	lit, ok := pattern.(*literalNode)
+	if !ok {
+		return nil, fmt.Errorf("%s expects a string literal as a regular expression at %d", op, pos)
+	}
+	re, err := regexp.Compile(lit.value.(string))
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q at %d: %s", lit.value, pos, err.Error())
+	}
+	return &matchNode{operand: operand, re: re}, nil
+}
+
+// checkTypes checks if all the operands are in type t
+func checkTypes(pos int, op string, t valueType, operands ...node) error {
+	for _, o := range operands {
+		if o.typ() != t {
+			return fmt.Errorf("%s expects %s, not %s at %d", op, t, o.typ(), pos)
+		}
+	}
+	return nil
+}
+
+// checkArgs checks the number and the types of the arguments
+func checkArgs(pos int, name string, args []node, types ...valueType) error {
+	if len(args) != len(types) {
+		return fmt.Errorf("%s expects %d arguments, not %d at %d", name, len(types), len(args), pos)
+	}
+	for i, arg := range args {
+		if arg.typ() != types[i] {
+			return fmt.Errorf("%s expects %s as argument %d, not %s at %d", name, types[i], i+1, arg.typ(), pos)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,583 @@
+package azuredevops
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	apiVersion = "api-version=6.0"
+
+	// Event types of azure devops service hooks
+	eventTypePush               = "git.push"
+	eventTypePullRequestCreated = "git.pullrequest.created"
+	eventTypePullRequestUpdated = "git.pullrequest.updated"
+	eventTypePullRequestComment = "ms.vss-code.git-pullrequest-comment-event"
+
+	// Notification type of git.pullrequest.updated events. Only the source branch updates are subscribed, as other
+	// notifications (e.g., status updates) cannot be told apart from them by the payload and would trigger the jobs again
+	notificationTypePush = "PushNotification"
+
+	// subscriptionIDSeparator separates the subscription ids of a webhook entry
+	subscriptionIDSeparator = ","
+
+	// continuationTokenHeader is a response header containing the token for the next page
+	continuationTokenHeader = "x-ms-continuationtoken"
+
+	// webhookUserName is a basic auth user name of service hook requests. Its password is the webhook secret
+	webhookUserName = "cicd-operator"
+
+	// gitRepositoriesSecurityNamespace is an id of the security namespace for git repositories
+	gitRepositoriesSecurityNamespace = "2e9eb7ed-3c0a-47d4-87c1-0ffdd20bf3ba"
+	// gitPermissionContribute is a 'Contribute' permission bit of the git repositories security namespace
+	gitPermissionContribute = 4
+)
+
+// commitShaPattern matches a full commit sha
+var commitShaPattern = regexp.MustCompile("^[0-9a-f]{40}$")
+
+// Client is an azure devops client struct
+type Client struct {
+	IntegrationConfig *cicdv1.IntegrationConfig
+	K8sClient         client.Client
+}
+
+// ParseWebhook parses a webhook body for azure devops
+func (c *Client) ParseWebhook(header http.Header, jsonString []byte) (*git.Webhook, error) {
+	if err := git.ValidateWebhookSecrets(c.IntegrationConfig.GetWebhookSecrets(), func(secret string) error {
+		return Validate(secret, header)
+	}); err != nil {
+		return nil, err
+	}
+
+	event := &ServiceHookEvent{}
+	if err := json.Unmarshal(jsonString, event); err != nil {
+		return nil, err
+	}
+
+	switch event.EventType {
+	case eventTypePullRequestCreated, eventTypePullRequestUpdated:
+		return c.parsePullRequestWebhook(event.EventType, jsonString)
+	case eventTypePush:
+		return c.parsePushWebhook(jsonString)
+	case eventTypePullRequestComment:
+		return c.parsePullRequestCommentWebhook(jsonString)
+	}
+	return nil, nil
+}
+
+// ListWebhook lists registered service hook subscriptions of the repository
+// Subscriptions of the same url are listed as a single webhook entry, whose id is a comma-separated list of the subscription ids
+func (c *Client) ListWebhook() ([]git.WebhookEntry, error) {
+	repo, err := c.getRepositoryInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := fmt.Sprintf("%s/_apis/hooks/subscriptions?%s", c.getOrgAPIUrl(), apiVersion)
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := &Subscriptions{}
+	if err := json.Unmarshal(data, subscriptions); err != nil {
+		return nil, err
+	}
+
+	var result []git.WebhookEntry
+	entryIndex := map[string]int{}
+	for _, s := range subscriptions.Value {
+		if s.PublisherInputs["repository"] != repo.ID {
+			continue
+		}
+		u := s.ConsumerInputs["url"]
+		i, exist := entryIndex[u]
+		if !exist {
+			entryIndex[u] = len(result)
+			result = append(result, git.WebhookEntry{ID: s.ID, URL: u, Events: []string{s.EventType}})
+			continue
+		}
+		result[i].ID += subscriptionIDSeparator + s.ID
+		result[i].Events = append(result[i].Events, s.EventType)
+	}
+
+	return result, nil
+}
+
+// RegisterWebhook creates service hook subscriptions for our webhook server
+// A subscription is created for each event type
+func (c *Client) RegisterWebhook(uri string) error {
+	repo, err := c.getRepositoryInfo()
+	if err != nil {
+		return err
+	}
+
+	apiURL := fmt.Sprintf("%s/_apis/hooks/subscriptions?%s", c.getOrgAPIUrl(), apiVersion)
+
+	for _, eventType := range c.webhookEvents() {
+		body := SubscriptionBody{
+			PublisherID:      "tfs",
+			EventType:        eventType,
+			ResourceVersion:  "1.0",
+			ConsumerID:       "webHooks",
+			ConsumerActionID: "httpRequest",
+			PublisherInputs: map[string]string{
+				"projectId":  repo.Project.ID,
+				"repository": repo.ID,
+			},
+			ConsumerInputs: map[string]string{
+				"url":               uri,
+				"basicAuthUsername": webhookUserName,
+				"basicAuthPassword": c.IntegrationConfig.Status.Secrets,
+			},
+		}
+		if eventType == eventTypePullRequestUpdated {
+			body.PublisherInputs["notificationType"] = notificationTypePush
+		}
+		if _, _, err := c.requestHTTP(http.MethodPost, apiURL, body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExpectedWebhook returns the webhook entry expected to be registered for the url
+// Azure devops does not report whether the secret is configured
+func (c *Client) ExpectedWebhook(url string) git.WebhookEntry {
+	return git.WebhookEntry{URL: url, Events: c.webhookEvents()}
+}
+
+// webhookEvents returns the azure devops event types which the subscriptions should be created for
+func (c *Client) webhookEvents() []string {
+	var events []string
+	for _, ev := range git.WebhookEvents(c.IntegrationConfig) {
+		switch ev {
+		case git.EventTypePullRequest:
+			events = append(events, eventTypePullRequestCreated, eventTypePullRequestUpdated)
+		case git.EventTypePush:
+			events = append(events, eventTypePush)
+		case git.EventTypeIssueComment:
+			events = append(events, eventTypePullRequestComment)
+		}
+	}
+
+	// A service hook subscription is needed at least
+	if len(events) == 0 {
+		events = []string{eventTypePush}
+	}
+	return events
+}
+
+// DeleteWebhook deletes registered service hook subscriptions
+// id is a comma-separated list of the subscription ids, as listed by ListWebhook
+func (c *Client) DeleteWebhook(id string) error {
+	for _, subscriptionID := range strings.Split(id, subscriptionIDSeparator) {
+		apiURL := fmt.Sprintf("%s/_apis/hooks/subscriptions/%s?%s", c.getOrgAPIUrl(), url.PathEscape(subscriptionID), apiVersion)
+		if _, _, err := c.requestHTTP(http.MethodDelete, apiURL, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetCommitStatus sets pull request status for the pull request, or commit status for the specific commit
+func (c *Client) SetCommitStatus(integrationJob *cicdv1.IntegrationJob, context string, state git.CommitStatusState, description, targetURL string) error {
+	var apiURL string
+	if integrationJob.Spec.Refs.Pull == nil {
+		apiURL = fmt.Sprintf("%s/commits/%s/statuses?%s", c.getRepoAPIUrl(), integrationJob.Spec.Refs.Base.Sha, apiVersion)
+	} else {
+		apiURL = fmt.Sprintf("%s/pullRequests/%d/statuses?%s", c.getRepoAPIUrl(), integrationJob.Spec.Refs.Pull.ID, apiVersion)
+	}
+
+	statusBody := StatusBody{
+		Description: description,
+		TargetURL:   targetURL,
+		Context:     StatusContext{Name: context, Genre: webhookUserName},
+	}
+	switch cicdv1.CommitStatusState(state) {
+	case cicdv1.CommitStatusStatePending:
+		statusBody.State = "pending"
+	case cicdv1.CommitStatusStateSuccess:
+		statusBody.State = "succeeded"
+	case cicdv1.CommitStatusStateFailure:
+		statusBody.State = "failed"
+	default:
+		statusBody.State = "error"
+	}
+
+	if _, _, err := c.requestHTTP(http.MethodPost, apiURL, statusBody); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetUserInfo gets a user's information
+func (c *Client) GetUserInfo(user string) (*git.User, error) {
+	identity, err := c.getIdentity(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &git.User{
+		ID:    git.UserIDFromString(identity.ID),
+		Name:  identity.Properties.Account.Value,
+		Email: identity.Properties.Mail.Value,
+	}, nil
+}
+
+// CanUserWriteToRepo decides if the user has 'Contribute' permission on the repo
+func (c *Client) CanUserWriteToRepo(user git.User) (bool, error) {
+	repo, err := c.getRepositoryInfo()
+	if err != nil {
+		return false, err
+	}
+
+	identity, err := c.getIdentity(user.Name)
+	if err != nil {
+		return false, err
+	}
+
+	query := url.Values{}
+	query.Set("token", fmt.Sprintf("repoV2/%s/%s", repo.Project.ID, repo.ID))
+	query.Set("descriptors", identity.Descriptor)
+	query.Set("includeExtendedInfo", "true")
+	apiURL := fmt.Sprintf("%s/_apis/accesscontrollists/%s?%s&%s", c.getOrgAPIUrl(), gitRepositoriesSecurityNamespace, query.Encode(), apiVersion)
+
+	result, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return false, err
+	}
+
+	acls := &AccessControlLists{}
+	if err := json.Unmarshal(result, acls); err != nil {
+		return false, err
+	}
+
+	for _, acl := range acls.Value {
+		for _, ace := range acl.AcesDictionary {
+			allow := ace.Allow | ace.ExtendedInfo.EffectiveAllow
+			deny := ace.Deny | ace.ExtendedInfo.EffectiveDeny
+			if allow&gitPermissionContribute != 0 && deny&gitPermissionContribute == 0 {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// RegisterComment registers comment to a pull request, as a new comment thread
+func (c *Client) RegisterComment(issueType git.IssueType, issueNo int, body string) error {
+	if issueType != git.IssueTypePullRequest {
+		return fmt.Errorf("issue type %s is not supported", issueType)
+	}
+	apiURL := fmt.Sprintf("%s/pullRequests/%d/threads?%s", c.getRepoAPIUrl(), issueNo, apiVersion)
+
+	threadBody := &CommentThreadBody{
+		// Status 1 is 'active'
+		Status: 1,
+		// Comment type 1 is 'text'
+		Comments: []CommentThreadComment{{ParentCommentID: 0, Content: body, CommentType: 1}},
+	}
+	if _, _, err := c.requestHTTP(http.MethodPost, apiURL, threadBody); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetRepository gets the repository's information
+func (c *Client) GetRepository() (*git.Repository, error) {
+	repo, err := c.getRepositoryInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	result := c.convertRepositoryToShared(&Repository{ID: repo.ID, Name: repo.Name, RemoteURL: repo.RemoteURL})
+	return &result, nil
+}
+
+// ListBranches lists branches of the repository
+func (c *Client) ListBranches() ([]git.Ref, error) {
+	return c.listRefs("heads/", "")
+}
+
+// GetBranch gets a branch of the repository
+func (c *Client) GetBranch(name string) (*git.Ref, error) {
+	return c.getRef("heads/", name)
+}
+
+// ListTags lists tags of the repository
+func (c *Client) ListTags() ([]git.Ref, error) {
+	return c.listRefs("tags/", "")
+}
+
+// GetTag gets a tag of the repository
+func (c *Client) GetTag(name string) (*git.Ref, error) {
+	return c.getRef("tags/", name)
+}
+
+// ListOpenPullRequests lists active pull requests of the repository
+func (c *Client) ListOpenPullRequests() ([]git.PullRequest, error) {
+	apiURL := fmt.Sprintf("%s/pullrequests?searchCriteria.status=active&$top=100&$skip=0&%s", c.getRepoAPIUrl(), apiVersion)
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := &PullRequests{}
+	if err := json.Unmarshal(data, &prs.Value); err != nil {
+		return nil, err
+	}
+
+	var result []git.PullRequest
+	for i := range prs.Value {
+		result = append(result, *convertPullRequestToShared(&prs.Value[i]))
+	}
+
+	return result, nil
+}
+
+// ListChangedFiles lists the files changed by the head commit, compared to the merge base of base and head
+func (c *Client) ListChangedFiles(base, head string) ([]string, error) {
+	query := url.Values{}
+	query.Set("baseVersion", base)
+	query.Set("baseVersionType", versionType(base))
+	query.Set("targetVersion", head)
+	query.Set("targetVersionType", versionType(head))
+	query.Set("diffCommonCommit", "true")
+	query.Set("$top", "2000")
+	apiURL := fmt.Sprintf("%s/diffs/commits?%s&%s", c.getRepoAPIUrl(), query.Encode(), apiVersion)
+
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := &CommitDiffs{}
+	if err := json.Unmarshal(data, diffs); err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, ch := range diffs.Changes {
+		if ch.Item.IsFolder {
+			continue
+		}
+		result = append(result, strings.TrimPrefix(ch.Item.Path, "/"))
+		if ch.SourceServerItem != "" && ch.SourceServerItem != ch.Item.Path {
+			result = append(result, strings.TrimPrefix(ch.SourceServerItem, "/"))
+		}
+	}
+
+	return result, nil
+}
+
+// getRef gets a ref of the type (heads/ or tags/). nil is returned if it does not exist
+// Refs are filtered by the prefix, so the other refs starting with the name should be skipped
+func (c *Client) getRef(refType, name string) (*git.Ref, error) {
+	refs, err := c.listRefs(refType, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range refs {
+		if r.Name == name {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+// listRefs lists the refs of the type (heads/ or tags/), whose names start with the prefix
+func (c *Client) listRefs(refType, prefix string) ([]git.Ref, error) {
+	apiURL := fmt.Sprintf("%s/refs?filter=%s&peelTags=true&$top=1000&%s", c.getRepoAPIUrl(), url.QueryEscape(refType+prefix), apiVersion)
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := &Refs{}
+	if err := json.Unmarshal(data, &refs.Value); err != nil {
+		return nil, err
+	}
+
+	var result []git.Ref
+	for _, r := range refs.Value {
+		sha := r.ObjectID
+		if r.PeeledObjectID != "" {
+			sha = r.PeeledObjectID
+		}
+		result = append(result, git.Ref{Name: strings.TrimPrefix(r.Name, "refs/"+refType), Sha: sha})
+	}
+
+	return result, nil
+}
+
+// versionType returns the version type of the version, which is a commit sha or a branch name
+func versionType(version string) string {
+	if commitShaPattern.MatchString(version) {
+		return "commit"
+	}
+	return "branch"
+}
+
+func (c *Client) getRepositoryInfo() (*RepositoryInfo, error) {
+	apiURL := fmt.Sprintf("%s?%s", c.getRepoAPIUrl(), apiVersion)
+
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &RepositoryInfo{}
+	if err := json.Unmarshal(data, repo); err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+func (c *Client) getIdentity(user string) (*Identity, error) {
+	query := url.Values{}
+	query.Set("searchFilter", "General")
+	query.Set("filterValue", user)
+	apiURL := fmt.Sprintf("%s/_apis/identities?%s&%s", c.getIdentityAPIUrl(), query.Encode(), apiVersion)
+
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	identities := &Identities{}
+	if err := json.Unmarshal(data, identities); err != nil {
+		return nil, err
+	}
+
+	if len(identities.Value) == 0 {
+		return nil, fmt.Errorf("user %s is not found", user)
+	}
+
+	return &identities.Value[0], nil
+}
+
+// splitRepository splits spec.git.repository into organization (or collection), project, and repository
+func (c *Client) splitRepository() (string, string, string) {
+	tokens := strings.SplitN(c.IntegrationConfig.Spec.Git.Repository, "/", 3)
+	for len(tokens) < 3 {
+		tokens = append(tokens, "")
+	}
+	return tokens[0], tokens[1], tokens[2]
+}
+
+func (c *Client) getOrgAPIUrl() string {
+	org, _, _ := c.splitRepository()
+	return fmt.Sprintf("%s/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), org)
+}
+
+// getIdentityAPIUrl returns identity api url. Identities are served by vssps.dev.azure.com for azure devops services
+func (c *Client) getIdentityAPIUrl() string {
+	if c.IntegrationConfig.Spec.Git.GetAPIUrl() == cicdv1.AzureDevOpsDefaultAPIUrl {
+		org, _, _ := c.splitRepository()
+		return fmt.Sprintf("%s/%s", cicdv1.AzureDevOpsDefaultIdentityAPIUrl, org)
+	}
+	return c.getOrgAPIUrl()
+}
+
+func (c *Client) getRepoAPIUrl() string {
+	_, project, repo := c.splitRepository()
+	return fmt.Sprintf("%s/%s/_apis/git/repositories/%s", c.getOrgAPIUrl(), url.PathEscape(project), url.PathEscape(repo))
+}
+
+func (c *Client) requestHTTP(method, apiURL string, data interface{}) ([]byte, http.Header, error) {
+	httpCli, header, err := c.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli.Request(method, apiURL, header, data)
+}
+
+// requestHTTPAll requests api call, following all the pages
+// Values of the pages are merged into a single JSON array
+func (c *Client) requestHTTPAll(method, apiURL string, data interface{}) ([]byte, http.Header, error) {
+	httpCli, header, err := c.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli.RequestPages(method, apiURL, header, data, nextPage)
+}
+
+// nextPage returns the values of the page and the url of the next page
+// Some APIs (e.g., refs) return a continuation token header for the next page, and the others (e.g., pull requests)
+// are followed by $skip query parameter if it's set, until a page has less values than $top
+func nextPage(current string, body []byte, header http.Header) ([]json.RawMessage, string, error) {
+	page := &Page{}
+	if err := json.Unmarshal(body, page); err != nil {
+		return nil, "", err
+	}
+
+	if token := header.Get(continuationTokenHeader); token != "" {
+		next, err := git.SetQuery(current, "continuationToken", token)
+		return page.Value, next, err
+	}
+
+	u, err := url.Parse(current)
+	if err != nil {
+		return nil, "", err
+	}
+	top, topErr := strconv.Atoi(u.Query().Get("$top"))
+	skip, skipErr := strconv.Atoi(u.Query().Get("$skip"))
+	if topErr != nil || skipErr != nil || len(page.Value) < top {
+		return page.Value, "", nil
+	}
+	next, err := git.SetQuery(current, "$skip", strconv.Itoa(skip+top))
+	return page.Value, next, err
+}
+
+// httpClient returns a http client and a request header for the API server
+func (c *Client) httpClient() (*git.HTTPClient, map[string]string, error) {
+	token, err := c.IntegrationConfig.GetToken(c.K8sClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := map[string]string{
+		// Personal access token is used as a password of basic auth, with an empty user name
+		"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(":"+token)),
+		"Content-Type":  "application/json",
+	}
+
+	httpCli, err := git.GetHTTPClient(c.IntegrationConfig, c.K8sClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli, header, nil
+}
+
+// Validate validates the service hook request
+// Service hooks do not sign payloads, so the webhook secret is sent as a basic auth password
+func Validate(secret string, header http.Header) error {
+	req := &http.Request{Header: header}
+	user, password, ok := req.BasicAuth()
+	if !ok || user != webhookUserName || subtle.ConstantTimeCompare([]byte(password), []byte(secret)) != 1 {
+		return fmt.Errorf("invalid request : Authorization header does not match secret")
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package azuredevops
+
+import "encoding/json"
+
+// Page is a page of the list APIs
+type Page struct {
+	Value []json.RawMessage `json:"value"`
+}
+
+// RepositoryInfo is a body of repository get API
+type RepositoryInfo struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	RemoteURL string `json:"remoteUrl"`
+	Project   struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"project"`
+}
+
+// Identities is a body of identity search API
+type Identities struct {
+	Value []Identity `json:"value"`
+}
+
+// Identity is an identity of a user
+type Identity struct {
+	ID                  string `json:"id"`
+	Descriptor          string `json:"descriptor"`
+	ProviderDisplayName string `json:"providerDisplayName"`
+	Properties          struct {
+		Account struct {
+			Value string `json:"$value"`
+		} `json:"Account"`
+		Mail struct {
+			Value string `json:"$value"`
+		} `json:"Mail"`
+	} `json:"properties"`
+}
+
+// AccessControlLists is a body of access control list query API
+type AccessControlLists struct {
+	Value []struct {
+		AcesDictionary map[string]AccessControlEntry `json:"acesDictionary"`
+	} `json:"value"`
+}
+
+// AccessControlEntry is an access control entry of an identity
+type AccessControlEntry struct {
+	Allow        int `json:"allow"`
+	Deny         int `json:"deny"`
+	ExtendedInfo struct {
+		EffectiveAllow int `json:"effectiveAllow"`
+		EffectiveDeny  int `json:"effectiveDeny"`
+	} `json:"extendedInfo"`
+}
+
+// StatusBody is an API body for setting pull request/commit status
+type StatusBody struct {
+	State       string        `json:"state"`
+	Description string        `json:"description"`
+	TargetURL   string        `json:"targetUrl,omitempty"`
+	Context     StatusContext `json:"context"`
+}
+
+// StatusContext is a context (identifier) of the status
+type StatusContext struct {
+	Name  string `json:"name"`
+	Genre string `json:"genre"`
+}
+
+// CommentThreadBody is a body structure for creating new comment thread
+type CommentThreadBody struct {
+	Comments []CommentThreadComment `json:"comments"`
+	Status   int                    `json:"status"`
+}
+
+// CommentThreadComment is a comment of the comment thread
+type CommentThreadComment struct {
+	ParentCommentID int    `json:"parentCommentId"`
+	Content         string `json:"content"`
+	CommentType     int    `json:"commentType"`
+}
+
+// Refs is a list of refs
+type Refs struct {
+	Value []struct {
+		Name     string `json:"name"`
+		ObjectID string `json:"objectId"`
+		// PeeledObjectID is a commit id of annotated tags
+		PeeledObjectID string `json:"peeledObjectId"`
+	} `json:"value"`
+}
+
+// PullRequests is a list of pull requests
+type PullRequests struct {
+	Value []PullRequest `json:"value"`
+}
+
+// CommitDiffs is a list of the changed files between two commits
+type CommitDiffs struct {
+	Changes []struct {
+		Item struct {
+			Path     string `json:"path"`
+			IsFolder bool   `json:"isFolder"`
+		} `json:"item"`
+		SourceServerItem string `json:"sourceServerItem"`
+	} `json:"changes"`
+}
@@ -0,0 +1,20 @@
+package azuredevops
+
+import (
+	"testing"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"github.com/tmax-cloud/cicd-operator/pkg/git/fake"
+)
+
+func TestClientContract(t *testing.T) {
+	fake.RunContractTests(t, fake.Contract{
+		Type:      cicdv1.GitTypeAzureDevOps,
+		NewServer: fake.NewAzureDevOpsServer,
+		NewClient: func(ic *cicdv1.IntegrationConfig) git.Client {
+			return &Client{IntegrationConfig: ic}
+		},
+		Repository: "tmax-cloud/cicd/cicd-operator",
+	})
+}
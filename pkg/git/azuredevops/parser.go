@@ -0,0 +1,121 @@
+package azuredevops
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+)
+
+const zeroObjectID = "0000000000000000000000000000000000000000"
+
+func (c *Client) parsePullRequestWebhook(eventType string, jsonString []byte) (*git.Webhook, error) {
+	var data PullRequestWebhook
+
+	if err := json.Unmarshal(jsonString, &data); err != nil {
+		return nil, err
+	}
+
+	pullRequest := convertPullRequestToShared(&data.Resource)
+	// Updated events are subscribed only for the pushes to the source branch (see RegisterWebhook)
+	switch {
+	case pullRequest.State == git.PullRequestStateClosed:
+		pullRequest.Action = git.PullRequestActionClose
+	case eventType == eventTypePullRequestCreated:
+		pullRequest.Action = git.PullRequestActionOpen
+	default:
+		pullRequest.Action = git.PullRequestActionSynchronize
+	}
+
+	return &git.Webhook{EventType: git.EventTypePullRequest, Repo: c.convertRepositoryToShared(&data.Resource.Repository), PullRequest: pullRequest}, nil
+}
+
+func (c *Client) parsePushWebhook(jsonString []byte) (*git.Webhook, error) {
+	var data PushWebhook
+
+	if err := json.Unmarshal(jsonString, &data); err != nil {
+		return nil, err
+	}
+
+	// Each updated (or created) ref is handled as a push
+	var pushes []git.Push
+	for _, ref := range data.Resource.RefUpdates {
+		// Deleted ref
+		if ref.NewObjectID == zeroObjectID {
+			continue
+		}
+		push := git.Push{Sender: convertUser(&data.Resource.PushedBy), Ref: ref.Name, Before: ref.OldObjectID, Sha: ref.NewObjectID}
+		for _, commit := range data.Resource.Commits {
+			if commit.CommitID == ref.NewObjectID {
+				push.Message = commit.Comment
+			}
+		}
+		pushes = append(pushes, push)
+	}
+
+	return git.NewPushWebhook(c.convertRepositoryToShared(&data.Resource.Repository), pushes), nil
+}
+
+func (c *Client) parsePullRequestCommentWebhook(jsonString []byte) (*git.Webhook, error) {
+	var data PullRequestCommentWebhook
+
+	if err := json.Unmarshal(jsonString, &data); err != nil {
+		return nil, err
+	}
+
+	comment := data.Resource.Comment
+	// Skip system comments and edited comments
+	if comment.CommentType == "system" || (comment.LastContentUpdatedDate != nil && comment.PublishedDate != nil && !comment.LastContentUpdatedDate.Equal(comment.PublishedDate)) {
+		return nil, nil
+	}
+
+	return &git.Webhook{EventType: git.EventTypeIssueComment, Repo: c.convertRepositoryToShared(&data.Resource.PullRequest.Repository), IssueComment: &git.IssueComment{
+		Comment: git.Comment{
+			Body:      comment.Content,
+			CreatedAt: comment.PublishedDate,
+		},
+		Issue: git.Issue{
+			PullRequest: convertPullRequestToShared(&data.Resource.PullRequest),
+		},
+		Sender: convertUser(&comment.Author),
+	}}, nil
+}
+
+// convertRepositoryToShared converts the repository. Repository name is always <organization>/<project>/<repository> form
+func (c *Client) convertRepositoryToShared(repo *Repository) git.Repository {
+	repoURL := stripUserInfo(repo.RemoteURL)
+	return git.Repository{Name: c.IntegrationConfig.Spec.Git.Repository, URL: repoURL, CloneURL: repoURL}
+}
+
+func convertPullRequestToShared(pr *PullRequest) *git.PullRequest {
+	state := git.PullRequestStateClosed
+	if pr.Status == "active" {
+		state = git.PullRequestStateOpen
+	}
+
+	return &git.PullRequest{
+		ID:     pr.ID,
+		Title:  pr.Title,
+		State:  state,
+		Sender: convertUser(&pr.CreatedBy),
+		URL:    stripUserInfo(pr.Repository.RemoteURL) + "/pullrequest/" + strconv.Itoa(pr.ID),
+		Base:   git.Base{Ref: strings.TrimPrefix(pr.TargetRefName, "refs/heads/")},
+		Head:   git.Head{Ref: strings.TrimPrefix(pr.SourceRefName, "refs/heads/"), Sha: pr.LastMergeSourceCommit.CommitID},
+	}
+}
+
+// stripUserInfo strips the user name from the remote url (e.g., https://org@dev.azure.com/org/project/_git/repo)
+func stripUserInfo(remoteURL string) string {
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return remoteURL
+	}
+	u.User = nil
+	return u.String()
+}
+
+func convertUser(user *User) git.User {
+	return git.User{ID: git.UserIDFromString(user.ID), Name: user.UniqueName}
+}
@@ -0,0 +1,228 @@
+package azuredevops
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	testSecret = "test-secret"
+
+	testPullRequestBody = `{
+  "eventType": "git.pullrequest.updated",
+  "resource": {
+    "repository": {
+      "id": "4bc14d40-c903-45e2-872e-0462c7748079",
+      "name": "cicd-operator",
+      "remoteUrl": "https://tmax-cloud@dev.azure.com/tmax-cloud/cicd/_git/cicd-operator",
+      "project": {"id": "6ce954b1-ce1f-45d1-b94d-e6bf2464ba2c", "name": "cicd"}
+    },
+    "pullRequestId": 3,
+    "status": "active",
+    "createdBy": {"id": "54d125f7-69f7-4191-904f-c5b96b6261c8", "displayName": "Author", "uniqueName": "author@tmax.co.kr"},
+    "title": "test pull request",
+    "sourceRefName": "refs/heads/new-feat",
+    "targetRefName": "refs/heads/master",
+    "lastMergeSourceCommit": {"commitId": "6dcb09b5b57875f334f61aebed695e2e4193db5e"}
+  }
+}`
+
+	testPushBody = `{
+  "eventType": "git.push",
+  "resource": {
+    "refUpdates": [
+      {"name": "refs/heads/old-feat", "oldObjectId": "aad331d8d3b131fa9ae03cf5e53965b51942618a", "newObjectId": "0000000000000000000000000000000000000000"},
+      {"name": "refs/heads/master", "oldObjectId": "aad331d8d3b131fa9ae03cf5e53965b51942618a", "newObjectId": "6dcb09b5b57875f334f61aebed695e2e4193db5e"},
+      {"name": "refs/tags/v0.1.0", "oldObjectId": "0000000000000000000000000000000000000000", "newObjectId": "6dcb09b5b57875f334f61aebed695e2e4193db5e"}
+    ],
+    "repository": {
+      "id": "4bc14d40-c903-45e2-872e-0462c7748079",
+      "name": "cicd-operator",
+      "remoteUrl": "https://dev.azure.com/tmax-cloud/cicd/_git/cicd-operator",
+      "project": {"id": "6ce954b1-ce1f-45d1-b94d-e6bf2464ba2c", "name": "cicd"}
+    },
+    "pushedBy": {"id": "54d125f7-69f7-4191-904f-c5b96b6261c8", "displayName": "Author", "uniqueName": "author@tmax.co.kr"}
+  }
+}`
+
+	testCommentBody = `{
+  "eventType": "ms.vss-code.git-pullrequest-comment-event",
+  "resource": {
+    "comment": {
+      "id": 1,
+      "author": {"id": "8c8c7d32-6b1b-47f4-b2e9-30b477b5ab3d", "displayName": "Reviewer", "uniqueName": "reviewer@tmax.co.kr"},
+      "content": "/test",
+      "commentType": "text",
+      "publishedDate": "2021-02-03T07:13:23.153Z",
+      "lastContentUpdatedDate": "2021-02-03T07:13:23.153Z"
+    },
+    "pullRequest": {
+      "repository": {
+        "id": "4bc14d40-c903-45e2-872e-0462c7748079",
+        "name": "cicd-operator",
+        "remoteUrl": "https://dev.azure.com/tmax-cloud/cicd/_git/cicd-operator",
+        "project": {"id": "6ce954b1-ce1f-45d1-b94d-e6bf2464ba2c", "name": "cicd"}
+      },
+      "pullRequestId": 3,
+      "status": "active",
+      "createdBy": {"id": "54d125f7-69f7-4191-904f-c5b96b6261c8", "displayName": "Author", "uniqueName": "author@tmax.co.kr"},
+      "title": "test pull request",
+      "sourceRefName": "refs/heads/new-feat",
+      "targetRefName": "refs/heads/master",
+      "lastMergeSourceCommit": {"commitId": "6dcb09b5b57875f334f61aebed695e2e4193db5e"}
+    }
+  }
+}`
+)
+
+func testClient() *Client {
+	return &Client{
+		IntegrationConfig: &cicdv1.IntegrationConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+			Spec: cicdv1.IntegrationConfigSpec{
+				Git: cicdv1.GitConfig{
+					Type:       cicdv1.GitTypeAzureDevOps,
+					Repository: "tmax-cloud/cicd/cicd-operator",
+					Token:      cicdv1.GitToken{Value: "test-token"},
+				},
+			},
+			Status: cicdv1.IntegrationConfigStatus{Secrets: testSecret},
+		},
+	}
+}
+
+func testHeader() http.Header {
+	req := &http.Request{Header: http.Header{}}
+	req.SetBasicAuth(webhookUserName, testSecret)
+	return req.Header
+}
+
+func TestClient_ParseWebhook(t *testing.T) {
+	c := testClient()
+
+	// Pull request
+	wh, err := c.ParseWebhook(testHeader(), []byte(testPullRequestBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, git.EventTypePullRequest, wh.EventType)
+	assert.Equal(t, "tmax-cloud/cicd/cicd-operator", wh.Repo.Name)
+	assert.Equal(t, "https://dev.azure.com/tmax-cloud/cicd/_git/cicd-operator", wh.Repo.CloneURL)
+	assert.Equal(t, 3, wh.PullRequest.ID)
+	assert.Equal(t, git.PullRequestActionSynchronize, wh.PullRequest.Action)
+	assert.Equal(t, git.PullRequestStateOpen, wh.PullRequest.State)
+	assert.Equal(t, "https://dev.azure.com/tmax-cloud/cicd/_git/cicd-operator/pullrequest/3", wh.PullRequest.URL)
+	assert.Equal(t, "master", wh.PullRequest.Base.Ref)
+	assert.Equal(t, "new-feat", wh.PullRequest.Head.Ref)
+	assert.Equal(t, "6dcb09b5b57875f334f61aebed695e2e4193db5e", wh.PullRequest.Head.Sha)
+	assert.Equal(t, "author@tmax.co.kr", wh.PullRequest.Sender.Name)
+
+	// Push
+	wh, err = c.ParseWebhook(testHeader(), []byte(testPushBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, git.EventTypePush, wh.EventType)
+	assert.Equal(t, "refs/heads/master", wh.Push.Ref)
+	assert.Equal(t, "6dcb09b5b57875f334f61aebed695e2e4193db5e", wh.Push.Sha)
+
+	// Each pushed ref is handled, without the deleted ones
+	split := wh.Split()
+	assert.Equal(t, 2, len(split))
+	assert.Equal(t, "refs/tags/v0.1.0", split[1].Push.Ref)
+	assert.Equal(t, "author@tmax.co.kr", split[1].Push.Sender.Name)
+
+	// Comment
+	wh, err = c.ParseWebhook(testHeader(), []byte(testCommentBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, git.EventTypeIssueComment, wh.EventType)
+	assert.Equal(t, "/test", wh.IssueComment.Comment.Body)
+	assert.Equal(t, "reviewer@tmax.co.kr", wh.IssueComment.Sender.Name)
+	assert.Equal(t, 3, wh.IssueComment.Issue.PullRequest.ID)
+
+	// Invalid secret
+	header := http.Header{}
+	header.Set("Authorization", "Basic invalid")
+	_, err = c.ParseWebhook(header, []byte(testPushBody))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestClient_parsePullRequestWebhook(t *testing.T) {
+	c := testClient()
+
+	tc := map[string]struct {
+		eventType      string
+		status         string
+		expectedAction git.PullRequestAction
+		expectedState  git.PullRequestState
+	}{
+		"created": {
+			eventType:      eventTypePullRequestCreated,
+			status:         "active",
+			expectedAction: git.PullRequestActionOpen,
+			expectedState:  git.PullRequestStateOpen,
+		},
+		"sourceUpdated": {
+			eventType:      eventTypePullRequestUpdated,
+			status:         "active",
+			expectedAction: git.PullRequestActionSynchronize,
+			expectedState:  git.PullRequestStateOpen,
+		},
+		"completed": {
+			eventType:      eventTypePullRequestUpdated,
+			status:         "completed",
+			expectedAction: git.PullRequestActionClose,
+			expectedState:  git.PullRequestStateClosed,
+		},
+		"abandoned": {
+			eventType:      eventTypePullRequestUpdated,
+			status:         "abandoned",
+			expectedAction: git.PullRequestActionClose,
+			expectedState:  git.PullRequestStateClosed,
+		},
+	}
+
+	for name, tc := range tc {
+		t.Run(name, func(t *testing.T) {
+			body := strings.Replace(testPullRequestBody, `"status": "active"`, fmt.Sprintf(`"status": "%s"`, tc.status), 1)
+			wh, err := c.parsePullRequestWebhook(tc.eventType, []byte(body))
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tc.expectedAction, wh.PullRequest.Action)
+			assert.Equal(t, tc.expectedState, wh.PullRequest.State)
+		})
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	// Continuation token
+	header := http.Header{}
+	header.Set(continuationTokenHeader, "next-token")
+	items, next, err := nextPage("https://dev.azure.com/tmax-cloud/cicd/_apis/git/repositories/cicd-operator/refs?$top=1", []byte(`{"value": [{"name": "refs/heads/master"}]}`), header)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, "https://dev.azure.com/tmax-cloud/cicd/_apis/git/repositories/cicd-operator/refs?%24top=1&continuationToken=next-token", next)
+
+	// Last page of continuation token
+	_, next, err = nextPage("https://dev.azure.com/tmax-cloud/cicd/_apis/git/repositories/cicd-operator/refs?$top=1", []byte(`{"value": [{"name": "refs/heads/master"}]}`), http.Header{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "", next)
+
+	// Full page of $skip
+	_, next, err = nextPage("https://dev.azure.com/tmax-cloud/cicd/_apis/git/repositories/cicd-operator/pullrequests?$top=2&$skip=2", []byte(`{"value": [{"pullRequestId": 3}, {"pullRequestId": 4}]}`), http.Header{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "https://dev.azure.com/tmax-cloud/cicd/_apis/git/repositories/cicd-operator/pullrequests?%24skip=4&%24top=2", next)
+
+	// Last page of $skip
+	_, next, err = nextPage("https://dev.azure.com/tmax-cloud/cicd/_apis/git/repositories/cicd-operator/pullrequests?$top=2&$skip=4", []byte(`{"value": [{"pullRequestId": 5}]}`), http.Header{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "", next)
+}
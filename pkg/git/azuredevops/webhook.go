@@ -0,0 +1,104 @@
+package azuredevops
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// ServiceHookEvent is a common envelope of azure devops service hook events
+type ServiceHookEvent struct {
+	EventType string `json:"eventType"`
+}
+
+// PullRequestWebhook is an azure devops-specific git.pullrequest.created/updated event webhook body
+type PullRequestWebhook struct {
+	Resource PullRequest `json:"resource"`
+}
+
+// PushWebhook is an azure devops-specific git.push event webhook body
+type PushWebhook struct {
+	Resource struct {
+		RefUpdates []struct {
+			Name        string `json:"name"`
+			OldObjectID string `json:"oldObjectId"`
+			NewObjectID string `json:"newObjectId"`
+		} `json:"refUpdates"`
+		Commits []struct {
+			CommitID string `json:"commitId"`
+			Comment  string `json:"comment"`
+		} `json:"commits"`
+		Repository Repository `json:"repository"`
+		PushedBy   User       `json:"pushedBy"`
+	} `json:"resource"`
+}
+
+// PullRequestCommentWebhook is an azure devops-specific pull request comment event webhook body
+type PullRequestCommentWebhook struct {
+	Resource struct {
+		Comment     Comment     `json:"comment"`
+		PullRequest PullRequest `json:"pullRequest"`
+	} `json:"resource"`
+}
+
+// PullRequest is a pull request info
+type PullRequest struct {
+	ID                    int        `json:"pullRequestId"`
+	Title                 string     `json:"title"`
+	Status                string     `json:"status"`
+	CreatedBy             User       `json:"createdBy"`
+	SourceRefName         string     `json:"sourceRefName"`
+	TargetRefName         string     `json:"targetRefName"`
+	LastMergeSourceCommit Commit     `json:"lastMergeSourceCommit"`
+	Repository            Repository `json:"repository"`
+}
+
+// Commit is a commit reference
+type Commit struct {
+	CommitID string `json:"commitId"`
+}
+
+// Repository structure for webhook event
+type Repository struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	RemoteURL string `json:"remoteUrl"`
+	Project   struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"project"`
+}
+
+// User is a sender of the event
+type User struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+}
+
+// Comment is a comment payload
+type Comment struct {
+	ID                     int          `json:"id"`
+	Author                 User         `json:"author"`
+	Content                string       `json:"content"`
+	CommentType            string       `json:"commentType"`
+	PublishedDate          *metav1.Time `json:"publishedDate"`
+	LastContentUpdatedDate *metav1.Time `json:"lastContentUpdatedDate"`
+}
+
+// SubscriptionBody is a request body for creating a service hook subscription
+type SubscriptionBody struct {
+	PublisherID      string            `json:"publisherId"`
+	EventType        string            `json:"eventType"`
+	ResourceVersion  string            `json:"resourceVersion"`
+	ConsumerID       string            `json:"consumerId"`
+	ConsumerActionID string            `json:"consumerActionId"`
+	PublisherInputs  map[string]string `json:"publisherInputs"`
+	ConsumerInputs   map[string]string `json:"consumerInputs"`
+}
+
+// Subscriptions is a body of list of service hook subscriptions
+type Subscriptions struct {
+	Value []struct {
+		ID              string            `json:"id"`
+		EventType       string            `json:"eventType"`
+		PublisherInputs map[string]string `json:"publisherInputs"`
+		ConsumerInputs  map[string]string `json:"consumerInputs"`
+	} `json:"value"`
+}
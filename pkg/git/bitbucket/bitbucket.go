@@ -0,0 +1,510 @@
+package bitbucket
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Event keys of bitbucket webhooks
+const (
+	eventKeyPullRequestCreated        = "pullrequest:created"
+	eventKeyPullRequestUpdated        = "pullrequest:updated"
+	eventKeyPullRequestFulfilled      = "pullrequest:fulfilled"
+	eventKeyPullRequestRejected       = "pullrequest:rejected"
+	eventKeyPullRequestCommentCreated = "pullrequest:comment_created"
+	eventKeyRepoPush                  = "repo:push"
+)
+
+// accountIDPattern matches the account ids of the atlassian accounts, e.g., 557058:f3ba6f0a-... or 5b10a2844c20165700ede21f
+var accountIDPattern = regexp.MustCompile(`^([0-9]+:[0-9a-f-]+|[0-9a-f]{24})$`)
+
+// fullCommitHashLength is the length of the full (not abbreviated) commit hashes
+const fullCommitHashLength = 40
+
+// Client is a bitbucket cloud client struct
+type Client struct {
+	IntegrationConfig *cicdv1.IntegrationConfig
+	K8sClient         client.Client
+}
+
+// ParseWebhook parses a webhook body for bitbucket cloud
+func (c *Client) ParseWebhook(header http.Header, jsonString []byte) (*git.Webhook, error) {
+	var signature = strings.Replace(header.Get("x-hub-signature"), "sha256=", "", 1)
+	if err := git.ValidateWebhookSecrets(c.IntegrationConfig.GetWebhookSecrets(), func(secret string) error {
+		return Validate(secret, signature, jsonString)
+	}); err != nil {
+		return nil, err
+	}
+	switch header.Get("x-event-key") {
+	case eventKeyPullRequestCreated, eventKeyPullRequestUpdated, eventKeyPullRequestFulfilled, eventKeyPullRequestRejected:
+		return c.parsePullRequestWebhook(header.Get("x-event-key"), jsonString)
+	case eventKeyRepoPush:
+		return c.parsePushWebhook(jsonString)
+	case eventKeyPullRequestCommentCreated:
+		return c.parsePullRequestCommentWebhook(jsonString)
+	}
+	return nil, nil
+}
+
+// ListWebhook lists registered webhooks
+func (c *Client) ListWebhook() ([]git.WebhookEntry, error) {
+	apiURL := c.getRepoAPIUrl() + "/hooks"
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := &WebhookEntries{}
+	if err := json.Unmarshal(data, &entries.Values); err != nil {
+		return nil, err
+	}
+
+	var result []git.WebhookEntry
+	for _, e := range entries.Values {
+		active := e.Active
+		result = append(result, git.WebhookEntry{ID: e.UUID, URL: e.URL, Events: e.Events, Active: &active})
+	}
+
+	return result, nil
+}
+
+// RegisterWebhook registers our webhook server to the remote git server
+func (c *Client) RegisterWebhook(uri string) error {
+	var registrationBody RegistrationWebhookBody
+	apiURL := c.getRepoAPIUrl() + "/hooks"
+
+	registrationBody.Description = "cicd-operator"
+	registrationBody.URL = uri
+	registrationBody.Active = true
+	registrationBody.Secret = c.IntegrationConfig.Status.Secrets
+	registrationBody.Events = c.webhookEvents()
+
+	if _, _, err := c.requestHTTP(http.MethodPost, apiURL, registrationBody); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ExpectedWebhook returns the webhook entry expected to be registered for the url
+// Bitbucket does not report whether the secret is configured
+func (c *Client) ExpectedWebhook(url string) git.WebhookEntry {
+	active := true
+	return git.WebhookEntry{URL: url, Events: c.webhookEvents(), Active: &active}
+}
+
+// webhookEvents returns the bitbucket events which the webhook should be subscribed to
+func (c *Client) webhookEvents() []string {
+	var events []string
+	for _, ev := range git.WebhookEvents(c.IntegrationConfig) {
+		switch ev {
+		case git.EventTypePullRequest:
+			events = append(events, eventKeyPullRequestCreated, eventKeyPullRequestUpdated, eventKeyPullRequestFulfilled, eventKeyPullRequestRejected)
+		case git.EventTypePush:
+			events = append(events, eventKeyRepoPush)
+		case git.EventTypeIssueComment:
+			events = append(events, eventKeyPullRequestCommentCreated)
+		}
+	}
+
+	// Bitbucket requires at least one event
+	if len(events) == 0 {
+		events = []string{eventKeyRepoPush}
+	}
+	return events
+}
+
+// DeleteWebhook deletes registered webhook
+func (c *Client) DeleteWebhook(id string) error {
+	apiURL := c.getRepoAPIUrl() + "/hooks/" + url.PathEscape(id)
+	if _, _, err := c.requestHTTP(http.MethodDelete, apiURL, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetCommitStatus sets commit status for the specific commit
+func (c *Client) SetCommitStatus(integrationJob *cicdv1.IntegrationJob, context string, state git.CommitStatusState, description, targetURL string) error {
+	var buildStatusBody BuildStatusBody
+	var sha string
+	if integrationJob.Spec.Refs.Pull == nil {
+		sha = integrationJob.Spec.Refs.Base.Sha
+	} else {
+		sha = integrationJob.Spec.Refs.Pull.Sha
+	}
+	apiURL := fmt.Sprintf("%s/commit/%s/statuses/build", c.getRepoAPIUrl(), sha)
+
+	switch cicdv1.CommitStatusState(state) {
+	case cicdv1.CommitStatusStatePending:
+		buildStatusBody.State = "INPROGRESS"
+	case cicdv1.CommitStatusStateSuccess:
+		buildStatusBody.State = "SUCCESSFUL"
+	default:
+		buildStatusBody.State = "FAILED"
+	}
+	// Key should be less than 40 characters
+	buildStatusBody.Key = context
+	if len(buildStatusBody.Key) > 40 {
+		buildStatusBody.Key = buildStatusBody.Key[:40]
+	}
+	buildStatusBody.Name = context
+	buildStatusBody.URL = targetURL
+	buildStatusBody.Description = description
+
+	if _, _, err := c.requestHTTP(http.MethodPost, apiURL, buildStatusBody); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetUserInfo gets a user's information
+// user is either UUID, account id or nickname (name of the users of the events)
+// Users are not found by their nicknames if they do not have any permission on the repository
+func (c *Client) GetUserInfo(user string) (*git.User, error) {
+	if !isUserID(user) {
+		permission, err := c.getUserPermission(user)
+		if err != nil {
+			return nil, err
+		}
+		if permission == nil {
+			return nil, fmt.Errorf("user %s is not found", user)
+		}
+		u := convertUser(&permission.User)
+		return &u, nil
+	}
+
+	apiURL := fmt.Sprintf("%s/users/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), url.PathEscape(user))
+
+	result, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var userInfo User
+	if err := json.Unmarshal(result, &userInfo); err != nil {
+		return nil, err
+	}
+
+	u := convertUser(&userInfo)
+	return &u, nil
+}
+
+// CanUserWriteToRepo decides if the user has write permission on the repo
+func (c *Client) CanUserWriteToRepo(user git.User) (bool, error) {
+	permission, err := c.getUserPermission(user.Name)
+	if err != nil {
+		return false, err
+	}
+
+	return permission != nil && (permission.Permission == "admin" || permission.Permission == "write"), nil
+}
+
+// RegisterComment registers comment to an issue
+func (c *Client) RegisterComment(issueType git.IssueType, issueNo int, body string) error {
+	var t string
+	switch issueType {
+	case git.IssueTypeIssue:
+		t = "issues"
+	case git.IssueTypePullRequest:
+		t = "pullrequests"
+	default:
+		return fmt.Errorf("issue type %s is not supported", issueType)
+	}
+	apiURL := fmt.Sprintf("%s/%s/%d/comments", c.getRepoAPIUrl(), t, issueNo)
+
+	commentBody := &CommentBody{}
+	commentBody.Content.Raw = body
+	if _, _, err := c.requestHTTP(http.MethodPost, apiURL, commentBody); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetRepository gets the repository's information
+func (c *Client) GetRepository() (*git.Repository, error) {
+	data, _, err := c.requestHTTP(http.MethodGet, c.getRepoAPIUrl(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &Repository{}
+	if err := json.Unmarshal(data, repo); err != nil {
+		return nil, err
+	}
+
+	return &git.Repository{Name: repo.FullName, URL: repo.Links.HTML.Href}, nil
+}
+
+// ListBranches lists branches of the repository
+func (c *Client) ListBranches() ([]git.Ref, error) {
+	return c.listRefs("branches")
+}
+
+// GetBranch gets a branch of the repository
+func (c *Client) GetBranch(name string) (*git.Ref, error) {
+	return c.getRef("branches", name)
+}
+
+// ListTags lists tags of the repository
+func (c *Client) ListTags() ([]git.Ref, error) {
+	return c.listRefs("tags")
+}
+
+// GetTag gets a tag of the repository
+func (c *Client) GetTag(name string) (*git.Ref, error) {
+	return c.getRef("tags", name)
+}
+
+// ListOpenPullRequests lists open pull requests of the repository
+func (c *Client) ListOpenPullRequests() ([]git.PullRequest, error) {
+	apiURL := fmt.Sprintf("%s/pullrequests?state=OPEN&pagelen=50", c.getRepoAPIUrl())
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := &PullRequests{}
+	if err := json.Unmarshal(data, &prs.Values); err != nil {
+		return nil, err
+	}
+
+	var result []git.PullRequest
+	for i := range prs.Values {
+		pr := convertPullRequestToShared(&prs.Values[i])
+		if err := c.resolveHeadSha(pr); err != nil {
+			return nil, err
+		}
+		result = append(result, *pr)
+	}
+
+	return result, nil
+}
+
+// ListChangedFiles lists the files changed by the head commit, compared to the merge base of base and head
+func (c *Client) ListChangedFiles(base, head string) ([]string, error) {
+	apiURL := fmt.Sprintf("%s/diffstat/%s..%s?pagelen=500", c.getRepoAPIUrl(), url.PathEscape(head), url.PathEscape(base))
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	diffStats := &DiffStats{}
+	if err := json.Unmarshal(data, &diffStats.Values); err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, d := range diffStats.Values {
+		if d.New != nil {
+			result = append(result, d.New.Path)
+		}
+		if d.Old != nil && (d.New == nil || d.Old.Path != d.New.Path) {
+			result = append(result, d.Old.Path)
+		}
+	}
+
+	return result, nil
+}
+
+// getRef gets a ref of the type (branches or tags). nil is returned if it does not exist
+func (c *Client) getRef(refType, name string) (*git.Ref, error) {
+	data, _, err := c.requestHTTP(http.MethodGet, fmt.Sprintf("%s/refs/%s/%s", c.getRepoAPIUrl(), refType, url.PathEscape(name)), nil)
+	if err != nil {
+		if git.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ref struct {
+		Name   string `json:"name"`
+		Target struct {
+			Hash string `json:"hash"`
+		} `json:"target"`
+	}
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return nil, err
+	}
+
+	return &git.Ref{Name: ref.Name, Sha: ref.Target.Hash}, nil
+}
+
+func (c *Client) listRefs(refType string) ([]git.Ref, error) {
+	apiURL := fmt.Sprintf("%s/refs/%s?pagelen=100", c.getRepoAPIUrl(), refType)
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := &Refs{}
+	if err := json.Unmarshal(data, &refs.Values); err != nil {
+		return nil, err
+	}
+
+	var result []git.Ref
+	for _, r := range refs.Values {
+		result = append(result, git.Ref{Name: r.Name, Sha: r.Target.Hash})
+	}
+
+	return result, nil
+}
+
+// getFullCommitHash gets the full hash of the commit
+// Webhook payloads of bitbucket contain abbreviated hashes only
+func (c *Client) getFullCommitHash(sha string) (string, error) {
+	apiURL := fmt.Sprintf("%s/commit/%s", c.getRepoAPIUrl(), sha)
+
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	commit := &CommitInfo{}
+	if err := json.Unmarshal(data, commit); err != nil {
+		return "", err
+	}
+
+	return commit.Hash, nil
+}
+
+// getUserPermission gets the permission of the user (by nickname) on the repository. Nil is returned if the user does not
+// have any permission
+func (c *Client) getUserPermission(nickname string) (*UserPermission, error) {
+	workspace, repo := c.splitRepository()
+	query := url.QueryEscape(fmt.Sprintf("user.nickname=\"%s\"", nickname))
+	apiURL := fmt.Sprintf("%s/workspaces/%s/permissions/repositories/%s?q=%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), workspace, repo, query)
+
+	result, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := &UserPermissions{}
+	if err := json.Unmarshal(result, permissions); err != nil {
+		return nil, err
+	}
+
+	for i := range permissions.Values {
+		if permissions.Values[i].User.Nickname == nickname {
+			return &permissions.Values[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// isUserID checks if the user is identified by UUID ({...}) or account id, not by nickname
+func isUserID(user string) bool {
+	return (strings.HasPrefix(user, "{") && strings.HasSuffix(user, "}")) || accountIDPattern.MatchString(user)
+}
+
+// splitRepository splits spec.git.repository into workspace and repository slug
+func (c *Client) splitRepository() (string, string) {
+	tokens := strings.SplitN(c.IntegrationConfig.Spec.Git.Repository, "/", 2)
+	if len(tokens) < 2 {
+		return tokens[0], ""
+	}
+	return tokens[0], tokens[1]
+}
+
+func (c *Client) getRepoAPIUrl() string {
+	return fmt.Sprintf("%s/repositories/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), c.IntegrationConfig.Spec.Git.Repository)
+}
+
+func (c *Client) requestHTTP(method, apiURL string, data interface{}) ([]byte, http.Header, error) {
+	httpCli, header, err := c.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli.Request(method, apiURL, header, data)
+}
+
+// requestHTTPAll requests api call, following all the pages by the next field of the paged responses
+// Values of the pages are merged into a single JSON array
+func (c *Client) requestHTTPAll(method, apiURL string, data interface{}) ([]byte, http.Header, error) {
+	httpCli, header, err := c.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli.RequestPages(method, apiURL, header, data, func(_ string, body []byte, _ http.Header) ([]json.RawMessage, string, error) {
+		page := &Page{}
+		if err := json.Unmarshal(body, page); err != nil {
+			return nil, "", err
+		}
+		return page.Values, page.Next, nil
+	})
+}
+
+// httpClient returns a http client and a request header for the API server
+func (c *Client) httpClient() (*git.HTTPClient, map[string]string, error) {
+	token, err := c.IntegrationConfig.GetToken(c.K8sClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := map[string]string{
+		"Authorization": authorizationHeader(token),
+		"Content-Type":  "application/json",
+	}
+
+	httpCli, err := git.GetHTTPClient(c.IntegrationConfig, c.K8sClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli, header, nil
+}
+
+// authorizationHeader returns Authorization header value for the token
+// Token in a form of <user name>:<app password> is used for basic auth, otherwise it's used as a bearer (OAuth) token
+func authorizationHeader(token string) string {
+	if strings.Contains(token, ":") {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(token))
+	}
+	return "Bearer " + token
+}
+
+// IsValidPayload validates the webhook payload
+func IsValidPayload(secret, headerHash string, payload []byte) bool {
+	hash := HashPayload(secret, payload)
+	return hmac.Equal(
+		[]byte(hash),
+		[]byte(headerHash),
+	)
+}
+
+// HashPayload hashes the payload
+func HashPayload(secret string, payloadBody []byte) string {
+	hm := hmac.New(sha256.New, []byte(secret))
+	_, err := hm.Write(payloadBody)
+	sum := hm.Sum(nil)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sum)
+}
+
+// Validate validates the webhook payload
+func Validate(secret, headerHash string, payload []byte) error {
+	if !IsValidPayload(secret, headerHash, payload) {
+		return fmt.Errorf("invalid request : X-Hub-Signature does not match secret")
+	}
+	return nil
+}
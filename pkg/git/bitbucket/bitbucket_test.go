@@ -0,0 +1,55 @@
+package bitbucket
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+)
+
+func TestClient_GetUserInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/{a1b2c3}":
+			_, _ = fmt.Fprint(w, `{"uuid": "{a1b2c3}", "nickname": "author"}`)
+		case "/workspaces/tmax-cloud/permissions/repositories/cicd-operator":
+			if r.URL.Query().Get("q") != `user.nickname="author"` {
+				_, _ = fmt.Fprint(w, `{"values": []}`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"values": [{"permission": "write", "user": {"uuid": "{a1b2c3}", "nickname": "author"}}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := testClient(srv.URL)
+
+	// By UUID
+	user, err := c.GetUserInfo("{a1b2c3}")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "author", user.Name)
+
+	// By nickname, i.e., the name of the users of the events
+	user, err = c.GetUserInfo("author")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, git.UserIDFromString("{a1b2c3}"), user.ID)
+
+	_, err = c.GetUserInfo("unknown")
+	assert.NotEqual(t, nil, err)
+
+	canWrite, err := c.CanUserWriteToRepo(git.User{Name: "author"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, canWrite)
+}
+
+func TestIsUserID(t *testing.T) {
+	assert.Equal(t, true, isUserID("{a1b2c3d4-e5f6-a7b8-c9d0-e1f2a3b4c5d6}"))
+	assert.Equal(t, true, isUserID("557058:f3ba6f0a-5b3e-4a1b-9c6d-0e1f2a3b4c5d"))
+	assert.Equal(t, true, isUserID("5b10a2844c20165700ede21f"))
+	assert.Equal(t, false, isUserID("author"))
+}
@@ -0,0 +1,69 @@
+package bitbucket
+
+import "encoding/json"
+
+// Page is a page of the paged lists. Next is the url of the next page, empty if it's the last page
+type Page struct {
+	Values []json.RawMessage `json:"values"`
+	Next   string            `json:"next"`
+}
+
+// UserPermissions is a paged list of users' permissions on a repository
+type UserPermissions struct {
+	Values []UserPermission `json:"values"`
+}
+
+// UserPermission is a user's permission on a repository
+type UserPermission struct {
+	Permission string `json:"permission"`
+	User       User   `json:"user"`
+}
+
+// CommitInfo is a body of commit get API
+type CommitInfo struct {
+	Hash string `json:"hash"`
+}
+
+// BuildStatusBody is an API body for setting commits' build status
+type BuildStatusBody struct {
+	Key         string `json:"key"`
+	State       string `json:"state"`
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+// CommentBody is a body structure for creating new comment
+type CommentBody struct {
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+}
+
+// Refs is a paged list of branches or tags
+type Refs struct {
+	Values []struct {
+		Name   string `json:"name"`
+		Target struct {
+			Hash string `json:"hash"`
+		} `json:"target"`
+	} `json:"values"`
+}
+
+// PullRequests is a paged list of pull requests
+type PullRequests struct {
+	Values []PullRequest `json:"values"`
+}
+
+// DiffStats is a list of the changed files between two commits
+type DiffStats struct {
+	Values []struct {
+		Old *DiffStatFile `json:"old"`
+		New *DiffStatFile `json:"new"`
+	} `json:"values"`
+}
+
+// DiffStatFile is a file of the diff stat
+type DiffStatFile struct {
+	Path string `json:"path"`
+}
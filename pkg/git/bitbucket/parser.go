@@ -0,0 +1,139 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+)
+
+func (c *Client) parsePullRequestWebhook(eventKey string, jsonString []byte) (*git.Webhook, error) {
+	var data PullRequestWebhook
+
+	if err := json.Unmarshal(jsonString, &data); err != nil {
+		return nil, err
+	}
+
+	var action git.PullRequestAction
+	switch eventKey {
+	case eventKeyPullRequestCreated:
+		action = git.PullRequestActionOpen
+	case eventKeyPullRequestUpdated:
+		action = git.PullRequestActionSynchronize
+	default:
+		action = git.PullRequestActionClose
+	}
+
+	repo := git.Repository{Name: data.Repository.FullName, URL: data.Repository.Links.HTML.Href}
+	pullRequest := convertPullRequestToShared(&data.PullRequest)
+	pullRequest.Action = action
+
+	// Jobs are not run for the closed pull requests
+	if action != git.PullRequestActionClose {
+		if err := c.resolveHeadSha(pullRequest); err != nil {
+			return nil, err
+		}
+	}
+	return &git.Webhook{EventType: git.EventTypePullRequest, Repo: repo, PullRequest: pullRequest}, nil
+}
+
+func (c *Client) parsePushWebhook(jsonString []byte) (*git.Webhook, error) {
+	var data PushWebhook
+
+	if err := json.Unmarshal(jsonString, &data); err != nil {
+		return nil, err
+	}
+	repo := git.Repository{Name: data.Repository.FullName, URL: data.Repository.Links.HTML.Href}
+
+	// Each updated (or created) ref is handled as a push
+	var pushes []git.Push
+	for _, change := range data.Push.Changes {
+		if change.Closed || change.New == nil {
+			continue
+		}
+		var ref string
+		switch change.New.Type {
+		case "branch":
+			ref = "refs/heads/" + change.New.Name
+		case "tag":
+			ref = "refs/tags/" + change.New.Name
+		default:
+			continue
+		}
+		push := git.Push{Sender: convertUser(&data.Actor), Ref: ref, Sha: change.New.Target.Hash, Message: change.New.Target.Message}
+		if change.Old != nil {
+			push.Before = change.Old.Target.Hash
+		}
+		pushes = append(pushes, push)
+	}
+
+	return git.NewPushWebhook(repo, pushes), nil
+}
+
+func (c *Client) parsePullRequestCommentWebhook(jsonString []byte) (*git.Webhook, error) {
+	var data PullRequestWebhook
+
+	if err := json.Unmarshal(jsonString, &data); err != nil {
+		return nil, err
+	}
+
+	if data.Comment == nil {
+		return nil, nil
+	}
+
+	pullRequest := convertPullRequestToShared(&data.PullRequest)
+	if err := c.resolveHeadSha(pullRequest); err != nil {
+		return nil, err
+	}
+
+	return &git.Webhook{EventType: git.EventTypeIssueComment, Repo: git.Repository{
+		Name: data.Repository.FullName,
+		URL:  data.Repository.Links.HTML.Href,
+	}, IssueComment: &git.IssueComment{
+		Comment: git.Comment{
+			Body:      data.Comment.Content.Raw,
+			CreatedAt: data.Comment.CreatedOn,
+		},
+		Issue: git.Issue{
+			PullRequest: pullRequest,
+		},
+		Sender: convertUser(&data.Actor),
+	}}, nil
+}
+
+// resolveHeadSha replaces the abbreviated head commit hash of the pull request with the full one
+func (c *Client) resolveHeadSha(pr *git.PullRequest) error {
+	if len(pr.Head.Sha) == fullCommitHashLength {
+		return nil
+	}
+	sha, err := c.getFullCommitHash(pr.Head.Sha)
+	if err != nil {
+		return err
+	}
+	if sha == "" {
+		return fmt.Errorf("cannot resolve the full hash of commit %s", pr.Head.Sha)
+	}
+	pr.Head.Sha = sha
+	return nil
+}
+
+func convertPullRequestToShared(pr *PullRequest) *git.PullRequest {
+	state := git.PullRequestStateClosed
+	if pr.State == "OPEN" {
+		state = git.PullRequestStateOpen
+	}
+
+	return &git.PullRequest{
+		ID:     pr.ID,
+		Title:  pr.Title,
+		State:  state,
+		Sender: convertUser(&pr.Author),
+		URL:    pr.Links.HTML.Href,
+		Base:   git.Base{Ref: pr.Destination.Branch.Name},
+		Head:   git.Head{Ref: pr.Source.Branch.Name, Sha: pr.Source.Commit.Hash},
+	}
+}
+
+func convertUser(user *User) git.User {
+	return git.User{ID: git.UserIDFromString(user.UUID), Name: user.Nickname}
+}
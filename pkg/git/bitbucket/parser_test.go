@@ -0,0 +1,159 @@
+package bitbucket
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	testSecret = "test-secret"
+
+	testFullHash = "6dcb09b5b57875f334f61aebed695e2e4193db5e"
+
+	testPullRequestBody = `{
+  "actor": {"uuid": "{a1b2c3}", "nickname": "author", "display_name": "Author"},
+  "pullrequest": {
+    "id": 3,
+    "title": "test pull request",
+    "state": "OPEN",
+    "author": {"uuid": "{a1b2c3}", "nickname": "author", "display_name": "Author"},
+    "source": {"branch": {"name": "new-feat"}, "commit": {"hash": "6dcb09b5b578"}},
+    "destination": {"branch": {"name": "master"}, "commit": {"hash": "0123456789ab"}},
+    "links": {"html": {"href": "https://bitbucket.org/tmax-cloud/cicd-operator/pull-requests/3"}}
+  },
+  "repository": {"full_name": "tmax-cloud/cicd-operator", "links": {"html": {"href": "https://bitbucket.org/tmax-cloud/cicd-operator"}}}
+}`
+
+	testPushBody = `{
+  "actor": {"uuid": "{a1b2c3}", "nickname": "author", "display_name": "Author"},
+  "repository": {"full_name": "tmax-cloud/cicd-operator", "links": {"html": {"href": "https://bitbucket.org/tmax-cloud/cicd-operator"}}},
+  "push": {"changes": [
+    {"new": null, "closed": true},
+    {"new": {"type": "tag", "name": "v0.1.0", "target": {"hash": "6dcb09b5b57875f334f61aebed695e2e4193db5e"}}, "closed": false},
+    {"new": {"type": "branch", "name": "master", "target": {"hash": "89abcdef0123456789abcdef0123456789abcdef"}}, "old": {"type": "branch", "name": "master", "target": {"hash": "6dcb09b5b57875f334f61aebed695e2e4193db5e"}}, "closed": false}
+  ]}
+}`
+
+	testCommentBody = `{
+  "actor": {"uuid": "{d4e5f6}", "nickname": "reviewer", "display_name": "Reviewer"},
+  "comment": {"content": {"raw": "/test"}, "created_on": "2021-02-03T07:13:23.123456+00:00"},
+  "pullrequest": {
+    "id": 3,
+    "title": "test pull request",
+    "state": "OPEN",
+    "author": {"uuid": "{a1b2c3}", "nickname": "author", "display_name": "Author"},
+    "source": {"branch": {"name": "new-feat"}, "commit": {"hash": "6dcb09b5b578"}},
+    "destination": {"branch": {"name": "master"}, "commit": {"hash": "0123456789ab"}},
+    "links": {"html": {"href": "https://bitbucket.org/tmax-cloud/cicd-operator/pull-requests/3"}}
+  },
+  "repository": {"full_name": "tmax-cloud/cicd-operator", "links": {"html": {"href": "https://bitbucket.org/tmax-cloud/cicd-operator"}}}
+}`
+)
+
+func testClient(apiURL string) *Client {
+	return &Client{
+		IntegrationConfig: &cicdv1.IntegrationConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+			Spec: cicdv1.IntegrationConfigSpec{
+				Git: cicdv1.GitConfig{
+					Type:       cicdv1.GitTypeBitbucket,
+					Repository: "tmax-cloud/cicd-operator",
+					APIUrl:     apiURL,
+					Token:      cicdv1.GitToken{Value: "test-token"},
+				},
+			},
+			Status: cicdv1.IntegrationConfigStatus{Secrets: testSecret},
+		},
+	}
+}
+
+func testHeader(event, body string) http.Header {
+	header := http.Header{}
+	header.Set("X-Event-Key", event)
+	header.Set("X-Hub-Signature", "sha256="+HashPayload(testSecret, []byte(body)))
+	return header
+}
+
+func TestClient_ParseWebhook(t *testing.T) {
+	// Fake api server for resolving full commit hashes
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repositories/tmax-cloud/cicd-operator/commit/6dcb09b5b578" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"hash": "%s"}`, testFullHash)
+	}))
+	defer srv.Close()
+
+	c := testClient(srv.URL)
+
+	// Pull request
+	wh, err := c.ParseWebhook(testHeader("pullrequest:updated", testPullRequestBody), []byte(testPullRequestBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, git.EventTypePullRequest, wh.EventType)
+	assert.Equal(t, "tmax-cloud/cicd-operator", wh.Repo.Name)
+	assert.Equal(t, 3, wh.PullRequest.ID)
+	assert.Equal(t, git.PullRequestActionSynchronize, wh.PullRequest.Action)
+	assert.Equal(t, git.PullRequestStateOpen, wh.PullRequest.State)
+	assert.Equal(t, "master", wh.PullRequest.Base.Ref)
+	assert.Equal(t, "new-feat", wh.PullRequest.Head.Ref)
+	assert.Equal(t, testFullHash, wh.PullRequest.Head.Sha)
+	assert.Equal(t, "author", wh.PullRequest.Sender.Name)
+
+	// Full commit hash is not resolved for the closed pull requests
+	wh, err = c.ParseWebhook(testHeader("pullrequest:fulfilled", testPullRequestBody), []byte(testPullRequestBody))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "6dcb09b5b578", wh.PullRequest.Head.Sha)
+
+	// Failure of resolving the full commit hash is not swallowed
+	failing := testClient(srv.URL + "/not-found")
+	_, err = failing.ParseWebhook(testHeader("pullrequest:updated", testPullRequestBody), []byte(testPullRequestBody))
+	assert.NotEqual(t, nil, err)
+
+	// Push
+	wh, err = c.ParseWebhook(testHeader("repo:push", testPushBody), []byte(testPushBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, git.EventTypePush, wh.EventType)
+	assert.Equal(t, "refs/tags/v0.1.0", wh.Push.Ref)
+	assert.Equal(t, testFullHash, wh.Push.Sha)
+
+	// Each pushed ref is handled
+	split := wh.Split()
+	assert.Equal(t, 2, len(split))
+	assert.Equal(t, "refs/heads/master", split[1].Push.Ref)
+	assert.Equal(t, testFullHash, split[1].Push.Before)
+	assert.Equal(t, "89abcdef0123456789abcdef0123456789abcdef", split[1].Push.Sha)
+
+	// Comment
+	wh, err = c.ParseWebhook(testHeader("pullrequest:comment_created", testCommentBody), []byte(testCommentBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, git.EventTypeIssueComment, wh.EventType)
+	assert.Equal(t, "/test", wh.IssueComment.Comment.Body)
+	assert.Equal(t, "reviewer", wh.IssueComment.Sender.Name)
+	assert.Equal(t, 3, wh.IssueComment.Issue.PullRequest.ID)
+	assert.NotEqual(t, wh.IssueComment.Sender.ID, wh.IssueComment.Issue.PullRequest.Sender.ID)
+
+	// Invalid signature
+	header := testHeader("repo:push", testPushBody)
+	header.Set("X-Hub-Signature", "sha256=invalid")
+	_, err = c.ParseWebhook(header, []byte(testPushBody))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestAuthorizationHeader(t *testing.T) {
+	assert.Equal(t, "Bearer test-token", authorizationHeader("test-token"))
+	assert.Equal(t, "Basic dXNlcjpwYXNz", authorizationHeader("user:pass"))
+}
@@ -0,0 +1,105 @@
+package bitbucket
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// PullRequestWebhook is a bitbucket-specific pull-request event webhook body
+// It is also used for pullrequest:comment_created events, with Comment set
+type PullRequestWebhook struct {
+	Actor       User        `json:"actor"`
+	PullRequest PullRequest `json:"pullrequest"`
+	Repository  Repository  `json:"repository"`
+	Comment     *Comment    `json:"comment,omitempty"`
+}
+
+// PushWebhook is a bitbucket-specific repo:push event webhook body
+type PushWebhook struct {
+	Actor      User       `json:"actor"`
+	Repository Repository `json:"repository"`
+	Push       struct {
+		Changes []struct {
+			New *struct {
+				Type   string `json:"type"`
+				Name   string `json:"name"`
+				Target struct {
+					Hash    string `json:"hash"`
+					Message string `json:"message"`
+				} `json:"target"`
+			} `json:"new"`
+			Old *struct {
+				Target struct {
+					Hash string `json:"hash"`
+				} `json:"target"`
+			} `json:"old"`
+			Closed bool `json:"closed"`
+		} `json:"changes"`
+	} `json:"push"`
+}
+
+// PullRequest is a pull request info
+type PullRequest struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	State       string `json:"state"`
+	Author      User   `json:"author"`
+	Source      Ref    `json:"source"`
+	Destination Ref    `json:"destination"`
+	Links       Links  `json:"links"`
+}
+
+// Ref is a source/destination of the pull request
+type Ref struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Commit struct {
+		Hash string `json:"hash"`
+	} `json:"commit"`
+}
+
+// Repository structure for webhook event
+type Repository struct {
+	FullName string `json:"full_name"`
+	Links    Links  `json:"links"`
+}
+
+// Links is a set of links of the object
+type Links struct {
+	HTML struct {
+		Href string `json:"href"`
+	} `json:"html"`
+}
+
+// User is a sender of the event
+type User struct {
+	UUID        string `json:"uuid"`
+	AccountID   string `json:"account_id"`
+	Nickname    string `json:"nickname"`
+	DisplayName string `json:"display_name"`
+}
+
+// Comment is a comment payload
+type Comment struct {
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+	CreatedOn *metav1.Time `json:"created_on"`
+}
+
+// RegistrationWebhookBody is a request body for registering webhook to remote git server
+type RegistrationWebhookBody struct {
+	Description string   `json:"description"`
+	URL         string   `json:"url"`
+	Active      bool     `json:"active"`
+	Secret      string   `json:"secret"`
+	Events      []string `json:"events"`
+}
+
+// WebhookEntries is a body of list of registered webhooks
+type WebhookEntries struct {
+	Values []struct {
+		UUID   string   `json:"uuid"`
+		URL    string   `json:"url"`
+		Active bool     `json:"active"`
+		Events []string `json:"events"`
+	} `json:"values"`
+}
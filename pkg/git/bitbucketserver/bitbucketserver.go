@@ -0,0 +1,457 @@
+package bitbucketserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Event keys of bitbucket server webhooks
+const (
+	eventKeyPullRequestOpened     = "pr:opened"
+	eventKeyPullRequestUpdated    = "pr:from_ref_updated"
+	eventKeyPullRequestDeclined   = "pr:declined"
+	eventKeyPullRequestMerged     = "pr:merged"
+	eventKeyPullRequestDeleted    = "pr:deleted"
+	eventKeyPullRequestComment    = "pr:comment:added"
+	eventKeyRepositoryRefsChanged = "repo:refs_changed"
+)
+
+// Client is a bitbucket server client struct
+type Client struct {
+	IntegrationConfig *cicdv1.IntegrationConfig
+	K8sClient         client.Client
+}
+
+// ParseWebhook parses a webhook body for bitbucket server
+func (c *Client) ParseWebhook(header http.Header, jsonString []byte) (*git.Webhook, error) {
+	var signature = strings.Replace(header.Get("x-hub-signature"), "sha256=", "", 1)
+	if err := git.ValidateWebhookSecrets(c.IntegrationConfig.GetWebhookSecrets(), func(secret string) error {
+		return Validate(secret, signature, jsonString)
+	}); err != nil {
+		return nil, err
+	}
+	switch header.Get("x-event-key") {
+	case eventKeyPullRequestOpened, eventKeyPullRequestUpdated, eventKeyPullRequestDeclined, eventKeyPullRequestMerged, eventKeyPullRequestDeleted:
+		return c.parsePullRequestWebhook(jsonString)
+	case eventKeyRepositoryRefsChanged:
+		return c.parsePushWebhook(jsonString)
+	case eventKeyPullRequestComment:
+		return c.parsePullRequestCommentWebhook(jsonString)
+	}
+	return nil, nil
+}
+
+// ListWebhook lists registered webhooks
+func (c *Client) ListWebhook() ([]git.WebhookEntry, error) {
+	apiURL := c.getRepoAPIUrl() + "/webhooks"
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := &WebhookEntries{}
+	if err := json.Unmarshal(data, &entries.Values); err != nil {
+		return nil, err
+	}
+
+	var result []git.WebhookEntry
+	for _, e := range entries.Values {
+		active := e.Active
+		result = append(result, git.WebhookEntry{ID: strconv.Itoa(e.ID), URL: e.URL, Events: e.Events, Active: &active})
+	}
+
+	return result, nil
+}
+
+// RegisterWebhook registers our webhook server to the remote git server
+func (c *Client) RegisterWebhook(uri string) error {
+	var registrationBody RegistrationWebhookBody
+	apiURL := c.getRepoAPIUrl() + "/webhooks"
+
+	registrationBody.Name = "cicd-operator"
+	registrationBody.Active = true
+	registrationBody.Events = c.webhookEvents()
+	registrationBody.URL = uri
+	registrationBody.Configuration.Secret = c.IntegrationConfig.Status.Secrets
+
+	if _, _, err := c.requestHTTP(http.MethodPost, apiURL, registrationBody); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ExpectedWebhook returns the webhook entry expected to be registered for the url
+// Bitbucket server does not report whether the secret is configured
+func (c *Client) ExpectedWebhook(url string) git.WebhookEntry {
+	active := true
+	return git.WebhookEntry{URL: url, Events: c.webhookEvents(), Active: &active}
+}
+
+// webhookEvents returns the bitbucket server events which the webhook should be subscribed to
+func (c *Client) webhookEvents() []string {
+	var events []string
+	for _, ev := range git.WebhookEvents(c.IntegrationConfig) {
+		switch ev {
+		case git.EventTypePullRequest:
+			events = append(events, eventKeyPullRequestOpened, eventKeyPullRequestUpdated, eventKeyPullRequestDeclined, eventKeyPullRequestMerged, eventKeyPullRequestDeleted)
+		case git.EventTypePush:
+			events = append(events, eventKeyRepositoryRefsChanged)
+		case git.EventTypeIssueComment:
+			events = append(events, eventKeyPullRequestComment)
+		}
+	}
+
+	// Bitbucket server requires at least one event
+	if len(events) == 0 {
+		events = []string{eventKeyRepositoryRefsChanged}
+	}
+	return events
+}
+
+// DeleteWebhook deletes registered webhook
+func (c *Client) DeleteWebhook(id string) error {
+	apiURL := c.getRepoAPIUrl() + "/webhooks/" + id
+	if _, _, err := c.requestHTTP(http.MethodDelete, apiURL, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetCommitStatus sets commit status for the specific commit
+func (c *Client) SetCommitStatus(integrationJob *cicdv1.IntegrationJob, context string, state git.CommitStatusState, description, targetURL string) error {
+	var buildStatusBody BuildStatusBody
+	var sha string
+	if integrationJob.Spec.Refs.Pull == nil {
+		sha = integrationJob.Spec.Refs.Base.Sha
+	} else {
+		sha = integrationJob.Spec.Refs.Pull.Sha
+	}
+	apiURL := c.IntegrationConfig.Spec.Git.GetAPIUrl() + "/rest/build-status/1.0/commits/" + sha
+
+	switch cicdv1.CommitStatusState(state) {
+	case cicdv1.CommitStatusStatePending:
+		buildStatusBody.State = "INPROGRESS"
+	case cicdv1.CommitStatusStateSuccess:
+		buildStatusBody.State = "SUCCESSFUL"
+	default:
+		buildStatusBody.State = "FAILED"
+	}
+	buildStatusBody.Key = context
+	buildStatusBody.Name = context
+	buildStatusBody.URL = targetURL
+	buildStatusBody.Description = description
+
+	if _, _, err := c.requestHTTP(http.MethodPost, apiURL, buildStatusBody); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetUserInfo gets a user's information
+func (c *Client) GetUserInfo(userSlug string) (*git.User, error) {
+	// userSlug is string!
+	apiURL := fmt.Sprintf("%s/rest/api/1.0/users/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), userSlug)
+
+	result, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var userInfo UserInfo
+	if err := json.Unmarshal(result, &userInfo); err != nil {
+		return nil, err
+	}
+
+	return &git.User{
+		ID:    userInfo.ID,
+		Name:  userInfo.UserName,
+		Email: userInfo.Email,
+	}, nil
+}
+
+// CanUserWriteToRepo decides if the user has write permission on the repo
+// Both repository-level and project-level permissions are checked
+func (c *Client) CanUserWriteToRepo(user git.User) (bool, error) {
+	project, _ := c.splitRepository()
+	targets := []struct {
+		apiURL string
+		prefix string
+	}{
+		{apiURL: c.getRepoAPIUrl(), prefix: "REPO_"},
+		{apiURL: fmt.Sprintf("%s/rest/api/1.0/projects/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), project), prefix: "PROJECT_"},
+	}
+
+	for _, target := range targets {
+		apiURL := fmt.Sprintf("%s/permissions/users?filter=%s", target.apiURL, url.QueryEscape(user.Name))
+		result, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+		if err != nil {
+			return false, err
+		}
+
+		permissions := &UserPermissions{}
+		if err := json.Unmarshal(result, permissions); err != nil {
+			return false, err
+		}
+
+		for _, p := range permissions.Values {
+			if p.User.UserName != user.Name {
+				continue
+			}
+			if p.Permission == target.prefix+"WRITE" || p.Permission == target.prefix+"ADMIN" {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// RegisterComment registers comment to a pull request
+// Bitbucket server does not have issues, so only pull requests are supported
+func (c *Client) RegisterComment(issueType git.IssueType, issueNo int, body string) error {
+	if issueType != git.IssueTypePullRequest {
+		return fmt.Errorf("issue type %s is not supported", issueType)
+	}
+	apiURL := fmt.Sprintf("%s/pull-requests/%d/comments", c.getRepoAPIUrl(), issueNo)
+
+	commentBody := &CommentBody{Text: body}
+	if _, _, err := c.requestHTTP(http.MethodPost, apiURL, commentBody); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetRepository gets the repository's information
+func (c *Client) GetRepository() (*git.Repository, error) {
+	data, _, err := c.requestHTTP(http.MethodGet, c.getRepoAPIUrl(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &Repository{}
+	if err := json.Unmarshal(data, repo); err != nil {
+		return nil, err
+	}
+
+	result := convertRepository(repo)
+	return &result, nil
+}
+
+// ListBranches lists branches of the repository
+func (c *Client) ListBranches() ([]git.Ref, error) {
+	return c.listRefs("branches")
+}
+
+// GetBranch gets a branch of the repository
+func (c *Client) GetBranch(name string) (*git.Ref, error) {
+	return c.getRef("branches", name)
+}
+
+// ListTags lists tags of the repository
+func (c *Client) ListTags() ([]git.Ref, error) {
+	return c.listRefs("tags")
+}
+
+// GetTag gets a tag of the repository
+func (c *Client) GetTag(name string) (*git.Ref, error) {
+	return c.getRef("tags", name)
+}
+
+// ListOpenPullRequests lists open pull requests of the repository
+func (c *Client) ListOpenPullRequests() ([]git.PullRequest, error) {
+	apiURL := fmt.Sprintf("%s/pull-requests?state=OPEN&limit=100", c.getRepoAPIUrl())
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := &PullRequests{}
+	if err := json.Unmarshal(data, &prs.Values); err != nil {
+		return nil, err
+	}
+
+	var result []git.PullRequest
+	for i := range prs.Values {
+		result = append(result, *convertPullRequestToShared(&prs.Values[i]))
+	}
+
+	return result, nil
+}
+
+// ListChangedFiles lists the files changed by the head commit, compared to the merge base of base and head
+func (c *Client) ListChangedFiles(base, head string) ([]string, error) {
+	query := url.Values{}
+	query.Set("from", head)
+	query.Set("to", base)
+	query.Set("limit", "1000")
+	apiURL := fmt.Sprintf("%s/compare/changes?%s", c.getRepoAPIUrl(), query.Encode())
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &Changes{}
+	if err := json.Unmarshal(data, &changes.Values); err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, ch := range changes.Values {
+		result = append(result, ch.Path.ToString)
+		if ch.SrcPath != nil && ch.SrcPath.ToString != ch.Path.ToString {
+			result = append(result, ch.SrcPath.ToString)
+		}
+	}
+
+	return result, nil
+}
+
+// getRef gets a ref of the type (branches or tags). nil is returned if it does not exist
+// There is no API for getting a single ref, so the refs are filtered by the name, which matches partially
+func (c *Client) getRef(refType, name string) (*git.Ref, error) {
+	apiURL := fmt.Sprintf("%s/%s?filterText=%s&limit=100", c.getRepoAPIUrl(), refType, url.QueryEscape(name))
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := &Refs{}
+	if err := json.Unmarshal(data, &refs.Values); err != nil {
+		return nil, err
+	}
+
+	for _, r := range refs.Values {
+		if r.DisplayID == name {
+			return &git.Ref{Name: r.DisplayID, Sha: r.LatestCommit}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *Client) listRefs(refType string) ([]git.Ref, error) {
+	apiURL := fmt.Sprintf("%s/%s?limit=100", c.getRepoAPIUrl(), refType)
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := &Refs{}
+	if err := json.Unmarshal(data, &refs.Values); err != nil {
+		return nil, err
+	}
+
+	var result []git.Ref
+	for _, r := range refs.Values {
+		result = append(result, git.Ref{Name: r.DisplayID, Sha: r.LatestCommit})
+	}
+
+	return result, nil
+}
+
+// splitRepository splits spec.git.repository into project key and repository slug
+func (c *Client) splitRepository() (string, string) {
+	tokens := strings.SplitN(c.IntegrationConfig.Spec.Git.Repository, "/", 2)
+	if len(tokens) < 2 {
+		return tokens[0], ""
+	}
+	return tokens[0], tokens[1]
+}
+
+func (c *Client) getRepoAPIUrl() string {
+	project, repo := c.splitRepository()
+	return fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), project, repo)
+}
+
+func (c *Client) requestHTTP(method, apiURL string, data interface{}) ([]byte, http.Header, error) {
+	httpCli, header, err := c.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli.Request(method, apiURL, header, data)
+}
+
+// requestHTTPAll requests api call, following all the pages by the start query parameter, until isLastPage is true
+// Values of the pages are merged into a single JSON array
+func (c *Client) requestHTTPAll(method, apiURL string, data interface{}) ([]byte, http.Header, error) {
+	httpCli, header, err := c.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli.RequestPages(method, apiURL, header, data, func(current string, body []byte, _ http.Header) ([]json.RawMessage, string, error) {
+		page := &Page{}
+		if err := json.Unmarshal(body, page); err != nil {
+			return nil, "", err
+		}
+		if page.IsLastPage {
+			return page.Values, "", nil
+		}
+		next, err := git.SetQuery(current, "start", strconv.Itoa(page.NextPageStart))
+		return page.Values, next, err
+	})
+}
+
+// httpClient returns a http client and a request header for the API server
+func (c *Client) httpClient() (*git.HTTPClient, map[string]string, error) {
+	token, err := c.IntegrationConfig.GetToken(c.K8sClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := map[string]string{
+		"Authorization": "Bearer " + token,
+		"Content-Type":  "application/json",
+	}
+
+	httpCli, err := git.GetHTTPClient(c.IntegrationConfig, c.K8sClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli, header, nil
+}
+
+// IsValidPayload validates the webhook payload
+func IsValidPayload(secret, headerHash string, payload []byte) bool {
+	hash := HashPayload(secret, payload)
+	return hmac.Equal(
+		[]byte(hash),
+		[]byte(headerHash),
+	)
+}
+
+// HashPayload hashes the payload
+func HashPayload(secret string, payloadBody []byte) string {
+	hm := hmac.New(sha256.New, []byte(secret))
+	_, err := hm.Write(payloadBody)
+	sum := hm.Sum(nil)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sum)
+}
+
+// Validate validates the webhook payload
+func Validate(secret, headerHash string, payload []byte) error {
+	if !IsValidPayload(secret, headerHash, payload) {
+		return fmt.Errorf("invalid request : X-Hub-Signature does not match secret")
+	}
+	return nil
+}
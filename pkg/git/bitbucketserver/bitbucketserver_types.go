@@ -0,0 +1,66 @@
+package bitbucketserver
+
+import "encoding/json"
+
+// Page is a page of the paged lists. Next page starts at NextPageStart, unless IsLastPage is true
+type Page struct {
+	Values        []json.RawMessage `json:"values"`
+	IsLastPage    bool              `json:"isLastPage"`
+	NextPageStart int               `json:"nextPageStart"`
+}
+
+// UserInfo is a body of user get API
+type UserInfo struct {
+	ID       int    `json:"id"`
+	UserName string `json:"name"`
+	Email    string `json:"emailAddress"`
+	Slug     string `json:"slug"`
+}
+
+// UserPermissions is a paged list of users' permissions on a repository/project
+type UserPermissions struct {
+	Values []struct {
+		User       UserInfo `json:"user"`
+		Permission string   `json:"permission"`
+	} `json:"values"`
+}
+
+// BuildStatusBody is an API body for setting commits' build status
+type BuildStatusBody struct {
+	State       string `json:"state"`
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+// CommentBody is a body structure for creating new comment
+type CommentBody struct {
+	Text string `json:"text"`
+}
+
+// Refs is a paged list of branches or tags
+type Refs struct {
+	Values []struct {
+		DisplayID    string `json:"displayId"`
+		LatestCommit string `json:"latestCommit"`
+	} `json:"values"`
+}
+
+// PullRequests is a paged list of pull requests
+type PullRequests struct {
+	Values []PullRequest `json:"values"`
+}
+
+// Changes is a list of the changed files between two commits
+type Changes struct {
+	Values []struct {
+		Path    ChangePath  `json:"path"`
+		SrcPath *ChangePath `json:"srcPath"`
+	} `json:"values"`
+}
+
+// ChangePath is a path of the changed file
+type ChangePath struct {
+	ToString string `json:"toString"`
+}
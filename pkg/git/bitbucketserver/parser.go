@@ -0,0 +1,114 @@
+package bitbucketserver
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (c *Client) parsePullRequestWebhook(jsonString []byte) (*git.Webhook, error) {
+	var data PullRequestWebhook
+
+	if err := json.Unmarshal(jsonString, &data); err != nil {
+		return nil, err
+	}
+
+	var action git.PullRequestAction
+	switch data.EventKey {
+	case eventKeyPullRequestOpened:
+		action = git.PullRequestActionOpen
+	case eventKeyPullRequestUpdated:
+		action = git.PullRequestActionSynchronize
+	default:
+		action = git.PullRequestActionClose
+	}
+
+	repo := convertRepository(&data.PullRequest.ToRef.Repository)
+	pullRequest := convertPullRequestToShared(&data.PullRequest)
+	pullRequest.Action = action
+	return &git.Webhook{EventType: git.EventTypePullRequest, Repo: repo, PullRequest: pullRequest}, nil
+}
+
+func (c *Client) parsePushWebhook(jsonString []byte) (*git.Webhook, error) {
+	var data PushWebhook
+
+	if err := json.Unmarshal(jsonString, &data); err != nil {
+		return nil, err
+	}
+	repo := convertRepository(&data.Repository)
+
+	// Each updated (or added) ref is handled as a push
+	var pushes []git.Push
+	for _, change := range data.Changes {
+		if change.Type == "DELETE" || strings.HasPrefix(change.ToHash, "0000") && strings.HasSuffix(change.ToHash, "0000") {
+			continue
+		}
+		pushes = append(pushes, git.Push{Sender: convertUser(&data.Actor), Ref: change.Ref.ID, Before: change.FromHash, Sha: change.ToHash})
+	}
+
+	return git.NewPushWebhook(repo, pushes), nil
+}
+
+func (c *Client) parsePullRequestCommentWebhook(jsonString []byte) (*git.Webhook, error) {
+	var data PullRequestWebhook
+
+	if err := json.Unmarshal(jsonString, &data); err != nil {
+		return nil, err
+	}
+
+	if data.Comment == nil {
+		return nil, nil
+	}
+
+	return &git.Webhook{EventType: git.EventTypeIssueComment, Repo: convertRepository(&data.PullRequest.ToRef.Repository), IssueComment: &git.IssueComment{
+		Comment: git.Comment{
+			Body:      data.Comment.Text,
+			CreatedAt: &metav1.Time{Time: time.Unix(0, data.Comment.CreatedDate*int64(time.Millisecond))},
+		},
+		Issue: git.Issue{
+			PullRequest: convertPullRequestToShared(&data.PullRequest),
+		},
+		Sender: convertUser(&data.Actor),
+	}}, nil
+}
+
+func convertPullRequestToShared(pr *PullRequest) *git.PullRequest {
+	state := git.PullRequestStateClosed
+	if pr.State == "OPEN" {
+		state = git.PullRequestStateOpen
+	}
+	var link string
+	if len(pr.Links.Self) > 0 {
+		link = pr.Links.Self[0].Href
+	}
+	return &git.PullRequest{
+		ID:     pr.ID,
+		Title:  pr.Title,
+		State:  state,
+		Sender: convertUser(&pr.Author.User),
+		URL:    link,
+		Base:   git.Base{Ref: pr.ToRef.DisplayID},
+		Head:   git.Head{Ref: pr.FromRef.DisplayID, Sha: pr.FromRef.LatestCommit},
+	}
+}
+
+func convertRepository(repo *Repository) git.Repository {
+	result := git.Repository{Name: repo.Project.Key + "/" + repo.Slug}
+	if len(repo.Links.Self) > 0 {
+		result.URL = strings.TrimSuffix(repo.Links.Self[0].Href, "/browse")
+	}
+	for _, l := range repo.Links.Clone {
+		if l.Name == "http" || l.Name == "https" {
+			result.CloneURL = l.Href
+			break
+		}
+	}
+	return result
+}
+
+func convertUser(user *User) git.User {
+	return git.User{ID: user.ID, Name: user.Name, Email: user.Email}
+}
@@ -0,0 +1,152 @@
+package bitbucketserver
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	testSecret = "test-secret"
+
+	testRepository = `{
+  "slug": "cicd-operator",
+  "project": {"key": "TMAX"},
+  "links": {
+    "self": [{"href": "https://bitbucket.tmax.io/projects/TMAX/repos/cicd-operator/browse"}],
+    "clone": [
+      {"href": "ssh://git@bitbucket.tmax.io:7999/tmax/cicd-operator.git", "name": "ssh"},
+      {"href": "https://bitbucket.tmax.io/scm/tmax/cicd-operator.git", "name": "http"}
+    ]
+  }
+}`
+
+	testPullRequest = `{
+  "id": 3,
+  "title": "test pull request",
+  "state": "OPEN",
+  "author": {"user": {"name": "author", "id": 2, "emailAddress": "author@tmax.co.kr"}},
+  "fromRef": {"id": "refs/heads/new-feat", "displayId": "new-feat", "latestCommit": "6dcb09b5b57875f334f61aebed695e2e4193db5e", "repository": ` + testRepository + `},
+  "toRef": {"id": "refs/heads/master", "displayId": "master", "latestCommit": "e2e4193db5e6dcb09b5b57875f334f61aebed695", "repository": ` + testRepository + `},
+  "links": {"self": [{"href": "https://bitbucket.tmax.io/projects/TMAX/repos/cicd-operator/pull-requests/3"}]}
+}`
+
+	testPullRequestBody = `{
+  "eventKey": "pr:from_ref_updated",
+  "actor": {"name": "author", "id": 2, "emailAddress": "author@tmax.co.kr"},
+  "pullRequest": ` + testPullRequest + `
+}`
+
+	testPushBody = `{
+  "eventKey": "repo:refs_changed",
+  "actor": {"name": "author", "id": 2, "emailAddress": "author@tmax.co.kr"},
+  "repository": ` + testRepository + `,
+  "changes": [{"ref": {"id": "refs/heads/master", "displayId": "master", "type": "BRANCH"}, "refId": "refs/heads/master", "fromHash": "e2e4193db5e6dcb09b5b57875f334f61aebed695", "toHash": "6dcb09b5b57875f334f61aebed695e2e4193db5e", "type": "UPDATE"}]
+}`
+
+	testMultiRefPushBody = `{
+  "eventKey": "repo:refs_changed",
+  "actor": {"name": "author", "id": 2, "emailAddress": "author@tmax.co.kr"},
+  "repository": ` + testRepository + `,
+  "changes": [
+    {"ref": {"id": "refs/heads/master", "displayId": "master", "type": "BRANCH"}, "refId": "refs/heads/master", "fromHash": "e2e4193db5e6dcb09b5b57875f334f61aebed695", "toHash": "6dcb09b5b57875f334f61aebed695e2e4193db5e", "type": "UPDATE"},
+    {"ref": {"id": "refs/heads/old", "displayId": "old", "type": "BRANCH"}, "refId": "refs/heads/old", "fromHash": "e2e4193db5e6dcb09b5b57875f334f61aebed695", "toHash": "0000000000000000000000000000000000000000", "type": "DELETE"},
+    {"ref": {"id": "refs/tags/v0.1.0", "displayId": "v0.1.0", "type": "TAG"}, "refId": "refs/tags/v0.1.0", "fromHash": "0000000000000000000000000000000000000000", "toHash": "6dcb09b5b57875f334f61aebed695e2e4193db5e", "type": "ADD"}
+  ]
+}`
+
+	testCommentBody = `{
+  "eventKey": "pr:comment:added",
+  "actor": {"name": "reviewer", "id": 4, "emailAddress": "reviewer@tmax.co.kr"},
+  "pullRequest": ` + testPullRequest + `,
+  "comment": {"id": 1, "text": "/test", "author": {"name": "reviewer", "id": 4}, "createdDate": 1612336403000}
+}`
+)
+
+func testClient() *Client {
+	return &Client{
+		IntegrationConfig: &cicdv1.IntegrationConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+			Spec: cicdv1.IntegrationConfigSpec{
+				Git: cicdv1.GitConfig{
+					Type:       cicdv1.GitTypeBitbucketServer,
+					Repository: "TMAX/cicd-operator",
+					APIUrl:     "https://bitbucket.tmax.io",
+					Token:      cicdv1.GitToken{Value: "test-token"},
+				},
+			},
+			Status: cicdv1.IntegrationConfigStatus{Secrets: testSecret},
+		},
+	}
+}
+
+func testHeader(event, body string) http.Header {
+	header := http.Header{}
+	header.Set("X-Event-Key", event)
+	header.Set("X-Hub-Signature", "sha256="+HashPayload(testSecret, []byte(body)))
+	return header
+}
+
+func TestClient_ParseWebhook(t *testing.T) {
+	c := testClient()
+
+	// Pull request
+	wh, err := c.ParseWebhook(testHeader("pr:from_ref_updated", testPullRequestBody), []byte(testPullRequestBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, git.EventTypePullRequest, wh.EventType)
+	assert.Equal(t, "TMAX/cicd-operator", wh.Repo.Name)
+	assert.Equal(t, "https://bitbucket.tmax.io/projects/TMAX/repos/cicd-operator", wh.Repo.URL)
+	assert.Equal(t, "https://bitbucket.tmax.io/scm/tmax/cicd-operator.git", wh.Repo.CloneURL)
+	assert.Equal(t, 3, wh.PullRequest.ID)
+	assert.Equal(t, git.PullRequestActionSynchronize, wh.PullRequest.Action)
+	assert.Equal(t, git.PullRequestStateOpen, wh.PullRequest.State)
+	assert.Equal(t, "master", wh.PullRequest.Base.Ref)
+	assert.Equal(t, "new-feat", wh.PullRequest.Head.Ref)
+	assert.Equal(t, "6dcb09b5b57875f334f61aebed695e2e4193db5e", wh.PullRequest.Head.Sha)
+
+	// Push
+	wh, err = c.ParseWebhook(testHeader("repo:refs_changed", testPushBody), []byte(testPushBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, git.EventTypePush, wh.EventType)
+	assert.Equal(t, "refs/heads/master", wh.Push.Ref)
+	assert.Equal(t, "6dcb09b5b57875f334f61aebed695e2e4193db5e", wh.Push.Sha)
+	assert.Equal(t, "author@tmax.co.kr", wh.Push.Sender.Email)
+	assert.Equal(t, 1, len(wh.Split()))
+
+	// Push of multiple refs, without the deleted ones
+	wh, err = c.ParseWebhook(testHeader("repo:refs_changed", testMultiRefPushBody), []byte(testMultiRefPushBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "refs/heads/master", wh.Push.Ref)
+	var refs []string
+	for _, split := range wh.Split() {
+		refs = append(refs, split.Push.Ref)
+	}
+	assert.Equal(t, []string{"refs/heads/master", "refs/tags/v0.1.0"}, refs)
+
+	// Comment
+	wh, err = c.ParseWebhook(testHeader("pr:comment:added", testCommentBody), []byte(testCommentBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, git.EventTypeIssueComment, wh.EventType)
+	assert.Equal(t, "/test", wh.IssueComment.Comment.Body)
+	assert.Equal(t, "reviewer", wh.IssueComment.Sender.Name)
+	assert.Equal(t, 3, wh.IssueComment.Issue.PullRequest.ID)
+	assert.Equal(t, int64(1612336403), wh.IssueComment.Comment.CreatedAt.Unix())
+
+	// Invalid signature
+	header := testHeader("repo:refs_changed", testPushBody)
+	header.Set("X-Hub-Signature", "sha256=invalid")
+	_, err = c.ParseWebhook(header, []byte(testPushBody))
+	assert.NotEqual(t, nil, err)
+}
@@ -0,0 +1,109 @@
+package bitbucketserver
+
+// PullRequestWebhook is a bitbucket-server-specific pull-request event webhook body
+// It is also used for pr:comment:added events, with Comment set
+type PullRequestWebhook struct {
+	EventKey    string      `json:"eventKey"`
+	Actor       User        `json:"actor"`
+	PullRequest PullRequest `json:"pullRequest"`
+	Comment     *Comment    `json:"comment,omitempty"`
+}
+
+// PushWebhook is a bitbucket-server-specific repo:refs_changed event webhook body
+type PushWebhook struct {
+	EventKey   string     `json:"eventKey"`
+	Actor      User       `json:"actor"`
+	Repository Repository `json:"repository"`
+	Changes    []struct {
+		Ref struct {
+			ID        string `json:"id"`
+			DisplayID string `json:"displayId"`
+			Type      string `json:"type"`
+		} `json:"ref"`
+		RefID    string `json:"refId"`
+		FromHash string `json:"fromHash"`
+		ToHash   string `json:"toHash"`
+		Type     string `json:"type"`
+	} `json:"changes"`
+}
+
+// PullRequest is a pull request info
+type PullRequest struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	Author struct {
+		User User `json:"user"`
+	} `json:"author"`
+	FromRef Ref   `json:"fromRef"`
+	ToRef   Ref   `json:"toRef"`
+	Links   Links `json:"links"`
+}
+
+// Ref is a reference (branch/tag) of the pull request
+type Ref struct {
+	ID           string     `json:"id"`
+	DisplayID    string     `json:"displayId"`
+	LatestCommit string     `json:"latestCommit"`
+	Repository   Repository `json:"repository"`
+}
+
+// Repository structure for webhook event
+type Repository struct {
+	Slug    string `json:"slug"`
+	Project struct {
+		Key string `json:"key"`
+	} `json:"project"`
+	Links Links `json:"links"`
+}
+
+// Links is a set of links of the object
+type Links struct {
+	Self []struct {
+		Href string `json:"href"`
+	} `json:"self"`
+	Clone []struct {
+		Href string `json:"href"`
+		Name string `json:"name"`
+	} `json:"clone"`
+}
+
+// User is a sender of the event
+type User struct {
+	Name  string `json:"name"`
+	ID    int    `json:"id"`
+	Email string `json:"emailAddress"`
+	Slug  string `json:"slug"`
+}
+
+// Comment is a comment payload
+type Comment struct {
+	ID          int    `json:"id"`
+	Text        string `json:"text"`
+	Author      User   `json:"author"`
+	CreatedDate int64  `json:"createdDate"`
+}
+
+// RegistrationWebhookBody is a request body for registering webhook to remote git server
+type RegistrationWebhookBody struct {
+	Name          string                        `json:"name"`
+	Active        bool                          `json:"active"`
+	Events        []string                      `json:"events"`
+	URL           string                        `json:"url"`
+	Configuration RegistrationWebhookBodyConfig `json:"configuration"`
+}
+
+// RegistrationWebhookBodyConfig is a config for the webhook
+type RegistrationWebhookBodyConfig struct {
+	Secret string `json:"secret"`
+}
+
+// WebhookEntries is a body of list of registered webhooks
+type WebhookEntries struct {
+	Values []struct {
+		ID     int      `json:"id"`
+		URL    string   `json:"url"`
+		Active bool     `json:"active"`
+		Events []string `json:"events"`
+	} `json:"values"`
+}
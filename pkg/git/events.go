@@ -0,0 +1,53 @@
+package git
+
+import (
+	"sync"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+)
+
+// consumedEvents are the events consumed by the webhook plugins
+var consumedEvents = map[EventType]bool{}
+var consumedEventsLock sync.RWMutex
+
+// AddConsumedEvents registers the events consumed by the webhook plugins
+// Webhooks are subscribed only to the consumed events
+func AddConsumedEvents(events ...EventType) {
+	consumedEventsLock.Lock()
+	defer consumedEventsLock.Unlock()
+	for _, ev := range events {
+		consumedEvents[ev] = true
+	}
+}
+
+// WebhookEvents returns the events which the webhook for the IntegrationConfig should be subscribed to
+// They are the events needed by the IntegrationConfig's jobs, and consumed by the webhook plugins
+// If no plugin is registered, every event needed by the jobs is returned
+func WebhookEvents(ic *cicdv1.IntegrationConfig) []EventType {
+	consumedEventsLock.RLock()
+	defer consumedEventsLock.RUnlock()
+
+	var events []EventType
+	for _, ev := range neededEvents(ic) {
+		if len(consumedEvents) == 0 || consumedEvents[ev] {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
+
+// neededEvents returns the events needed by the jobs of the IntegrationConfig
+func neededEvents(ic *cicdv1.IntegrationConfig) []EventType {
+	var events []EventType
+	if len(ic.Spec.Jobs.PreSubmit) > 0 {
+		// Comments are needed for the chat-ops commands on the pull requests
+		events = append(events, EventTypePullRequest, EventTypeIssueComment)
+	}
+	if len(ic.Spec.Jobs.PostSubmit) > 0 {
+		events = append(events, EventTypePush)
+	}
+	if len(ic.Spec.Jobs.Release) > 0 {
+		events = append(events, EventTypeRelease)
+	}
+	return events
+}
@@ -0,0 +1,335 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+)
+
+const (
+	// azureRepositoryID and azureProjectID are the ids of the repository and its project
+	azureRepositoryID = "5febef5a-833d-4e14-b9c0-14cb638f91e6"
+	azureProjectID    = "6ce954b1-ce1f-45d1-b94d-e6bf2464ba2c"
+
+	// azureContributePermission is a 'Contribute' permission bit of the git repositories security namespace
+	azureContributePermission = 4
+	// azureContinuationTokenHeader is a response header containing the token for the next page
+	azureContinuationTokenHeader = "x-ms-continuationtoken"
+)
+
+// NewAzureDevOpsServer starts a fake Azure DevOps API server, serving the repository
+// Name of the repository should be in a form of <organization>/<project>/<repository>
+// Requests should be authorized by basic auth, with an empty user name and the token as a password
+func NewAzureDevOpsServer(repo *Repository, token string) *Server {
+	s := newServer(repo, token, func(u User) string { return u.Name }, serveAzureDevOps)
+	s.hookKey = func(h Hook) string { return azureGUID(h.ID) }
+	s.sharedUser = func(u User) git.User {
+		return git.User{ID: git.UserIDFromString(azureGUID(u.ID)), Name: u.Name, Email: u.Email}
+	}
+	s.sharedPullRequest = func(pr git.PullRequest) git.PullRequest {
+		pr = s.withoutLabels(pr)
+		pr.Sender.Email = ""
+		pr.URL = fmt.Sprintf("%s/pullrequest/%d", s.repo.URL, pr.ID)
+		return pr
+	}
+	return s
+}
+
+func serveAzureDevOps(s *Server, w http.ResponseWriter, r *http.Request, body []byte) {
+	req := &http.Request{Header: r.Header}
+	user, password, ok := req.BasicAuth()
+	if !ok || user != "" || password != s.Token {
+		writeError(w, http.StatusUnauthorized, "TF400813: The user is not authorized to access this resource.")
+		return
+	}
+
+	path := r.URL.EscapedPath()
+	tokens := strings.SplitN(s.repo.Name, "/", 3)
+	if len(tokens) != 3 {
+		writeError(w, http.StatusNotFound, "repository name should be <organization>/<project>/<repository>")
+		return
+	}
+	org, project, repoName := tokens[0], tokens[1], tokens[2]
+
+	// Service hook APIs
+	if path == "/"+org+"/_apis/hooks/subscriptions" {
+		s.serveAzureSubscriptions(w, r, body)
+		return
+	}
+	if params, ok := route(path, "/"+org+"/_apis/hooks/subscriptions/*"); ok && r.Method == http.MethodDelete {
+		if !s.deleteHook(params[0]) {
+			writeError(w, http.StatusNotFound, "subscription does not exist")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Identity APIs
+	if path == "/"+org+"/_apis/identities" && r.Method == http.MethodGet {
+		var identities []interface{}
+		if u := s.findUser(r.URL.Query().Get("filterValue")); u != nil {
+			identities = append(identities, map[string]interface{}{
+				"id":         azureGUID(u.ID),
+				"descriptor": azureDescriptor(u.Name),
+				"properties": map[string]interface{}{
+					"Account": map[string]string{"$value": u.Name},
+					"Mail":    map[string]string{"$value": u.Email},
+				},
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"count": len(identities), "value": identities})
+		return
+	}
+
+	// Security APIs. Permissions are inherited from the project, so they are reported as effective permissions
+	if _, ok := route(path, "/"+org+"/_apis/accesscontrollists/*"); ok && r.Method == http.MethodGet {
+		if r.URL.Query().Get("token") != fmt.Sprintf("repoV2/%s/%s", azureProjectID, azureRepositoryID) {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"count": 0, "value": []interface{}{}})
+			return
+		}
+		aces := map[string]interface{}{}
+		for _, u := range s.repo.Users {
+			descriptor := azureDescriptor(u.Name)
+			if descriptor != r.URL.Query().Get("descriptors") {
+				continue
+			}
+			allow := 0
+			if u.CanWrite {
+				allow = azureContributePermission
+			}
+			aces[descriptor] = map[string]interface{}{"descriptor": descriptor, "allow": 0, "deny": 0, "extendedInfo": map[string]int{"effectiveAllow": allow}}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"count": 1, "value": []interface{}{map[string]interface{}{"acesDictionary": aces}}})
+		return
+	}
+
+	// Repository APIs
+	repoPrefix := "/" + org + "/" + project + "/_apis/git/repositories/" + repoName
+	if path != repoPrefix && !strings.HasPrefix(path, repoPrefix+"/") {
+		writeError(w, http.StatusNotFound, "TF401019: The Git repository does not exist.")
+		return
+	}
+	path = strings.TrimPrefix(path, repoPrefix)
+
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.azureRepository())
+	case strings.HasPrefix(path, "/commits/") && r.Method == http.MethodPost:
+		params, ok := route(path, "/commits/*/statuses")
+		if !ok {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		s.setAzureStatus(w, params[0], body)
+	case strings.HasPrefix(path, "/pullRequests/") && strings.HasSuffix(path, "/statuses") && r.Method == http.MethodPost:
+		params, _ := route(path, "/pullRequests/*/statuses")
+		var pr *git.PullRequest
+		if len(params) > 0 {
+			pr = s.findPullRequest(params[0])
+		}
+		if pr == nil {
+			writeError(w, http.StatusNotFound, "TF401180: The requested pull request was not found.")
+			return
+		}
+		// Pull request statuses are shown for the head commit of the pull request
+		s.setAzureStatus(w, pr.Head.Sha, body)
+	case strings.HasPrefix(path, "/pullRequests/") && strings.HasSuffix(path, "/threads") && r.Method == http.MethodPost:
+		params, _ := route(path, "/pullRequests/*/threads")
+		thread := struct {
+			Comments []struct {
+				Content string `json:"content"`
+			} `json:"comments"`
+		}{}
+		var pr *git.PullRequest
+		if len(params) > 0 {
+			pr = s.findPullRequest(params[0])
+		}
+		if pr == nil || json.Unmarshal(body, &thread) != nil || len(thread.Comments) == 0 {
+			writeError(w, http.StatusNotFound, "TF401180: The requested pull request was not found.")
+			return
+		}
+		s.repo.Comments = append(s.repo.Comments, Comment{IssueNo: pr.ID, Body: thread.Comments[0].Content})
+		writeJSON(w, http.StatusOK, thread)
+	case path == "/refs" && r.Method == http.MethodGet:
+		filter := r.URL.Query().Get("filter")
+		var items []interface{}
+		for _, ref := range s.repo.Branches {
+			items = append(items, map[string]string{"name": "refs/heads/" + ref.Name, "objectId": ref.Sha})
+		}
+		for _, ref := range s.repo.Tags {
+			items = append(items, map[string]string{"name": "refs/tags/" + ref.Name, "objectId": ref.Sha})
+		}
+		var filtered []interface{}
+		for _, item := range items {
+			if strings.HasPrefix(item.(map[string]string)["name"], "refs/"+filter) {
+				filtered = append(filtered, item)
+			}
+		}
+		s.writeAzureContinuationPage(w, r, filtered)
+	case path == "/pullrequests" && r.Method == http.MethodGet:
+		status := r.URL.Query().Get("searchCriteria.status")
+		var items []interface{}
+		for _, pr := range s.repo.PullRequests {
+			prStatus := "abandoned"
+			if pr.State == git.PullRequestStateOpen {
+				prStatus = "active"
+			}
+			if status != "" && status != "all" && prStatus != status {
+				continue
+			}
+			items = append(items, map[string]interface{}{
+				"pullRequestId":         pr.ID,
+				"title":                 pr.Title,
+				"status":                prStatus,
+				"createdBy":             map[string]string{"id": azureGUID(pr.Sender.ID), "uniqueName": pr.Sender.Name},
+				"sourceRefName":         "refs/heads/" + pr.Head.Ref,
+				"targetRefName":         "refs/heads/" + pr.Base.Ref,
+				"lastMergeSourceCommit": map[string]string{"commitId": pr.Head.Sha},
+				"repository":            s.azureRepository(),
+			})
+		}
+		// Pull requests are paginated by $top and $skip, not by continuation tokens
+		top, err := strconv.Atoi(r.URL.Query().Get("$top"))
+		if err != nil || top <= 0 {
+			top = len(items)
+		}
+		skip, _ := strconv.Atoi(r.URL.Query().Get("$skip"))
+		values := sliceItems(items, skip, top)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"count": len(values), "value": values})
+	case path == "/diffs/commits" && r.Method == http.MethodGet:
+		files, ok := s.repo.ChangedFiles[r.URL.Query().Get("targetVersion")]
+		if !ok || r.URL.Query().Get("baseVersion") == "" {
+			writeError(w, http.StatusNotFound, "TF401175: The version descriptor could not be resolved to a version in the repository.")
+			return
+		}
+		var changes []interface{}
+		folders := map[string]bool{}
+		for _, f := range files {
+			// Folders containing the changed files are also reported
+			if i := strings.LastIndex(f, "/"); i > 0 && !folders[f[:i]] {
+				folders[f[:i]] = true
+				changes = append(changes, map[string]interface{}{"item": map[string]interface{}{"path": "/" + f[:i], "isFolder": true}, "changeType": "edit"})
+			}
+			changes = append(changes, map[string]interface{}{"item": map[string]interface{}{"path": "/" + f}, "changeType": "edit"})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"changes": changes})
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// serveAzureSubscriptions lists or creates service hook subscriptions
+// Subscriptions are registered to the organization, so they are filtered by the repository id by the clients
+func (s *Server) serveAzureSubscriptions(w http.ResponseWriter, r *http.Request, body []byte) {
+	switch r.Method {
+	case http.MethodGet:
+		var items []interface{}
+		for _, h := range s.repo.Hooks {
+			items = append(items, map[string]interface{}{
+				"id":              s.hookKey(h),
+				"eventType":       h.Events[0],
+				"publisherInputs": map[string]string{"projectId": azureProjectID, "repository": azureRepositoryID},
+				"consumerInputs":  map[string]string{"url": h.URL},
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"count": len(items), "value": items})
+	case http.MethodPost:
+		subscription := struct {
+			EventType       string            `json:"eventType"`
+			PublisherInputs map[string]string `json:"publisherInputs"`
+			ConsumerInputs  map[string]string `json:"consumerInputs"`
+		}{}
+		if err := json.Unmarshal(body, &subscription); err != nil || subscription.EventType == "" || subscription.ConsumerInputs["url"] == "" {
+			writeError(w, http.StatusBadRequest, "eventType and url are required")
+			return
+		}
+		if subscription.PublisherInputs["repository"] != azureRepositoryID || subscription.PublisherInputs["projectId"] != azureProjectID {
+			writeError(w, http.StatusBadRequest, "repository does not exist")
+			return
+		}
+		h := s.addHook(Hook{URL: subscription.ConsumerInputs["url"], Secret: subscription.ConsumerInputs["basicAuthPassword"], Events: []string{subscription.EventType}, Active: true})
+		writeJSON(w, http.StatusOK, map[string]interface{}{"id": s.hookKey(h), "eventType": subscription.EventType})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// setAzureStatus sets a commit status
+func (s *Server) setAzureStatus(w http.ResponseWriter, sha string, body []byte) {
+	status := struct {
+		State       string `json:"state"`
+		Description string `json:"description"`
+		TargetURL   string `json:"targetUrl"`
+		Context     struct {
+			Name  string `json:"name"`
+			Genre string `json:"genre"`
+		} `json:"context"`
+	}{}
+	if err := json.Unmarshal(body, &status); err != nil || status.Context.Name == "" {
+		writeError(w, http.StatusBadRequest, "context is required")
+		return
+	}
+
+	var state git.CommitStatusState
+	switch status.State {
+	case "pending":
+		state = "pending"
+	case "succeeded":
+		state = "success"
+	case "failed":
+		state = "failure"
+	case "error":
+		state = "error"
+	default:
+		writeError(w, http.StatusBadRequest, "invalid state "+status.State)
+		return
+	}
+
+	s.setStatus(sha, Status{Context: status.Context.Name, State: state, Description: status.Description, TargetURL: status.TargetURL})
+	writeJSON(w, http.StatusCreated, status)
+}
+
+// azureRepository returns the repository, whose remote url contains the organization as a user name
+func (s *Server) azureRepository() map[string]interface{} {
+	remoteURL := s.repo.URL
+	if u, err := url.Parse(remoteURL); err == nil {
+		u.User = url.User(strings.SplitN(s.repo.Name, "/", 2)[0])
+		remoteURL = u.String()
+	}
+	return map[string]interface{}{
+		"id":        azureRepositoryID,
+		"name":      s.repo.Name[strings.LastIndex(s.repo.Name, "/")+1:],
+		"remoteUrl": remoteURL,
+		"project":   map[string]string{"id": azureProjectID},
+	}
+}
+
+// writeAzureContinuationPage writes a page of the items, with a continuation token header for the next page
+func (s *Server) writeAzureContinuationPage(w http.ResponseWriter, r *http.Request, items []interface{}) {
+	top := s.requestedPageSize(r, "$top")
+	start, err := strconv.Atoi(r.URL.Query().Get("continuationToken"))
+	if err != nil || start < 0 {
+		start = 0
+	}
+
+	values := sliceItems(items, start, top)
+	if start+top < len(items) {
+		w.Header().Set(azureContinuationTokenHeader, strconv.Itoa(start+top))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"count": len(values), "value": values})
+}
+
+// azureGUID returns a guid, as azure devops identifies users and subscriptions by guids
+func azureGUID(id int) string {
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", id)
+}
+
+func azureDescriptor(account string) string {
+	return "Microsoft.IdentityModel.Claims.ClaimsIdentity;" + account
+}
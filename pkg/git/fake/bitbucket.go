@@ -0,0 +1,274 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+)
+
+// bitbucketShortHashLength is the length of the abbreviated commit hashes, used by the pull request APIs
+const bitbucketShortHashLength = 12
+
+// bitbucketNicknameQuery matches the query for filtering the users by their nicknames
+var bitbucketNicknameQuery = regexp.MustCompile(`^user\.nickname="(.*)"$`)
+
+// NewBitbucketServer starts a fake Bitbucket (cloud) API server, serving the repository
+// Name of the repository should be in a form of <workspace>/<repository slug>
+// Requests should be authorized with 'Authorization: Bearer <token>' header
+func NewBitbucketServer(repo *Repository, token string) *Server {
+	s := newServer(repo, token, func(u User) string { return bitbucketUUID(u.ID) }, serveBitbucket)
+	s.hookKey = func(h Hook) string { return fmt.Sprintf("{%08d-0000-4000-8000-000000000000}", h.ID) }
+	s.sharedUser = func(u User) git.User {
+		return git.User{ID: git.UserIDFromString(bitbucketUUID(u.ID)), Name: u.Name}
+	}
+	s.sharedPullRequest = s.withoutLabels
+	return s
+}
+
+func serveBitbucket(s *Server, w http.ResponseWriter, r *http.Request, body []byte) {
+	if r.Header.Get("Authorization") != "Bearer "+s.Token {
+		writeBitbucketError(w, http.StatusUnauthorized, "Access token expired.")
+		return
+	}
+
+	path := r.URL.EscapedPath()
+	workspace, slug := splitRepositoryName(s.repo.Name)
+
+	// User APIs
+	if params, ok := route(path, "/users/*"); ok && r.Method == http.MethodGet {
+		u := s.findUser(params[0])
+		if u == nil {
+			writeBitbucketError(w, http.StatusNotFound, params[0]+" not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, bitbucketUser(u.ID, u.Name))
+		return
+	}
+
+	// Workspace APIs
+	if path == "/workspaces/"+workspace+"/permissions/repositories/"+slug && r.Method == http.MethodGet {
+		query := bitbucketNicknameQuery.FindStringSubmatch(r.URL.Query().Get("q"))
+		var items []interface{}
+		for _, u := range s.repo.Users {
+			if query != nil && query[1] != u.Name {
+				continue
+			}
+			permission := "read"
+			if u.CanWrite {
+				permission = "write"
+			}
+			items = append(items, map[string]interface{}{"permission": permission, "user": bitbucketUser(u.ID, u.Name)})
+		}
+		s.writeBitbucketPage(w, r, items)
+		return
+	}
+
+	// Repository APIs
+	repoPrefix := "/repositories/" + s.repo.Name
+	if path != repoPrefix && !strings.HasPrefix(path, repoPrefix+"/") {
+		writeBitbucketError(w, http.StatusNotFound, "Repository not found")
+		return
+	}
+	path = strings.TrimPrefix(path, repoPrefix)
+
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]interface{}{"full_name": s.repo.Name, "links": map[string]interface{}{"html": map[string]string{"href": s.repo.URL}}})
+	case path == "/hooks" && r.Method == http.MethodGet:
+		var items []interface{}
+		for _, h := range s.repo.Hooks {
+			items = append(items, map[string]interface{}{"uuid": s.hookKey(h), "url": h.URL, "active": h.Active, "events": h.Events})
+		}
+		s.writeBitbucketPage(w, r, items)
+	case path == "/hooks" && r.Method == http.MethodPost:
+		hook := struct {
+			Description string   `json:"description"`
+			URL         string   `json:"url"`
+			Active      bool     `json:"active"`
+			Secret      string   `json:"secret"`
+			Events      []string `json:"events"`
+		}{}
+		if err := json.Unmarshal(body, &hook); err != nil || hook.URL == "" || len(hook.Events) == 0 {
+			writeBitbucketError(w, http.StatusBadRequest, "url and events are required")
+			return
+		}
+		h := s.addHook(Hook{Name: hook.Description, URL: hook.URL, Secret: hook.Secret, Events: hook.Events, Active: hook.Active})
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"uuid": s.hookKey(h), "url": h.URL})
+	case strings.HasPrefix(path, "/hooks/") && r.Method == http.MethodDelete:
+		params, _ := route(path, "/hooks/*")
+		if len(params) == 0 || !s.deleteHook(params[0]) {
+			writeBitbucketError(w, http.StatusNotFound, "Webhook not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case strings.HasPrefix(path, "/commit/") && strings.HasSuffix(path, "/statuses/build") && r.Method == http.MethodPost:
+		params, _ := route(path, "/commit/*/statuses/build")
+		status := struct {
+			Key         string `json:"key"`
+			State       string `json:"state"`
+			Name        string `json:"name"`
+			URL         string `json:"url"`
+			Description string `json:"description"`
+		}{}
+		if len(params) == 0 || json.Unmarshal(body, &status) != nil || status.Key == "" || len(status.Key) > 40 {
+			writeBitbucketError(w, http.StatusBadRequest, "invalid build status")
+			return
+		}
+		var state git.CommitStatusState
+		switch status.State {
+		case "INPROGRESS":
+			state = "pending"
+		case "SUCCESSFUL":
+			state = "success"
+		case "FAILED", "STOPPED":
+			state = "failure"
+		default:
+			writeBitbucketError(w, http.StatusBadRequest, "invalid state "+status.State)
+			return
+		}
+		s.setStatus(params[0], Status{Context: status.Key, State: state, Description: status.Description, TargetURL: status.URL})
+		writeJSON(w, http.StatusCreated, status)
+	case strings.HasPrefix(path, "/commit/") && r.Method == http.MethodGet:
+		params, _ := route(path, "/commit/*")
+		sha := ""
+		if len(params) > 0 {
+			sha = s.resolveCommitHash(params[0])
+		}
+		if sha == "" {
+			writeBitbucketError(w, http.StatusNotFound, "Commit not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"hash": sha})
+	case (strings.HasPrefix(path, "/pullrequests/") || strings.HasPrefix(path, "/issues/")) && r.Method == http.MethodPost:
+		params, ok := route(path, "/*/*/comments")
+		comment := struct {
+			Content struct {
+				Raw string `json:"raw"`
+			} `json:"content"`
+		}{}
+		var pr *git.PullRequest
+		if ok && params[0] == "pullrequests" {
+			pr = s.findPullRequest(params[1])
+		}
+		if pr == nil || json.Unmarshal(body, &comment) != nil {
+			writeBitbucketError(w, http.StatusNotFound, "Pull request not found")
+			return
+		}
+		s.repo.Comments = append(s.repo.Comments, Comment{IssueNo: pr.ID, Body: comment.Content.Raw})
+		writeJSON(w, http.StatusCreated, comment)
+	case (path == "/refs/branches" || path == "/refs/tags") && r.Method == http.MethodGet:
+		refs := s.repo.Branches
+		if path == "/refs/tags" {
+			refs = s.repo.Tags
+		}
+		var items []interface{}
+		for _, ref := range refs {
+			items = append(items, map[string]interface{}{"name": ref.Name, "target": map[string]string{"hash": ref.Sha}})
+		}
+		s.writeBitbucketPage(w, r, items)
+	case (strings.HasPrefix(path, "/refs/branches/") || strings.HasPrefix(path, "/refs/tags/")) && r.Method == http.MethodGet:
+		params, ok := route(path, "/refs/*/*")
+		var ref *git.Ref
+		if ok && params[0] == "branches" {
+			ref = s.findBranch(params[1])
+		} else if ok {
+			ref = s.findTag(params[1])
+		}
+		if ref == nil {
+			writeBitbucketError(w, http.StatusNotFound, "Ref not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"name": ref.Name, "target": map[string]string{"hash": ref.Sha}})
+	case path == "/pullrequests" && r.Method == http.MethodGet:
+		state := r.URL.Query().Get("state")
+		var items []interface{}
+		for _, pr := range s.repo.PullRequests {
+			prState := "DECLINED"
+			if pr.State == git.PullRequestStateOpen {
+				prState = "OPEN"
+			}
+			if state != "" && prState != state {
+				continue
+			}
+			items = append(items, map[string]interface{}{
+				"id":     pr.ID,
+				"title":  pr.Title,
+				"state":  prState,
+				"author": bitbucketUser(pr.Sender.ID, pr.Sender.Name),
+				"source": map[string]interface{}{
+					"branch": map[string]string{"name": pr.Head.Ref},
+					"commit": map[string]string{"hash": pr.Head.Sha[:bitbucketShortHashLength]},
+				},
+				"destination": map[string]interface{}{"branch": map[string]string{"name": pr.Base.Ref}},
+				"links":       map[string]interface{}{"html": map[string]string{"href": pr.URL}},
+			})
+		}
+		s.writeBitbucketPage(w, r, items)
+	case strings.HasPrefix(path, "/diffstat/") && r.Method == http.MethodGet:
+		params, _ := route(path, "/diffstat/*")
+		var spec []string
+		if len(params) > 0 {
+			spec = strings.SplitN(params[0], "..", 2)
+		}
+		if len(spec) != 2 {
+			writeBitbucketError(w, http.StatusNotFound, "Commit not found")
+			return
+		}
+		files, ok := s.repo.ChangedFiles[spec[0]]
+		if !ok {
+			writeBitbucketError(w, http.StatusNotFound, "Commit not found")
+			return
+		}
+		var items []interface{}
+		for _, f := range files {
+			items = append(items, map[string]interface{}{"status": "modified", "old": map[string]string{"path": f}, "new": map[string]string{"path": f}})
+		}
+		s.writeBitbucketPage(w, r, items)
+	default:
+		writeBitbucketError(w, http.StatusNotFound, "Resource not found")
+	}
+}
+
+// resolveCommitHash returns the full hash of the known commit, which starts with the (abbreviated) hash
+func (s *Server) resolveCommitHash(hash string) string {
+	var shas []string
+	for _, ref := range append(append([]git.Ref(nil), s.repo.Branches...), s.repo.Tags...) {
+		shas = append(shas, ref.Sha)
+	}
+	for _, pr := range s.repo.PullRequests {
+		shas = append(shas, pr.Head.Sha)
+	}
+	for _, sha := range shas {
+		if strings.HasPrefix(sha, hash) {
+			return sha
+		}
+	}
+	return ""
+}
+
+// writeBitbucketPage writes a page of the items, with the url of the next page
+func (s *Server) writeBitbucketPage(w http.ResponseWriter, r *http.Request, items []interface{}) {
+	p := s.paginate(r, items)
+	page := map[string]interface{}{"values": p.items, "pagelen": p.perPage, "size": len(items)}
+	if p.next != 0 {
+		page["next"] = s.URL + pageURL(r, p.next)
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// bitbucketUUID returns the uuid of the user
+func bitbucketUUID(id int) string {
+	return fmt.Sprintf("{00000000-0000-4000-8000-%012d}", id)
+}
+
+func bitbucketUser(id int, nickname string) map[string]interface{} {
+	return map[string]interface{}{"uuid": bitbucketUUID(id), "nickname": nickname, "display_name": nickname}
+}
+
+func writeBitbucketError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{"type": "error", "error": map[string]string{"message": message}})
+}
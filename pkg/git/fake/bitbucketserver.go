@@ -0,0 +1,234 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+)
+
+// NewBitbucketServerServer starts a fake Bitbucket Server API server, serving the repository
+// Name of the repository should be in a form of <project key>/<repository slug>
+// Requests should be authorized with 'Authorization: Bearer <token>' header
+func NewBitbucketServerServer(repo *Repository, token string) *Server {
+	s := newServer(repo, token, func(u User) string { return u.Name }, serveBitbucketServer)
+	s.sharedPullRequest = s.withoutLabels
+	return s
+}
+
+func serveBitbucketServer(s *Server, w http.ResponseWriter, r *http.Request, body []byte) {
+	if r.Header.Get("Authorization") != "Bearer "+s.Token {
+		writeBitbucketServerError(w, http.StatusUnauthorized, "Authentication failed. Please check your credentials and try again.")
+		return
+	}
+
+	path := r.URL.EscapedPath()
+	project, slug := splitRepositoryName(s.repo.Name)
+
+	// Build status APIs
+	if params, ok := route(path, "/rest/build-status/1.0/commits/*"); ok && r.Method == http.MethodPost {
+		status := struct {
+			State       string `json:"state"`
+			Key         string `json:"key"`
+			Name        string `json:"name"`
+			URL         string `json:"url"`
+			Description string `json:"description"`
+		}{}
+		if err := json.Unmarshal(body, &status); err != nil || status.Key == "" {
+			writeBitbucketServerError(w, http.StatusBadRequest, "key is required")
+			return
+		}
+		var state git.CommitStatusState
+		switch status.State {
+		case "INPROGRESS":
+			state = "pending"
+		case "SUCCESSFUL":
+			state = "success"
+		case "FAILED":
+			state = "failure"
+		default:
+			writeBitbucketServerError(w, http.StatusBadRequest, "invalid state "+status.State)
+			return
+		}
+		s.setStatus(params[0], Status{Context: status.Key, State: state, Description: status.Description, TargetURL: status.URL})
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// User APIs
+	if params, ok := route(path, "/rest/api/1.0/users/*"); ok && r.Method == http.MethodGet {
+		u := s.findUser(params[0])
+		if u == nil {
+			writeBitbucketServerError(w, http.StatusNotFound, "User "+params[0]+" does not exist.")
+			return
+		}
+		writeJSON(w, http.StatusOK, bitbucketServerUser(u.ID, u.Name, u.Email))
+		return
+	}
+
+	// Project APIs. Permissions are granted to the repository, not to the project
+	if path == "/rest/api/1.0/projects/"+project+"/permissions/users" && r.Method == http.MethodGet {
+		s.writeBitbucketServerPage(w, r, nil)
+		return
+	}
+
+	// Repository APIs
+	repoPrefix := "/rest/api/1.0/projects/" + project + "/repos/" + slug
+	if path != repoPrefix && !strings.HasPrefix(path, repoPrefix+"/") {
+		writeBitbucketServerError(w, http.StatusNotFound, "Repository does not exist.")
+		return
+	}
+	path = strings.TrimPrefix(path, repoPrefix)
+
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"slug":    slug,
+			"project": map[string]string{"key": project},
+			"links": map[string]interface{}{
+				"self":  []interface{}{map[string]string{"href": s.repo.URL + "/browse"}},
+				"clone": []interface{}{map[string]string{"href": s.repo.URL + ".git", "name": "http"}},
+			},
+		})
+	case path == "/webhooks" && r.Method == http.MethodGet:
+		var items []interface{}
+		for _, h := range s.repo.Hooks {
+			items = append(items, map[string]interface{}{"id": h.ID, "url": h.URL, "active": h.Active, "events": h.Events})
+		}
+		s.writeBitbucketServerPage(w, r, items)
+	case path == "/webhooks" && r.Method == http.MethodPost:
+		hook := struct {
+			Name          string   `json:"name"`
+			Active        bool     `json:"active"`
+			Events        []string `json:"events"`
+			URL           string   `json:"url"`
+			Configuration struct {
+				Secret string `json:"secret"`
+			} `json:"configuration"`
+		}{}
+		if err := json.Unmarshal(body, &hook); err != nil || hook.URL == "" || len(hook.Events) == 0 {
+			writeBitbucketServerError(w, http.StatusBadRequest, "url and events are required")
+			return
+		}
+		h := s.addHook(Hook{Name: hook.Name, URL: hook.URL, Secret: hook.Configuration.Secret, Events: hook.Events, Active: hook.Active})
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"id": h.ID, "name": h.Name, "url": h.URL})
+	case strings.HasPrefix(path, "/webhooks/") && r.Method == http.MethodDelete:
+		params, _ := route(path, "/webhooks/*")
+		if len(params) == 0 || !s.deleteHook(params[0]) {
+			writeBitbucketServerError(w, http.StatusNotFound, "Webhook does not exist.")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case path == "/permissions/users" && r.Method == http.MethodGet:
+		filter := r.URL.Query().Get("filter")
+		var items []interface{}
+		for _, u := range s.repo.Users {
+			if !strings.Contains(u.Name, filter) {
+				continue
+			}
+			permission := "REPO_READ"
+			if u.CanWrite {
+				permission = "REPO_WRITE"
+			}
+			items = append(items, map[string]interface{}{"user": bitbucketServerUser(u.ID, u.Name, u.Email), "permission": permission})
+		}
+		s.writeBitbucketServerPage(w, r, items)
+	case strings.HasPrefix(path, "/pull-requests/") && r.Method == http.MethodPost:
+		params, ok := route(path, "/pull-requests/*/comments")
+		comment := struct {
+			Text string `json:"text"`
+		}{}
+		var pr *git.PullRequest
+		if ok {
+			pr = s.findPullRequest(params[0])
+		}
+		if pr == nil || json.Unmarshal(body, &comment) != nil {
+			writeBitbucketServerError(w, http.StatusNotFound, "Pull request does not exist.")
+			return
+		}
+		s.repo.Comments = append(s.repo.Comments, Comment{IssueNo: pr.ID, Body: comment.Text})
+		writeJSON(w, http.StatusCreated, comment)
+	case (path == "/branches" || path == "/tags") && r.Method == http.MethodGet:
+		refs, prefix := s.repo.Branches, "refs/heads/"
+		if path == "/tags" {
+			refs, prefix = s.repo.Tags, "refs/tags/"
+		}
+		// Refs are filtered by the names containing the filter text
+		filter := r.URL.Query().Get("filterText")
+		var items []interface{}
+		for _, ref := range refs {
+			if !strings.Contains(ref.Name, filter) {
+				continue
+			}
+			items = append(items, map[string]string{"id": prefix + ref.Name, "displayId": ref.Name, "latestCommit": ref.Sha})
+		}
+		s.writeBitbucketServerPage(w, r, items)
+	case path == "/pull-requests" && r.Method == http.MethodGet:
+		state := r.URL.Query().Get("state")
+		var items []interface{}
+		for _, pr := range s.repo.PullRequests {
+			prState := "DECLINED"
+			if pr.State == git.PullRequestStateOpen {
+				prState = "OPEN"
+			}
+			if state != "" && state != "ALL" && prState != state {
+				continue
+			}
+			items = append(items, map[string]interface{}{
+				"id":      pr.ID,
+				"title":   pr.Title,
+				"state":   prState,
+				"author":  map[string]interface{}{"user": bitbucketServerUser(pr.Sender.ID, pr.Sender.Name, pr.Sender.Email)},
+				"fromRef": map[string]string{"id": "refs/heads/" + pr.Head.Ref, "displayId": pr.Head.Ref, "latestCommit": pr.Head.Sha},
+				"toRef":   map[string]string{"id": "refs/heads/" + pr.Base.Ref, "displayId": pr.Base.Ref},
+				"links":   map[string]interface{}{"self": []interface{}{map[string]string{"href": pr.URL}}},
+			})
+		}
+		s.writeBitbucketServerPage(w, r, items)
+	case path == "/compare/changes" && r.Method == http.MethodGet:
+		files, ok := s.repo.ChangedFiles[r.URL.Query().Get("from")]
+		if !ok || r.URL.Query().Get("to") == "" {
+			writeBitbucketServerError(w, http.StatusNotFound, "Commit does not exist.")
+			return
+		}
+		var items []interface{}
+		for _, f := range files {
+			items = append(items, map[string]interface{}{"path": map[string]string{"toString": f}, "type": "MODIFY"})
+		}
+		s.writeBitbucketServerPage(w, r, items)
+	default:
+		writeBitbucketServerError(w, http.StatusNotFound, "Not found.")
+	}
+}
+
+// writeBitbucketServerPage writes a page of the items, starting from the start query parameter
+func (s *Server) writeBitbucketServerPage(w http.ResponseWriter, r *http.Request, items []interface{}) {
+	limit := s.requestedPageSize(r, "limit")
+	start, err := strconv.Atoi(r.URL.Query().Get("start"))
+	if err != nil || start < 0 {
+		start = 0
+	}
+
+	values := sliceItems(items, start, limit)
+	p := map[string]interface{}{
+		"size":       len(values),
+		"limit":      limit,
+		"start":      start,
+		"values":     values,
+		"isLastPage": start+limit >= len(items),
+	}
+	if start+limit < len(items) {
+		p["nextPageStart"] = start + limit
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func bitbucketServerUser(id int, name, email string) map[string]interface{} {
+	return map[string]interface{}{"id": id, "name": name, "slug": name, "emailAddress": email}
+}
+
+func writeBitbucketServerError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{"errors": []interface{}{map[string]string{"message": message}}})
+}
@@ -0,0 +1,439 @@
+package fake
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Contract is a git.Client implementation to be tested by the contract test suite
+type Contract struct {
+	// Type is a git type of the client
+	Type cicdv1.GitType
+	// NewServer starts a fake server for the git type
+	NewServer func(repo *Repository, token string) *Server
+	// NewClient creates a client for the IntegrationConfig
+	NewClient func(ic *cicdv1.IntegrationConfig) git.Client
+
+	// Repository is a name of the repository, if the git type has its own form of names (e.g., org/project/repo)
+	Repository string
+	// Skip is the reasons of the tests to be skipped, keyed by the test names. Tests are skipped only if the git
+	// server has nothing to be tested (e.g., gerrit has no commit statuses), not for hiding the failures
+	Skip map[string]string
+}
+
+const (
+	contractToken      = "contract-token"
+	contractSecret     = "contract-secret"
+	contractRepository = "tmax-cloud/cicd-operator"
+	contractHeadSha    = "6dcb09b5b57875f334f61aebed695e2e4193db5e"
+	contractBaseSha    = "0123456789abcdef0123456789abcdef01234567"
+)
+
+var (
+	contractWriter = User{ID: 1, Name: "writer", Email: "writer@tmax.co.kr", CanWrite: true, OrgMember: true}
+	contractReader = User{ID: 2, Name: "reader", Email: "reader@tmax.co.kr"}
+)
+
+// RunContractTests runs the contract test suite, which every git.Client implementation should pass
+func RunContractTests(t *testing.T, c Contract) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, c Contract)
+	}{
+		{name: "Webhook", test: testContractWebhook},
+		{name: "CommitStatus", test: testContractCommitStatus},
+		{name: "User", test: testContractUser},
+		{name: "Comment", test: testContractComment},
+		{name: "Repository", test: testContractRepository},
+		{name: "Pagination", test: testContractPagination},
+		{name: "PullRequest", test: testContractPullRequest},
+		{name: "ChangedFiles", test: testContractChangedFiles},
+		{name: "Unauthorized", test: testContractUnauthorized},
+		{name: "Organization", test: testContractOrganization},
+		{name: "OrganizationMember", test: testContractOrganizationMember},
+		{name: "Label", test: testContractLabel},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if reason, ok := c.Skip[tc.name]; ok {
+				t.Skip(reason)
+			}
+			tc.test(t, c)
+		})
+	}
+}
+
+// repository returns the name of the repository of the contract tests
+func (c Contract) repository() string {
+	if c.Repository != "" {
+		return c.Repository
+	}
+	return contractRepository
+}
+
+// newContractRepository returns a repository state of the contract tests
+func newContractRepository(name string) *Repository {
+	return &Repository{
+		Name:  name,
+		URL:   "https://git.tmax.co.kr/" + name,
+		Users: []User{contractWriter, contractReader},
+		Branches: []git.Ref{
+			{Name: "master", Sha: contractBaseSha},
+			{Name: "feat/a", Sha: contractHeadSha},
+			{Name: "feat/b", Sha: "1111111111111111111111111111111111111111"},
+		},
+		Tags: []git.Ref{
+			{Name: "v0.1.0", Sha: "2222222222222222222222222222222222222222"},
+		},
+		PullRequests: []git.PullRequest{
+			{ID: 3, Title: "feat a", State: git.PullRequestStateOpen, Sender: git.User{ID: contractWriter.ID, Name: contractWriter.Name},
+				URL: "https://git.tmax.co.kr/" + name + "/pull/3", Base: git.Base{Ref: "master"}, Head: git.Head{Ref: "feat/a", Sha: contractHeadSha},
+				Labels: []git.IssueLabel{{Name: "kind/feature"}, {Name: "run-e2e"}}},
+			{ID: 4, Title: "feat b", State: git.PullRequestStateClosed, Sender: git.User{ID: contractReader.ID, Name: contractReader.Name},
+				URL: "https://git.tmax.co.kr/" + name + "/pull/4", Base: git.Base{Ref: "master"}, Head: git.Head{Ref: "feat/b", Sha: "1111111111111111111111111111111111111111"}},
+			{ID: 5, Title: "feat c", State: git.PullRequestStateOpen, Sender: git.User{ID: contractReader.ID, Name: contractReader.Name},
+				URL: "https://git.tmax.co.kr/" + name + "/pull/5", Base: git.Base{Ref: "master"}, Head: git.Head{Ref: "feat/c", Sha: "3333333333333333333333333333333333333333"},
+				Draft: true},
+		},
+		ChangedFiles: map[string][]string{
+			contractHeadSha: {"README.md", "pkg/git/git.go"},
+		},
+		Organization: "tmax-cloud",
+		OrganizationRepositories: []git.OrganizationRepository{
+			{Name: name, URL: "https://git.tmax.co.kr/" + name, Topics: []string{"cicd"}},
+			{Name: "tmax-cloud/hypercloud", URL: "https://git.tmax.co.kr/tmax-cloud/hypercloud", Topics: []string{}},
+			{Name: "tmax-cloud/legacy", URL: "https://git.tmax.co.kr/tmax-cloud/legacy", Topics: []string{}, Archived: true},
+		},
+	}
+}
+
+// setUp starts a fake server and creates a client for it
+func (c Contract) setUp(token string) (*Server, git.Client) {
+	srv := c.NewServer(newContractRepository(c.repository()), contractToken)
+	ic := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "contract-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Git: cicdv1.GitConfig{
+				Type:       c.Type,
+				APIUrl:     srv.URL,
+				Repository: c.repository(),
+				Token:      cicdv1.GitToken{Value: token},
+			},
+			Jobs: cicdv1.IntegrationConfigJobs{
+				PreSubmit:  cicdv1.Jobs{{Container: corev1.Container{Name: "test-unit"}}},
+				PostSubmit: cicdv1.Jobs{{Container: corev1.Container{Name: "build"}}},
+			},
+		},
+		Status: cicdv1.IntegrationConfigStatus{Secrets: contractSecret},
+	}
+	return srv, c.NewClient(ic)
+}
+
+func testContractWebhook(t *testing.T, c Contract) {
+	srv, cli := c.setUp(contractToken)
+	defer srv.Close()
+
+	hooks, err := cli.ListWebhook()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, len(hooks))
+
+	// Register
+	hookURL := "https://cicd.tmax.co.kr/webhook/default/contract-ic"
+	if err := cli.RegisterWebhook(hookURL); err != nil {
+		t.Fatal(err)
+	}
+	// Some git servers (e.g., azure devops) need a webhook for each event
+	registered := srv.Repository().Hooks
+	assert.NotEqual(t, 0, len(registered))
+	for _, h := range registered {
+		assert.Equal(t, hookURL, h.URL)
+		assert.Equal(t, contractSecret, h.Secret)
+	}
+
+	// List
+	hooks, err = cli.ListWebhook()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A listed webhook may consist of several registered ones, e.g., subscriptions of azure devops
+	var registeredKeys, listedKeys []string
+	for _, h := range registered {
+		registeredKeys = append(registeredKeys, srv.HookKey(h))
+	}
+	for _, h := range hooks {
+		assert.Equal(t, hookURL, h.URL)
+		listedKeys = append(listedKeys, strings.Split(h.ID, ",")...)
+	}
+	assert.Equal(t, registeredKeys, listedKeys)
+
+	// Drift
+	if detector, ok := cli.(git.WebhookDriftDetector); ok {
+		reason, _ := git.WebhookDrift(detector.ExpectedWebhook(hookURL), hooks[0])
+		assert.Equal(t, "", reason)
+
+		var expectedReason string
+		srv.Update(func(repo *Repository) {
+			expectedReason = srv.driftHook(&repo.Hooks[0])
+		})
+		drifted, err := cli.ListWebhook()
+		if err != nil {
+			t.Fatal(err)
+		}
+		reason, _ = git.WebhookDrift(detector.ExpectedWebhook(hookURL), drifted[0])
+		assert.Equal(t, expectedReason, reason)
+	}
+
+	// Delete
+	for _, h := range hooks {
+		if err := cli.DeleteWebhook(h.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.Equal(t, 0, len(srv.Repository().Hooks))
+	assert.NotEqual(t, nil, cli.DeleteWebhook(hooks[0].ID))
+}
+
+func testContractCommitStatus(t *testing.T, c Contract) {
+	srv, cli := c.setUp(contractToken)
+	defer srv.Close()
+
+	ij := &cicdv1.IntegrationJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "contract-ij", Namespace: "default"},
+		Spec: cicdv1.IntegrationJobSpec{
+			Refs: cicdv1.IntegrationJobRefs{
+				Repository: c.repository(),
+				Base:       cicdv1.IntegrationJobRefsBase{Ref: "master", Sha: contractBaseSha},
+			},
+		},
+	}
+
+	// Push
+	if err := cli.SetCommitStatus(ij, "test-unit", git.CommitStatusState(cicdv1.CommitStatusStateSuccess), "Job succeeded", "https://cicd.tmax.co.kr/report"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []Status{{Context: "test-unit", State: git.CommitStatusState(cicdv1.CommitStatusStateSuccess), Description: "Job succeeded", TargetURL: "https://cicd.tmax.co.kr/report"}},
+		srv.Repository().Statuses[contractBaseSha])
+
+	// Pull request, status is set to the head commit
+	ij.Spec.Refs.Pull = &cicdv1.IntegrationJobRefsPull{ID: 3, Ref: "feat/a", Sha: contractHeadSha}
+	for _, state := range []cicdv1.CommitStatusState{cicdv1.CommitStatusStatePending, cicdv1.CommitStatusStatePending, cicdv1.CommitStatusStateFailure} {
+		if err := cli.SetCommitStatus(ij, "test-lint", git.CommitStatusState(state), "Job is "+string(state), ""); err != nil {
+			t.Fatal(err)
+		}
+		statuses := srv.Repository().Statuses[contractHeadSha]
+		assert.Equal(t, 1, len(statuses))
+		assert.Equal(t, "test-lint", statuses[0].Context)
+		assert.Equal(t, git.CommitStatusState(state), statuses[0].State)
+	}
+	assert.Equal(t, 1, len(srv.Repository().Statuses[contractBaseSha]))
+}
+
+func testContractUser(t *testing.T, c Contract) {
+	srv, cli := c.setUp(contractToken)
+	defer srv.Close()
+
+	for _, u := range []User{contractWriter, contractReader} {
+		user, err := cli.GetUserInfo(srv.UserKey(u))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, srv.SharedUser(u), *user)
+
+		canWrite, err := cli.CanUserWriteToRepo(*user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, u.CanWrite, canWrite)
+	}
+
+	_, err := cli.GetUserInfo(srv.UserKey(User{ID: 99, Name: "unknown"}))
+	assert.NotEqual(t, nil, err)
+}
+
+func testContractComment(t *testing.T, c Contract) {
+	srv, cli := c.setUp(contractToken)
+	defer srv.Close()
+
+	if err := cli.RegisterComment(git.IssueTypePullRequest, 3, "Tests passed"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []Comment{{IssueNo: 3, Body: "Tests passed"}}, srv.Repository().Comments)
+}
+
+func testContractRepository(t *testing.T, c Contract) {
+	srv, cli := c.setUp(contractToken)
+	defer srv.Close()
+
+	repo, err := cli.GetRepository()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := srv.SharedRepository()
+	assert.Equal(t, expected.Name, repo.Name)
+	assert.Equal(t, expected.URL, repo.URL)
+
+	branches, err := cli.ListBranches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, srv.Repository().Branches, branches)
+
+	branch, err := cli.GetBranch("feat/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &git.Ref{Name: "feat/a", Sha: contractHeadSha}, branch)
+
+	// Branches whose names contain the name are not matched
+	branch, err = cli.GetBranch("feat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, (*git.Ref)(nil), branch)
+
+	tags, err := cli.ListTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, srv.Repository().Tags, tags)
+
+	tag, err := cli.GetTag("v0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &git.Ref{Name: "v0.1.0", Sha: "2222222222222222222222222222222222222222"}, tag)
+
+	// Tags whose names contain the name are not matched
+	tag, err = cli.GetTag("v0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, (*git.Ref)(nil), tag)
+}
+
+func testContractPagination(t *testing.T, c Contract) {
+	srv, cli := c.setUp(contractToken)
+	defer srv.Close()
+
+	// Small pages, so that the list APIs should follow the pages
+	srv.SetPageSize(2)
+
+	branches, err := cli.ListBranches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, srv.Repository().Branches, branches)
+	assert.Equal(t, 2, len(srv.Requests()))
+}
+
+func testContractPullRequest(t *testing.T, c Contract) {
+	srv, cli := c.setUp(contractToken)
+	defer srv.Close()
+
+	prs, err := cli.ListOpenPullRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := srv.Repository().PullRequests
+	assert.Equal(t, []git.PullRequest{srv.SharedPullRequest(expected[0]), srv.SharedPullRequest(expected[2])}, prs)
+}
+
+func testContractChangedFiles(t *testing.T, c Contract) {
+	srv, cli := c.setUp(contractToken)
+	defer srv.Close()
+
+	files, err := cli.ListChangedFiles("master", contractHeadSha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"README.md", "pkg/git/git.go"}, files)
+
+	_, err = cli.ListChangedFiles("master", "3333333333333333333333333333333333333333")
+	assert.NotEqual(t, nil, err)
+}
+
+func testContractUnauthorized(t *testing.T, c Contract) {
+	srv, cli := c.setUp("wrong-token")
+	defer srv.Close()
+
+	_, err := cli.ListWebhook()
+	assert.NotEqual(t, nil, err)
+	assert.NotEqual(t, nil, cli.RegisterWebhook("https://cicd.tmax.co.kr/webhook/default/contract-ic"))
+	assert.Equal(t, 0, len(srv.Repository().Hooks))
+}
+
+// testContractOrganization tests the clients which implement git.OrganizationClient
+func testContractOrganization(t *testing.T, c Contract) {
+	srv, cli := c.setUp(contractToken)
+	defer srv.Close()
+
+	orgCli, ok := cli.(git.OrganizationClient)
+	if !ok {
+		t.Skip("organization is not supported")
+	}
+
+	// Small pages, so that the list APIs should follow the pages
+	srv.SetPageSize(2)
+
+	repos, err := orgCli.ListOrganizationRepositories("tmax-cloud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, srv.Repository().OrganizationRepositories, repos)
+
+	_, err = orgCli.ListOrganizationRepositories("unknown")
+	assert.NotEqual(t, nil, err)
+}
+
+// testContractOrganizationMember tests the clients which implement git.OrganizationMemberClient
+func testContractOrganizationMember(t *testing.T, c Contract) {
+	srv, cli := c.setUp(contractToken)
+	defer srv.Close()
+
+	memberCli, ok := cli.(git.OrganizationMemberClient)
+	if !ok {
+		t.Skip("organization member is not supported")
+	}
+
+	for _, u := range []User{contractWriter, contractReader, {ID: 99, Name: "unknown"}} {
+		isMember, err := memberCli.IsOrganizationMember(git.User{ID: u.ID, Name: u.Name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, u.OrgMember, isMember)
+	}
+}
+
+// testContractLabel tests the clients which implement git.LabelClient
+func testContractLabel(t *testing.T, c Contract) {
+	srv, cli := c.setUp(contractToken)
+	defer srv.Close()
+
+	labelCli, ok := cli.(git.LabelClient)
+	if !ok {
+		t.Skip("label is not supported")
+	}
+
+	if err := labelCli.AddLabel(3, "ok-to-test"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []git.IssueLabel{{Name: "kind/feature"}, {Name: "run-e2e"}, {Name: "ok-to-test"}}, srv.Repository().PullRequests[0].Labels)
+
+	if err := labelCli.RemoveLabel(3, "run-e2e"); err != nil {
+		t.Fatal(err)
+	}
+	// Removing a label which is not set is not an error
+	if err := labelCli.RemoveLabel(3, "needs-ok-to-test"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []git.IssueLabel{{Name: "kind/feature"}, {Name: "ok-to-test"}}, srv.Repository().PullRequests[0].Labels)
+}
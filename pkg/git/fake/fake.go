@@ -0,0 +1,446 @@
+// Package fake provides in-memory fake git servers and a contract test suite for git.Client implementations
+package fake
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+)
+
+// Repository is an in-memory state of the repository served by a fake server
+type Repository struct {
+	// Name is a full path of the repository (e.g., tmax-cloud/cicd-operator)
+	Name string
+	URL  string
+
+	Users        []User
+	Branches     []git.Ref
+	Tags         []git.Ref
+	PullRequests []git.PullRequest
+
+	Hooks    []Hook
+	Statuses map[string][]Status
+	Comments []Comment
+
+	// ChangedFiles are the files changed by each head commit, keyed by the commit sha
+	ChangedFiles map[string][]string
+
+	// Organization is an organization (github) or a group (gitlab) of the repository
+	Organization string
+	// OrganizationRepositories are the repositories of the organization, including the repository itself if needed
+	OrganizationRepositories []git.OrganizationRepository
+}
+
+// User is a user of the fake server
+type User struct {
+	ID       int
+	Name     string
+	Email    string
+	CanWrite bool
+	// OrgMember is whether the user is a member of the organization (github) or the group (gitlab) of the repository
+	OrgMember bool
+}
+
+// Hook is a webhook registered to the fake server
+type Hook struct {
+	ID int
+	// Name is a name of the webhook, for the git servers identifying webhooks by their names (e.g., gerrit)
+	Name        string
+	URL         string
+	Secret      string
+	Events      []string
+	ContentType string
+	Active      bool
+}
+
+// Status is a commit status set to the fake server
+// State is converted to the common git.CommitStatusState
+type Status struct {
+	Context     string
+	State       git.CommitStatusState
+	Description string
+	TargetURL   string
+}
+
+// Comment is a comment registered to an issue or a pull request
+type Comment struct {
+	IssueNo int
+	Body    string
+}
+
+// Request is a request recorded by the fake server
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// Server is an in-memory fake git server
+type Server struct {
+	*httptest.Server
+
+	// Token is an access token accepted by the server
+	Token string
+
+	// userKey is the identifier of a user, used for the user APIs
+	userKey func(u User) string
+	// hookKey is the identifier of a webhook, used for the webhook APIs
+	hookKey func(h Hook) string
+
+	// sharedUser, sharedPullRequest and sharedRepository convert the server's state into the ones returned by the
+	// clients, as each git server identifies and represents them in its own way
+	sharedUser        func(u User) git.User
+	sharedPullRequest func(pr git.PullRequest) git.PullRequest
+	sharedRepository  func(repo *Repository) git.Repository
+
+	// driftHook changes the webhook so that it drifts from the expected one, and returns the expected drift reason
+	driftHook func(h *Hook) string
+
+	lock       sync.Mutex
+	pageSize   int
+	repo       *Repository
+	requests   []Request
+	nextHookID int
+}
+
+// defaultPageSize is the default maximum number of items in a page
+const defaultPageSize = 100
+
+func newServer(repo *Repository, token string, userKey func(u User) string, handler func(s *Server, w http.ResponseWriter, r *http.Request, body []byte)) *Server {
+	if repo.Statuses == nil {
+		repo.Statuses = map[string][]Status{}
+	}
+	s := &Server{
+		Token:      token,
+		pageSize:   defaultPageSize,
+		userKey:    userKey,
+		hookKey:    func(h Hook) string { return strconv.Itoa(h.ID) },
+		sharedUser: func(u User) git.User { return git.User{ID: u.ID, Name: u.Name, Email: u.Email} },
+		sharedPullRequest: func(pr git.PullRequest) git.PullRequest {
+			return pr
+		},
+		sharedRepository: func(repo *Repository) git.Repository {
+			return git.Repository{Name: repo.Name, URL: repo.URL}
+		},
+		driftHook: func(h *Hook) string {
+			h.Events = []string{"push_events"}
+			return git.WebhookDriftReasonEvents
+		},
+		repo:       repo,
+		nextHookID: 1,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		s.requests = append(s.requests, Request{
+			Method: r.Method,
+			Path:   r.URL.EscapedPath(),
+			Query:  r.URL.Query(),
+			Header: r.Header.Clone(),
+			Body:   body,
+		})
+		handler(s, w, r, body)
+	}))
+	return s
+}
+
+// UserKey returns the identifier of the user, used for GetUserInfo
+func (s *Server) UserKey(u User) string {
+	return s.userKey(u)
+}
+
+// Requests returns the requests recorded by the server
+func (s *Server) Requests() []Request {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// ResetRequests clears the recorded requests
+func (s *Server) ResetRequests() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.requests = nil
+}
+
+// SetPageSize sets the maximum number of items in a page of list APIs
+func (s *Server) SetPageSize(size int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.pageSize = size
+}
+
+// Update updates the repository's state
+func (s *Server) Update(f func(repo *Repository)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f(s.repo)
+}
+
+// Repository returns a copy of the repository's state
+func (s *Server) Repository() Repository {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	repo := *s.repo
+	repo.Users = append([]User(nil), s.repo.Users...)
+	repo.Branches = append([]git.Ref(nil), s.repo.Branches...)
+	repo.Tags = append([]git.Ref(nil), s.repo.Tags...)
+	repo.PullRequests = append([]git.PullRequest(nil), s.repo.PullRequests...)
+	repo.Hooks = append([]Hook(nil), s.repo.Hooks...)
+	repo.Comments = append([]Comment(nil), s.repo.Comments...)
+	repo.OrganizationRepositories = append([]git.OrganizationRepository(nil), s.repo.OrganizationRepositories...)
+	repo.Statuses = map[string][]Status{}
+	for sha, statuses := range s.repo.Statuses {
+		repo.Statuses[sha] = append([]Status(nil), statuses...)
+	}
+	return repo
+}
+
+// SharedRepository returns the repository, as returned by the client
+func (s *Server) SharedRepository() git.Repository {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.sharedRepository(s.repo)
+}
+
+// SharedUser returns the user, as returned by the client
+func (s *Server) SharedUser(u User) git.User {
+	return s.sharedUser(u)
+}
+
+// SharedPullRequest returns the pull request, as returned by the client
+func (s *Server) SharedPullRequest(pr git.PullRequest) git.PullRequest {
+	return s.sharedPullRequest(pr)
+}
+
+// HookKey returns the identifier of the webhook, used for DeleteWebhook
+func (s *Server) HookKey(h Hook) string {
+	return s.hookKey(h)
+}
+
+func (s *Server) addHook(h Hook) Hook {
+	h.ID = s.nextHookID
+	s.nextHookID++
+	s.repo.Hooks = append(s.repo.Hooks, h)
+	return h
+}
+
+func (s *Server) findHook(id string) *Hook {
+	for i := range s.repo.Hooks {
+		if s.hookKey(s.repo.Hooks[i]) == id {
+			return &s.repo.Hooks[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) deleteHook(id string) bool {
+	for i, h := range s.repo.Hooks {
+		if s.hookKey(h) == id {
+			s.repo.Hooks = append(s.repo.Hooks[:i], s.repo.Hooks[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// setStatus sets a commit status, replacing the status of the same context
+func (s *Server) setStatus(sha string, status Status) {
+	statuses := s.repo.Statuses[sha]
+	for i := range statuses {
+		if statuses[i].Context == status.Context {
+			statuses[i] = status
+			return
+		}
+	}
+	s.repo.Statuses[sha] = append(statuses, status)
+}
+
+func (s *Server) findUser(key string) *User {
+	for i := range s.repo.Users {
+		if s.userKey(s.repo.Users[i]) == key {
+			return &s.repo.Users[i]
+		}
+	}
+	return nil
+}
+
+// isOrgMember checks if the user is a member of the organization
+func (s *Server) isOrgMember(organization, key string) bool {
+	u := s.findUser(key)
+	return organization == s.repo.Organization && u != nil && u.OrgMember
+}
+
+func (s *Server) findBranch(name string) *git.Ref {
+	for i := range s.repo.Branches {
+		if s.repo.Branches[i].Name == name {
+			return &s.repo.Branches[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) findTag(name string) *git.Ref {
+	for i := range s.repo.Tags {
+		if s.repo.Tags[i].Name == name {
+			return &s.repo.Tags[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) findPullRequest(id string) *git.PullRequest {
+	for i := range s.repo.PullRequests {
+		if strconv.Itoa(s.repo.PullRequests[i].ID) == id {
+			return &s.repo.PullRequests[i]
+		}
+	}
+	return nil
+}
+
+// withoutLabels converts the pull request for the git servers which support neither labels nor drafts
+// Its sender is converted by sharedUser
+func (s *Server) withoutLabels(pr git.PullRequest) git.PullRequest {
+	pr.Labels = nil
+	pr.Draft = false
+	pr.Sender = s.sharedUser(User{ID: pr.Sender.ID, Name: pr.Sender.Name, Email: pr.Sender.Email})
+	return pr
+}
+
+// addLabel adds a label to the pull request, if it's not set yet
+func addLabel(pr *git.PullRequest, label string) {
+	for _, l := range pr.Labels {
+		if l.Name == label {
+			return
+		}
+	}
+	pr.Labels = append(pr.Labels, git.IssueLabel{Name: label})
+}
+
+// removeLabel removes a label from the pull request. It returns false if the label is not set
+func removeLabel(pr *git.PullRequest, label string) bool {
+	for i, l := range pr.Labels {
+		if l.Name == label {
+			pr.Labels = append(pr.Labels[:i:i], pr.Labels[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// page is a page of list APIs
+type page struct {
+	items    []interface{}
+	next     int
+	perPage  int
+	lastPage int
+}
+
+// paginate slices the items, using page and per_page (limit for gitea, pagelen for bitbucket) query parameters
+func (s *Server) paginate(r *http.Request, items []interface{}) *page {
+	perPage := s.requestedPageSize(r, "per_page", "limit", "pagelen")
+	pageNo, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || pageNo <= 0 {
+		pageNo = 1
+	}
+
+	p := &page{items: sliceItems(items, (pageNo-1)*perPage, perPage), perPage: perPage, lastPage: (len(items) + perPage - 1) / perPage}
+	if pageNo < p.lastPage {
+		p.next = pageNo + 1
+	}
+	return p
+}
+
+// requestedPageSize returns the page size requested by the first query parameter set, capped by the server's page size
+func (s *Server) requestedPageSize(r *http.Request, keys ...string) int {
+	for _, key := range keys {
+		if r.URL.Query().Get(key) == "" {
+			continue
+		}
+		size, err := strconv.Atoi(r.URL.Query().Get(key))
+		if err != nil || size <= 0 || size > s.pageSize {
+			return s.pageSize
+		}
+		return size
+	}
+	return s.pageSize
+}
+
+// sliceItems returns at most size items, starting from start
+func sliceItems(items []interface{}, start, size int) []interface{} {
+	if start < 0 || start >= len(items) {
+		return []interface{}{}
+	}
+	end := start + size
+	if end > len(items) || size < 0 {
+		end = len(items)
+	}
+	return items[start:end]
+}
+
+// pageURL returns a url of the page, keeping the other query parameters
+func pageURL(r *http.Request, pageNo int) string {
+	u := *r.URL
+	q := u.Query()
+	q.Set("page", strconv.Itoa(pageNo))
+	u.RawQuery = q.Encode()
+	return u.RequestURI()
+}
+
+// splitRepositoryName splits the name of the repository into its owner (e.g., project, workspace) and its name
+func splitRepositoryName(name string) (string, string) {
+	tokens := strings.SplitN(name, "/", 2)
+	if len(tokens) < 2 {
+		return tokens[0], ""
+	}
+	return tokens[0], tokens[1]
+}
+
+// route matches the path against the pattern, in which '*' matches a path segment
+// Matched segments are returned
+func route(path, pattern string) ([]string, bool) {
+	pathTokens := strings.Split(strings.Trim(path, "/"), "/")
+	patternTokens := strings.Split(strings.Trim(pattern, "/"), "/")
+	if len(pathTokens) != len(patternTokens) {
+		return nil, false
+	}
+
+	var params []string
+	for i := range patternTokens {
+		if patternTokens[i] == "*" {
+			p, err := url.PathUnescape(pathTokens[i])
+			if err != nil {
+				return nil, false
+			}
+			params = append(params, p)
+			continue
+		}
+		if patternTokens[i] != pathTokens[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
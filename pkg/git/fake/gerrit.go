@@ -0,0 +1,317 @@
+package fake
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+)
+
+const (
+	// gerritJSONPrefix is a prefix of every json response, for preventing XSSI
+	gerritJSONPrefix = ")]}'\n"
+	// gerritHead is the HEAD of the project
+	gerritHead = "refs/heads/master"
+)
+
+// NewGerritServer starts a fake Gerrit REST API server (with the webhooks plugin), serving the repository
+// Requests should be authorized with 'Authorization: Basic <base64 encoded token>' header
+func NewGerritServer(repo *Repository, token string) *Server {
+	s := newServer(repo, token, func(u User) string { return u.Name }, serveGerrit)
+	s.hookKey = func(h Hook) string { return h.Name }
+	s.sharedUser = func(u User) git.User {
+		return git.User{ID: git.UserIDFromString(u.Name), Name: u.Name, Email: u.Email}
+	}
+	s.sharedPullRequest = func(pr git.PullRequest) git.PullRequest {
+		pr = s.withoutLabels(pr)
+		pr.URL = fmt.Sprintf("%s/c/%s/+/%d", s.URL, s.repo.Name, pr.ID)
+		pr.Head.Ref = gerritChangeRef(pr.ID)
+		return pr
+	}
+	s.sharedRepository = func(repo *Repository) git.Repository {
+		return git.Repository{Name: repo.Name, URL: fmt.Sprintf("%s/admin/repos/%s", s.URL, repo.Name)}
+	}
+	// The webhooks plugin does not report the events, so the webhook drifts by losing its token
+	s.driftHook = func(h *Hook) string {
+		h.Secret = ""
+		return git.WebhookDriftReasonSecret
+	}
+	return s
+}
+
+func serveGerrit(s *Server, w http.ResponseWriter, r *http.Request, body []byte) {
+	if r.Header.Get("Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte(s.Token)) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte("Unauthorized"))
+		return
+	}
+
+	path := r.URL.EscapedPath()
+
+	// Account APIs
+	if params, ok := route(path, "/a/accounts/*"); ok && r.Method == http.MethodGet {
+		u := s.findUser(params[0])
+		if u == nil {
+			writeGerritError(w, http.StatusNotFound, "Account '"+params[0]+"' not found")
+			return
+		}
+		writeGerritJSON(w, http.StatusOK, gerritAccount(u.ID, u.Name, u.Email))
+		return
+	}
+
+	// Webhook APIs
+	if params, ok := route(path, "/a/config/server/webhooks~projects/*/remotes"); ok && r.Method == http.MethodGet {
+		if params[0] != s.repo.Name {
+			writeGerritError(w, http.StatusNotFound, "Not found: "+params[0])
+			return
+		}
+		remotes := map[string]interface{}{}
+		for _, h := range s.repo.Hooks {
+			remotes[h.Name] = map[string]interface{}{"url": gerritWebhookURL(h), "events": h.Events}
+		}
+		writeGerritJSON(w, http.StatusOK, remotes)
+		return
+	}
+	if params, ok := route(path, "/a/config/server/webhooks~projects/*/remotes/*"); ok && params[0] == s.repo.Name {
+		s.serveGerritRemote(w, r, params[1], body)
+		return
+	}
+
+	// Change APIs
+	if path == "/a/changes/" && r.Method == http.MethodGet {
+		s.serveGerritChanges(w, r)
+		return
+	}
+	if params, ok := route(path, "/a/changes/*/revisions/*/review"); ok && r.Method == http.MethodPost {
+		var pr *git.PullRequest
+		if i := strings.LastIndex(params[0], "~"); i >= 0 && params[0][:i] == s.repo.Name {
+			pr = s.findPullRequest(params[0][i+1:])
+		}
+		if pr == nil || (params[1] != "current" && params[1] != pr.Head.Sha) {
+			writeGerritError(w, http.StatusNotFound, "Not found: "+params[0])
+			return
+		}
+		review := struct {
+			Message string `json:"message"`
+		}{}
+		if err := json.Unmarshal(body, &review); err != nil {
+			writeGerritError(w, http.StatusBadRequest, "Invalid review")
+			return
+		}
+		s.repo.Comments = append(s.repo.Comments, Comment{IssueNo: pr.ID, Body: review.Message})
+		writeGerritJSON(w, http.StatusOK, map[string]interface{}{})
+		return
+	}
+
+	// Project APIs
+	projectPrefix := "/a/projects/" + url.PathEscape(s.repo.Name)
+	if path != projectPrefix && !strings.HasPrefix(path, projectPrefix+"/") {
+		writeGerritError(w, http.StatusNotFound, "Not found: "+s.repo.Name)
+		return
+	}
+	path = strings.TrimPrefix(path, projectPrefix)
+
+	switch {
+	case path == "/HEAD" && r.Method == http.MethodGet:
+		writeGerritJSON(w, http.StatusOK, gerritHead)
+	case path == "/check.access" && r.Method == http.MethodGet:
+		u := s.findUser(r.URL.Query().Get("account"))
+		if u == nil {
+			writeGerritError(w, http.StatusUnprocessableEntity, "Account '"+r.URL.Query().Get("account")+"' not found")
+			return
+		}
+		// Writers can submit changes to the HEAD branch only
+		if u.CanWrite && r.URL.Query().Get("ref") == gerritHead && r.URL.Query().Get("perm") == "submit" {
+			writeGerritJSON(w, http.StatusOK, map[string]interface{}{"status": http.StatusOK})
+			return
+		}
+		writeGerritJSON(w, http.StatusOK, map[string]interface{}{"status": http.StatusForbidden, "message": "user " + u.Name + " lacks permission submit"})
+	case (path == "/branches/" || path == "/tags/") && r.Method == http.MethodGet:
+		// HEAD and refs/meta/config are listed with the branches
+		items := []interface{}{}
+		if path == "/branches/" {
+			items = append(items, map[string]string{"ref": "HEAD", "revision": strings.TrimPrefix(gerritHead, "refs/heads/")})
+			items = append(items, map[string]string{"ref": "refs/meta/config", "revision": "4444444444444444444444444444444444444444"})
+			for _, ref := range s.repo.Branches {
+				items = append(items, map[string]string{"ref": "refs/heads/" + ref.Name, "revision": ref.Sha})
+			}
+		} else {
+			for _, ref := range s.repo.Tags {
+				items = append(items, map[string]string{"ref": "refs/tags/" + ref.Name, "revision": ref.Sha})
+			}
+		}
+		// Refs are sliced as requested, without limiting the page size
+		limit, err := strconv.Atoi(r.URL.Query().Get("n"))
+		if err != nil || limit <= 0 {
+			limit = len(items)
+		}
+		skip, _ := strconv.Atoi(r.URL.Query().Get("S"))
+		writeGerritJSON(w, http.StatusOK, sliceItems(items, skip, limit))
+	case (strings.HasPrefix(path, "/branches/") || strings.HasPrefix(path, "/tags/")) && r.Method == http.MethodGet:
+		params, ok := route(path, "/*/*")
+		var ref *git.Ref
+		prefix := "refs/heads/"
+		if ok && params[0] == "branches" {
+			ref = s.findBranch(params[1])
+		} else if ok {
+			prefix = "refs/tags/"
+			ref = s.findTag(params[1])
+		}
+		if ref == nil {
+			writeGerritError(w, http.StatusNotFound, "Not found: "+strings.TrimPrefix(path, "/"))
+			return
+		}
+		writeGerritJSON(w, http.StatusOK, map[string]string{"ref": prefix + ref.Name, "revision": ref.Sha})
+	case strings.HasPrefix(path, "/commits/") && r.Method == http.MethodGet:
+		params, ok := route(path, "/commits/*/files")
+		var files []string
+		if ok {
+			files, ok = s.repo.ChangedFiles[params[0]]
+		}
+		if !ok {
+			writeGerritError(w, http.StatusNotFound, "Not found: "+strings.TrimPrefix(path, "/commits/"))
+			return
+		}
+		result := map[string]interface{}{"/COMMIT_MSG": map[string]interface{}{"status": "A"}}
+		for _, f := range files {
+			result[f] = map[string]interface{}{}
+		}
+		writeGerritJSON(w, http.StatusOK, result)
+	default:
+		writeGerritError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// serveGerritRemote creates, updates or deletes a remote of the webhooks plugin
+// Tokens of the webhook urls are stored as the secrets of the webhooks
+func (s *Server) serveGerritRemote(w http.ResponseWriter, r *http.Request, name string, body []byte) {
+	switch r.Method {
+	case http.MethodPut:
+		remote := struct {
+			URL    string   `json:"url"`
+			Events []string `json:"events"`
+		}{}
+		if err := json.Unmarshal(body, &remote); err != nil || remote.URL == "" {
+			writeGerritError(w, http.StatusBadRequest, "url is required")
+			return
+		}
+		u, err := url.Parse(remote.URL)
+		if err != nil {
+			writeGerritError(w, http.StatusBadRequest, "invalid url")
+			return
+		}
+		query := u.Query()
+		token := query.Get(git.WebhookTokenQuery)
+		query.Del(git.WebhookTokenQuery)
+		u.RawQuery = query.Encode()
+
+		hook := Hook{Name: name, URL: u.String(), Secret: token, Events: remote.Events, Active: true}
+		if h := s.findHook(name); h != nil {
+			hook.ID = h.ID
+			*h = hook
+		} else {
+			hook = s.addHook(hook)
+		}
+		writeGerritJSON(w, http.StatusOK, map[string]interface{}{"url": gerritWebhookURL(hook), "events": hook.Events})
+	case http.MethodDelete:
+		if !s.deleteHook(name) {
+			writeGerritError(w, http.StatusNotFound, "Not found: "+name)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeGerritError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// serveGerritChanges queries the changes of the project
+// The number of the changes is limited by the page size, and the last change has _more_changes if there are more
+func (s *Server) serveGerritChanges(w http.ResponseWriter, r *http.Request) {
+	query := strings.Fields(r.URL.Query().Get("q"))
+	var items []interface{}
+	for _, pr := range s.repo.PullRequests {
+		status := "ABANDONED"
+		if pr.State == git.PullRequestStateOpen {
+			status = "NEW"
+		}
+		matched := true
+		for _, q := range query {
+			switch {
+			case strings.HasPrefix(q, "project:"):
+				matched = matched && strings.TrimPrefix(q, "project:") == s.repo.Name
+			case q == "status:open":
+				matched = matched && status == "NEW"
+			case q == "status:closed":
+				matched = matched && status != "NEW"
+			}
+		}
+		if !matched {
+			continue
+		}
+		items = append(items, map[string]interface{}{
+			"_number":          pr.ID,
+			"project":          s.repo.Name,
+			"subject":          pr.Title,
+			"branch":           pr.Base.Ref,
+			"status":           status,
+			"owner":            gerritAccount(pr.Sender.ID, pr.Sender.Name, pr.Sender.Email),
+			"current_revision": pr.Head.Sha,
+			"revisions":        map[string]interface{}{pr.Head.Sha: map[string]interface{}{"_number": 1, "ref": gerritChangeRef(pr.ID)}},
+		})
+	}
+
+	limit := s.requestedPageSize(r, "n")
+	skip, _ := strconv.Atoi(r.URL.Query().Get("S"))
+	changes := sliceItems(items, skip, limit)
+	if len(changes) > 0 && skip+len(changes) < len(items) {
+		last := map[string]interface{}{"_more_changes": true}
+		for k, v := range changes[len(changes)-1].(map[string]interface{}) {
+			last[k] = v
+		}
+		changes = append(append([]interface{}(nil), changes[:len(changes)-1]...), last)
+	}
+	writeGerritJSON(w, http.StatusOK, changes)
+}
+
+// gerritWebhookURL returns the url of the webhook, containing its secret as a token query parameter
+func gerritWebhookURL(h Hook) string {
+	if h.Secret == "" {
+		return h.URL
+	}
+	u, err := url.Parse(h.URL)
+	if err != nil {
+		return h.URL
+	}
+	query := u.Query()
+	query.Set(git.WebhookTokenQuery, h.Secret)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// gerritChangeRef returns the ref of the first patch set of the change
+func gerritChangeRef(number int) string {
+	return fmt.Sprintf("refs/changes/%02d/%d/1", number%100, number)
+}
+
+func gerritAccount(id int, username, email string) map[string]interface{} {
+	return map[string]interface{}{"_account_id": id, "name": username, "email": email, "username": username}
+}
+
+func writeGerritJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(gerritJSONPrefix))
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeGerritError writes an error message. Errors of gerrit are plain texts, not json
+func writeGerritError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(message))
+}
@@ -0,0 +1,213 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+)
+
+// NewGiteaServer starts a fake Gitea API server, serving the repository
+// Requests should be authorized with 'Authorization: token <token>' header
+func NewGiteaServer(repo *Repository, token string) *Server {
+	s := newServer(repo, token, func(u User) string { return u.Name }, serveGitea)
+	s.sharedPullRequest = s.withoutLabels
+	return s
+}
+
+func serveGitea(s *Server, w http.ResponseWriter, r *http.Request, body []byte) {
+	if r.Header.Get("Authorization") != "token "+s.Token {
+		writeError(w, http.StatusUnauthorized, "token is required")
+		return
+	}
+
+	path := r.URL.EscapedPath()
+
+	// User APIs
+	if params, ok := route(path, "/api/v1/users/*"); ok && r.Method == http.MethodGet {
+		u := s.findUser(params[0])
+		if u == nil {
+			writeError(w, http.StatusNotFound, "user does not exist")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"id": u.ID, "login": u.Name, "email": u.Email})
+		return
+	}
+
+	// Repository APIs
+	repoPrefix := "/api/v1/repos/" + s.repo.Name
+	if path != repoPrefix && !strings.HasPrefix(path, repoPrefix+"/") {
+		writeError(w, http.StatusNotFound, "repository does not exist")
+		return
+	}
+	path = strings.TrimPrefix(path, repoPrefix)
+
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]interface{}{"full_name": s.repo.Name, "html_url": s.repo.URL})
+	case path == "/hooks" && r.Method == http.MethodGet:
+		var items []interface{}
+		for _, h := range s.repo.Hooks {
+			items = append(items, map[string]interface{}{
+				"id":     h.ID,
+				"type":   "gitea",
+				"active": h.Active,
+				"events": h.Events,
+				"config": map[string]string{"url": h.URL, "content_type": h.ContentType},
+			})
+		}
+		s.writeGitHubPage(w, r, items)
+	case path == "/hooks" && r.Method == http.MethodPost:
+		hook := struct {
+			Type   string   `json:"type"`
+			Active bool     `json:"active"`
+			Events []string `json:"events"`
+			Config struct {
+				URL         string `json:"url"`
+				ContentType string `json:"content_type"`
+				Secret      string `json:"secret"`
+			} `json:"config"`
+		}{}
+		if err := json.Unmarshal(body, &hook); err != nil || hook.Type != "gitea" || hook.Config.URL == "" {
+			writeError(w, http.StatusUnprocessableEntity, "invalid hook")
+			return
+		}
+		h := s.addHook(Hook{URL: hook.Config.URL, Secret: hook.Config.Secret, Events: hook.Events, ContentType: hook.Config.ContentType, Active: hook.Active})
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"id": h.ID, "config": map[string]string{"url": h.URL}})
+	case strings.HasPrefix(path, "/hooks/") && r.Method == http.MethodDelete:
+		params, _ := route(path, "/hooks/*")
+		if len(params) == 0 || !s.deleteHook(params[0]) {
+			writeError(w, http.StatusNotFound, "hook does not exist")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case strings.HasPrefix(path, "/statuses/") && r.Method == http.MethodPost:
+		params, _ := route(path, "/statuses/*")
+		status := struct {
+			State       string `json:"state"`
+			TargetURL   string `json:"target_url"`
+			Description string `json:"description"`
+			Context     string `json:"context"`
+		}{}
+		if len(params) == 0 || json.Unmarshal(body, &status) != nil {
+			writeError(w, http.StatusBadRequest, "invalid status")
+			return
+		}
+		switch git.CommitStatusState(status.State) {
+		case "pending", "success", "error", "failure", "warning":
+		default:
+			writeError(w, http.StatusUnprocessableEntity, "invalid state "+status.State)
+			return
+		}
+		s.setStatus(params[0], Status{Context: status.Context, State: git.CommitStatusState(status.State), Description: status.Description, TargetURL: status.TargetURL})
+		writeJSON(w, http.StatusCreated, status)
+	case strings.HasPrefix(path, "/collaborators/") && r.Method == http.MethodGet:
+		params, ok := route(path, "/collaborators/*/permission")
+		var u *User
+		if ok {
+			u = s.findUser(params[0])
+		}
+		if u == nil {
+			writeError(w, http.StatusNotFound, "user does not exist")
+			return
+		}
+		permission := "read"
+		if u.CanWrite {
+			permission = "write"
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"permission": permission, "user": map[string]interface{}{"id": u.ID, "login": u.Name}})
+	case strings.HasPrefix(path, "/issues/") && r.Method == http.MethodPost:
+		params, ok := route(path, "/issues/*/comments")
+		comment := struct {
+			Body string `json:"body"`
+		}{}
+		if !ok || json.Unmarshal(body, &comment) != nil {
+			writeError(w, http.StatusNotFound, "issue does not exist")
+			return
+		}
+		no, err := strconv.Atoi(params[0])
+		if err != nil {
+			writeError(w, http.StatusNotFound, "issue does not exist")
+			return
+		}
+		s.repo.Comments = append(s.repo.Comments, Comment{IssueNo: no, Body: comment.Body})
+		writeJSON(w, http.StatusCreated, comment)
+	case (path == "/branches" || path == "/tags") && r.Method == http.MethodGet:
+		var items []interface{}
+		if path == "/branches" {
+			for _, ref := range s.repo.Branches {
+				items = append(items, map[string]interface{}{"name": ref.Name, "commit": map[string]string{"id": ref.Sha}})
+			}
+		} else {
+			// Commits of the tags have sha, not id
+			for _, ref := range s.repo.Tags {
+				items = append(items, map[string]interface{}{"name": ref.Name, "commit": map[string]string{"sha": ref.Sha}})
+			}
+		}
+		s.writeGitHubPage(w, r, items)
+	case strings.HasPrefix(path, "/branches/") && r.Method == http.MethodGet:
+		params, _ := route(path, "/branches/*")
+		var ref *git.Ref
+		if len(params) > 0 {
+			ref = s.findBranch(params[0])
+		}
+		if ref == nil {
+			writeError(w, http.StatusNotFound, "branch does not exist")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"name": ref.Name, "commit": map[string]string{"id": ref.Sha}})
+	case strings.HasPrefix(path, "/tags/") && r.Method == http.MethodGet:
+		params, _ := route(path, "/tags/*")
+		var ref *git.Ref
+		if len(params) > 0 {
+			ref = s.findTag(params[0])
+		}
+		if ref == nil {
+			writeError(w, http.StatusNotFound, "tag does not exist")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"name": ref.Name, "commit": map[string]string{"sha": ref.Sha}})
+	case path == "/pulls" && r.Method == http.MethodGet:
+		state := r.URL.Query().Get("state")
+		var items []interface{}
+		for _, pr := range s.repo.PullRequests {
+			if state != "" && state != "all" && string(pr.State) != state {
+				continue
+			}
+			items = append(items, map[string]interface{}{
+				"number":   pr.ID,
+				"title":    pr.Title,
+				"state":    pr.State,
+				"html_url": pr.URL,
+				"user":     map[string]interface{}{"id": pr.Sender.ID, "login": pr.Sender.Name, "email": pr.Sender.Email},
+				"head":     map[string]string{"ref": pr.Head.Ref, "sha": pr.Head.Sha},
+				"base":     map[string]string{"ref": pr.Base.Ref},
+			})
+		}
+		s.writeGitHubPage(w, r, items)
+	case strings.HasPrefix(path, "/compare/") && r.Method == http.MethodGet:
+		params, _ := route(path, "/compare/*")
+		var refs []string
+		if len(params) > 0 {
+			refs = strings.SplitN(params[0], "...", 2)
+		}
+		if len(refs) != 2 {
+			writeError(w, http.StatusNotFound, "invalid compare")
+			return
+		}
+		files, ok := s.repo.ChangedFiles[refs[1]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "commit does not exist")
+			return
+		}
+		var changedFiles []interface{}
+		for _, f := range files {
+			changedFiles = append(changedFiles, map[string]string{"filename": f})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"commits": []interface{}{map[string]interface{}{"sha": refs[1], "files": changedFiles}}})
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
@@ -0,0 +1,302 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+)
+
+// NewGitHubServer starts a fake GitHub API server, serving the repository
+// Requests should be authorized with 'Authorization: token <token>' header
+func NewGitHubServer(repo *Repository, token string) *Server {
+	return newServer(repo, token, func(u User) string { return u.Name }, serveGitHub)
+}
+
+func serveGitHub(s *Server, w http.ResponseWriter, r *http.Request, body []byte) {
+	if r.Header.Get("Authorization") != "token "+s.Token {
+		writeError(w, http.StatusUnauthorized, "Bad credentials")
+		return
+	}
+
+	path := r.URL.EscapedPath()
+
+	// User APIs
+	if params, ok := route(path, "/users/*"); ok && r.Method == http.MethodGet {
+		u := s.findUser(params[0])
+		if u == nil {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"login": u.Name, "id": u.ID, "email": u.Email})
+		return
+	}
+
+	// Organization APIs
+	if params, ok := route(path, "/orgs/*/members/*"); ok && r.Method == http.MethodGet {
+		if !s.isOrgMember(params[0], params[1]) {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if params, ok := route(path, "/orgs/*/repos"); ok && r.Method == http.MethodGet {
+		if params[0] != s.repo.Organization {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		var items []interface{}
+		for _, repo := range s.repo.OrganizationRepositories {
+			items = append(items, map[string]interface{}{"full_name": repo.Name, "html_url": repo.URL, "topics": repo.Topics, "archived": repo.Archived})
+		}
+		s.writeGitHubPage(w, r, items)
+		return
+	}
+
+	// Repository APIs
+	repoPrefix := "/repos/" + s.repo.Name
+	if path != repoPrefix && !strings.HasPrefix(path, repoPrefix+"/") {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	path = strings.TrimPrefix(path, repoPrefix)
+
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]interface{}{"full_name": s.repo.Name, "html_url": s.repo.URL})
+	case path == "/hooks" && r.Method == http.MethodGet:
+		var items []interface{}
+		for _, h := range s.repo.Hooks {
+			config := map[string]string{"url": h.URL, "content_type": h.ContentType}
+			if h.Secret != "" {
+				config["secret"] = "********"
+			}
+			items = append(items, map[string]interface{}{"id": h.ID, "active": h.Active, "events": h.Events, "config": config})
+		}
+		s.writeGitHubPage(w, r, items)
+	case path == "/hooks" && r.Method == http.MethodPost:
+		hook := struct {
+			Active bool     `json:"active"`
+			Events []string `json:"events"`
+			Config struct {
+				URL         string `json:"url"`
+				ContentType string `json:"content_type"`
+				Secret      string `json:"secret"`
+			} `json:"config"`
+		}{}
+		if err := json.Unmarshal(body, &hook); err != nil || hook.Config.URL == "" {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+			return
+		}
+		s.addHook(Hook{URL: hook.Config.URL, Secret: hook.Config.Secret, Events: hook.Events, ContentType: hook.Config.ContentType, Active: hook.Active})
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"id": s.nextHookID - 1})
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, "/hooks/"):
+		params, _ := route(path, "/hooks/*")
+		if len(params) == 0 || !s.deleteHook(params[0]) {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case strings.HasPrefix(path, "/statuses/") && r.Method == http.MethodPost:
+		params, _ := route(path, "/statuses/*")
+		status := struct {
+			State       string `json:"state"`
+			TargetURL   string `json:"target_url"`
+			Description string `json:"description"`
+			Context     string `json:"context"`
+		}{}
+		if len(params) == 0 || json.Unmarshal(body, &status) != nil {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+			return
+		}
+		switch status.State {
+		case "error", "failure", "pending", "success":
+		default:
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+			return
+		}
+		s.setStatus(params[0], Status{Context: status.Context, State: git.CommitStatusState(status.State), Description: status.Description, TargetURL: status.TargetURL})
+		writeJSON(w, http.StatusCreated, status)
+	case strings.HasPrefix(path, "/collaborators/") && r.Method == http.MethodGet:
+		params, ok := route(path, "/collaborators/*/permission")
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		u := s.findUser(params[0])
+		if u == nil {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		permission := "read"
+		if u.CanWrite {
+			permission = "write"
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"permission": permission})
+	case strings.HasSuffix(path, "/labels") && r.Method == http.MethodPost:
+		params, ok := route(path, "/issues/*/labels")
+		labels := struct {
+			Labels []string `json:"labels"`
+		}{}
+		var pr *git.PullRequest
+		if ok {
+			pr = s.findPullRequest(params[0])
+		}
+		if pr == nil || json.Unmarshal(body, &labels) != nil {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		for _, l := range labels.Labels {
+			addLabel(pr, l)
+		}
+		writeJSON(w, http.StatusOK, pr.Labels)
+	case strings.HasPrefix(path, "/issues/") && r.Method == http.MethodDelete:
+		params, ok := route(path, "/issues/*/labels/*")
+		var pr *git.PullRequest
+		if ok {
+			pr = s.findPullRequest(params[0])
+		}
+		if pr == nil || !removeLabel(pr, params[1]) {
+			writeError(w, http.StatusNotFound, "Label does not exist")
+			return
+		}
+		writeJSON(w, http.StatusOK, pr.Labels)
+	case strings.HasPrefix(path, "/issues/") && r.Method == http.MethodPost:
+		params, ok := route(path, "/issues/*/comments")
+		comment := struct {
+			Body string `json:"body"`
+		}{}
+		if !ok || json.Unmarshal(body, &comment) != nil {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		no, err := strconv.Atoi(params[0])
+		if err != nil {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		s.repo.Comments = append(s.repo.Comments, Comment{IssueNo: no, Body: comment.Body})
+		writeJSON(w, http.StatusCreated, comment)
+	case (path == "/branches" || path == "/tags") && r.Method == http.MethodGet:
+		refs := s.repo.Branches
+		if path == "/tags" {
+			refs = s.repo.Tags
+		}
+		var items []interface{}
+		for _, ref := range refs {
+			items = append(items, map[string]interface{}{"name": ref.Name, "commit": map[string]string{"sha": ref.Sha}})
+		}
+		s.writeGitHubPage(w, r, items)
+	case strings.HasPrefix(path, "/branches/") && r.Method == http.MethodGet:
+		params, _ := route(path, "/branches/*")
+		var ref *git.Ref
+		if len(params) > 0 {
+			ref = s.findBranch(params[0])
+		}
+		if ref == nil {
+			writeError(w, http.StatusNotFound, "Branch not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"name": ref.Name, "commit": map[string]string{"sha": ref.Sha}})
+	case strings.HasPrefix(path, "/commits/tags/") && r.Method == http.MethodGet:
+		params, _ := route(path, "/commits/tags/*")
+		for _, tag := range s.repo.Tags {
+			if len(params) > 0 && tag.Name == params[0] {
+				writeJSON(w, http.StatusOK, map[string]interface{}{"sha": tag.Sha})
+				return
+			}
+		}
+		writeError(w, http.StatusUnprocessableEntity, "No commit found for SHA")
+	case path == "/pulls" && r.Method == http.MethodGet:
+		state := r.URL.Query().Get("state")
+		if state == "" {
+			state = "open"
+		}
+		var items []interface{}
+		for i := range s.repo.PullRequests {
+			pr := &s.repo.PullRequests[i]
+			if state != "all" && string(pr.State) != state {
+				continue
+			}
+			items = append(items, gitHubPullRequest(pr))
+		}
+		s.writeGitHubPage(w, r, items)
+	case strings.HasPrefix(path, "/pulls/") && strings.HasSuffix(path, "/files") && r.Method == http.MethodGet:
+		params, _ := route(path, "/pulls/*/files")
+		for i := range s.repo.PullRequests {
+			pr := &s.repo.PullRequests[i]
+			if len(params) == 0 || strconv.Itoa(pr.ID) != params[0] {
+				continue
+			}
+			var items []interface{}
+			for _, f := range s.repo.ChangedFiles[pr.Head.Sha] {
+				items = append(items, map[string]string{"filename": f})
+			}
+			s.writeGitHubPage(w, r, items)
+			return
+		}
+		writeError(w, http.StatusNotFound, "Not Found")
+	case strings.HasPrefix(path, "/pulls/") && r.Method == http.MethodGet:
+		params, _ := route(path, "/pulls/*")
+		for i := range s.repo.PullRequests {
+			if len(params) > 0 && strconv.Itoa(s.repo.PullRequests[i].ID) == params[0] {
+				writeJSON(w, http.StatusOK, gitHubPullRequest(&s.repo.PullRequests[i]))
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "Not Found")
+	case strings.HasPrefix(path, "/compare/") && r.Method == http.MethodGet:
+		params, _ := route(path, "/compare/*")
+		var refs []string
+		if len(params) > 0 {
+			refs = strings.SplitN(params[0], "...", 2)
+		}
+		if len(refs) != 2 {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		files, ok := s.repo.ChangedFiles[refs[1]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		var items []interface{}
+		for _, f := range files {
+			items = append(items, map[string]string{"filename": f})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"files": items})
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+// writeGitHubPage writes a page of the items, with a Link header for the next page
+func (s *Server) writeGitHubPage(w http.ResponseWriter, r *http.Request, items []interface{}) {
+	p := s.paginate(r, items)
+	if p.next != 0 {
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="next", <%s%s>; rel="last"`, s.URL, pageURL(r, p.next), s.URL, pageURL(r, p.lastPage)))
+	}
+	writeJSON(w, http.StatusOK, p.items)
+}
+
+func gitHubPullRequest(pr *git.PullRequest) map[string]interface{} {
+	labels := []map[string]string{}
+	for _, l := range pr.Labels {
+		labels = append(labels, map[string]string{"name": l.Name})
+	}
+	return map[string]interface{}{
+		"number":   pr.ID,
+		"title":    pr.Title,
+		"state":    pr.State,
+		"html_url": pr.URL,
+		"user":     map[string]interface{}{"login": pr.Sender.Name, "id": pr.Sender.ID},
+		"head":     map[string]string{"ref": pr.Head.Ref, "sha": pr.Head.Sha},
+		"base":     map[string]string{"ref": pr.Base.Ref},
+		"labels":   labels,
+		"draft":    pr.Draft,
+	}
+}
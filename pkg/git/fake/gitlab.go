@@ -0,0 +1,302 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+)
+
+// gitLabWriteAccessLevel is the access level of developers, who can push to the repository
+const gitLabWriteAccessLevel = 30
+
+// NewGitLabServer starts a fake GitLab API server, serving the repository
+// Requests should be authorized with 'PRIVATE-TOKEN: <token>' header
+func NewGitLabServer(repo *Repository, token string) *Server {
+	return newServer(repo, token, func(u User) string { return strconv.Itoa(u.ID) }, serveGitLab)
+}
+
+func serveGitLab(s *Server, w http.ResponseWriter, r *http.Request, body []byte) {
+	if r.Header.Get("PRIVATE-TOKEN") != s.Token {
+		writeError(w, http.StatusUnauthorized, "401 Unauthorized")
+		return
+	}
+
+	path := r.URL.EscapedPath()
+
+	// User APIs
+	if params, ok := route(path, "/api/v4/users/*"); ok && r.Method == http.MethodGet {
+		u := s.findUser(params[0])
+		if u == nil {
+			writeError(w, http.StatusNotFound, "404 User Not Found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"id": u.ID, "username": u.Name, "public_email": u.Email})
+		return
+	}
+
+	// Group APIs
+	if params, ok := route(path, "/api/v4/groups/*/members/all/*"); ok && r.Method == http.MethodGet {
+		if !s.isOrgMember(params[0], params[1]) {
+			writeError(w, http.StatusNotFound, "404 Not found")
+			return
+		}
+		u := s.findUser(params[1])
+		writeJSON(w, http.StatusOK, map[string]interface{}{"id": u.ID, "username": u.Name, "access_level": gitLabWriteAccessLevel})
+		return
+	}
+	if params, ok := route(path, "/api/v4/groups/*/projects"); ok && r.Method == http.MethodGet {
+		if params[0] != s.repo.Organization {
+			writeError(w, http.StatusNotFound, "404 Group Not Found")
+			return
+		}
+		var items []interface{}
+		for _, repo := range s.repo.OrganizationRepositories {
+			items = append(items, map[string]interface{}{"path_with_namespace": repo.Name, "web_url": repo.URL, "topics": repo.Topics, "archived": repo.Archived})
+		}
+		s.writeGitLabPage(w, r, items)
+		return
+	}
+
+	// Project APIs
+	projectPrefix := "/api/v4/projects/" + url.QueryEscape(s.repo.Name)
+	if path != projectPrefix && !strings.HasPrefix(path, projectPrefix+"/") {
+		writeError(w, http.StatusNotFound, "404 Project Not Found")
+		return
+	}
+	path = strings.TrimPrefix(path, projectPrefix)
+
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]interface{}{"path_with_namespace": s.repo.Name, "web_url": s.repo.URL})
+	case path == "/hooks" && r.Method == http.MethodGet:
+		var items []interface{}
+		for _, h := range s.repo.Hooks {
+			item := map[string]interface{}{"id": h.ID, "url": h.URL}
+			for _, e := range h.Events {
+				item[e] = true
+			}
+			items = append(items, item)
+		}
+		s.writeGitLabPage(w, r, items)
+	case path == "/hooks" && r.Method == http.MethodPost:
+		hook := map[string]interface{}{}
+		if err := json.Unmarshal(body, &hook); err != nil {
+			writeError(w, http.StatusBadRequest, "400 Bad request")
+			return
+		}
+		hookURL, _ := hook["url"].(string)
+		if hookURL == "" {
+			writeError(w, http.StatusBadRequest, "400 Bad request - url is missing")
+			return
+		}
+		token, _ := hook["token"].(string)
+		var events []string
+		for k, v := range hook {
+			if enabled, ok := v.(bool); ok && enabled && strings.HasSuffix(k, "_events") {
+				events = append(events, k)
+			}
+		}
+		s.addHook(Hook{URL: hookURL, Secret: token, Events: events})
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"id": s.nextHookID - 1, "url": hookURL})
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, "/hooks/"):
+		params, _ := route(path, "/hooks/*")
+		if len(params) == 0 || !s.deleteHook(params[0]) {
+			writeError(w, http.StatusNotFound, "404 Not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case strings.HasPrefix(path, "/statuses/") && r.Method == http.MethodPost:
+		s.setGitLabStatus(w, path, body)
+	case strings.HasPrefix(path, "/members/all/") && r.Method == http.MethodGet:
+		params, _ := route(path, "/members/all/*")
+		var u *User
+		if len(params) > 0 {
+			u = s.findUser(params[0])
+		}
+		if u == nil {
+			writeError(w, http.StatusNotFound, "404 Not found")
+			return
+		}
+		accessLevel := 20
+		if u.CanWrite {
+			accessLevel = gitLabWriteAccessLevel
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"id": u.ID, "username": u.Name, "access_level": accessLevel})
+	case strings.HasSuffix(path, "/notes") && r.Method == http.MethodPost:
+		params, ok := route(path, "/*/*/notes")
+		comment := struct {
+			Body string `json:"body"`
+		}{}
+		if !ok || (params[0] != "issues" && params[0] != "merge_requests") || json.Unmarshal(body, &comment) != nil {
+			writeError(w, http.StatusNotFound, "404 Not found")
+			return
+		}
+		no, err := strconv.Atoi(params[1])
+		if err != nil {
+			writeError(w, http.StatusNotFound, "404 Not found")
+			return
+		}
+		s.repo.Comments = append(s.repo.Comments, Comment{IssueNo: no, Body: comment.Body})
+		writeJSON(w, http.StatusCreated, comment)
+	case (path == "/repository/branches" || path == "/repository/tags") && r.Method == http.MethodGet:
+		refs := s.repo.Branches
+		if path == "/repository/tags" {
+			refs = s.repo.Tags
+		}
+		var items []interface{}
+		for _, ref := range refs {
+			items = append(items, map[string]interface{}{"name": ref.Name, "commit": map[string]string{"id": ref.Sha}})
+		}
+		s.writeGitLabPage(w, r, items)
+	case strings.HasPrefix(path, "/repository/branches/") && r.Method == http.MethodGet:
+		params, _ := route(path, "/repository/branches/*")
+		var ref *git.Ref
+		if len(params) > 0 {
+			ref = s.findBranch(params[0])
+		}
+		if ref == nil {
+			writeError(w, http.StatusNotFound, "404 Branch Not Found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"name": ref.Name, "commit": map[string]string{"id": ref.Sha}})
+	case strings.HasPrefix(path, "/repository/tags/") && r.Method == http.MethodGet:
+		params, _ := route(path, "/repository/tags/*")
+		var ref *git.Ref
+		if len(params) > 0 {
+			ref = s.findTag(params[0])
+		}
+		if ref == nil {
+			writeError(w, http.StatusNotFound, "404 Tag Not Found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"name": ref.Name, "commit": map[string]string{"id": ref.Sha}})
+	case path == "/merge_requests" && r.Method == http.MethodGet:
+		state := r.URL.Query().Get("state")
+		var items []interface{}
+		for i := range s.repo.PullRequests {
+			pr := &s.repo.PullRequests[i]
+			mrState := "closed"
+			if pr.State == git.PullRequestStateOpen {
+				mrState = "opened"
+			}
+			if state != "" && state != "all" && mrState != state {
+				continue
+			}
+			labels := []string{}
+			for _, l := range pr.Labels {
+				labels = append(labels, l.Name)
+			}
+			items = append(items, map[string]interface{}{
+				"id":            gitLabGlobalID(pr.ID),
+				"iid":           pr.ID,
+				"title":         pr.Title,
+				"state":         mrState,
+				"web_url":       pr.URL,
+				"author":        map[string]interface{}{"id": pr.Sender.ID, "username": pr.Sender.Name},
+				"source_branch": pr.Head.Ref,
+				"target_branch": pr.Base.Ref,
+				"sha":           pr.Head.Sha,
+				"labels":        labels,
+				"draft":         pr.Draft,
+			})
+		}
+		s.writeGitLabPage(w, r, items)
+	case strings.HasPrefix(path, "/merge_requests/") && r.Method == http.MethodPut:
+		params, _ := route(path, "/merge_requests/*")
+		labels := struct {
+			AddLabels    string `json:"add_labels"`
+			RemoveLabels string `json:"remove_labels"`
+		}{}
+		var pr *git.PullRequest
+		if len(params) > 0 {
+			pr = s.findPullRequest(params[0])
+		}
+		if pr == nil || json.Unmarshal(body, &labels) != nil {
+			writeError(w, http.StatusNotFound, "404 Not found")
+			return
+		}
+		for _, l := range strings.Split(labels.AddLabels, ",") {
+			if l != "" {
+				addLabel(pr, l)
+			}
+		}
+		for _, l := range strings.Split(labels.RemoveLabels, ",") {
+			removeLabel(pr, l)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"iid": pr.ID})
+	case path == "/repository/compare" && r.Method == http.MethodGet:
+		files, ok := s.repo.ChangedFiles[r.URL.Query().Get("to")]
+		if !ok || r.URL.Query().Get("from") == "" {
+			writeError(w, http.StatusNotFound, "404 Not found")
+			return
+		}
+		var diffs []interface{}
+		for _, f := range files {
+			diffs = append(diffs, map[string]string{"old_path": f, "new_path": f})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"diffs": diffs})
+	default:
+		writeError(w, http.StatusNotFound, "404 Not found")
+	}
+}
+
+// setGitLabStatus sets a commit status
+// Like GitLab, a running status cannot be set again to the running status
+func (s *Server) setGitLabStatus(w http.ResponseWriter, path string, body []byte) {
+	params, _ := route(path, "/statuses/*")
+	status := struct {
+		State       string `json:"state"`
+		TargetURL   string `json:"target_url"`
+		Description string `json:"description"`
+		Context     string `json:"context"`
+	}{}
+	if len(params) == 0 || json.Unmarshal(body, &status) != nil {
+		writeError(w, http.StatusBadRequest, "400 Bad request")
+		return
+	}
+
+	var state git.CommitStatusState
+	switch status.State {
+	case "pending", "running":
+		state = "pending"
+	case "success":
+		state = "success"
+	case "failed":
+		state = "failure"
+	case "canceled":
+		state = "error"
+	default:
+		writeError(w, http.StatusBadRequest, "400 Bad request - state does not have a valid value")
+		return
+	}
+
+	for _, st := range s.repo.Statuses[params[0]] {
+		if st.Context == status.Context && st.State == state && status.State == "running" {
+			writeError(w, http.StatusBadRequest, "Cannot transition status via :run from :running")
+			return
+		}
+	}
+
+	s.setStatus(params[0], Status{Context: status.Context, State: state, Description: status.Description, TargetURL: status.TargetURL})
+	writeJSON(w, http.StatusCreated, status)
+}
+
+// writeGitLabPage writes a page of the items, with X-Next-Page header for the next page
+func (s *Server) writeGitLabPage(w http.ResponseWriter, r *http.Request, items []interface{}) {
+	p := s.paginate(r, items)
+	if p.next != 0 {
+		w.Header().Set("X-Next-Page", strconv.Itoa(p.next))
+	}
+	w.Header().Set("X-Per-Page", strconv.Itoa(p.perPage))
+	w.Header().Set("X-Total-Pages", strconv.Itoa(p.lastPage))
+	writeJSON(w, http.StatusOK, p.items)
+}
+
+// gitLabGlobalID returns a global ID of the merge request, which differs from its IID (ID in the project)
+func gitLabGlobalID(iid int) int {
+	return iid + 1000
+}
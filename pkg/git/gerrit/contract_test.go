@@ -0,0 +1,23 @@
+package gerrit
+
+import (
+	"testing"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"github.com/tmax-cloud/cicd-operator/pkg/git/fake"
+)
+
+func TestClientContract(t *testing.T) {
+	fake.RunContractTests(t, fake.Contract{
+		Type:      cicdv1.GitTypeGerrit,
+		NewServer: fake.NewGerritServer,
+		NewClient: func(ic *cicdv1.IntegrationConfig) git.Client {
+			return &Client{IntegrationConfig: ic}
+		},
+		Skip: map[string]string{
+			"CommitStatus": "gerrit has no commit status, the jobs are reported by reviews",
+			"Pagination":   "gerrit returns as many refs as requested, without limiting the page size",
+		},
+	})
+}
@@ -0,0 +1,629 @@
+package gerrit
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Types of gerrit stream events
+const (
+	eventTypePatchSetCreated = "patchset-created"
+	eventTypeCommentAdded    = "comment-added"
+	eventTypeRefUpdated      = "ref-updated"
+	eventTypeChangeAbandoned = "change-abandoned"
+	eventTypeChangeMerged    = "change-merged"
+)
+
+const (
+	// verifiedLabel is a review label, voted as the result of the jobs
+	verifiedLabel = "Verified"
+	// reviewTag is a tag for the review messages, so that they are shown as bot messages
+	reviewTag = "autogenerated:cicd-operator"
+
+	// jsonPrefix is a prefix of every json response, for preventing XSSI
+	jsonPrefix = ")]}'"
+)
+
+// Client is a gerrit client struct
+type Client struct {
+	IntegrationConfig *cicdv1.IntegrationConfig
+	K8sClient         client.Client
+}
+
+// ParseWebhook parses a webhook body for gerrit
+// The webhooks plugin can neither sign the payload nor set headers, so the secret is carried by the webhook url.
+// The change and the ref of the event are also fetched from the gerrit server, rather than trusting the payload
+func (c *Client) ParseWebhook(header http.Header, jsonString []byte) (*git.Webhook, error) {
+	if err := git.ValidateWebhookSecrets(c.IntegrationConfig.GetWebhookSecrets(), func(secret string) error {
+		return Validate(secret, header.Get(git.WebhookTokenHeader))
+	}); err != nil {
+		return nil, err
+	}
+
+	event := &Event{}
+	if err := json.Unmarshal(jsonString, event); err != nil {
+		return nil, err
+	}
+
+	handled, err := isHandled(event)
+	if err != nil || !handled {
+		return nil, err
+	}
+
+	if err := c.resolveEvent(event); err != nil {
+		return nil, err
+	}
+
+	switch event.Type {
+	case eventTypePatchSetCreated, eventTypeChangeAbandoned, eventTypeChangeMerged:
+		return c.parseChangeEvent(event)
+	case eventTypeRefUpdated:
+		return c.parseRefUpdatedEvent(event)
+	case eventTypeCommentAdded:
+		return c.parseCommentAddedEvent(event)
+	}
+	return nil, nil
+}
+
+// ListWebhook lists registered webhooks (remotes of the webhooks plugin)
+// Webhook tokens are stripped from the urls, and reported as SecretConfigured
+func (c *Client) ListWebhook() ([]git.WebhookEntry, error) {
+	data, _, err := c.requestHTTP(http.MethodGet, c.getWebhookAPIUrl(""), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	remotes := map[string]RemoteInfo{}
+	if err := json.Unmarshal(data, &remotes); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range remotes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result []git.WebhookEntry
+	for _, name := range names {
+		uri, token := splitWebhookToken(remotes[name].URL)
+		secretConfigured := token != "" && token == c.IntegrationConfig.Status.Secrets
+		result = append(result, git.WebhookEntry{ID: name, URL: uri, Events: remotes[name].Events, SecretConfigured: &secretConfigured})
+	}
+
+	return result, nil
+}
+
+// ExpectedWebhook returns the webhook entry expected to be registered for the url
+func (c *Client) ExpectedWebhook(uri string) git.WebhookEntry {
+	secretConfigured := true
+	return git.WebhookEntry{URL: uri, Events: c.webhookEvents(), SecretConfigured: &secretConfigured}
+}
+
+// webhookEvents returns the gerrit events which the webhook should be subscribed to
+func (c *Client) webhookEvents() []string {
+	var events []string
+	for _, ev := range git.WebhookEvents(c.IntegrationConfig) {
+		switch ev {
+		case git.EventTypePullRequest:
+			events = append(events, eventTypePatchSetCreated, eventTypeChangeAbandoned, eventTypeChangeMerged)
+		case git.EventTypePush:
+			events = append(events, eventTypeRefUpdated)
+		case git.EventTypeIssueComment:
+			events = append(events, eventTypeCommentAdded)
+		}
+	}
+
+	// The webhooks plugin sends all the events if no event is specified
+	if len(events) == 0 {
+		events = []string{eventTypeRefUpdated}
+	}
+	return events
+}
+
+// RegisterWebhook registers our webhook server to the remote git server
+// The secret is set as a query parameter of the url
+func (c *Client) RegisterWebhook(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+	query := u.Query()
+	query.Set(git.WebhookTokenQuery, c.IntegrationConfig.Status.Secrets)
+	u.RawQuery = query.Encode()
+
+	remote := RemoteInfo{
+		URL:    u.String(),
+		Events: c.webhookEvents(),
+	}
+	name := fmt.Sprintf("cicd-operator-%s-%s", c.IntegrationConfig.Namespace, c.IntegrationConfig.Name)
+
+	if _, _, err := c.requestHTTP(http.MethodPut, c.getWebhookAPIUrl(name), remote); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteWebhook deletes registered webhook
+func (c *Client) DeleteWebhook(id string) error {
+	if _, _, err := c.requestHTTP(http.MethodDelete, c.getWebhookAPIUrl(id), nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetCommitStatus reviews the patch set with a message and Verified label, in place of commit statuses
+// The label is voted considering all the jobs of the IntegrationJob, i.e., -1 if any job failed, +1 if all jobs succeeded
+// Pending states and push events are not reported, as gerrit has no place to show them
+func (c *Client) SetCommitStatus(integrationJob *cicdv1.IntegrationJob, context string, state git.CommitStatusState, description, targetURL string) error {
+	if integrationJob.Spec.Refs.Pull == nil || cicdv1.CommitStatusState(state) == cicdv1.CommitStatusStatePending {
+		return nil
+	}
+
+	apiURL := fmt.Sprintf("%s/revisions/%s/review", c.getChangeAPIUrl(integrationJob.Spec.Refs.Pull.ID), integrationJob.Spec.Refs.Pull.Sha)
+
+	review := &ReviewInput{
+		Message: fmt.Sprintf("Job %s %s: %s\n\n%s", context, state, description, targetURL),
+		Tag:     reviewTag,
+	}
+	if vote, ok := verifiedVote(integrationJob.Status.Jobs); ok {
+		review.Labels = map[string]int{verifiedLabel: vote}
+	}
+
+	if _, _, err := c.requestHTTP(http.MethodPost, apiURL, review); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetUserInfo gets a user's information
+func (c *Client) GetUserInfo(user string) (*git.User, error) {
+	apiURL := fmt.Sprintf("%s/a/accounts/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), url.PathEscape(user))
+
+	result, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var account AccountInfo
+	if err := json.Unmarshal(result, &account); err != nil {
+		return nil, err
+	}
+
+	return &git.User{
+		ID:    git.UserIDFromString(account.Username),
+		Name:  account.Username,
+		Email: account.Email,
+	}, nil
+}
+
+// CanUserWriteToRepo decides if the user can submit changes to the HEAD branch of the project
+func (c *Client) CanUserWriteToRepo(user git.User) (bool, error) {
+	head, err := c.getHead()
+	if err != nil {
+		return false, err
+	}
+
+	query := url.Values{}
+	query.Set("account", user.Name)
+	query.Set("ref", head)
+	query.Set("perm", "submit")
+	apiURL := fmt.Sprintf("%s/check.access?%s", c.getProjectAPIUrl(), query.Encode())
+
+	result, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return false, err
+	}
+
+	var accessCheck AccessCheckInfo
+	if err := json.Unmarshal(result, &accessCheck); err != nil {
+		return false, err
+	}
+
+	return accessCheck.Status == http.StatusOK, nil
+}
+
+// RegisterComment registers comment to a change, as a review message of the current revision
+func (c *Client) RegisterComment(issueType git.IssueType, issueNo int, body string) error {
+	if issueType != git.IssueTypePullRequest {
+		return fmt.Errorf("issue type %s is not supported", issueType)
+	}
+	apiURL := fmt.Sprintf("%s/revisions/current/review", c.getChangeAPIUrl(issueNo))
+
+	review := &ReviewInput{Message: body, Tag: reviewTag}
+	if _, _, err := c.requestHTTP(http.MethodPost, apiURL, review); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetRepository gets the project's information
+func (c *Client) GetRepository() (*git.Repository, error) {
+	repo := c.getRepository()
+	return &repo, nil
+}
+
+// ListBranches lists branches of the project
+func (c *Client) ListBranches() ([]git.Ref, error) {
+	return c.listRefs("branches", "refs/heads/")
+}
+
+// GetBranch gets a branch of the project
+func (c *Client) GetBranch(name string) (*git.Ref, error) {
+	return c.getRef("branches", "refs/heads/", name)
+}
+
+// ListTags lists tags of the project
+func (c *Client) ListTags() ([]git.Ref, error) {
+	return c.listRefs("tags", "refs/tags/")
+}
+
+// GetTag gets a tag of the project
+func (c *Client) GetTag(name string) (*git.Ref, error) {
+	return c.getRef("tags", "refs/tags/", name)
+}
+
+// ListOpenPullRequests lists open changes of the project
+func (c *Client) ListOpenPullRequests() ([]git.PullRequest, error) {
+	query := url.Values{}
+	query.Set("q", fmt.Sprintf("project:%s status:open", c.IntegrationConfig.Spec.Git.Repository))
+	query.Add("o", "CURRENT_REVISION")
+	query.Add("o", "DETAILED_ACCOUNTS")
+	query.Set("n", "100")
+	apiURL := fmt.Sprintf("%s/a/changes/?%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), query.Encode())
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []ChangeListItem
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return nil, err
+	}
+
+	var result []git.PullRequest
+	for _, ch := range changes {
+		revision := ch.Revisions[ch.CurrentRevision]
+		patchSet := &PatchSet{Number: revision.Number, Revision: ch.CurrentRevision, Ref: revision.Ref}
+		result = append(result, *convertChangeToShared(c.convertChangeInfo(&ch), patchSet))
+	}
+
+	return result, nil
+}
+
+// ListChangedFiles lists the files changed by the head commit
+// Changes of gerrit are single commits, so base is not used
+func (c *Client) ListChangedFiles(_, head string) ([]string, error) {
+	data, _, err := c.requestHTTP(http.MethodGet, fmt.Sprintf("%s/commits/%s/files/", c.getProjectAPIUrl(), url.PathEscape(head)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]interface{}{}
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for f := range files {
+		// Magic files are not real files of the repository
+		if f == "/COMMIT_MSG" || f == "/MERGE_LIST" {
+			continue
+		}
+		result = append(result, f)
+	}
+	sort.Strings(result)
+
+	return result, nil
+}
+
+// getRef gets a ref of the type (branches or tags). nil is returned if it does not exist
+func (c *Client) getRef(refType, prefix, name string) (*git.Ref, error) {
+	data, _, err := c.requestHTTP(http.MethodGet, fmt.Sprintf("%s/%s/%s", c.getProjectAPIUrl(), refType, url.PathEscape(name)), nil)
+	if err != nil {
+		if git.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	info := &RefInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, err
+	}
+
+	return &git.Ref{Name: strings.TrimPrefix(info.Ref, prefix), Sha: info.sha()}, nil
+}
+
+func (c *Client) listRefs(refType, prefix string) ([]git.Ref, error) {
+	data, _, err := c.requestHTTPAll(http.MethodGet, fmt.Sprintf("%s/%s/?n=100", c.getProjectAPIUrl(), refType), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []RefInfo
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return nil, err
+	}
+
+	var result []git.Ref
+	for _, r := range refs {
+		// Skip HEAD, refs/meta/config, etc.
+		if !strings.HasPrefix(r.Ref, prefix) {
+			continue
+		}
+		result = append(result, git.Ref{Name: strings.TrimPrefix(r.Ref, prefix), Sha: r.sha()})
+	}
+
+	return result, nil
+}
+
+// isHandled checks if the event is handled, before resolving it from the gerrit server
+// Only branches and tags are handled for ref-updated events (not changes, meta refs, etc.), and deleted refs are skipped
+func isHandled(event *Event) (bool, error) {
+	switch event.Type {
+	case eventTypePatchSetCreated, eventTypeChangeAbandoned, eventTypeChangeMerged:
+		if event.Change == nil || event.PatchSet == nil {
+			return false, fmt.Errorf("change or patchSet is not set for %s event", event.Type)
+		}
+	case eventTypeCommentAdded:
+		if event.Change == nil || event.PatchSet == nil || event.Author == nil {
+			return false, fmt.Errorf("change, patchSet or author is not set for %s event", event.Type)
+		}
+	case eventTypeRefUpdated:
+		if event.RefUpdate == nil {
+			return false, fmt.Errorf("refUpdate is not set for %s event", event.Type)
+		}
+		ref := normalizeRef(event.RefUpdate.RefName)
+		return (strings.HasPrefix(ref, "refs/heads/") || strings.HasPrefix(ref, "refs/tags/")) && event.RefUpdate.NewRev != zeroRevision, nil
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// resolveEvent verifies if the event really happened in the gerrit server,
+// and replaces the change, the patch set and the ref of the event with the ones fetched from the gerrit server
+func (c *Client) resolveEvent(event *Event) error {
+	invalidErr := fmt.Errorf("invalid request : %s event cannot be verified", event.Type)
+
+	if event.Type == eventTypeRefUpdated {
+		// Ref should point to the new revision
+		ref := normalizeRef(event.RefUpdate.RefName)
+		refType, name := "branches", strings.TrimPrefix(ref, "refs/heads/")
+		if strings.HasPrefix(ref, "refs/tags/") {
+			refType, name = "tags", strings.TrimPrefix(ref, "refs/tags/")
+		}
+		data, _, err := c.requestHTTP(http.MethodGet, fmt.Sprintf("%s/%s/%s", c.getProjectAPIUrl(), refType, url.PathEscape(name)), nil)
+		if err != nil {
+			return invalidErr
+		}
+		info := &RefInfo{}
+		if err := json.Unmarshal(data, info); err != nil || (info.Revision != event.RefUpdate.NewRev && info.Object != event.RefUpdate.NewRev) {
+			return invalidErr
+		}
+		event.RefUpdate.RefName = info.Ref
+		event.RefUpdate.Project = c.IntegrationConfig.Spec.Git.Repository
+		return nil
+	}
+
+	// Patch set should exist in the change
+	query := url.Values{}
+	query.Add("o", "ALL_REVISIONS")
+	query.Add("o", "DETAILED_ACCOUNTS")
+	data, _, err := c.requestHTTP(http.MethodGet, fmt.Sprintf("%s?%s", c.getChangeAPIUrl(event.Change.Number), query.Encode()), nil)
+	if err != nil {
+		return invalidErr
+	}
+	change := &ChangeListItem{}
+	if err := json.Unmarshal(data, change); err != nil {
+		return invalidErr
+	}
+	revision, ok := change.Revisions[event.PatchSet.Revision]
+	if !ok {
+		return invalidErr
+	}
+
+	switch event.Type {
+	case eventTypeChangeAbandoned, eventTypeChangeMerged:
+		// Change should be closed
+		if change.Status != "ABANDONED" && change.Status != "MERGED" {
+			return invalidErr
+		}
+	case eventTypeCommentAdded:
+		// Same message should exist
+		data, _, err := c.requestHTTP(http.MethodGet, c.getChangeAPIUrl(event.Change.Number)+"/messages", nil)
+		if err != nil {
+			return invalidErr
+		}
+		var messages []ChangeMessageInfo
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return invalidErr
+		}
+		found := false
+		for _, m := range messages {
+			if m.Author.Username == event.Author.Username && m.Message == event.Comment {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return invalidErr
+		}
+	}
+
+	event.Change = c.convertChangeInfo(change)
+	event.PatchSet = &PatchSet{Number: revision.Number, Revision: event.PatchSet.Revision, Ref: revision.Ref}
+	return nil
+}
+
+// convertChangeInfo converts the change fetched from the gerrit server to the change of the events
+func (c *Client) convertChangeInfo(change *ChangeListItem) *Change {
+	return &Change{
+		Project: c.IntegrationConfig.Spec.Git.Repository,
+		Branch:  change.Branch,
+		Number:  change.Number,
+		Subject: change.Subject,
+		Owner:   Account{Name: change.Owner.Name, Email: change.Owner.Email, Username: change.Owner.Username},
+		URL:     fmt.Sprintf("%s/c/%s/+/%d", c.IntegrationConfig.Spec.Git.GetAPIUrl(), c.IntegrationConfig.Spec.Git.Repository, change.Number),
+		Status:  change.Status,
+	}
+}
+
+// splitWebhookToken splits the webhook url into the url without the token and the token
+func splitWebhookToken(uri string) (string, string) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri, ""
+	}
+	query := u.Query()
+	token := query.Get(git.WebhookTokenQuery)
+	query.Del(git.WebhookTokenQuery)
+	u.RawQuery = query.Encode()
+	return u.String(), token
+}
+
+// Validate validates the webhook token of the request
+func Validate(secret, token string) error {
+	if secret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(token)) != 1 {
+		return fmt.Errorf("invalid request : webhook token does not match secret")
+	}
+	return nil
+}
+
+func (c *Client) getHead() (string, error) {
+	data, _, err := c.requestHTTP(http.MethodGet, c.getProjectAPIUrl()+"/HEAD", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var head string
+	if err := json.Unmarshal(data, &head); err != nil {
+		return "", err
+	}
+	return head, nil
+}
+
+func (c *Client) getProjectAPIUrl() string {
+	return fmt.Sprintf("%s/a/projects/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), url.PathEscape(c.IntegrationConfig.Spec.Git.Repository))
+}
+
+func (c *Client) getChangeAPIUrl(number int) string {
+	return fmt.Sprintf("%s/a/changes/%s~%d", c.IntegrationConfig.Spec.Git.GetAPIUrl(), url.PathEscape(c.IntegrationConfig.Spec.Git.Repository), number)
+}
+
+func (c *Client) getWebhookAPIUrl(name string) string {
+	return fmt.Sprintf("%s/a/config/server/webhooks~projects/%s/remotes/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), url.PathEscape(c.IntegrationConfig.Spec.Git.Repository), url.PathEscape(name))
+}
+
+func (c *Client) requestHTTP(method, apiURL string, data interface{}) ([]byte, http.Header, error) {
+	httpCli, header, err := c.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, respHeader, err := httpCli.Request(method, apiURL, header, data)
+	return bytes.TrimPrefix(body, []byte(jsonPrefix)), respHeader, err
+}
+
+// requestHTTPAll requests api call, following all the pages by S (skip) query parameter
+// Next page is requested if the page is full (has n items), or its last item has _more_changes (for change queries)
+func (c *Client) requestHTTPAll(method, apiURL string, data interface{}) ([]byte, http.Header, error) {
+	httpCli, header, err := c.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli.RequestPages(method, apiURL, header, data, func(current string, body []byte, _ http.Header) ([]json.RawMessage, string, error) {
+		var items []json.RawMessage
+		if err := json.Unmarshal(bytes.TrimPrefix(body, []byte(jsonPrefix)), &items); err != nil {
+			return nil, "", err
+		}
+		if len(items) == 0 {
+			return items, "", nil
+		}
+
+		u, err := url.Parse(current)
+		if err != nil {
+			return nil, "", err
+		}
+		limit, _ := strconv.Atoi(u.Query().Get("n"))
+		last := &struct {
+			MoreChanges bool `json:"_more_changes"`
+		}{}
+		_ = json.Unmarshal(items[len(items)-1], last)
+		if !last.MoreChanges && (limit == 0 || len(items) < limit) {
+			return items, "", nil
+		}
+
+		skip, _ := strconv.Atoi(u.Query().Get("S"))
+		next, err := git.SetQuery(current, "S", strconv.Itoa(skip+len(items)))
+		return items, next, err
+	})
+}
+
+// httpClient returns a http client and a request header for the API server
+func (c *Client) httpClient() (*git.HTTPClient, map[string]string, error) {
+	token, err := c.IntegrationConfig.GetToken(c.K8sClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := map[string]string{
+		// Token should be in a form of <user name>:<http password>
+		"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(token)),
+		"Content-Type":  "application/json",
+	}
+
+	httpCli, err := git.GetHTTPClient(c.IntegrationConfig, c.K8sClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli, header, nil
+}
+
+// verifiedVote decides the Verified label's vote from the jobs' status
+// ok is false if the vote cannot be decided yet
+func verifiedVote(jobs []cicdv1.JobStatus) (vote int, ok bool) {
+	allSucceeded := true
+	for _, j := range jobs {
+		switch j.State {
+		case cicdv1.CommitStatusStateFailure, cicdv1.CommitStatusStateError:
+			return -1, true
+		case cicdv1.CommitStatusStateSuccess:
+		default:
+			allSucceeded = false
+		}
+	}
+	if !allSucceeded || len(jobs) == 0 {
+		return 0, false
+	}
+	return 1, true
+}
+
+// trimCommentHeader trims the 'Patch Set <n>: <votes>' header of the comment
+func trimCommentHeader(comment string) string {
+	if !strings.HasPrefix(comment, "Patch Set ") {
+		return comment
+	}
+	lines := strings.SplitN(comment, "\n", 2)
+	if len(lines) < 2 {
+		return ""
+	}
+	return strings.TrimLeft(lines[1], "\n")
+}
@@ -0,0 +1,68 @@
+package gerrit
+
+// AccountInfo is a body of account get API
+type AccountInfo struct {
+	AccountID int    `json:"_account_id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+}
+
+// ChangeInfo is a body of change get API
+type ChangeInfo struct {
+	Number int    `json:"_number"`
+	Status string `json:"status"`
+}
+
+// ChangeMessageInfo is a message of the change
+type ChangeMessageInfo struct {
+	Author  AccountInfo `json:"author"`
+	Message string      `json:"message"`
+}
+
+// AccessCheckInfo is a body of access check API
+type AccessCheckInfo struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// ReviewInput is an API body for reviewing a revision
+type ReviewInput struct {
+	Message string         `json:"message"`
+	Tag     string         `json:"tag,omitempty"`
+	Labels  map[string]int `json:"labels,omitempty"`
+	Notify  string         `json:"notify,omitempty"`
+}
+
+// RefInfo is a branch or a tag of branch/tag list API
+type RefInfo struct {
+	Ref      string `json:"ref"`
+	Revision string `json:"revision"`
+	// Object is a commit id of annotated tags
+	Object string `json:"object,omitempty"`
+}
+
+// sha returns the commit id of the ref, which is the object for annotated tags
+func (r *RefInfo) sha() string {
+	if r.Object != "" {
+		return r.Object
+	}
+	return r.Revision
+}
+
+// ChangeListItem is a change of change query API
+type ChangeListItem struct {
+	Number          int                     `json:"_number"`
+	Subject         string                  `json:"subject"`
+	Branch          string                  `json:"branch"`
+	Status          string                  `json:"status"`
+	Owner           AccountInfo             `json:"owner"`
+	CurrentRevision string                  `json:"current_revision"`
+	Revisions       map[string]RevisionInfo `json:"revisions"`
+}
+
+// RevisionInfo is a revision (patch set) of a change
+type RevisionInfo struct {
+	Number int    `json:"_number"`
+	Ref    string `json:"ref"`
+}
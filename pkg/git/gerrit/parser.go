@@ -0,0 +1,95 @@
+package gerrit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const zeroRevision = "0000000000000000000000000000000000000000"
+
+func (c *Client) parseChangeEvent(event *Event) (*git.Webhook, error) {
+	pullRequest := convertChangeToShared(event.Change, event.PatchSet)
+	switch event.Type {
+	case eventTypePatchSetCreated:
+		if event.PatchSet.Number == 1 {
+			pullRequest.Action = git.PullRequestActionOpen
+		} else {
+			pullRequest.Action = git.PullRequestActionSynchronize
+		}
+	default:
+		pullRequest.State = git.PullRequestStateClosed
+		pullRequest.Action = git.PullRequestActionClose
+	}
+
+	return &git.Webhook{EventType: git.EventTypePullRequest, Repo: c.getRepository(), PullRequest: pullRequest}, nil
+}
+
+func (c *Client) parseRefUpdatedEvent(event *Event) (*git.Webhook, error) {
+	ref := normalizeRef(event.RefUpdate.RefName)
+
+	var sender git.User
+	if event.Submitter != nil {
+		sender = convertAccount(event.Submitter)
+	}
+
+	push := git.Push{Sender: sender, Ref: ref, Before: event.RefUpdate.OldRev, Sha: event.RefUpdate.NewRev}
+	return &git.Webhook{EventType: git.EventTypePush, Repo: c.getRepository(), Push: &push}, nil
+}
+
+func (c *Client) parseCommentAddedEvent(event *Event) (*git.Webhook, error) {
+	createdAt := metav1.NewTime(time.Unix(event.EventCreatedOn, 0))
+	return &git.Webhook{EventType: git.EventTypeIssueComment, Repo: c.getRepository(), IssueComment: &git.IssueComment{
+		Comment: git.Comment{
+			Body:      trimCommentHeader(event.Comment),
+			CreatedAt: &createdAt,
+		},
+		Issue: git.Issue{
+			PullRequest: convertChangeToShared(event.Change, event.PatchSet),
+		},
+		Sender: convertAccount(event.Author),
+	}}, nil
+}
+
+// normalizeRef returns the full name of the ref
+// Older gerrit versions send branch names without refs/heads/ prefix
+func normalizeRef(ref string) string {
+	if !strings.HasPrefix(ref, "refs/") {
+		return "refs/heads/" + ref
+	}
+	return ref
+}
+
+// getRepository returns the repository. Repositories are cloned via /a/ path, which requires authentication
+func (c *Client) getRepository() git.Repository {
+	apiURL := strings.TrimSuffix(c.IntegrationConfig.Spec.Git.GetAPIUrl(), "/")
+	return git.Repository{
+		Name:     c.IntegrationConfig.Spec.Git.Repository,
+		URL:      fmt.Sprintf("%s/admin/repos/%s", apiURL, c.IntegrationConfig.Spec.Git.Repository),
+		CloneURL: fmt.Sprintf("%s/a/%s", apiURL, c.IntegrationConfig.Spec.Git.Repository),
+	}
+}
+
+func convertChangeToShared(change *Change, patchSet *PatchSet) *git.PullRequest {
+	state := git.PullRequestStateClosed
+	if change.Status == "" || change.Status == "NEW" {
+		state = git.PullRequestStateOpen
+	}
+
+	return &git.PullRequest{
+		ID:     change.Number,
+		Title:  change.Subject,
+		State:  state,
+		Sender: convertAccount(&change.Owner),
+		URL:    change.URL,
+		Base:   git.Base{Ref: change.Branch},
+		Head:   git.Head{Ref: patchSet.Ref, Sha: patchSet.Revision},
+	}
+}
+
+func convertAccount(account *Account) git.User {
+	return git.User{ID: git.UserIDFromString(account.Username), Name: account.Username, Email: account.Email}
+}
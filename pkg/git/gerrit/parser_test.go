@@ -0,0 +1,202 @@
+package gerrit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	testPatchSetCreatedBody = `{
+  "type": "patchset-created",
+  "change": {
+    "project": "cicd-operator",
+    "branch": "master",
+    "id": "I3a2c7e1b9f0d4c5e6a7b8c9d0e1f2a3b4c5d6e7f",
+    "number": 1234,
+    "subject": "test change",
+    "owner": {"name": "Author", "email": "author@tmax.co.kr", "username": "author"},
+    "url": "https://gerrit.tmax.io/c/cicd-operator/+/1234",
+    "status": "NEW"
+  },
+  "patchSet": {"number": 2, "revision": "6dcb09b5b57875f334f61aebed695e2e4193db5e", "ref": "refs/changes/34/1234/2"},
+  "uploader": {"name": "Author", "email": "author@tmax.co.kr", "username": "author"},
+  "eventCreatedOn": 1612336403
+}`
+
+	testCommentAddedBody = `{
+  "type": "comment-added",
+  "change": {
+    "project": "cicd-operator",
+    "branch": "master",
+    "number": 1234,
+    "subject": "test change",
+    "owner": {"name": "Author", "email": "author@tmax.co.kr", "username": "author"},
+    "url": "https://gerrit.tmax.io/c/cicd-operator/+/1234",
+    "status": "NEW"
+  },
+  "patchSet": {"number": 2, "revision": "6dcb09b5b57875f334f61aebed695e2e4193db5e", "ref": "refs/changes/34/1234/2"},
+  "author": {"name": "Reviewer", "email": "reviewer@tmax.co.kr", "username": "reviewer"},
+  "comment": "Patch Set 2: Code-Review+1\n\n/test",
+  "eventCreatedOn": 1612336403
+}`
+
+	testRefUpdatedBody = `{
+  "type": "ref-updated",
+  "submitter": {"name": "Author", "email": "author@tmax.co.kr", "username": "author"},
+  "refUpdate": {
+    "oldRev": "aad331d8d3b131fa9ae03cf5e53965b51942618a",
+    "newRev": "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+    "refName": "refs/heads/master",
+    "project": "cicd-operator"
+  },
+  "eventCreatedOn": 1612336403
+}`
+)
+
+func testServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a/changes/cicd-operator~1234":
+			_, _ = w.Write([]byte(")]}'\n{\"_number\": 1234, \"subject\": \"test change\", \"branch\": \"master\", \"status\": \"NEW\", \"owner\": {\"name\": \"Author\", \"email\": \"author@tmax.co.kr\", \"username\": \"author\"}, \"current_revision\": \"6dcb09b5b57875f334f61aebed695e2e4193db5e\", \"revisions\": {\"6dcb09b5b57875f334f61aebed695e2e4193db5e\": {\"_number\": 2, \"ref\": \"refs/changes/34/1234/2\"}}}"))
+		case "/a/changes/cicd-operator~1234/messages":
+			_, _ = w.Write([]byte(")]}'\n[{\"author\": {\"username\": \"reviewer\"}, \"message\": \"Patch Set 2: Code-Review+1\\n\\n/test\"}]"))
+		case "/a/projects/cicd-operator/branches/master":
+			_, _ = w.Write([]byte(")]}'\n{\"ref\": \"refs/heads/master\", \"revision\": \"6dcb09b5b57875f334f61aebed695e2e4193db5e\"}"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func testClient(apiURL string) *Client {
+	return &Client{
+		IntegrationConfig: &cicdv1.IntegrationConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+			Spec: cicdv1.IntegrationConfigSpec{
+				Git: cicdv1.GitConfig{
+					Type:       cicdv1.GitTypeGerrit,
+					Repository: "cicd-operator",
+					APIUrl:     apiURL,
+					Token:      cicdv1.GitToken{Value: "bot:test-password"},
+				},
+			},
+			Status: cicdv1.IntegrationConfigStatus{Secrets: "test-secret"},
+		},
+	}
+}
+
+func testHeader(token string) http.Header {
+	header := http.Header{}
+	header.Set(git.WebhookTokenHeader, token)
+	return header
+}
+
+func TestClient_ParseWebhook(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	c := testClient(srv.URL)
+
+	// Patch set created
+	wh, err := c.ParseWebhook(testHeader("test-secret"), []byte(testPatchSetCreatedBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, git.EventTypePullRequest, wh.EventType)
+	assert.Equal(t, "cicd-operator", wh.Repo.Name)
+	assert.Equal(t, srv.URL+"/a/cicd-operator", wh.Repo.CloneURL)
+	assert.Equal(t, 1234, wh.PullRequest.ID)
+	assert.Equal(t, git.PullRequestActionSynchronize, wh.PullRequest.Action)
+	assert.Equal(t, git.PullRequestStateOpen, wh.PullRequest.State)
+	assert.Equal(t, "master", wh.PullRequest.Base.Ref)
+	assert.Equal(t, "refs/changes/34/1234/2", wh.PullRequest.Head.Ref)
+	assert.Equal(t, "6dcb09b5b57875f334f61aebed695e2e4193db5e", wh.PullRequest.Head.Sha)
+	assert.Equal(t, "author", wh.PullRequest.Sender.Name)
+
+	// Comment added
+	wh, err = c.ParseWebhook(testHeader("test-secret"), []byte(testCommentAddedBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, git.EventTypeIssueComment, wh.EventType)
+	assert.Equal(t, "/test", wh.IssueComment.Comment.Body)
+	assert.Equal(t, "reviewer", wh.IssueComment.Sender.Name)
+	assert.Equal(t, 1234, wh.IssueComment.Issue.PullRequest.ID)
+
+	// Ref updated
+	wh, err = c.ParseWebhook(testHeader("test-secret"), []byte(testRefUpdatedBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, git.EventTypePush, wh.EventType)
+	assert.Equal(t, "refs/heads/master", wh.Push.Ref)
+	assert.Equal(t, "6dcb09b5b57875f334f61aebed695e2e4193db5e", wh.Push.Sha)
+
+	// Spoofed payload is overwritten by the change of the gerrit server
+	wh, err = c.ParseWebhook(testHeader("test-secret"), []byte(strings.NewReplacer(`"branch": "master"`, `"branch": "release"`, `"username": "author"`, `"username": "admin"`).Replace(testPatchSetCreatedBody)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "master", wh.PullRequest.Base.Ref)
+	assert.Equal(t, "author", wh.PullRequest.Sender.Name)
+
+	// Ref not pointing to the new revision
+	_, err = c.ParseWebhook(testHeader("test-secret"), []byte(strings.Replace(testRefUpdatedBody, `"newRev": "6dcb09b5b57875f334f61aebed695e2e4193db5e"`, `"newRev": "0123456789abcdef0123456789abcdef01234567"`, 1)))
+	assert.NotEqual(t, nil, err)
+
+	// Ref not handled
+	wh, err = c.ParseWebhook(testHeader("test-secret"), []byte(strings.Replace(testRefUpdatedBody, "refs/heads/master", "refs/meta/config", 1)))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, (*git.Webhook)(nil), wh)
+
+	// Invalid token
+	_, err = c.ParseWebhook(testHeader("wrong-secret"), []byte(testPatchSetCreatedBody))
+	assert.NotEqual(t, nil, err)
+	_, err = c.ParseWebhook(http.Header{}, []byte(testPatchSetCreatedBody))
+	assert.NotEqual(t, nil, err)
+
+	// Unverifiable event
+	notFoundSrv := httptest.NewServer(http.NotFoundHandler())
+	defer notFoundSrv.Close()
+	c = testClient(notFoundSrv.URL)
+	_, err = c.ParseWebhook(testHeader("test-secret"), []byte(testPatchSetCreatedBody))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestClient_ListWebhook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(")]}'\n{\"cicd-operator-default-test-ic\": {\"url\": \"http://cicd.tmax.io/webhook/default/test-ic?token=test-secret\"}, \"stale\": {\"url\": \"http://cicd.tmax.io/webhook/default/stale?token=old-secret\"}}"))
+	}))
+	defer srv.Close()
+
+	c := testClient(srv.URL)
+	entries, err := c.ListWebhook()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "http://cicd.tmax.io/webhook/default/test-ic", entries[0].URL)
+	assert.Equal(t, true, *entries[0].SecretConfigured)
+	assert.Equal(t, "http://cicd.tmax.io/webhook/default/stale", entries[1].URL)
+	assert.Equal(t, false, *entries[1].SecretConfigured)
+}
+
+func TestVerifiedVote(t *testing.T) {
+	_, ok := verifiedVote([]cicdv1.JobStatus{{State: cicdv1.CommitStatusStateSuccess}, {State: cicdv1.CommitStatusStatePending}})
+	assert.Equal(t, false, ok)
+
+	vote, ok := verifiedVote([]cicdv1.JobStatus{{State: cicdv1.CommitStatusStateFailure}, {State: cicdv1.CommitStatusStatePending}})
+	assert.Equal(t, true, ok)
+	assert.Equal(t, -1, vote)
+
+	vote, ok = verifiedVote([]cicdv1.JobStatus{{State: cicdv1.CommitStatusStateSuccess}, {State: cicdv1.CommitStatusStateSuccess}})
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, vote)
+}
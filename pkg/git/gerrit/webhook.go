@@ -0,0 +1,63 @@
+package gerrit
+
+// Event is a gerrit stream event, sent by the webhooks plugin
+// Fields are filled up depending on the event type
+type Event struct {
+	Type string `json:"type"`
+
+	Change    *Change    `json:"change,omitempty"`
+	PatchSet  *PatchSet  `json:"patchSet,omitempty"`
+	RefUpdate *RefUpdate `json:"refUpdate,omitempty"`
+
+	// Uploader is set for patchset-created events
+	Uploader *Account `json:"uploader,omitempty"`
+	// Author and Comment are set for comment-added events
+	Author  *Account `json:"author,omitempty"`
+	Comment string   `json:"comment,omitempty"`
+	// Submitter is set for ref-updated and change-merged events
+	Submitter *Account `json:"submitter,omitempty"`
+	// Abandoner is set for change-abandoned events
+	Abandoner *Account `json:"abandoner,omitempty"`
+
+	EventCreatedOn int64 `json:"eventCreatedOn"`
+}
+
+// Change is a change (i.e., pull request) info
+type Change struct {
+	Project string  `json:"project"`
+	Branch  string  `json:"branch"`
+	ID      string  `json:"id"`
+	Number  int     `json:"number"`
+	Subject string  `json:"subject"`
+	Owner   Account `json:"owner"`
+	URL     string  `json:"url"`
+	Status  string  `json:"status"`
+}
+
+// PatchSet is a patch set of the change
+type PatchSet struct {
+	Number   int    `json:"number"`
+	Revision string `json:"revision"`
+	Ref      string `json:"ref"`
+}
+
+// RefUpdate is an updated ref info
+type RefUpdate struct {
+	OldRev  string `json:"oldRev"`
+	NewRev  string `json:"newRev"`
+	RefName string `json:"refName"`
+	Project string `json:"project"`
+}
+
+// Account is a gerrit user account
+type Account struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+// RemoteInfo is a webhook (remote) configuration of the webhooks plugin
+type RemoteInfo struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events,omitempty"`
+}
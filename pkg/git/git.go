@@ -1,7 +1,11 @@
 package git
 
 import (
+	"fmt"
+	"hash/fnv"
 	"net/http"
+	"sort"
+	"strings"
 
 	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
 )
@@ -11,7 +15,7 @@ type Client interface {
 	// Webhooks
 	ListWebhook() ([]WebhookEntry, error)
 	RegisterWebhook(url string) error
-	DeleteWebhook(id int) error
+	DeleteWebhook(id string) error
 	ParseWebhook(http.Header, []byte) (*Webhook, error)
 
 	// Commit Status
@@ -23,6 +27,76 @@ type Client interface {
 
 	// Comments
 	RegisterComment(issueType IssueType, issueNo int, body string) error
+
+	// Repository, refs and pull requests
+	GetRepository() (*Repository, error)
+	ListBranches() ([]Ref, error)
+	// GetBranch gets a branch of the repository. nil is returned if the branch does not exist
+	GetBranch(name string) (*Ref, error)
+	ListTags() ([]Ref, error)
+	// GetTag gets a tag of the repository, with the sha of the commit it points to. nil is returned if the tag does not exist
+	GetTag(name string) (*Ref, error)
+	ListOpenPullRequests() ([]PullRequest, error)
+
+	// Changed files
+	// ListChangedFiles lists the files changed by the head commit, compared to the merge base of base and head
+	// base is a branch name or a commit sha. An error is returned if the files may be truncated
+	ListChangedFiles(base, head string) ([]string, error)
+}
+
+// CheckRunClient is a git client which can report the jobs' results as check runs, rather than commit statuses
+type CheckRunClient interface {
+	Client
+
+	// CheckRunEnabled returns if check runs can be used for the repository
+	CheckRunEnabled() bool
+	SetCheckRun(integrationJob *cicdv1.IntegrationJob, jobStatus *cicdv1.JobStatus, detailsURL string) error
+}
+
+// WebhookDriftDetector is a git client which can report the expected configuration of its webhook,
+// to detect the drifts of the registered webhook
+type WebhookDriftDetector interface {
+	// ExpectedWebhook returns the webhook entry expected to be registered for the url
+	ExpectedWebhook(url string) WebhookEntry
+}
+
+// PullRequestFilesClient is a git client which can list the files changed by a pull request, without the limit of the
+// number of files for comparing commits (github)
+type PullRequestFilesClient interface {
+	// ListPullRequestChangedFiles lists the files changed by the pull request. An error is returned if the files may be truncated
+	ListPullRequestChangedFiles(id int) ([]string, error)
+}
+
+// OrganizationClient is a git client which can list the repositories of an organization (github) or a group (gitlab)
+type OrganizationClient interface {
+	ListOrganizationRepositories(organization string) ([]OrganizationRepository, error)
+}
+
+// OrganizationMemberClient is a git client which can check if a user is a member of the organization (github)
+// or the group (gitlab) the repository belongs to
+type OrganizationMemberClient interface {
+	IsOrganizationMember(user User) (bool, error)
+}
+
+// LabelClient is a git client which can add or remove labels of the pull requests
+type LabelClient interface {
+	AddLabel(issueNo int, label string) error
+	RemoveLabel(issueNo int, label string) error
+}
+
+// OrganizationRepository is a repository of an organization
+type OrganizationRepository struct {
+	// Name is a full name of the repository (e.g., tmax-cloud/cicd-operator)
+	Name     string
+	URL      string
+	Topics   []string
+	Archived bool
+}
+
+// Ref is a branch or a tag, and the commit it points to
+type Ref struct {
+	Name string
+	Sha  string
 }
 
 // IssueType is a type of the issue
@@ -36,3 +110,69 @@ const (
 
 // CommitStatusState is a commit status type
 type CommitStatusState string
+
+// UserIDFromString converts a non-numeric user identifier (e.g., UUID) into an integer user id
+// It is for the git servers which do not identify users by integers
+func UserIDFromString(id string) int {
+	if id == "" {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return int(h.Sum32() & 0x7fffffff)
+}
+
+// ValidateWebhookSecrets validates the webhook with each of the secrets, and succeeds if any of them is valid
+// Multiple secrets are accepted during the grace period of the secret rotation
+func ValidateWebhookSecrets(secrets []string, validate func(secret string) error) error {
+	err := fmt.Errorf("no webhook secret is configured")
+	for _, secret := range secrets {
+		if err = validate(secret); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// Reasons of the webhook drifts
+const (
+	WebhookDriftReasonEvents      = "webhookEventsChanged"
+	WebhookDriftReasonContentType = "webhookContentTypeChanged"
+	WebhookDriftReasonSecret      = "webhookSecretMissing"
+	WebhookDriftReasonInactive    = "webhookInactive"
+)
+
+// WebhookDrift compares the registered webhook with the expected one, and returns the reason and the message of the drift
+// Fields not reported by the git server are not compared. Empty reason is returned if the webhook is up to date
+func WebhookDrift(expected, registered WebhookEntry) (string, string) {
+	if registered.Active != nil && expected.Active != nil && *registered.Active != *expected.Active {
+		return WebhookDriftReasonInactive, "webhook is not active"
+	}
+	if registered.Events != nil && !sameStrings(expected.Events, registered.Events) {
+		return WebhookDriftReasonEvents, fmt.Sprintf("webhook events [%s] are different from [%s]", strings.Join(registered.Events, ", "), strings.Join(expected.Events, ", "))
+	}
+	if registered.ContentType != "" && registered.ContentType != expected.ContentType {
+		return WebhookDriftReasonContentType, fmt.Sprintf("webhook content type %s is different from %s", registered.ContentType, expected.ContentType)
+	}
+	if registered.SecretConfigured != nil && expected.SecretConfigured != nil && *expected.SecretConfigured && !*registered.SecretConfigured {
+		return WebhookDriftReasonSecret, "webhook secret is not configured"
+	}
+	return "", ""
+}
+
+// sameStrings returns if the two lists have the same strings, regardless of the order
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+)
+
+func TestWebhookDrift(t *testing.T) {
+	yes, no := true, false
+	expected := WebhookEntry{URL: "http://cicd.tmax.co.kr/webhook/default/test", Events: []string{"push", "pull_request"}, ContentType: "json", SecretConfigured: &yes, Active: &yes}
+
+	tc := map[string]struct {
+		registered WebhookEntry
+		reason     string
+	}{
+		"upToDate":           {registered: WebhookEntry{Events: []string{"pull_request", "push"}, ContentType: "json", SecretConfigured: &yes, Active: &yes}},
+		"notReported":        {registered: WebhookEntry{}},
+		"inactive":           {registered: WebhookEntry{Active: &no}, reason: WebhookDriftReasonInactive},
+		"eventsChanged":      {registered: WebhookEntry{Events: []string{"push"}}, reason: WebhookDriftReasonEvents},
+		"contentTypeChanged": {registered: WebhookEntry{ContentType: "form"}, reason: WebhookDriftReasonContentType},
+		"secretMissing":      {registered: WebhookEntry{SecretConfigured: &no}, reason: WebhookDriftReasonSecret},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			reason, message := WebhookDrift(expected, c.registered)
+			assert.Equal(t, c.reason, reason)
+			assert.Equal(t, c.reason == "", message == "")
+		})
+	}
+}
+
+func TestWebhookEvents(t *testing.T) {
+	ic := &cicdv1.IntegrationConfig{}
+	assert.Equal(t, 0, len(WebhookEvents(ic)))
+
+	// Every needed event, if no plugin is registered
+	ic.Spec.Jobs.PreSubmit = cicdv1.Jobs{{}}
+	assert.Equal(t, []EventType{EventTypePullRequest, EventTypeIssueComment}, WebhookEvents(ic))
+
+	// Only the consumed events
+	AddConsumedEvents(EventTypePullRequest, EventTypePush)
+	defer func() { consumedEvents = map[EventType]bool{} }()
+	ic.Spec.Jobs.PostSubmit = cicdv1.Jobs{{}}
+	assert.Equal(t, []EventType{EventTypePullRequest, EventTypePush}, WebhookEvents(ic))
+
+	// Release events, if there are release jobs
+	AddConsumedEvents(EventTypeRelease)
+	ic.Spec.Jobs.Release = cicdv1.Jobs{{}}
+	assert.Equal(t, []EventType{EventTypePullRequest, EventTypePush, EventTypeRelease}, WebhookEvents(ic))
+}
+
+func TestWebhook_Split(t *testing.T) {
+	repo := Repository{Name: "tmax-cloud/cicd-operator"}
+	assert.Equal(t, (*Webhook)(nil), NewPushWebhook(repo, nil))
+
+	// Single ref
+	wh := NewPushWebhook(repo, []Push{{Ref: "refs/heads/master"}})
+	assert.Equal(t, []*Webhook{wh}, wh.Split())
+
+	// Multiple refs
+	wh = NewPushWebhook(repo, []Push{{Ref: "refs/heads/master"}, {Ref: "refs/tags/v0.1.0"}})
+	wh.DeliveryID = "test-delivery"
+	assert.Equal(t, "refs/heads/master", wh.Push.Ref)
+	split := wh.Split()
+	assert.Equal(t, 2, len(split))
+	for i, ref := range []string{"refs/heads/master", "refs/tags/v0.1.0"} {
+		assert.Equal(t, EventTypePush, split[i].EventType)
+		assert.Equal(t, "test-delivery", split[i].DeliveryID)
+		assert.Equal(t, ref, split[i].Push.Ref)
+		assert.Equal(t, 0, len(split[i].Pushes))
+	}
+}
@@ -0,0 +1,19 @@
+package gitea
+
+import (
+	"testing"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"github.com/tmax-cloud/cicd-operator/pkg/git/fake"
+)
+
+func TestClientContract(t *testing.T) {
+	fake.RunContractTests(t, fake.Contract{
+		Type:      cicdv1.GitTypeGitea,
+		NewServer: fake.NewGiteaServer,
+		NewClient: func(ic *cicdv1.IntegrationConfig) git.Client {
+			return &Client{IntegrationConfig: ic}
+		},
+	})
+}
@@ -0,0 +1,434 @@
+package gitea
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// webhookContentType is a content type of the webhook payloads
+const webhookContentType = "json"
+
+// Client is a gitea client struct
+type Client struct {
+	IntegrationConfig *cicdv1.IntegrationConfig
+	K8sClient         client.Client
+}
+
+// ParseWebhook parses a webhook body for gitea
+func (c *Client) ParseWebhook(header http.Header, jsonString []byte) (*git.Webhook, error) {
+	if err := git.ValidateWebhookSecrets(c.IntegrationConfig.GetWebhookSecrets(), func(secret string) error {
+		return Validate(secret, header.Get("x-gitea-signature"), jsonString)
+	}); err != nil {
+		return nil, err
+	}
+	eventType := git.EventType(header.Get("x-gitea-event"))
+	switch eventType {
+	case git.EventTypePullRequest:
+		return c.parsePullRequestWebhook(jsonString)
+	case git.EventTypePush:
+		return c.parsePushWebhook(jsonString)
+	case git.EventTypeIssueComment:
+		return c.parseIssueCommentWebhook(jsonString)
+	}
+	return nil, nil
+}
+
+// ListWebhook lists registered webhooks
+func (c *Client) ListWebhook() ([]git.WebhookEntry, error) {
+	apiURL := c.getRepoAPIUrl() + "/hooks"
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []WebhookEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	var result []git.WebhookEntry
+	for _, e := range entries {
+		active := e.Active
+		result = append(result, git.WebhookEntry{ID: strconv.Itoa(e.ID), URL: e.Config.URL, Events: e.Events, ContentType: e.Config.ContentType, Active: &active})
+	}
+
+	return result, nil
+}
+
+// RegisterWebhook registers our webhook server to the remote git server
+func (c *Client) RegisterWebhook(url string) error {
+	var registrationBody RegistrationWebhookBody
+	var registrationConfig RegistrationWebhookBodyConfig
+	apiURL := c.getRepoAPIUrl() + "/hooks"
+
+	registrationBody.Type = "gitea"
+	registrationBody.Active = true
+	registrationBody.Events = c.webhookEvents()
+	registrationConfig.URL = url
+	registrationConfig.ContentType = webhookContentType
+	registrationConfig.Secret = c.IntegrationConfig.Status.Secrets
+
+	registrationBody.Config = registrationConfig
+
+	if _, _, err := c.requestHTTP(http.MethodPost, apiURL, registrationBody); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ExpectedWebhook returns the webhook entry expected to be registered for the url
+// Gitea does not report whether the secret is configured
+func (c *Client) ExpectedWebhook(url string) git.WebhookEntry {
+	active := true
+	return git.WebhookEntry{URL: url, Events: c.webhookEvents(), ContentType: webhookContentType, Active: &active}
+}
+
+// webhookEvents returns the gitea events which the webhook should be subscribed to
+func (c *Client) webhookEvents() []string {
+	var events []string
+	for _, ev := range git.WebhookEvents(c.IntegrationConfig) {
+		switch ev {
+		case git.EventTypePullRequest:
+			events = append(events, "pull_request", "pull_request_sync")
+		case git.EventTypePush:
+			events = append(events, "push")
+		case git.EventTypeIssueComment:
+			events = append(events, "issue_comment", "pull_request_comment")
+		}
+	}
+
+	// Gitea subscribes the webhook to push events if no event is specified
+	if len(events) == 0 {
+		events = []string{"push"}
+	}
+	return events
+}
+
+// DeleteWebhook deletes registered webhook
+func (c *Client) DeleteWebhook(id string) error {
+	apiURL := c.getRepoAPIUrl() + "/hooks/" + id
+	if _, _, err := c.requestHTTP(http.MethodDelete, apiURL, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetCommitStatus sets commit status for the specific commit
+func (c *Client) SetCommitStatus(integrationJob *cicdv1.IntegrationJob, context string, state git.CommitStatusState, description, targetURL string) error {
+	var commitStatusBody CommitStatusBody
+	var sha string
+	if integrationJob.Spec.Refs.Pull == nil {
+		sha = integrationJob.Spec.Refs.Base.Sha
+	} else {
+		sha = integrationJob.Spec.Refs.Pull.Sha
+	}
+	apiURL := fmt.Sprintf("%s/api/v1/repos/%s/statuses/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), integrationJob.Spec.Refs.Repository, sha)
+
+	commitStatusBody.State = string(state)
+	commitStatusBody.TargetURL = targetURL
+	commitStatusBody.Description = description
+	commitStatusBody.Context = context
+
+	if _, _, err := c.requestHTTP(http.MethodPost, apiURL, commitStatusBody); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetUserInfo gets a user's information
+func (c *Client) GetUserInfo(userName string) (*git.User, error) {
+	// userName is string!
+	apiURL := fmt.Sprintf("%s/api/v1/users/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), userName)
+
+	result, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var userInfo UserInfo
+	if err := json.Unmarshal(result, &userInfo); err != nil {
+		return nil, err
+	}
+
+	return &git.User{
+		ID:    userInfo.ID,
+		Name:  userInfo.UserName,
+		Email: userInfo.Email,
+	}, nil
+}
+
+// CanUserWriteToRepo decides if the user has write permission on the repo
+func (c *Client) CanUserWriteToRepo(user git.User) (bool, error) {
+	// userName is string!
+	apiURL := fmt.Sprintf("%s/collaborators/%s/permission", c.getRepoAPIUrl(), user.Name)
+
+	result, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return false, err
+	}
+
+	var permission UserPermission
+	if err := json.Unmarshal(result, &permission); err != nil {
+		return false, err
+	}
+
+	return permission.Permission == "owner" || permission.Permission == "admin" || permission.Permission == "write", nil
+}
+
+// RegisterComment registers comment to an issue
+func (c *Client) RegisterComment(_ git.IssueType, issueNo int, body string) error {
+	apiURL := fmt.Sprintf("%s/issues/%d/comments", c.getRepoAPIUrl(), issueNo)
+
+	commentBody := &CommentBody{Body: body}
+	if _, _, err := c.requestHTTP(http.MethodPost, apiURL, commentBody); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetRepository gets the repository's information
+func (c *Client) GetRepository() (*git.Repository, error) {
+	data, _, err := c.requestHTTP(http.MethodGet, c.getRepoAPIUrl(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &Repo{}
+	if err := json.Unmarshal(data, repo); err != nil {
+		return nil, err
+	}
+
+	return &git.Repository{Name: repo.Name, URL: repo.URL}, nil
+}
+
+// ListBranches lists branches of the repository
+func (c *Client) ListBranches() ([]git.Ref, error) {
+	return c.listRefs("branches")
+}
+
+// GetBranch gets a branch of the repository
+func (c *Client) GetBranch(name string) (*git.Ref, error) {
+	data, _, err := c.requestHTTP(http.MethodGet, fmt.Sprintf("%s/branches/%s", c.getRepoAPIUrl(), url.PathEscape(name)), nil)
+	if err != nil {
+		if git.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ref RefInfo
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return nil, err
+	}
+
+	return &git.Ref{Name: ref.Name, Sha: ref.Commit.ID}, nil
+}
+
+// ListTags lists tags of the repository
+func (c *Client) ListTags() ([]git.Ref, error) {
+	return c.listRefs("tags")
+}
+
+// GetTag gets a tag of the repository
+// Commits of the tags have sha, not id
+func (c *Client) GetTag(name string) (*git.Ref, error) {
+	data, _, err := c.requestHTTP(http.MethodGet, fmt.Sprintf("%s/tags/%s", c.getRepoAPIUrl(), url.PathEscape(name)), nil)
+	if err != nil {
+		if git.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ref RefInfo
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return nil, err
+	}
+
+	return &git.Ref{Name: ref.Name, Sha: ref.Commit.Sha}, nil
+}
+
+// ListOpenPullRequests lists open pull requests of the repository
+func (c *Client) ListOpenPullRequests() ([]git.PullRequest, error) {
+	apiURL := fmt.Sprintf("%s/pulls?state=open&limit=50", c.getRepoAPIUrl())
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var prs []PullRequest
+	if err := json.Unmarshal(data, &prs); err != nil {
+		return nil, err
+	}
+
+	var result []git.PullRequest
+	for i := range prs {
+		result = append(result, *convertPullRequestToShared(&prs[i]))
+	}
+
+	return result, nil
+}
+
+// ListChangedFiles lists the files changed by the head commit, compared to the merge base of base and head
+func (c *Client) ListChangedFiles(base, head string) ([]string, error) {
+	apiURL := fmt.Sprintf("%s/compare/%s...%s", c.getRepoAPIUrl(), url.PathEscape(base), url.PathEscape(head))
+
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &Comparison{}
+	if err := json.Unmarshal(data, comparison); err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, commit := range comparison.Commits {
+		for _, f := range commit.Files {
+			result = append(result, f.FileName)
+		}
+	}
+
+	return result, nil
+}
+
+func (c *Client) listRefs(refType string) ([]git.Ref, error) {
+	apiURL := fmt.Sprintf("%s/%s?limit=50", c.getRepoAPIUrl(), refType)
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []RefInfo
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return nil, err
+	}
+
+	var result []git.Ref
+	for _, r := range refs {
+		sha := r.Commit.ID
+		if sha == "" {
+			sha = r.Commit.Sha
+		}
+		result = append(result, git.Ref{Name: r.Name, Sha: sha})
+	}
+
+	return result, nil
+}
+
+func (c *Client) getPullRequestInfo(id int) (*git.PullRequest, error) {
+	apiURL := fmt.Sprintf("%s/pulls/%d", c.getRepoAPIUrl(), id)
+
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pr := &PullRequest{}
+	if err := json.Unmarshal(data, &pr); err != nil {
+		return nil, err
+	}
+
+	return convertPullRequestToShared(pr), nil
+}
+
+func convertPullRequestToShared(pr *PullRequest) *git.PullRequest {
+	return &git.PullRequest{
+		ID:    pr.Number,
+		Title: pr.Title,
+		State: git.PullRequestState(pr.State),
+		Sender: git.User{
+			ID:    pr.User.ID,
+			Name:  pr.User.Name,
+			Email: pr.User.Email,
+		},
+		URL:  pr.URL,
+		Base: git.Base{Ref: pr.Base.Ref},
+		Head: git.Head{Ref: pr.Head.Ref, Sha: pr.Head.Sha},
+	}
+}
+
+func (c *Client) getRepoAPIUrl() string {
+	return fmt.Sprintf("%s/api/v1/repos/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), c.IntegrationConfig.Spec.Git.Repository)
+}
+
+func (c *Client) requestHTTP(method, apiURL string, data interface{}) ([]byte, http.Header, error) {
+	httpCli, header, err := c.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli.Request(method, apiURL, header, data)
+}
+
+// requestHTTPAll requests api call, following all the pages
+func (c *Client) requestHTTPAll(method, apiURL string, data interface{}) ([]byte, http.Header, error) {
+	httpCli, header, err := c.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli.RequestAll(method, apiURL, header, data)
+}
+
+// httpClient returns a http client and a request header for the API server
+func (c *Client) httpClient() (*git.HTTPClient, map[string]string, error) {
+	token, err := c.IntegrationConfig.GetToken(c.K8sClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := map[string]string{
+		"Authorization": "token " + token,
+		"Content-Type":  "application/json",
+	}
+
+	httpCli, err := git.GetHTTPClient(c.IntegrationConfig, c.K8sClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli, header, nil
+}
+
+// IsValidPayload validates the webhook payload
+func IsValidPayload(secret, headerHash string, payload []byte) bool {
+	hash := HashPayload(secret, payload)
+	return hmac.Equal(
+		[]byte(hash),
+		[]byte(headerHash),
+	)
+}
+
+// HashPayload hashes the payload
+func HashPayload(secret string, payloadBody []byte) string {
+	hm := hmac.New(sha256.New, []byte(secret))
+	_, err := hm.Write(payloadBody)
+	sum := hm.Sum(nil)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sum)
+}
+
+// Validate validates the webhook payload
+func Validate(secret, headerHash string, payload []byte) error {
+	if !IsValidPayload(secret, headerHash, payload) {
+		return fmt.Errorf("invalid request : X-Gitea-Signature does not match secret")
+	}
+	return nil
+}
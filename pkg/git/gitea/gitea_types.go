@@ -0,0 +1,45 @@
+package gitea
+
+// UserInfo is a body of user get API
+type UserInfo struct {
+	ID       int    `json:"id"`
+	UserName string `json:"login"`
+	Email    string `json:"email"`
+}
+
+// UserPermission is a user's permission on a repository
+type UserPermission struct {
+	Permission string `json:"permission"`
+}
+
+// CommitStatusBody is an API body for setting commits' status
+type CommitStatusBody struct {
+	State       string `json:"state"`
+	TargetURL   string `json:"target_url"`
+	Description string `json:"description"`
+	Context     string `json:"context"`
+}
+
+// CommentBody is a body structure for creating new comment
+type CommentBody struct {
+	Body string `json:"body"`
+}
+
+// RefInfo is a branch or a tag of branch/tag list API
+// Commit hash is set to commit.id for branches, and to commit.sha for tags
+type RefInfo struct {
+	Name   string `json:"name"`
+	Commit struct {
+		ID  string `json:"id"`
+		Sha string `json:"sha"`
+	} `json:"commit"`
+}
+
+// Comparison is a result of comparing two commits
+type Comparison struct {
+	Commits []struct {
+		Files []struct {
+			FileName string `json:"filename"`
+		} `json:"files"`
+	} `json:"commits"`
+}
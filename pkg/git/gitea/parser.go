@@ -0,0 +1,105 @@
+package gitea
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+)
+
+func (c *Client) parsePullRequestWebhook(jsonString []byte) (*git.Webhook, error) {
+	var data PullRequestWebhook
+
+	if err := json.Unmarshal(jsonString, &data); err != nil {
+		return nil, err
+	}
+
+	// Get sender email
+	sender := git.User{Name: data.Sender.Name, ID: data.Sender.ID, Email: data.Sender.Email}
+	if sender.Email == "" {
+		userInfo, err := c.GetUserInfo(data.Sender.Name)
+		if err == nil {
+			sender.Email = userInfo.Email
+		}
+	}
+
+	action := git.PullRequestAction(data.Action)
+	switch data.Action {
+	case "synchronized":
+		action = git.PullRequestActionSynchronize
+	}
+
+	base := git.Base{Ref: data.PullRequest.Base.Ref}
+	head := git.Head{Ref: data.PullRequest.Head.Ref, Sha: data.PullRequest.Head.Sha}
+	repo := git.Repository{Name: data.Repo.Name, URL: data.Repo.URL}
+	pullRequest := git.PullRequest{ID: data.Number, Title: data.PullRequest.Title, Sender: sender, URL: data.PullRequest.URL, Base: base, Head: head, State: git.PullRequestState(data.PullRequest.State), Action: action}
+	return &git.Webhook{EventType: git.EventTypePullRequest, Repo: repo, PullRequest: &pullRequest}, nil
+}
+
+func (c *Client) parsePushWebhook(jsonString []byte) (*git.Webhook, error) {
+	var data PushWebhook
+
+	if err := json.Unmarshal(jsonString, &data); err != nil {
+		return nil, err
+	}
+	repo := git.Repository{Name: data.Repo.Name, URL: data.Repo.URL}
+	if strings.HasPrefix(data.Sha, "0000") && strings.HasSuffix(data.Sha, "0000") {
+		return nil, nil
+	}
+	push := git.Push{Sender: git.User{Name: data.Sender.Name, ID: data.Sender.ID, Email: data.Sender.Email}, Ref: data.Ref, Before: data.Before, Sha: data.Sha}
+	for _, commit := range data.Commits {
+		if commit.ID == data.Sha {
+			push.Message = commit.Message
+		}
+	}
+
+	// Get sender email
+	if push.Sender.Email == "" {
+		userInfo, err := c.GetUserInfo(data.Sender.Name)
+		if err == nil {
+			push.Sender.Email = userInfo.Email
+		}
+	}
+
+	return &git.Webhook{EventType: git.EventTypePush, Repo: repo, Push: &push}, nil
+}
+
+func (c *Client) parseIssueCommentWebhook(jsonString []byte) (*git.Webhook, error) {
+	issueComment := &IssueCommentWebhook{}
+	if err := json.Unmarshal(jsonString, issueComment); err != nil {
+		return nil, err
+	}
+
+	// Only handle creation
+	if issueComment.Action != "created" {
+		return nil, nil
+	}
+
+	// Get Pull Request info.
+	var pr *git.PullRequest
+	if issueComment.IsPull {
+		var err error
+		pr, err = c.getPullRequestInfo(issueComment.Issue.Number)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &git.Webhook{EventType: git.EventTypeIssueComment, Repo: git.Repository{
+		Name: issueComment.Repo.Name,
+		URL:  issueComment.Repo.URL,
+	}, IssueComment: &git.IssueComment{
+		Comment: git.Comment{
+			Body:      issueComment.Comment.Body,
+			CreatedAt: issueComment.Comment.CreatedAt,
+		},
+		Issue: git.Issue{
+			PullRequest: pr,
+		},
+		Sender: git.User{
+			ID:    issueComment.Sender.ID,
+			Name:  issueComment.Sender.Name,
+			Email: issueComment.Sender.Email,
+		},
+	}}, nil
+}
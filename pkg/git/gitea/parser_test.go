@@ -0,0 +1,96 @@
+package gitea
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	testSecret = "test-secret"
+
+	testPullRequestBody = `{
+  "action": "synchronized",
+  "number": 3,
+  "pull_request": {
+    "number": 3,
+    "title": "test pull request",
+    "state": "open",
+    "html_url": "https://gitea.tmax.io/tmax-cloud/cicd-operator/pulls/3",
+    "user": {"id": 2, "login": "author", "email": "author@tmax.co.kr"},
+    "head": {"ref": "new-feat", "sha": "6dcb09b5b57875f334f61aebed695e2e4193db5e"},
+    "base": {"ref": "master"}
+  },
+  "repository": {"full_name": "tmax-cloud/cicd-operator", "html_url": "https://gitea.tmax.io/tmax-cloud/cicd-operator"},
+  "sender": {"id": 2, "login": "author", "email": "author@tmax.co.kr"}
+}`
+
+	testPushBody = `{
+  "ref": "refs/heads/master",
+  "after": "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+  "repository": {"full_name": "tmax-cloud/cicd-operator", "html_url": "https://gitea.tmax.io/tmax-cloud/cicd-operator"},
+  "sender": {"id": 2, "login": "author", "email": "author@tmax.co.kr"}
+}`
+)
+
+func testClient() *Client {
+	return &Client{
+		IntegrationConfig: &cicdv1.IntegrationConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+			Spec: cicdv1.IntegrationConfigSpec{
+				Git: cicdv1.GitConfig{
+					Type:       cicdv1.GitTypeGitea,
+					Repository: "tmax-cloud/cicd-operator",
+					APIUrl:     "https://gitea.tmax.io",
+					Token:      cicdv1.GitToken{Value: "test-token"},
+				},
+			},
+			Status: cicdv1.IntegrationConfigStatus{Secrets: testSecret},
+		},
+	}
+}
+
+func testHeader(event, body string) http.Header {
+	header := http.Header{}
+	header.Set("X-Gitea-Event", event)
+	header.Set("X-Gitea-Signature", HashPayload(testSecret, []byte(body)))
+	return header
+}
+
+func TestClient_ParseWebhook(t *testing.T) {
+	c := testClient()
+
+	// Pull request
+	wh, err := c.ParseWebhook(testHeader("pull_request", testPullRequestBody), []byte(testPullRequestBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, git.EventTypePullRequest, wh.EventType)
+	assert.Equal(t, "tmax-cloud/cicd-operator", wh.Repo.Name)
+	assert.Equal(t, 3, wh.PullRequest.ID)
+	assert.Equal(t, git.PullRequestActionSynchronize, wh.PullRequest.Action)
+	assert.Equal(t, git.PullRequestStateOpen, wh.PullRequest.State)
+	assert.Equal(t, "master", wh.PullRequest.Base.Ref)
+	assert.Equal(t, "new-feat", wh.PullRequest.Head.Ref)
+	assert.Equal(t, "6dcb09b5b57875f334f61aebed695e2e4193db5e", wh.PullRequest.Head.Sha)
+	assert.Equal(t, "author@tmax.co.kr", wh.PullRequest.Sender.Email)
+
+	// Push
+	wh, err = c.ParseWebhook(testHeader("push", testPushBody), []byte(testPushBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, git.EventTypePush, wh.EventType)
+	assert.Equal(t, "refs/heads/master", wh.Push.Ref)
+	assert.Equal(t, "6dcb09b5b57875f334f61aebed695e2e4193db5e", wh.Push.Sha)
+
+	// Invalid signature
+	header := testHeader("push", testPushBody)
+	header.Set("X-Gitea-Signature", "invalid")
+	_, err = c.ParseWebhook(header, []byte(testPushBody))
+	assert.NotEqual(t, nil, err)
+}
@@ -0,0 +1,183 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// appJWTDuration is a lifetime of JWTs for authenticating as a GitHub App (at most 10 minutes)
+	appJWTDuration = 9 * time.Minute
+
+	// installationTokenRefreshMargin is a margin before installation tokens expire, when they are refreshed
+	installationTokenRefreshMargin = 15 * time.Minute
+)
+
+// installationToken is an installation access token of a GitHub App
+type installationToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// installationTokens caches the installation access tokens, as they are valid for an hour
+// The lock only guards the map, so the tokens are issued without blocking the other installations
+var installationTokens = map[string]installationToken{}
+var installationTokensLock sync.Mutex
+
+// GetInstallationToken gets an installation access token of the GitHub App configured in the IntegrationConfig
+// Tokens are cached and refreshed before they expire
+func GetInstallationToken(ic *cicdv1.IntegrationConfig, k8sClient client.Client) (string, time.Time, error) {
+	app := ic.Spec.Git.GitHubApp
+	if app == nil {
+		return "", time.Time{}, fmt.Errorf("githubApp is not configured")
+	}
+
+	privateKey, err := ic.GetGitHubAppPrivateKey(k8sClient)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	key := installationTokenKey(ic.Spec.Git.GetAPIUrl(), ic.Spec.Git.Repository, app, privateKey)
+
+	installationTokensLock.Lock()
+	t, exist := installationTokens[key]
+	installationTokensLock.Unlock()
+	if exist && time.Now().Add(installationTokenRefreshMargin).Before(t.expiresAt) {
+		return t.token, t.expiresAt, nil
+	}
+
+	httpCli, err := git.GetHTTPClient(ic, k8sClient)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	issued, err := issueInstallationToken(httpCli, ic.Spec.Git.GetAPIUrl(), ic.Spec.Git.Repository, app, privateKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	installationTokensLock.Lock()
+	installationTokens[key] = *issued
+	installationTokensLock.Unlock()
+
+	return issued.token, issued.expiresAt, nil
+}
+
+// installationTokenKey returns a cache key of the installation access tokens, which is a hash of the app id, the installation
+// id and the private key. So the tokens are shared only by the holders of the key, and not used after the key is rotated
+func installationTokenKey(apiURL, repository string, app *cicdv1.GitHubAppConfig, privateKey []byte) string {
+	installation := strconv.FormatInt(app.InstallationID, 10)
+	if app.InstallationID == 0 {
+		installation = repository
+	}
+
+	h := sha256.New()
+	for _, s := range []string{apiURL, strconv.FormatInt(app.AppID, 10), installation, string(privateKey)} {
+		_, _ = h.Write([]byte(s))
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// issueInstallationToken issues a new installation access token
+func issueInstallationToken(httpCli *git.HTTPClient, apiURL, repository string, app *cicdv1.GitHubAppConfig, privateKey []byte) (*installationToken, error) {
+	jwt, err := generateAppJWT(app.AppID, privateKey, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	header := map[string]string{
+		"Authorization": "Bearer " + jwt,
+		"Accept":        "application/vnd.github.v3+json",
+	}
+
+	// Find installation for the repository, if it's not specified
+	installationID := app.InstallationID
+	if installationID == 0 {
+		data, _, err := httpCli.Request(http.MethodGet, fmt.Sprintf("%s/repos/%s/installation", apiURL, repository), header, nil)
+		if err != nil {
+			return nil, err
+		}
+		installation := &AppInstallation{}
+		if err := json.Unmarshal(data, installation); err != nil {
+			return nil, err
+		}
+		installationID = installation.ID
+	}
+
+	data, _, err := httpCli.Request(http.MethodPost, fmt.Sprintf("%s/app/installations/%d/access_tokens", apiURL, installationID), header, nil)
+	if err != nil {
+		return nil, err
+	}
+	token := &InstallationAccessToken{}
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, err
+	}
+
+	return &installationToken{token: token.Token, expiresAt: token.ExpiresAt}, nil
+}
+
+// generateAppJWT generates a JWT (signed with RS256) for authenticating as a GitHub App
+func generateAppJWT(appID int64, privateKeyPEM []byte, now time.Time) (string, error) {
+	privateKey, err := parseRSAPrivateKey(privateKeyPEM)
+	if err != nil {
+		return "", err
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	// iat is set 60 seconds in the past, to allow clock drift
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(appJWTDuration).Unix(),
+		"iss": strconv.FormatInt(appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hashed := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// parseRSAPrivateKey parses PEM-encoded RSA private key, either in PKCS#1 or PKCS#8 form
+func parseRSAPrivateKey(privateKeyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("private key is not in PEM format")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not a RSA key")
+	}
+	return rsaKey, nil
+}
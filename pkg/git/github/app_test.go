@@ -0,0 +1,175 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testPrivateKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func TestGenerateAppJWT(t *testing.T) {
+	key, keyPEM := testPrivateKey(t)
+	now := time.Unix(1600000000, 0)
+
+	jwt, err := generateAppJWT(1234, keyPEM, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(jwt, ".")
+	assert.Equal(t, 3, len(parts))
+
+	// Verify signature
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify claims
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "1234", claims["iss"])
+	assert.Equal(t, float64(now.Unix()-60), claims["iat"])
+	assert.Equal(t, float64(now.Add(appJWTDuration).Unix()), claims["exp"])
+
+	// Invalid key
+	_, err = generateAppJWT(1234, []byte("invalid"), now)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestGetInstallationToken(t *testing.T) {
+	_, keyPEM := testPrivateKey(t)
+
+	issued := 0
+	expiresAt := time.Now().Add(time.Hour)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/tmax-cloud/cicd-operator/installation":
+			_, _ = fmt.Fprint(w, `{"id": 42}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/app/installations/42/access_tokens":
+			issued++
+			w.WriteHeader(http.StatusCreated)
+			_, _ = fmt.Fprintf(w, `{"token": "token-%d", "expires_at": "%s"}`, issued, expiresAt.UTC().Format(time.RFC3339))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	ic := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Git: cicdv1.GitConfig{
+				Type:       cicdv1.GitTypeGitHub,
+				APIUrl:     srv.URL,
+				Repository: "tmax-cloud/cicd-operator",
+				GitHubApp: &cicdv1.GitHubAppConfig{
+					AppID: 1234,
+					PrivateKey: corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "github-app"},
+						Key:                  "private-key",
+					},
+				},
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "github-app", Namespace: "default"},
+		Data:       map[string][]byte{"private-key": keyPEM},
+	}
+	c := &Client{IntegrationConfig: ic, K8sClient: fake.NewFakeClientWithScheme(scheme.Scheme, secret)}
+
+	token, err := c.getToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "token-1", token)
+
+	// Cached token is used
+	token, err = c.getToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "token-1", token)
+	assert.Equal(t, 1, issued)
+
+	// Token is refreshed before it expires
+	installationTokensLock.Lock()
+	for k, v := range installationTokens {
+		v.expiresAt = time.Now().Add(installationTokenRefreshMargin / 2)
+		installationTokens[k] = v
+	}
+	installationTokensLock.Unlock()
+	token, _, err = GetInstallationToken(ic, c.K8sClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "token-2", token)
+
+	// Token is shared with the IntegrationConfigs holding the same private key
+	other := ic.DeepCopy()
+	other.Namespace = "other"
+	otherSecret := secret.DeepCopy()
+	otherSecret.Namespace = "other"
+	otherSecret.ResourceVersion = ""
+	if err := c.K8sClient.Create(context.Background(), otherSecret); err != nil {
+		t.Fatal(err)
+	}
+	token, _, err = GetInstallationToken(other, c.K8sClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "token-2", token)
+
+	// New token is issued after the private key is rotated
+	_, otherKeyPEM := testPrivateKey(t)
+	otherSecret.Data["private-key"] = otherKeyPEM
+	if err := c.K8sClient.Update(context.Background(), otherSecret); err != nil {
+		t.Fatal(err)
+	}
+	token, _, err = GetInstallationToken(other, c.K8sClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "token-3", token)
+}
@@ -0,0 +1,209 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+)
+
+// Check run statuses and conclusions
+const (
+	checkRunStatusQueued     = "queued"
+	checkRunStatusInProgress = "in_progress"
+	checkRunStatusCompleted  = "completed"
+
+	checkRunConclusionSuccess = "success"
+	checkRunConclusionFailure = "failure"
+)
+
+// maxCheckRunTextLength is the maximum length of check run's output text
+const maxCheckRunTextLength = 65535
+
+// CheckRunEnabled returns if check runs can be used for the repository
+// Check runs can only be created by GitHub Apps, so they are used only if GitHub App is configured
+func (c *Client) CheckRunEnabled() bool {
+	return c.IntegrationConfig.Spec.Git.GitHubApp != nil
+}
+
+// SetCheckRun creates or updates the check run for the job
+func (c *Client) SetCheckRun(integrationJob *cicdv1.IntegrationJob, jobStatus *cicdv1.JobStatus, detailsURL string) error {
+	var sha string
+	if integrationJob.Spec.Refs.Pull == nil {
+		sha = integrationJob.Spec.Refs.Base.Sha
+	} else {
+		sha = integrationJob.Spec.Refs.Pull.Sha
+	}
+	externalID := checkRunExternalID(integrationJob, jobStatus.Name)
+
+	body := generateCheckRunBody(integrationJob, jobStatus, detailsURL)
+	body.HeadSha = sha
+	body.ExternalID = externalID
+
+	id, err := c.findCheckRun(integrationJob.Spec.Refs.Repository, sha, jobStatus.Name, externalID)
+	if err != nil {
+		return err
+	}
+
+	apiURL := fmt.Sprintf("%s/repos/%s/check-runs", c.IntegrationConfig.Spec.Git.GetAPIUrl(), integrationJob.Spec.Refs.Repository)
+	method := http.MethodPost
+	if id != 0 {
+		apiURL = fmt.Sprintf("%s/%d", apiURL, id)
+		method = http.MethodPatch
+	}
+	if _, _, err := c.requestHTTP(method, apiURL, body); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// findCheckRun finds the check run created for the job, returns 0 if it doesn't exist
+func (c *Client) findCheckRun(repository, sha, name, externalID string) (int64, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/commits/%s/check-runs?filter=all&check_name=%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), repository, sha, url.QueryEscape(name))
+
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	list := &CheckRunList{}
+	if err := json.Unmarshal(data, list); err != nil {
+		return 0, err
+	}
+
+	for _, run := range list.CheckRuns {
+		if run.ExternalID == externalID {
+			return run.ID, nil
+		}
+	}
+	return 0, nil
+}
+
+// generateCheckRunBody generates a check run body, except for the head sha and the external id
+func generateCheckRunBody(integrationJob *cicdv1.IntegrationJob, jobStatus *cicdv1.JobStatus, detailsURL string) *CheckRunBody {
+	body := &CheckRunBody{
+		Name:       jobStatus.Name,
+		DetailsURL: detailsURL,
+	}
+
+	var title string
+	switch jobStatus.State {
+	case cicdv1.CommitStatusStatePending:
+		if jobStatus.StartTime == nil {
+			body.Status = checkRunStatusQueued
+			title = "Job is queued"
+		} else {
+			body.Status = checkRunStatusInProgress
+			title = "Job is running"
+		}
+	case cicdv1.CommitStatusStateSuccess:
+		body.Status = checkRunStatusCompleted
+		body.Conclusion = checkRunConclusionSuccess
+		title = "Job succeeded"
+	default:
+		body.Status = checkRunStatusCompleted
+		body.Conclusion = checkRunConclusionFailure
+		title = "Job failed"
+	}
+
+	if jobStatus.StartTime != nil {
+		t := jobStatus.StartTime.UTC()
+		body.StartedAt = &t
+	}
+	if body.Status == checkRunStatusCompleted {
+		t := time.Now().UTC()
+		if jobStatus.CompletionTime != nil {
+			t = jobStatus.CompletionTime.UTC()
+		}
+		body.CompletedAt = &t
+	}
+
+	text := jobStatus.Message
+	if len(text) > maxCheckRunTextLength {
+		text = text[:maxCheckRunTextLength]
+	}
+	body.Output = &CheckRunOutput{
+		Title:   title,
+		Summary: generateCheckRunSummary(integrationJob, jobStatus, detailsURL),
+		Text:    text,
+	}
+
+	return body
+}
+
+// generateCheckRunSummary generates a markdown summary of the job
+func generateCheckRunSummary(integrationJob *cicdv1.IntegrationJob, jobStatus *cicdv1.JobStatus, detailsURL string) string {
+	var b strings.Builder
+	b.WriteString("| | |\n|---|---|\n")
+	b.WriteString(fmt.Sprintf("| IntegrationJob | `%s/%s` |\n", integrationJob.Namespace, integrationJob.Name))
+	b.WriteString(fmt.Sprintf("| Job | `%s` |\n", jobStatus.Name))
+	b.WriteString(fmt.Sprintf("| State | %s |\n", jobStatus.State))
+	if jobStatus.StartTime != nil {
+		b.WriteString(fmt.Sprintf("| Started | %s |\n", jobStatus.StartTime.UTC().Format(time.RFC3339)))
+	}
+	if jobStatus.CompletionTime != nil {
+		b.WriteString(fmt.Sprintf("| Completed | %s |\n", jobStatus.CompletionTime.UTC().Format(time.RFC3339)))
+		if jobStatus.StartTime != nil {
+			b.WriteString(fmt.Sprintf("| Duration | %s |\n", jobStatus.CompletionTime.Sub(jobStatus.StartTime.Time).Round(time.Second)))
+		}
+	}
+	if detailsURL != "" {
+		b.WriteString(fmt.Sprintf("\n[Job report](%s)\n", detailsURL))
+	}
+	return b.String()
+}
+
+// checkRunExternalID is an identifier of the check run for the IntegrationJob's job
+// It's in <namespace>/<IntegrationConfig>/<IntegrationJob>/<job> form, so that the IntegrationConfig handling the
+// rerequested check run can be told
+func checkRunExternalID(integrationJob *cicdv1.IntegrationJob, jobName string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", integrationJob.Namespace, integrationJob.Spec.ConfigRef.Name, integrationJob.Name, jobName)
+}
+
+// parseCheckRunWebhook parses check_run webhook
+// Rerequested check runs are handled as '/test <job>' comments on the pull request
+func (c *Client) parseCheckRunWebhook(jsonString []byte) (*git.Webhook, error) {
+	data := &CheckRunWebhook{}
+	if err := json.Unmarshal(jsonString, data); err != nil {
+		return nil, err
+	}
+
+	// Only handle the rerequested check runs created by this IntegrationConfig
+	if data.Action != "rerequested" || len(data.CheckRun.PullRequests) == 0 {
+		return nil, nil
+	}
+	tokens := strings.SplitN(data.CheckRun.ExternalID, "/", 4)
+	if len(tokens) != 4 || tokens[0] != c.IntegrationConfig.Namespace || tokens[1] != c.IntegrationConfig.Name {
+		return nil, nil
+	}
+
+	pr, err := c.getPullRequestInfo(data.CheckRun.PullRequests[0].Number)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get sender email
+	sender, err := c.GetUserInfo(data.Sender.Name)
+	if err != nil {
+		sender = &git.User{Name: data.Sender.Name, ID: data.Sender.ID}
+	}
+
+	return &git.Webhook{EventType: git.EventTypeIssueComment, Repo: git.Repository{
+		Name: data.Repo.Name,
+		URL:  data.Repo.URL,
+	}, IssueComment: &git.IssueComment{
+		Comment: git.Comment{
+			Body: "/test " + data.CheckRun.Name,
+		},
+		Issue: git.Issue{
+			PullRequest: pr,
+		},
+		Sender: *sender,
+	}}, nil
+}
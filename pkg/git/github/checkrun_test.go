@@ -0,0 +1,183 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testCheckRunRerequestedBody = `{
+  "action": "rerequested",
+  "check_run": {
+    "name": "test-unit",
+    "external_id": "default/test-ic/test-ij/test-unit",
+    "head_sha": "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+    "pull_requests": [{"number": 3}]
+  },
+  "repository": {"full_name": "tmax-cloud/cicd-operator", "html_url": "https://github.com/tmax-cloud/cicd-operator"},
+  "sender": {"login": "reviewer", "id": 2}
+}`
+
+func testCheckRunClient(apiURL string) *Client {
+	return &Client{IntegrationConfig: &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Git: cicdv1.GitConfig{
+				Type:       cicdv1.GitTypeGitHub,
+				APIUrl:     apiURL,
+				Repository: "tmax-cloud/cicd-operator",
+				Token:      cicdv1.GitToken{Value: "test-token"},
+			},
+		},
+	}}
+}
+
+func testIntegrationJob() *cicdv1.IntegrationJob {
+	return &cicdv1.IntegrationJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ij", Namespace: "default"},
+		Spec: cicdv1.IntegrationJobSpec{
+			ConfigRef: cicdv1.IntegrationJobConfigRef{Name: "test-ic", Type: cicdv1.JobTypePreSubmit},
+			Refs: cicdv1.IntegrationJobRefs{
+				Repository: "tmax-cloud/cicd-operator",
+				Base:       cicdv1.IntegrationJobRefsBase{Ref: "master", Sha: "0123456789abcdef0123456789abcdef01234567"},
+				Pull:       &cicdv1.IntegrationJobRefsPull{ID: 3, Sha: "6dcb09b5b57875f334f61aebed695e2e4193db5e"},
+			},
+		},
+	}
+}
+
+func TestGenerateCheckRunBody(t *testing.T) {
+	ij := testIntegrationJob()
+	start := metav1.NewTime(time.Date(2021, 2, 3, 7, 0, 0, 0, time.UTC))
+	end := metav1.NewTime(time.Date(2021, 2, 3, 7, 1, 30, 0, time.UTC))
+
+	// Queued
+	body := generateCheckRunBody(ij, &cicdv1.JobStatus{Name: "test-unit", State: cicdv1.CommitStatusStatePending}, "http://report")
+	assert.Equal(t, checkRunStatusQueued, body.Status)
+	assert.Equal(t, "", body.Conclusion)
+	assert.Equal(t, (*time.Time)(nil), body.StartedAt)
+
+	// Running
+	body = generateCheckRunBody(ij, &cicdv1.JobStatus{Name: "test-unit", State: cicdv1.CommitStatusStatePending, StartTime: &start}, "http://report")
+	assert.Equal(t, checkRunStatusInProgress, body.Status)
+	assert.Equal(t, start.Time, *body.StartedAt)
+	assert.Equal(t, (*time.Time)(nil), body.CompletedAt)
+
+	// Succeeded
+	body = generateCheckRunBody(ij, &cicdv1.JobStatus{Name: "test-unit", State: cicdv1.CommitStatusStateSuccess, StartTime: &start, CompletionTime: &end}, "http://report")
+	assert.Equal(t, checkRunStatusCompleted, body.Status)
+	assert.Equal(t, checkRunConclusionSuccess, body.Conclusion)
+	assert.Equal(t, end.Time, *body.CompletedAt)
+	assert.Equal(t, "| | |\n|---|---|\n"+
+		"| IntegrationJob | `default/test-ij` |\n"+
+		"| Job | `test-unit` |\n"+
+		"| State | success |\n"+
+		"| Started | 2021-02-03T07:00:00Z |\n"+
+		"| Completed | 2021-02-03T07:01:30Z |\n"+
+		"| Duration | 1m30s |\n"+
+		"\n[Job report](http://report)\n", body.Output.Summary)
+
+	// Failed
+	body = generateCheckRunBody(ij, &cicdv1.JobStatus{Name: "test-unit", State: cicdv1.CommitStatusStateFailure, Message: "exit code 1", StartTime: &start, CompletionTime: &end}, "http://report")
+	assert.Equal(t, checkRunStatusCompleted, body.Status)
+	assert.Equal(t, checkRunConclusionFailure, body.Conclusion)
+	assert.Equal(t, "exit code 1", body.Output.Text)
+}
+
+func TestClient_SetCheckRun(t *testing.T) {
+	var requests []string
+	var lastBody CheckRunBody
+	existing := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case http.MethodGet:
+			if existing {
+				_, _ = fmt.Fprint(w, `{"total_count": 2, "check_runs": [{"id": 1, "external_id": "default/test-ic/old-ij/test-unit"}, {"id": 2, "external_id": "default/test-ic/test-ij/test-unit"}]}`)
+			} else {
+				_, _ = fmt.Fprint(w, `{"total_count": 0, "check_runs": []}`)
+			}
+		default:
+			b, _ := ioutil.ReadAll(r.Body)
+			_ = json.Unmarshal(b, &lastBody)
+			_, _ = fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer srv.Close()
+
+	c := testCheckRunClient(srv.URL)
+	ij := testIntegrationJob()
+
+	// Create
+	if err := c.SetCheckRun(ij, &cicdv1.JobStatus{Name: "test-unit", State: cicdv1.CommitStatusStatePending}, "http://report"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{
+		"GET /repos/tmax-cloud/cicd-operator/commits/6dcb09b5b57875f334f61aebed695e2e4193db5e/check-runs",
+		"POST /repos/tmax-cloud/cicd-operator/check-runs",
+	}, requests)
+	assert.Equal(t, "6dcb09b5b57875f334f61aebed695e2e4193db5e", lastBody.HeadSha)
+	assert.Equal(t, "default/test-ic/test-ij/test-unit", lastBody.ExternalID)
+
+	// Update
+	requests = nil
+	existing = true
+	if err := c.SetCheckRun(ij, &cicdv1.JobStatus{Name: "test-unit", State: cicdv1.CommitStatusStateSuccess}, "http://report"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "PATCH /repos/tmax-cloud/cicd-operator/check-runs/2", requests[1])
+	assert.Equal(t, checkRunConclusionSuccess, lastBody.Conclusion)
+}
+
+func TestClient_ParseCheckRunWebhook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/tmax-cloud/cicd-operator/pulls/3":
+			_, _ = fmt.Fprint(w, `{"number": 3, "title": "test", "state": "open", "user": {"login": "author", "id": 1}, "head": {"ref": "feat", "sha": "6dcb09b5b57875f334f61aebed695e2e4193db5e"}, "base": {"ref": "master"}}`)
+		case "/users/reviewer":
+			_, _ = fmt.Fprint(w, `{"login": "reviewer", "id": 2, "email": "reviewer@tmax.co.kr"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := testCheckRunClient(srv.URL)
+
+	wh, err := c.parseCheckRunWebhook([]byte(testCheckRunRerequestedBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, git.EventTypeIssueComment, wh.EventType)
+	assert.Equal(t, "/test test-unit", wh.IssueComment.Comment.Body)
+	assert.Equal(t, 3, wh.IssueComment.Issue.PullRequest.ID)
+	assert.Equal(t, git.PullRequestStateOpen, wh.IssueComment.Issue.PullRequest.State)
+	assert.Equal(t, "reviewer", wh.IssueComment.Sender.Name)
+	assert.Equal(t, "reviewer@tmax.co.kr", wh.IssueComment.Sender.Email)
+
+	// Check runs of other IntegrationConfigs are ignored, even if the names of their IntegrationJobs share the prefix
+	c.IntegrationConfig.Name = "test"
+	wh, err = c.parseCheckRunWebhook([]byte(testCheckRunRerequestedBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, (*git.Webhook)(nil), wh)
+
+	// Check runs of other namespaces are ignored
+	c.IntegrationConfig.Name = "test-ic"
+	c.IntegrationConfig.Namespace = "other"
+	wh, err = c.parseCheckRunWebhook([]byte(testCheckRunRerequestedBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, (*git.Webhook)(nil), wh)
+}
@@ -3,17 +3,34 @@ package github
 import (
 	"crypto/hmac"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
 	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/internal/configs"
 	"github.com/tmax-cloud/cicd-operator/pkg/git"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// eventTypeCheckRun is a github-specific event type for check runs
+const eventTypeCheckRun = git.EventType("check_run")
+
+// webhookContentType is a content type of the webhook payloads
+const webhookContentType = "json"
+
+// Maximum numbers of the changed files returned by the APIs. More files are truncated
+const (
+	maxComparisonFiles  = 300
+	maxPullRequestFiles = 3000
+)
+
 // Client is a gitlab client struct
 type Client struct {
 	IntegrationConfig *cicdv1.IntegrationConfig
@@ -22,11 +39,20 @@ type Client struct {
 
 // ParseWebhook parses a webhook body for github
 func (c *Client) ParseWebhook(header http.Header, jsonString []byte) (*git.Webhook, error) {
-	var signature = strings.Replace(header.Get("x-hub-signature"), "sha1=", "", 1)
-	if err := Validate(c.IntegrationConfig.Status.Secrets, signature, jsonString); err != nil {
+	if err := git.ValidateWebhookSecrets(c.IntegrationConfig.GetWebhookSecrets(), func(secret string) error {
+		return Validate(secret, header, jsonString)
+	}); err != nil {
 		return nil, err
 	}
-	eventType := git.EventType(header.Get("x-github-event"))
+	wh, err := c.parseWebhook(git.EventType(header.Get("x-github-event")), jsonString)
+	if err != nil || wh == nil {
+		return wh, err
+	}
+	wh.DeliveryID = header.Get("x-github-delivery")
+	return wh, nil
+}
+
+func (c *Client) parseWebhook(eventType git.EventType, jsonString []byte) (*git.Webhook, error) {
 	switch eventType {
 	case git.EventTypePullRequest:
 		return c.parsePullRequestWebhook(jsonString)
@@ -38,6 +64,10 @@ func (c *Client) ParseWebhook(header http.Header, jsonString []byte) (*git.Webho
 		return c.parsePullRequestReviewWebhook(jsonString)
 	case git.EventTypePullRequestReviewComment:
 		return c.parsePullRequestReviewCommentWebhook(jsonString)
+	case git.EventTypeRelease:
+		return c.parseReleaseWebhook(jsonString)
+	case eventTypeCheckRun:
+		return c.parseCheckRunWebhook(jsonString)
 	}
 	return nil, nil
 }
@@ -46,7 +76,7 @@ func (c *Client) ParseWebhook(header http.Header, jsonString []byte) (*git.Webho
 func (c *Client) ListWebhook() ([]git.WebhookEntry, error) {
 	var apiURL = c.IntegrationConfig.Spec.Git.GetAPIUrl() + "/repos/" + c.IntegrationConfig.Spec.Git.Repository + "/hooks"
 
-	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -58,7 +88,16 @@ func (c *Client) ListWebhook() ([]git.WebhookEntry, error) {
 
 	var result []git.WebhookEntry
 	for _, e := range entries {
-		result = append(result, git.WebhookEntry{ID: e.ID, URL: e.Config.URL})
+		active := e.Active
+		secretConfigured := e.Config.Secret != ""
+		result = append(result, git.WebhookEntry{
+			ID:               strconv.Itoa(e.ID),
+			URL:              e.Config.URL,
+			Events:           e.Events,
+			ContentType:      e.Config.ContentType,
+			SecretConfigured: &secretConfigured,
+			Active:           &active,
+		})
 	}
 
 	return result, nil
@@ -72,9 +111,9 @@ func (c *Client) RegisterWebhook(url string) error {
 
 	registrationBody.Name = "web"
 	registrationBody.Active = true
-	registrationBody.Events = []string{"*"}
+	registrationBody.Events = c.webhookEvents()
 	registrationConfig.URL = url
-	registrationConfig.ContentType = "json"
+	registrationConfig.ContentType = webhookContentType
 	registrationConfig.InsecureSsl = "0"
 	registrationConfig.Secret = c.IntegrationConfig.Status.Secrets
 
@@ -87,9 +126,46 @@ func (c *Client) RegisterWebhook(url string) error {
 	return nil
 }
 
+// ExpectedWebhook returns the webhook entry expected to be registered for the url
+func (c *Client) ExpectedWebhook(url string) git.WebhookEntry {
+	active, secretConfigured := true, true
+	return git.WebhookEntry{
+		URL:              url,
+		Events:           c.webhookEvents(),
+		ContentType:      webhookContentType,
+		SecretConfigured: &secretConfigured,
+		Active:           &active,
+	}
+}
+
+// webhookEvents returns the github events which the webhook should be subscribed to
+func (c *Client) webhookEvents() []string {
+	var events []string
+	for _, ev := range git.WebhookEvents(c.IntegrationConfig) {
+		switch ev {
+		case git.EventTypePullRequest:
+			events = append(events, string(git.EventTypePullRequest))
+			// Rerequested check runs trigger the pull request jobs again
+			if c.CheckRunEnabled() {
+				events = append(events, string(eventTypeCheckRun))
+			}
+		case git.EventTypeIssueComment:
+			events = append(events, string(git.EventTypeIssueComment), string(git.EventTypePullRequestReview), string(git.EventTypePullRequestReviewComment))
+		default:
+			events = append(events, string(ev))
+		}
+	}
+
+	// GitHub subscribes the webhook to push events if no event is specified
+	if len(events) == 0 {
+		events = []string{string(git.EventTypePush)}
+	}
+	return events
+}
+
 // DeleteWebhook deletes registered webhook
-func (c *Client) DeleteWebhook(id int) error {
-	var apiURL = c.IntegrationConfig.Spec.Git.GetAPIUrl() + "/repos/" + c.IntegrationConfig.Spec.Git.Repository + "/hooks/" + strconv.Itoa(id)
+func (c *Client) DeleteWebhook(id string) error {
+	var apiURL = c.IntegrationConfig.Spec.Git.GetAPIUrl() + "/repos/" + c.IntegrationConfig.Spec.Git.Repository + "/hooks/" + id
 	if _, _, err := c.requestHTTP(http.MethodDelete, apiURL, nil); err != nil {
 		return err
 	}
@@ -170,6 +246,239 @@ func (c *Client) RegisterComment(_ git.IssueType, issueNo int, body string) erro
 	return nil
 }
 
+// GetRepository gets the repository's information
+func (c *Client) GetRepository() (*git.Repository, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), c.IntegrationConfig.Spec.Git.Repository)
+
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &Repo{}
+	if err := json.Unmarshal(data, repo); err != nil {
+		return nil, err
+	}
+
+	return &git.Repository{Name: repo.Name, URL: repo.URL}, nil
+}
+
+// ListBranches lists branches of the repository
+func (c *Client) ListBranches() ([]git.Ref, error) {
+	return c.listRefs("branches")
+}
+
+// GetBranch gets a branch of the repository
+func (c *Client) GetBranch(name string) (*git.Ref, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/branches/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), c.IntegrationConfig.Spec.Git.Repository, url.PathEscape(name))
+
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		if git.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ref RefInfo
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return nil, err
+	}
+
+	return &git.Ref{Name: ref.Name, Sha: ref.Commit.Sha}, nil
+}
+
+// ListTags lists tags of the repository
+func (c *Client) ListTags() ([]git.Ref, error) {
+	return c.listRefs("tags")
+}
+
+// GetTag gets a tag of the repository
+// The commits API responds 422 for the tags which do not exist
+func (c *Client) GetTag(name string) (*git.Ref, error) {
+	sha, err := c.getTagSha(name)
+	if err != nil {
+		var httpErr *git.HTTPError
+		if git.IsNotFound(err) || (errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnprocessableEntity) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &git.Ref{Name: name, Sha: sha}, nil
+}
+
+// ListOpenPullRequests lists open pull requests of the repository
+func (c *Client) ListOpenPullRequests() ([]git.PullRequest, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/pulls?state=open&per_page=100", c.IntegrationConfig.Spec.Git.GetAPIUrl(), c.IntegrationConfig.Spec.Git.Repository)
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var prs []PullRequest
+	if err := json.Unmarshal(data, &prs); err != nil {
+		return nil, err
+	}
+
+	var result []git.PullRequest
+	for i := range prs {
+		result = append(result, *convertPullRequestToShared(&prs[i]))
+	}
+
+	return result, nil
+}
+
+// ListChangedFiles lists the files changed by the head commit, compared to the merge base of base and head
+func (c *Client) ListChangedFiles(base, head string) ([]string, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/compare/%s...%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), c.IntegrationConfig.Spec.Git.Repository, url.PathEscape(base), url.PathEscape(head))
+
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &Comparison{}
+	if err := json.Unmarshal(data, comparison); err != nil {
+		return nil, err
+	}
+	if len(comparison.Files) >= maxComparisonFiles {
+		return nil, fmt.Errorf("changed files between %s and %s may be truncated", base, head)
+	}
+
+	return changedFileNames(comparison.Files), nil
+}
+
+// ListPullRequestChangedFiles lists the files changed by the pull request
+func (c *Client) ListPullRequestChangedFiles(id int) ([]string, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/pulls/%d/files?per_page=100", c.IntegrationConfig.Spec.Git.GetAPIUrl(), c.IntegrationConfig.Spec.Git.Repository, id)
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []ChangedFile
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, err
+	}
+	if len(files) >= maxPullRequestFiles {
+		return nil, fmt.Errorf("changed files of pull request %d may be truncated", id)
+	}
+
+	return changedFileNames(files), nil
+}
+
+// changedFileNames returns the names of the changed files, including the previous names of the renamed files
+func changedFileNames(files []ChangedFile) []string {
+	var result []string
+	for _, f := range files {
+		result = append(result, f.FileName)
+		if f.PreviousFileName != "" {
+			result = append(result, f.PreviousFileName)
+		}
+	}
+	return result
+}
+
+// IsOrganizationMember checks if the user is a member of the organization owning the repository
+func (c *Client) IsOrganizationMember(user git.User) (bool, error) {
+	organization := strings.SplitN(c.IntegrationConfig.Spec.Git.Repository, "/", 2)[0]
+	apiURL := fmt.Sprintf("%s/orgs/%s/members/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), organization, user.Name)
+
+	if _, _, err := c.requestHTTP(http.MethodGet, apiURL, nil); err != nil {
+		if git.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// AddLabel adds a label to the issue or the pull request
+func (c *Client) AddLabel(issueNo int, label string) error {
+	apiURL := fmt.Sprintf("%s/repos/%s/issues/%d/labels", c.IntegrationConfig.Spec.Git.GetAPIUrl(), c.IntegrationConfig.Spec.Git.Repository, issueNo)
+
+	if _, _, err := c.requestHTTP(http.MethodPost, apiURL, &LabelsBody{Labels: []string{label}}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RemoveLabel removes a label from the issue or the pull request. It's not an error if the label is not set
+func (c *Client) RemoveLabel(issueNo int, label string) error {
+	apiURL := fmt.Sprintf("%s/repos/%s/issues/%d/labels/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), c.IntegrationConfig.Spec.Git.Repository, issueNo, url.PathEscape(label))
+
+	if _, _, err := c.requestHTTP(http.MethodDelete, apiURL, nil); err != nil && !git.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// ListOrganizationRepositories lists repositories of the organization
+func (c *Client) ListOrganizationRepositories(organization string) ([]git.OrganizationRepository, error) {
+	apiURL := fmt.Sprintf("%s/orgs/%s/repos?type=all&per_page=100", c.IntegrationConfig.Spec.Git.GetAPIUrl(), organization)
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []OrganizationRepo
+	if err := json.Unmarshal(data, &repos); err != nil {
+		return nil, err
+	}
+
+	var result []git.OrganizationRepository
+	for _, r := range repos {
+		result = append(result, git.OrganizationRepository{Name: r.Name, URL: r.URL, Topics: r.Topics, Archived: r.Archived})
+	}
+
+	return result, nil
+}
+
+func (c *Client) listRefs(refType string) ([]git.Ref, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/%s?per_page=100", c.IntegrationConfig.Spec.Git.GetAPIUrl(), c.IntegrationConfig.Spec.Git.Repository, refType)
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []RefInfo
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return nil, err
+	}
+
+	var result []git.Ref
+	for _, r := range refs {
+		result = append(result, git.Ref{Name: r.Name, Sha: r.Commit.Sha})
+	}
+
+	return result, nil
+}
+
+// getTagSha gets the sha of the commit which the tag points to
+func (c *Client) getTagSha(tag string) (string, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/commits/tags/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), c.IntegrationConfig.Spec.Git.Repository, url.PathEscape(tag))
+
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	commit := &CommitInfo{}
+	if err := json.Unmarshal(data, commit); err != nil {
+		return "", err
+	}
+	if commit.Sha == "" {
+		return "", fmt.Errorf("tag %s is not found", tag)
+	}
+
+	return commit.Sha, nil
+}
+
 func (c *Client) getPullRequestInfo(id int) (*git.PullRequest, error) {
 	apiURL := fmt.Sprintf("%s/repos/%s/pulls/%d", c.IntegrationConfig.Spec.Git.GetAPIUrl(), c.IntegrationConfig.Spec.Git.Repository, id)
 
@@ -195,14 +504,44 @@ func convertPullRequestToShared(pr *PullRequest) *git.PullRequest {
 			ID:   pr.User.ID,
 			Name: pr.User.Name,
 		},
-		URL:  pr.URL,
-		Base: git.Base{Ref: pr.Base.Ref},
-		Head: git.Head{Ref: pr.Head.Ref, Sha: pr.Head.Sha},
+		URL:    pr.URL,
+		Base:   git.Base{Ref: pr.Base.Ref},
+		Head:   git.Head{Ref: pr.Head.Ref, Sha: pr.Head.Sha},
+		Labels: convertLabelsToShared(pr.Labels),
+		Draft:  pr.Draft,
 	}
 }
 
+func convertLabelsToShared(labels []Label) []git.IssueLabel {
+	var result []git.IssueLabel
+	for _, l := range labels {
+		result = append(result, git.IssueLabel{Name: l.Name})
+	}
+	return result
+}
+
 func (c *Client) requestHTTP(method, apiURL string, data interface{}) ([]byte, http.Header, error) {
-	token, err := c.IntegrationConfig.GetToken(c.K8sClient)
+	httpCli, header, err := c.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli.Request(method, apiURL, header, data)
+}
+
+// requestHTTPAll requests api call, following all the pages
+func (c *Client) requestHTTPAll(method, apiURL string, data interface{}) ([]byte, http.Header, error) {
+	httpCli, header, err := c.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli.RequestAll(method, apiURL, header, data)
+}
+
+// httpClient returns a http client and a request header for the API server
+func (c *Client) httpClient() (*git.HTTPClient, map[string]string, error) {
+	token, err := c.getToken()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -211,21 +550,36 @@ func (c *Client) requestHTTP(method, apiURL string, data interface{}) ([]byte, h
 		"Accept":        "application/vnd.github.v3+json",
 	}
 
-	return git.RequestHTTP(method, apiURL, header, data)
+	httpCli, err := git.GetHTTPClient(c.IntegrationConfig, c.K8sClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli, header, nil
+}
+
+// getToken gets a token for accessing the repository
+// Installation access token is used if GitHub App is configured
+func (c *Client) getToken() (string, error) {
+	if c.IntegrationConfig.Spec.Git.GitHubApp != nil {
+		token, _, err := GetInstallationToken(c.IntegrationConfig, c.K8sClient)
+		return token, err
+	}
+	return c.IntegrationConfig.GetToken(c.K8sClient)
 }
 
 // IsValidPayload validates the webhook payload
-func IsValidPayload(secret, headerHash string, payload []byte) bool {
-	hash := HashPayload(secret, payload)
+func IsValidPayload(hashFunc func() hash.Hash, secret, headerHash string, payload []byte) bool {
+	payloadHash := HashPayload(hashFunc, secret, payload)
 	return hmac.Equal(
-		[]byte(hash),
+		[]byte(payloadHash),
 		[]byte(headerHash),
 	)
 }
 
 // HashPayload hashes the payload
-func HashPayload(secret string, payloadBody []byte) string {
-	hm := hmac.New(sha1.New, []byte(secret))
+func HashPayload(hashFunc func() hash.Hash, secret string, payloadBody []byte) string {
+	hm := hmac.New(hashFunc, []byte(secret))
 	_, err := hm.Write(payloadBody)
 	sum := hm.Sum(nil)
 	if err != nil {
@@ -234,9 +588,20 @@ func HashPayload(secret string, payloadBody []byte) string {
 	return fmt.Sprintf("%x", sum)
 }
 
-// Validate validates the webhook payload
-func Validate(secret, headerHash string, payload []byte) error {
-	if !IsValidPayload(secret, headerHash, payload) {
+// Validate validates the webhook payload using X-Hub-Signature-256 header
+// X-Hub-Signature (SHA-1) header is used only if X-Hub-Signature-256 is not given and SHA-1 signatures are allowed
+func Validate(secret string, header http.Header, payload []byte) error {
+	if signature := header.Get("x-hub-signature-256"); signature != "" {
+		if !IsValidPayload(sha256.New, secret, strings.TrimPrefix(signature, "sha256="), payload) {
+			return fmt.Errorf("invalid request : X-Hub-Signature-256 does not match secret")
+		}
+		return nil
+	}
+
+	if !configs.AllowSHA1WebhookSignature {
+		return fmt.Errorf("invalid request : X-Hub-Signature-256 is not given")
+	}
+	if !IsValidPayload(sha1.New, secret, strings.TrimPrefix(header.Get("x-hub-signature"), "sha1="), payload) {
 		return fmt.Errorf("invalid request : X-Hub-Signature does not match secret")
 	}
 	return nil
@@ -0,0 +1,103 @@
+package github
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/internal/configs"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"github.com/tmax-cloud/cicd-operator/pkg/git/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testPayload = `{"zen": "Keep it logically awesome."}`
+
+func testSignedHeader(secret string, sha256Signed, sha1Signed bool) http.Header {
+	header := http.Header{}
+	header.Set("x-github-event", "ping")
+	if sha256Signed {
+		header.Set("x-hub-signature-256", "sha256="+HashPayload(sha256.New, secret, []byte(testPayload)))
+	}
+	if sha1Signed {
+		header.Set("x-hub-signature", "sha1="+HashPayload(sha1.New, secret, []byte(testPayload)))
+	}
+	return header
+}
+
+func TestValidate(t *testing.T) {
+	defer func() {
+		configs.AllowSHA1WebhookSignature = false
+	}()
+
+	// SHA-256
+	assert.Equal(t, nil, Validate("secret", testSignedHeader("secret", true, true), []byte(testPayload)))
+	assert.NotEqual(t, nil, Validate("secret", testSignedHeader("wrong-secret", true, true), []byte(testPayload)))
+
+	// SHA-1 is not allowed by default
+	assert.NotEqual(t, nil, Validate("secret", testSignedHeader("secret", false, true), []byte(testPayload)))
+
+	// SHA-1 fallback
+	configs.AllowSHA1WebhookSignature = true
+	assert.Equal(t, nil, Validate("secret", testSignedHeader("secret", false, true), []byte(testPayload)))
+	assert.NotEqual(t, nil, Validate("secret", testSignedHeader("wrong-secret", false, true), []byte(testPayload)))
+
+	// SHA-256 is preferred, even if SHA-1 is allowed
+	header := testSignedHeader("secret", false, true)
+	header.Set("x-hub-signature-256", "sha256="+HashPayload(sha256.New, "wrong-secret", []byte(testPayload)))
+	assert.NotEqual(t, nil, Validate("secret", header, []byte(testPayload)))
+}
+
+func TestClient_ParseWebhookRotatedSecret(t *testing.T) {
+	c := &Client{IntegrationConfig: &cicdv1.IntegrationConfig{
+		Status: cicdv1.IntegrationConfigStatus{
+			Secrets:                  "new-secret",
+			PreviousSecrets:          "old-secret",
+			PreviousSecretsExpiresAt: &metav1.Time{Time: time.Now().Add(time.Hour)},
+		},
+	}}
+
+	// Both secrets are accepted during the grace period
+	_, err := c.ParseWebhook(testSignedHeader("new-secret", true, false), []byte(testPayload))
+	assert.Equal(t, nil, err)
+	_, err = c.ParseWebhook(testSignedHeader("old-secret", true, false), []byte(testPayload))
+	assert.Equal(t, nil, err)
+	_, err = c.ParseWebhook(testSignedHeader("wrong-secret", true, false), []byte(testPayload))
+	assert.NotEqual(t, nil, err)
+
+	// Previous secret is not accepted after the grace period
+	c.IntegrationConfig.Status.PreviousSecretsExpiresAt = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+	_, err = c.ParseWebhook(testSignedHeader("old-secret", true, false), []byte(testPayload))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestClient_ListChangedFiles(t *testing.T) {
+	const headSha = "6dcb09b5b57875f334f61aebed695e2e4193db5e"
+	var manyFiles []string
+	for i := 0; i < maxComparisonFiles; i++ {
+		manyFiles = append(manyFiles, fmt.Sprintf("pkg/file%d.go", i))
+	}
+
+	srv := fake.NewGitHubServer(&fake.Repository{
+		Name:         "tmax-cloud/cicd-operator",
+		PullRequests: []git.PullRequest{{ID: 3, State: git.PullRequestStateOpen, Head: git.Head{Sha: headSha}}},
+		ChangedFiles: map[string][]string{headSha: manyFiles},
+	}, "test-token")
+	defer srv.Close()
+
+	c := testCheckRunClient(srv.URL)
+
+	// Comparison may be truncated
+	_, err := c.ListChangedFiles("master", headSha)
+	assert.NotEqual(t, nil, err)
+
+	// Files of the pull request are listed across the pages
+	files, err := c.ListPullRequestChangedFiles(3)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, manyFiles, files)
+}
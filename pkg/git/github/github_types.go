@@ -1,5 +1,7 @@
 package github
 
+import "time"
+
 // UserInfo is a body of user get API
 type UserInfo struct {
 	ID       int    `json:"id"`
@@ -24,3 +26,75 @@ type CommitStatusBody struct {
 type CommentBody struct {
 	Body string `json:"body"`
 }
+
+// RefInfo is a branch or a tag of branch/tag list API
+type RefInfo struct {
+	Name   string `json:"name"`
+	Commit struct {
+		Sha string `json:"sha"`
+	} `json:"commit"`
+}
+
+// CommitInfo is a commit of commit API
+type CommitInfo struct {
+	Sha string `json:"sha"`
+}
+
+// OrganizationRepo is a repository of organization repository list API
+type OrganizationRepo struct {
+	Name     string   `json:"full_name"`
+	URL      string   `json:"html_url"`
+	Topics   []string `json:"topics"`
+	Archived bool     `json:"archived"`
+}
+
+// AppInstallation is an installation of GitHub App
+type AppInstallation struct {
+	ID int64 `json:"id"`
+}
+
+// InstallationAccessToken is an access token of GitHub App's installation
+type InstallationAccessToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CheckRunBody is an API body for creating/updating check runs
+type CheckRunBody struct {
+	Name        string          `json:"name"`
+	HeadSha     string          `json:"head_sha,omitempty"`
+	DetailsURL  string          `json:"details_url,omitempty"`
+	ExternalID  string          `json:"external_id,omitempty"`
+	Status      string          `json:"status"`
+	Conclusion  string          `json:"conclusion,omitempty"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	Output      *CheckRunOutput `json:"output,omitempty"`
+}
+
+// CheckRunOutput is an output of a check run
+type CheckRunOutput struct {
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+	Text    string `json:"text,omitempty"`
+}
+
+// CheckRunList is a body of check run list API
+type CheckRunList struct {
+	TotalCount int `json:"total_count"`
+	CheckRuns  []struct {
+		ID         int64  `json:"id"`
+		ExternalID string `json:"external_id"`
+	} `json:"check_runs"`
+}
+
+// Comparison is a result of comparing two commits
+type Comparison struct {
+	Files []ChangedFile `json:"files"`
+}
+
+// ChangedFile is a file changed by the commits or the pull request
+type ChangedFile struct {
+	FileName         string `json:"filename"`
+	PreviousFileName string `json:"previous_filename"`
+}
@@ -24,7 +24,11 @@ func (c *Client) parsePullRequestWebhook(jsonString []byte) (*git.Webhook, error
 	base := git.Base{Ref: data.PullRequest.Base.Ref}
 	head := git.Head{Ref: data.PullRequest.Head.Ref, Sha: data.PullRequest.Head.Sha}
 	repo := git.Repository{Name: data.Repo.Name, URL: data.Repo.URL}
-	pullRequest := git.PullRequest{ID: data.Number, Title: data.PullRequest.Title, Sender: sender, URL: data.Repo.URL, Base: base, Head: head, State: git.PullRequestState(data.PullRequest.State), Action: git.PullRequestAction(data.Action)}
+	pullRequest := git.PullRequest{ID: data.Number, Title: data.PullRequest.Title, Sender: sender, URL: data.Repo.URL, Base: base, Head: head, State: git.PullRequestState(data.PullRequest.State), Action: git.PullRequestAction(data.Action), Labels: convertLabelsToShared(data.PullRequest.Labels), Draft: data.PullRequest.Draft}
+	pullRequest.Author = git.User{Name: data.PullRequest.User.Name, ID: data.PullRequest.User.ID}
+	if data.Label != nil {
+		pullRequest.LabelChanged = []git.IssueLabel{{Name: data.Label.Name}}
+	}
 	return &git.Webhook{EventType: git.EventTypePullRequest, Repo: repo, PullRequest: &pullRequest}, nil
 }
 
@@ -38,7 +42,10 @@ func (c *Client) parsePushWebhook(jsonString []byte) (*git.Webhook, error) {
 	if strings.HasPrefix(data.Sha, "0000") && strings.HasSuffix(data.Sha, "0000") {
 		return nil, nil
 	}
-	push := git.Push{Sender: git.User{Name: data.Sender.Name, ID: data.Sender.ID}, Ref: data.Ref, Sha: data.Sha}
+	push := git.Push{Sender: git.User{Name: data.Sender.Name, ID: data.Sender.ID}, Ref: data.Ref, Before: data.Before, Sha: data.Sha}
+	if data.HeadCommit != nil {
+		push.Message = data.HeadCommit.Message
+	}
 
 	// Get sender email
 	userInfo, err := c.GetUserInfo(data.Sender.Name)
@@ -49,6 +56,36 @@ func (c *Client) parsePushWebhook(jsonString []byte) (*git.Webhook, error) {
 	return &git.Webhook{EventType: git.EventTypePush, Repo: repo, Push: &push}, nil
 }
 
+func (c *Client) parseReleaseWebhook(jsonString []byte) (*git.Webhook, error) {
+	var data ReleaseWebhook
+
+	if err := json.Unmarshal(jsonString, &data); err != nil {
+		return nil, err
+	}
+
+	// Only handle publication (drafts are not published yet)
+	if data.Action != "published" {
+		return nil, nil
+	}
+
+	// Release webhook does not contain the sha of the tag
+	sha, err := c.getTagSha(data.Release.TagName)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := git.Repository{Name: data.Repo.Name, URL: data.Repo.URL}
+	release := git.Release{Sender: git.User{Name: data.Sender.Name, ID: data.Sender.ID}, Tag: data.Release.TagName, Name: data.Release.Name, Notes: data.Release.Body, Prerelease: data.Release.Prerelease, Sha: sha, URL: data.Release.URL}
+
+	// Get sender email
+	userInfo, err := c.GetUserInfo(data.Sender.Name)
+	if err == nil {
+		release.Sender.Email = userInfo.Email
+	}
+
+	return &git.Webhook{EventType: git.EventTypeRelease, Repo: repo, Release: &release}, nil
+}
+
 func (c *Client) parseIssueCommentWebhook(jsonString []byte) (*git.Webhook, error) {
 	issueComment := &IssueCommentWebhook{}
 	if err := json.Unmarshal(jsonString, issueComment); err != nil {
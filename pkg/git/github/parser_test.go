@@ -0,0 +1,66 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"github.com/tmax-cloud/cicd-operator/pkg/git/fake"
+)
+
+func TestClient_parseReleaseWebhook(t *testing.T) {
+	srv := fake.NewGitHubServer(&fake.Repository{
+		Name:  "tmax-cloud/cicd-operator",
+		Users: []fake.User{{ID: 1, Name: "releaser", Email: "releaser@tmax.co.kr"}},
+		Tags:  []git.Ref{{Name: "v0.1.0", Sha: "0123456789abcdef0123456789abcdef01234567"}, {Name: "v0.2.0-rc.1", Sha: "6dcb09b5b57875f334f61aebed695e2e4193db5e"}, {Name: "release/v0.3.0", Sha: "89abcdef0123456789abcdef0123456789abcdef"}},
+	}, "test-token")
+	defer srv.Close()
+
+	c := testCheckRunClient(srv.URL)
+
+	tc := map[string]struct {
+		body        string
+		expected    *git.Release
+		errorOccurs bool
+	}{
+		"published": {
+			body:     `{"action": "published", "release": {"tag_name": "v0.2.0-rc.1", "name": "v0.2.0 RC 1", "body": "notes", "prerelease": true, "html_url": "https://github.com/tmax-cloud/cicd-operator/releases/tag/v0.2.0-rc.1"}, "repository": {"full_name": "tmax-cloud/cicd-operator"}, "sender": {"login": "releaser", "id": 1}}`,
+			expected: &git.Release{Sender: git.User{ID: 1, Name: "releaser", Email: "releaser@tmax.co.kr"}, Tag: "v0.2.0-rc.1", Name: "v0.2.0 RC 1", Notes: "notes", Prerelease: true, Sha: "6dcb09b5b57875f334f61aebed695e2e4193db5e", URL: "https://github.com/tmax-cloud/cicd-operator/releases/tag/v0.2.0-rc.1"},
+		},
+		"tagWithSlash": {
+			body:     `{"action": "published", "release": {"tag_name": "release/v0.3.0"}, "repository": {"full_name": "tmax-cloud/cicd-operator"}, "sender": {"login": "releaser", "id": 1}}`,
+			expected: &git.Release{Sender: git.User{ID: 1, Name: "releaser", Email: "releaser@tmax.co.kr"}, Tag: "release/v0.3.0", Sha: "89abcdef0123456789abcdef0123456789abcdef"},
+		},
+		"created": {
+			body: `{"action": "created", "release": {"tag_name": "v0.1.0"}, "repository": {"full_name": "tmax-cloud/cicd-operator"}, "sender": {"login": "releaser", "id": 1}}`,
+		},
+		"tagNotFound": {
+			body:        `{"action": "published", "release": {"tag_name": "v9.9.9"}, "repository": {"full_name": "tmax-cloud/cicd-operator"}, "sender": {"login": "releaser", "id": 1}}`,
+			errorOccurs: true,
+		},
+	}
+
+	for name, tc := range tc {
+		t.Run(name, func(t *testing.T) {
+			wh, err := c.parseWebhook(git.EventTypeRelease, []byte(tc.body))
+			if tc.errorOccurs {
+				assert.NotEqual(t, nil, err)
+				return
+			}
+			assert.Equal(t, nil, err)
+			if tc.expected == nil {
+				assert.Equal(t, (*git.Webhook)(nil), wh)
+				return
+			}
+			assert.Equal(t, git.EventTypeRelease, wh.EventType)
+			assert.Equal(t, "tmax-cloud/cicd-operator", wh.Repo.Name)
+			assert.Equal(t, tc.expected, wh.Release)
+		})
+	}
+
+	// Tag is resolved by a single request, not by listing the tags
+	srv.ResetRequests()
+	_, err := c.parseWebhook(git.EventTypeRelease, []byte(tc["published"].body))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "/repos/tmax-cloud/cicd-operator/commits/tags/v0.2.0-rc.1", srv.Requests()[0].Path)
+}
@@ -9,6 +9,8 @@ type PullRequestWebhook struct {
 	Sender User   `json:"sender"`
 
 	PullRequest PullRequest `json:"pull_request"`
+	// Label is set only for labeled/unlabeled actions
+	Label *Label `json:"label"`
 
 	Repo Repo `json:"repository"`
 }
@@ -18,7 +20,26 @@ type PushWebhook struct {
 	Ref    string `json:"ref"`
 	Repo   Repo   `json:"repository"`
 	Sender User   `json:"sender"`
+	Before string `json:"before"`
 	Sha    string `json:"after"`
+
+	HeadCommit *struct {
+		Message string `json:"message"`
+	} `json:"head_commit"`
+}
+
+// ReleaseWebhook is a github-specific release event webhook body
+type ReleaseWebhook struct {
+	Action  string `json:"action"`
+	Release struct {
+		TagName    string `json:"tag_name"`
+		Name       string `json:"name"`
+		Body       string `json:"body"`
+		Prerelease bool   `json:"prerelease"`
+		URL        string `json:"html_url"`
+	} `json:"release"`
+	Repo   Repo `json:"repository"`
+	Sender User `json:"sender"`
 }
 
 // IssueCommentWebhook is a github-specific issue_comment webhook body
@@ -79,6 +100,18 @@ type PullRequest struct {
 	Base struct {
 		Ref string `json:"ref"`
 	} `json:"base"`
+	Labels []Label `json:"labels"`
+	Draft  bool    `json:"draft"`
+}
+
+// LabelsBody is a request body for adding labels to an issue or a pull request
+type LabelsBody struct {
+	Labels []string `json:"labels"`
+}
+
+// Label is a label of an issue or a pull request
+type Label struct {
+	Name string `json:"name"`
 }
 
 // User is a sender of the event
@@ -112,8 +145,28 @@ type RegistrationWebhookBodyConfig struct {
 
 // WebhookEntry is a body of list of registered webhooks
 type WebhookEntry struct {
-	ID     int `json:"id"`
+	ID     int      `json:"id"`
+	Active bool     `json:"active"`
+	Events []string `json:"events"`
 	Config struct {
-		URL string `json:"url"`
+		URL         string `json:"url"`
+		ContentType string `json:"content_type"`
+		// Secret is masked by GitHub, but is empty if it's not configured
+		Secret string `json:"secret"`
 	} `json:"config"`
 }
+
+// CheckRunWebhook is a github-specific check_run webhook body
+type CheckRunWebhook struct {
+	Action   string `json:"action"`
+	CheckRun struct {
+		Name         string `json:"name"`
+		ExternalID   string `json:"external_id"`
+		HeadSha      string `json:"head_sha"`
+		PullRequests []struct {
+			Number int `json:"number"`
+		} `json:"pull_requests"`
+	} `json:"check_run"`
+	Repo   Repo `json:"repository"`
+	Sender User `json:"sender"`
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"path"
 	"strconv"
 	"strings"
 
@@ -21,11 +22,21 @@ type Client struct {
 
 // ParseWebhook parses a webhook body for gitlab
 func (c *Client) ParseWebhook(header http.Header, jsonString []byte) (*git.Webhook, error) {
-	if err := Validate(c.IntegrationConfig.Status.Secrets, header.Get("x-gitlab-token")); err != nil {
+	if err := git.ValidateWebhookSecrets(c.IntegrationConfig.GetWebhookSecrets(), func(secret string) error {
+		return Validate(secret, header.Get("x-gitlab-token"))
+	}); err != nil {
 		return nil, err
 	}
 
-	eventFromHeader := header.Get("x-gitlab-event")
+	wh, err := c.parseWebhook(header.Get("x-gitlab-event"), jsonString)
+	if err != nil || wh == nil {
+		return wh, err
+	}
+	wh.DeliveryID = header.Get("x-gitlab-event-uuid")
+	return wh, nil
+}
+
+func (c *Client) parseWebhook(eventFromHeader string, jsonString []byte) (*git.Webhook, error) {
 	switch eventFromHeader {
 	case "Merge Request Hook":
 		return c.parsePullRequestWebhook(jsonString)
@@ -33,6 +44,8 @@ func (c *Client) ParseWebhook(header http.Header, jsonString []byte) (*git.Webho
 		return c.parsePushWebhook(jsonString)
 	case "Note Hook":
 		return c.parseIssueComment(jsonString)
+	case "Release Hook":
+		return c.parseReleaseWebhook(jsonString)
 	}
 
 	return nil, nil
@@ -43,7 +56,7 @@ func (c *Client) ListWebhook() ([]git.WebhookEntry, error) {
 	encodedRepoPath := url.QueryEscape(c.IntegrationConfig.Spec.Git.Repository)
 	apiURL := c.IntegrationConfig.Spec.Git.GetAPIUrl() + "/api/v4/projects/" + encodedRepoPath + "/hooks"
 
-	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -55,7 +68,7 @@ func (c *Client) ListWebhook() ([]git.WebhookEntry, error) {
 
 	var result []git.WebhookEntry
 	for _, e := range entries {
-		result = append(result, git.WebhookEntry{ID: e.ID, URL: e.URL})
+		result = append(result, git.WebhookEntry{ID: strconv.Itoa(e.ID), URL: e.URL, Events: e.Enabled()})
 	}
 
 	return result, nil
@@ -67,19 +80,8 @@ func (c *Client) RegisterWebhook(uri string) error {
 	EncodedRepoPath := url.QueryEscape(c.IntegrationConfig.Spec.Git.Repository)
 	apiURL := c.IntegrationConfig.Spec.Git.GetAPIUrl() + "/api/v4/projects/" + EncodedRepoPath + "/hooks"
 
-	//enable hooks from every events
 	registrationBody.EnableSSLVerification = false
-	registrationBody.ConfidentialIssueEvents = true
-	registrationBody.ConfidentialNoteEvents = true
-	registrationBody.DeploymentEvents = true
-	registrationBody.IssueEvents = true
-	registrationBody.JobEvents = true
-	registrationBody.MergeRequestEvents = true
-	registrationBody.NoteEvents = true
-	registrationBody.PipeLineEvents = true
-	registrationBody.PushEvents = true
-	registrationBody.TagPushEvents = true
-	registrationBody.WikiPageEvents = true
+	registrationBody.WebhookEvents = c.webhookEvents()
 	registrationBody.URL = uri
 	registrationBody.ID = EncodedRepoPath
 	registrationBody.Token = c.IntegrationConfig.Status.Secrets
@@ -91,10 +93,36 @@ func (c *Client) RegisterWebhook(uri string) error {
 	return nil
 }
 
+// ExpectedWebhook returns the webhook entry expected to be registered for the url
+// GitLab does not report whether the secret token is configured
+func (c *Client) ExpectedWebhook(url string) git.WebhookEntry {
+	events := c.webhookEvents()
+	return git.WebhookEntry{URL: url, Events: events.Enabled()}
+}
+
+// webhookEvents returns the gitlab events which the webhook should be subscribed to
+func (c *Client) webhookEvents() WebhookEvents {
+	events := WebhookEvents{}
+	for _, ev := range git.WebhookEvents(c.IntegrationConfig) {
+		switch ev {
+		case git.EventTypePullRequest:
+			events.MergeRequestEvents = true
+		case git.EventTypePush:
+			events.PushEvents = true
+			events.TagPushEvents = true
+		case git.EventTypeIssueComment:
+			events.NoteEvents = true
+		case git.EventTypeRelease:
+			events.ReleasesEvents = true
+		}
+	}
+	return events
+}
+
 // DeleteWebhook deletes registered webhook
-func (c *Client) DeleteWebhook(id int) error {
+func (c *Client) DeleteWebhook(id string) error {
 	encodedRepoPath := url.QueryEscape(c.IntegrationConfig.Spec.Git.Repository)
-	apiURL := c.IntegrationConfig.Spec.Git.GetAPIUrl() + "/api/v4/projects/" + encodedRepoPath + "/hooks/" + strconv.Itoa(id)
+	apiURL := c.IntegrationConfig.Spec.Git.GetAPIUrl() + "/api/v4/projects/" + encodedRepoPath + "/hooks/" + id
 
 	if _, _, err := c.requestHTTP(http.MethodDelete, apiURL, nil); err != nil {
 		return err
@@ -168,6 +196,10 @@ func (c *Client) CanUserWriteToRepo(user git.User) (bool, error) {
 
 	result, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
 	if err != nil {
+		// Not a member of the project
+		if git.IsNotFound(err) {
+			return false, nil
+		}
 		return false, err
 	}
 
@@ -200,7 +232,236 @@ func (c *Client) RegisterComment(issueType git.IssueType, issueNo int, body stri
 	return nil
 }
 
+// GetRepository gets the project's information
+func (c *Client) GetRepository() (*git.Repository, error) {
+	apiURL := fmt.Sprintf("%s/api/v4/projects/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), url.QueryEscape(c.IntegrationConfig.Spec.Git.Repository))
+
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	project := &Project{}
+	if err := json.Unmarshal(data, project); err != nil {
+		return nil, err
+	}
+
+	return &git.Repository{Name: project.Name, URL: project.WebURL}, nil
+}
+
+// ListBranches lists branches of the repository
+func (c *Client) ListBranches() ([]git.Ref, error) {
+	return c.listRefs("branches")
+}
+
+// GetBranch gets a branch of the repository
+func (c *Client) GetBranch(name string) (*git.Ref, error) {
+	apiURL := fmt.Sprintf("%s/api/v4/projects/%s/repository/branches/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), url.QueryEscape(c.IntegrationConfig.Spec.Git.Repository), url.PathEscape(name))
+
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		if git.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ref RefInfo
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return nil, err
+	}
+
+	return &git.Ref{Name: ref.Name, Sha: ref.Commit.ID}, nil
+}
+
+// ListTags lists tags of the repository
+func (c *Client) ListTags() ([]git.Ref, error) {
+	return c.listRefs("tags")
+}
+
+// GetTag gets a tag of the repository
+func (c *Client) GetTag(name string) (*git.Ref, error) {
+	apiURL := fmt.Sprintf("%s/api/v4/projects/%s/repository/tags/%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), url.QueryEscape(c.IntegrationConfig.Spec.Git.Repository), url.PathEscape(name))
+
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		if git.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ref RefInfo
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return nil, err
+	}
+
+	return &git.Ref{Name: ref.Name, Sha: ref.Commit.ID}, nil
+}
+
+// ListOpenPullRequests lists open merge requests of the repository
+func (c *Client) ListOpenPullRequests() ([]git.PullRequest, error) {
+	apiURL := fmt.Sprintf("%s/api/v4/projects/%s/merge_requests?state=opened&per_page=100", c.IntegrationConfig.Spec.Git.GetAPIUrl(), url.QueryEscape(c.IntegrationConfig.Spec.Git.Repository))
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var mrs []MergeRequest
+	if err := json.Unmarshal(data, &mrs); err != nil {
+		return nil, err
+	}
+
+	var result []git.PullRequest
+	for _, mr := range mrs {
+		result = append(result, git.PullRequest{
+			ID:     mr.IID,
+			Title:  mr.Title,
+			State:  git.PullRequestStateOpen,
+			Sender: git.User{ID: mr.Author.ID, Name: mr.Author.UserName},
+			URL:    mr.WebURL,
+			Base:   git.Base{Ref: mr.TargetBranch},
+			Head:   git.Head{Ref: mr.SourceBranch, Sha: mr.Sha},
+			Labels: convertLabelNamesToShared(mr.Labels),
+			Draft:  mr.Draft || mr.WIP,
+		})
+	}
+
+	return result, nil
+}
+
+// ListChangedFiles lists the files changed by the head commit, compared to the merge base of base and head
+func (c *Client) ListChangedFiles(base, head string) ([]string, error) {
+	encodedRepoPath := url.QueryEscape(c.IntegrationConfig.Spec.Git.Repository)
+	query := url.Values{}
+	query.Set("from", base)
+	query.Set("to", head)
+	apiURL := fmt.Sprintf("%s/api/v4/projects/%s/repository/compare?%s", c.IntegrationConfig.Spec.Git.GetAPIUrl(), encodedRepoPath, query.Encode())
+
+	data, _, err := c.requestHTTP(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &Comparison{}
+	if err := json.Unmarshal(data, comparison); err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, d := range comparison.Diffs {
+		result = append(result, d.NewPath)
+		if d.OldPath != d.NewPath {
+			result = append(result, d.OldPath)
+		}
+	}
+
+	return result, nil
+}
+
+// IsOrganizationMember checks if the user is a member of the group the project belongs to, including the inherited members
+func (c *Client) IsOrganizationMember(user git.User) (bool, error) {
+	group := path.Dir(c.IntegrationConfig.Spec.Git.Repository)
+	apiURL := fmt.Sprintf("%s/api/v4/groups/%s/members/all/%d", c.IntegrationConfig.Spec.Git.GetAPIUrl(), url.QueryEscape(group), user.ID)
+
+	if _, _, err := c.requestHTTP(http.MethodGet, apiURL, nil); err != nil {
+		if git.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// AddLabel adds a label to the merge request
+func (c *Client) AddLabel(issueNo int, label string) error {
+	return c.updateMergeRequestLabels(issueNo, &MergeRequestLabelsBody{AddLabels: label})
+}
+
+// RemoveLabel removes a label from the merge request. It's not an error if the label is not set
+func (c *Client) RemoveLabel(issueNo int, label string) error {
+	return c.updateMergeRequestLabels(issueNo, &MergeRequestLabelsBody{RemoveLabels: label})
+}
+
+func (c *Client) updateMergeRequestLabels(issueNo int, body *MergeRequestLabelsBody) error {
+	apiURL := fmt.Sprintf("%s/api/v4/projects/%s/merge_requests/%d", c.IntegrationConfig.Spec.Git.GetAPIUrl(), url.QueryEscape(c.IntegrationConfig.Spec.Git.Repository), issueNo)
+
+	if _, _, err := c.requestHTTP(http.MethodPut, apiURL, body); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListOrganizationRepositories lists projects of the group, including the projects of its subgroups
+func (c *Client) ListOrganizationRepositories(organization string) ([]git.OrganizationRepository, error) {
+	apiURL := fmt.Sprintf("%s/api/v4/groups/%s/projects?include_subgroups=true&per_page=100", c.IntegrationConfig.Spec.Git.GetAPIUrl(), url.QueryEscape(organization))
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var projects []GroupProject
+	if err := json.Unmarshal(data, &projects); err != nil {
+		return nil, err
+	}
+
+	var result []git.OrganizationRepository
+	for _, p := range projects {
+		topics := p.Topics
+		if topics == nil {
+			// Topics were called tag list before GitLab 14.0
+			topics = p.TagList
+		}
+		result = append(result, git.OrganizationRepository{Name: p.Name, URL: p.WebURL, Topics: topics, Archived: p.Archived})
+	}
+
+	return result, nil
+}
+
+func (c *Client) listRefs(refType string) ([]git.Ref, error) {
+	apiURL := fmt.Sprintf("%s/api/v4/projects/%s/repository/%s?per_page=100", c.IntegrationConfig.Spec.Git.GetAPIUrl(), url.QueryEscape(c.IntegrationConfig.Spec.Git.Repository), refType)
+
+	data, _, err := c.requestHTTPAll(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []RefInfo
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return nil, err
+	}
+
+	var result []git.Ref
+	for _, r := range refs {
+		result = append(result, git.Ref{Name: r.Name, Sha: r.Commit.ID})
+	}
+
+	return result, nil
+}
+
 func (c *Client) requestHTTP(method, apiURL string, data interface{}) ([]byte, http.Header, error) {
+	httpCli, header, err := c.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli.Request(method, apiURL, header, data)
+}
+
+// requestHTTPAll requests api call, following all the pages
+func (c *Client) requestHTTPAll(method, apiURL string, data interface{}) ([]byte, http.Header, error) {
+	httpCli, header, err := c.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli.RequestAll(method, apiURL, header, data)
+}
+
+// httpClient returns a http client and a request header for the API server
+func (c *Client) httpClient() (*git.HTTPClient, map[string]string, error) {
 	token, err := c.IntegrationConfig.GetToken(c.K8sClient)
 	if err != nil {
 		return nil, nil, err
@@ -210,7 +471,12 @@ func (c *Client) requestHTTP(method, apiURL string, data interface{}) ([]byte, h
 		"Content-Type":  "application/json",
 	}
 
-	return git.RequestHTTP(method, apiURL, header, data)
+	httpCli, err := git.GetHTTPClient(c.IntegrationConfig, c.K8sClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return httpCli, header, nil
 }
 
 // Validate validates the webhook payload
@@ -220,3 +486,19 @@ func Validate(secret, headerToken string) error {
 	}
 	return nil
 }
+
+func convertLabelsToShared(labels []Label) []git.IssueLabel {
+	var result []git.IssueLabel
+	for _, l := range labels {
+		result = append(result, git.IssueLabel{Name: l.Title})
+	}
+	return result
+}
+
+func convertLabelNamesToShared(names []string) []git.IssueLabel {
+	var result []git.IssueLabel
+	for _, n := range names {
+		result = append(result, git.IssueLabel{Name: n})
+	}
+	return result
+}
@@ -25,3 +25,46 @@ type CommitStatusBody struct {
 type CommentBody struct {
 	Body string `json:"body"`
 }
+
+// RefInfo is a branch or a tag of branch/tag list API
+type RefInfo struct {
+	Name   string `json:"name"`
+	Commit struct {
+		ID string `json:"id"`
+	} `json:"commit"`
+}
+
+// MergeRequest is a merge request of merge request list API
+type MergeRequest struct {
+	IID    int    `json:"iid"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	WebURL string `json:"web_url"`
+	Author struct {
+		ID       int    `json:"id"`
+		UserName string `json:"username"`
+	} `json:"author"`
+	SourceBranch string   `json:"source_branch"`
+	TargetBranch string   `json:"target_branch"`
+	Sha          string   `json:"sha"`
+	Labels       []string `json:"labels"`
+	Draft        bool     `json:"draft"`
+	WIP          bool     `json:"work_in_progress"`
+}
+
+// GroupProject is a project of group project list API
+type GroupProject struct {
+	Name     string   `json:"path_with_namespace"`
+	WebURL   string   `json:"web_url"`
+	Topics   []string `json:"topics"`
+	TagList  []string `json:"tag_list"`
+	Archived bool     `json:"archived"`
+}
+
+// Comparison is a result of comparing two commits
+type Comparison struct {
+	Diffs []struct {
+		OldPath string `json:"old_path"`
+		NewPath string `json:"new_path"`
+	} `json:"diffs"`
+}
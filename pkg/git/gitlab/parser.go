@@ -14,13 +14,13 @@ func (c *Client) parsePullRequestWebhook(jsonString []byte) (*git.Webhook, error
 	if err := json.Unmarshal(jsonString, &data); err != nil {
 		return nil, err
 	}
-	sender := git.User{Name: data.User.Name, Email: data.User.Email}
+	sender := git.User{ID: data.User.ID, Name: data.User.UserName, Email: data.User.Email}
 	base := git.Base{Ref: data.ObjectAttribute.BaseRef}
 	head := git.Head{Ref: data.ObjectAttribute.HeadRef, Sha: data.ObjectAttribute.LastCommit.Sha}
 	repo := git.Repository{Name: data.Project.Name, URL: data.Project.WebURL}
 	action := git.PullRequestAction(data.ObjectAttribute.Action)
 	switch string(action) {
-	case "close":
+	case "close", "merge":
 		action = git.PullRequestActionClose
 	case "open":
 		action = git.PullRequestActionOpen
@@ -29,6 +29,16 @@ func (c *Client) parsePullRequestWebhook(jsonString []byte) (*git.Webhook, error
 	case "update":
 		action = git.PullRequestActionSynchronize
 	}
+	// Draft flag removal without new commits is converted to ready_for_review action,
+	// and label changes without new commits are converted to labeled/unlabeled actions
+	var labelChanged []git.IssueLabel
+	if action == git.PullRequestActionSynchronize && data.ObjectAttribute.OldRev == "" {
+		if isDraftRemoved(data.Changes.Draft) || isDraftRemoved(data.Changes.WIP) {
+			action = git.PullRequestActionReadyForReview
+		} else if data.Changes.Labels != nil {
+			action, labelChanged = diffLabels(data.Changes.Labels.Previous, data.Changes.Labels.Current)
+		}
+	}
 	state := git.PullRequestState(data.ObjectAttribute.State)
 	switch string(state) {
 	case "opened":
@@ -36,7 +46,8 @@ func (c *Client) parsePullRequestWebhook(jsonString []byte) (*git.Webhook, error
 	case "closed":
 		state = git.PullRequestStateClosed
 	}
-	pullRequest := git.PullRequest{ID: data.ObjectAttribute.ID, Title: data.ObjectAttribute.Title, Sender: sender, URL: data.Project.WebURL, Base: base, Head: head, State: state, Action: action}
+	pullRequest := git.PullRequest{ID: data.ObjectAttribute.IID, Title: data.ObjectAttribute.Title, Sender: sender, URL: data.Project.WebURL, Base: base, Head: head, State: state, Action: action, Labels: convertLabelsToShared(data.Labels), LabelChanged: labelChanged, Draft: data.ObjectAttribute.Draft || data.ObjectAttribute.WIP}
+	pullRequest.Author = c.getAuthor(data.ObjectAttribute.AuthorID, sender)
 	return &git.Webhook{EventType: git.EventTypePullRequest, Repo: repo, PullRequest: &pullRequest}, nil
 }
 
@@ -50,7 +61,12 @@ func (c *Client) parsePushWebhook(jsonString []byte) (*git.Webhook, error) {
 	if strings.HasPrefix(data.Sha, "0000") && strings.HasSuffix(data.Sha, "0000") {
 		return nil, nil
 	}
-	push := git.Push{Sender: git.User{Name: data.UserName, ID: data.UserID}, Ref: data.Ref, Sha: data.Sha}
+	push := git.Push{Sender: git.User{Name: data.UserName, ID: data.UserID}, Ref: data.Ref, Before: data.Before, Sha: data.Sha}
+	for _, commit := range data.Commits {
+		if commit.ID == data.Sha {
+			push.Message = commit.Message
+		}
+	}
 
 	// Get sender email
 	userInfo, err := c.GetUserInfo(strconv.Itoa(data.UserID))
@@ -61,6 +77,24 @@ func (c *Client) parsePushWebhook(jsonString []byte) (*git.Webhook, error) {
 	return &git.Webhook{EventType: git.EventTypePush, Repo: repo, Push: &push}, nil
 }
 
+func (c *Client) parseReleaseWebhook(jsonString []byte) (*git.Webhook, error) {
+	var data ReleaseHook
+
+	if err := json.Unmarshal(jsonString, &data); err != nil {
+		return nil, err
+	}
+
+	// Only handle creation
+	if data.Action != "create" {
+		return nil, nil
+	}
+
+	repo := git.Repository{Name: data.Project.Name, URL: data.Project.WebURL}
+	release := git.Release{Tag: data.Tag, Name: data.Name, Notes: data.Description, Prerelease: data.UpcomingRelease, Sha: data.Commit.ID, URL: data.URL}
+
+	return &git.Webhook{EventType: git.EventTypeRelease, Repo: repo, Release: &release}, nil
+}
+
 func (c *Client) parseIssueComment(jsonString []byte) (*git.Webhook, error) {
 	data := &NoteHook{}
 
@@ -89,7 +123,7 @@ func (c *Client) parseIssueComment(jsonString []byte) (*git.Webhook, error) {
 			mrAuthor = &git.User{ID: data.MergeRequest.AuthorID}
 		}
 		pr = &git.PullRequest{
-			ID:     data.MergeRequest.ID,
+			ID:     data.MergeRequest.IID,
 			Title:  data.MergeRequest.Title,
 			State:  mrState,
 			Sender: *mrAuthor,
@@ -101,6 +135,8 @@ func (c *Client) parseIssueComment(jsonString []byte) (*git.Webhook, error) {
 				Ref: data.MergeRequest.SourceBranch,
 				Sha: data.MergeRequest.LastCommit.ID,
 			},
+			Labels: convertLabelsToShared(data.MergeRequest.Labels),
+			Draft:  data.MergeRequest.Draft || data.MergeRequest.WIP,
 		}
 	}
 
@@ -117,8 +153,50 @@ func (c *Client) parseIssueComment(jsonString []byte) (*git.Webhook, error) {
 		},
 		Sender: git.User{
 			ID:    data.ObjectAttributes.AuthorID,
-			Name:  data.User.Name,
+			Name:  data.User.UserName,
 			Email: data.User.Email,
 		},
 	}}, nil
 }
+
+// diffLabels returns labeled action and the added labels if any label is added, or unlabeled action and the removed labels
+func diffLabels(previous, current []Label) (git.PullRequestAction, []git.IssueLabel) {
+	if added := subtractLabels(current, previous); len(added) > 0 {
+		return git.PullRequestActionLabeled, added
+	}
+	return git.PullRequestActionUnlabeled, subtractLabels(previous, current)
+}
+
+// subtractLabels returns the labels in a, but not in b
+func subtractLabels(a, b []Label) []git.IssueLabel {
+	var result []git.IssueLabel
+	for _, l := range a {
+		found := false
+		for _, m := range b {
+			if l.Title == m.Title {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, git.IssueLabel{Name: l.Title})
+		}
+	}
+	return result
+}
+
+func isDraftRemoved(change *BoolChange) bool {
+	return change != nil && change.Previous && !change.Current
+}
+
+// getAuthor returns the author of the merge request. The name is looked up if the author is not the sender
+func (c *Client) getAuthor(authorID int, sender git.User) git.User {
+	if authorID == 0 || authorID == sender.ID {
+		return sender
+	}
+	author, err := c.GetUserInfo(strconv.Itoa(authorID))
+	if err != nil {
+		return git.User{ID: authorID}
+	}
+	return *author
+}
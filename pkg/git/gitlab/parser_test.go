@@ -0,0 +1,121 @@
+package gitlab
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	"github.com/tmax-cloud/cicd-operator/pkg/git/fake"
+)
+
+func TestClient_parsePullRequestWebhook(t *testing.T) {
+	tc := map[string]struct {
+		body                 string
+		expectedAction       git.PullRequestAction
+		expectedLabels       []git.IssueLabel
+		expectedLabelChanged []git.IssueLabel
+		expectedDraft        bool
+	}{
+		"newCommit": {
+			body:           `{"object_attributes": {"action": "update", "oldrev": "1234"}, "labels": [{"title": "run-e2e"}], "changes": {"labels": {"previous": [], "current": [{"title": "run-e2e"}]}}}`,
+			expectedAction: git.PullRequestActionSynchronize,
+			expectedLabels: []git.IssueLabel{{Name: "run-e2e"}},
+		},
+		"labeled": {
+			body:                 `{"object_attributes": {"action": "update"}, "labels": [{"title": "kind/bug"}, {"title": "run-e2e"}], "changes": {"labels": {"previous": [{"title": "kind/bug"}], "current": [{"title": "kind/bug"}, {"title": "run-e2e"}]}}}`,
+			expectedAction:       git.PullRequestActionLabeled,
+			expectedLabels:       []git.IssueLabel{{Name: "kind/bug"}, {Name: "run-e2e"}},
+			expectedLabelChanged: []git.IssueLabel{{Name: "run-e2e"}},
+		},
+		"unlabeled": {
+			body:                 `{"object_attributes": {"action": "update"}, "labels": [], "changes": {"labels": {"previous": [{"title": "run-e2e"}], "current": []}}}`,
+			expectedAction:       git.PullRequestActionUnlabeled,
+			expectedLabelChanged: []git.IssueLabel{{Name: "run-e2e"}},
+		},
+		"readyForReview": {
+			body:           `{"object_attributes": {"action": "update", "draft": false}, "changes": {"draft": {"previous": true, "current": false}}}`,
+			expectedAction: git.PullRequestActionReadyForReview,
+		},
+		"draftOpened": {
+			body:           `{"object_attributes": {"action": "open", "work_in_progress": true}}`,
+			expectedAction: git.PullRequestActionOpen,
+			expectedDraft:  true,
+		},
+		"titleChanged": {
+			body:           `{"object_attributes": {"action": "update"}, "changes": {"title": {"previous": "a", "current": "b"}}}`,
+			expectedAction: git.PullRequestActionSynchronize,
+		},
+	}
+
+	c := &Client{}
+	for name, tc := range tc {
+		t.Run(name, func(t *testing.T) {
+			wh, err := c.parsePullRequestWebhook([]byte(tc.body))
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tc.expectedAction, wh.PullRequest.Action)
+			assert.Equal(t, tc.expectedLabels, wh.PullRequest.Labels)
+			assert.Equal(t, tc.expectedLabelChanged, wh.PullRequest.LabelChanged)
+			assert.Equal(t, tc.expectedDraft, wh.PullRequest.Draft)
+		})
+	}
+}
+
+func TestClient_parseReleaseWebhook(t *testing.T) {
+	c := &Client{}
+
+	wh, err := c.parseWebhook("Release Hook", []byte(`{"object_kind": "release", "action": "create", "tag": "v0.2.0-rc.1", "name": "v0.2.0 RC 1", "description": "notes", "url": "https://gitlab.com/tmax-cloud/cicd-operator/-/releases/v0.2.0-rc.1", "upcoming_release": true, "project": {"path_with_namespace": "tmax-cloud/cicd-operator", "web_url": "https://gitlab.com/tmax-cloud/cicd-operator"}, "commit": {"id": "6dcb09b5b57875f334f61aebed695e2e4193db5e"}}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, git.EventTypeRelease, wh.EventType)
+	assert.Equal(t, "tmax-cloud/cicd-operator", wh.Repo.Name)
+	assert.Equal(t, &git.Release{Tag: "v0.2.0-rc.1", Name: "v0.2.0 RC 1", Notes: "notes", Prerelease: true, Sha: "6dcb09b5b57875f334f61aebed695e2e4193db5e", URL: "https://gitlab.com/tmax-cloud/cicd-operator/-/releases/v0.2.0-rc.1"}, wh.Release)
+
+	// Only creation is handled
+	wh, err = c.parseWebhook("Release Hook", []byte(`{"object_kind": "release", "action": "update", "tag": "v0.2.0-rc.1"}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, (*git.Webhook)(nil), wh)
+}
+
+func TestClient_parseWebhookUserName(t *testing.T) {
+	srv := fake.NewGitLabServer(&fake.Repository{Name: "tmax-cloud/cicd-operator"}, "test-token")
+	defer srv.Close()
+	c := testClient(srv.URL)
+
+	// Display names can be set to anything, e.g., a trusted user's name. Users are identified by their usernames
+	wh, err := c.parseWebhook("Merge Request Hook", []byte(`{"user": {"id": 2, "name": "admin", "username": "mallory"}, "object_attributes": {"action": "open", "author_id": 2}}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, git.User{ID: 2, Name: "mallory"}, wh.PullRequest.Sender)
+	assert.Equal(t, git.User{ID: 2, Name: "mallory"}, wh.PullRequest.Author)
+
+	wh, err = c.parseWebhook("Note Hook", []byte(`{"user": {"id": 2, "name": "admin", "username": "mallory"}, "object_attributes": {"note": "/ok-to-test", "author_id": 2}}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, git.User{ID: 2, Name: "mallory"}, wh.IssueComment.Sender)
+
+	wh, err = c.parseWebhook("Push Hook", []byte(`{"ref": "refs/heads/master", "user_id": 2, "user_name": "admin", "user_username": "mallory", "after": "6dcb09b5b57875f334f61aebed695e2e4193db5e"}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "mallory", wh.Push.Sender.Name)
+}
+
+func TestClient_parseWebhookIID(t *testing.T) {
+	srv := fake.NewGitLabServer(&fake.Repository{Name: "tmax-cloud/cicd-operator"}, "test-token")
+	defer srv.Close()
+	c := testClient(srv.URL)
+
+	// Merge requests are identified by their IIDs, not the global IDs
+	wh, err := c.parseWebhook("Merge Request Hook", []byte(`{"object_attributes": {"action": "open", "id": 5003, "iid": 3}}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, wh.PullRequest.ID)
+
+	wh, err = c.parseWebhook("Note Hook", []byte(`{"object_attributes": {"note": "/ok-to-test"}, "merge_request": {"id": 5003, "iid": 3, "target_branch": "master"}}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, wh.IssueComment.Issue.PullRequest.ID)
+}
+
+func testClient(apiURL string) *Client {
+	return &Client{IntegrationConfig: &cicdv1.IntegrationConfig{Spec: cicdv1.IntegrationConfigSpec{Git: cicdv1.GitConfig{
+		Type:       cicdv1.GitTypeGitLab,
+		APIUrl:     apiURL,
+		Repository: "tmax-cloud/cicd-operator",
+		Token:      cicdv1.GitToken{Value: "test-token"},
+	}}}}
+}
@@ -5,17 +5,43 @@ type MergeRequestWebhook struct {
 	Kind            string `json:"kind"`
 	User            User   `json:"user"`
 	ObjectAttribute struct {
-		Title      string `json:"title"`
-		ID         int    `json:"id"`
+		Title string `json:"title"`
+		// IID is an ID of the merge request in the project, which is used by the merge request APIs
+		// ID is a global ID, which should not be used
+		IID        int    `json:"iid"`
 		BaseRef    string `json:"target_branch"`
 		HeadRef    string `json:"source_branch"`
 		LastCommit struct {
 			Sha string `json:"id"`
 		} `json:"last_commit"`
-		State  string `json:"state"`
-		Action string `json:"action"`
+		State    string `json:"state"`
+		Action   string `json:"action"`
+		OldRev   string `json:"oldrev"`
+		AuthorID int    `json:"author_id"`
+		Draft    bool   `json:"draft"`
+		WIP      bool   `json:"work_in_progress"`
 	} `json:"object_attributes"`
 	Project Project `json:"project"`
+	Labels  []Label `json:"labels"`
+	Changes struct {
+		Labels *struct {
+			Previous []Label `json:"previous"`
+			Current  []Label `json:"current"`
+		} `json:"labels"`
+		Draft *BoolChange `json:"draft"`
+		WIP   *BoolChange `json:"work_in_progress"`
+	} `json:"changes"`
+}
+
+// BoolChange is a change of a boolean field
+type BoolChange struct {
+	Previous bool `json:"previous"`
+	Current  bool `json:"current"`
+}
+
+// Label is a label of an issue or a merge request
+type Label struct {
+	Title string `json:"title"`
 }
 
 // PushWebhook is a gitlab-specific push event webhook body
@@ -23,9 +49,29 @@ type PushWebhook struct {
 	Kind     string  `json:"object_kind"`
 	Ref      string  `json:"ref"`
 	Project  Project `json:"project"`
-	UserName string  `json:"user_name"`
+	UserName string  `json:"user_username"`
 	UserID   int     `json:"user_id"`
+	Before   string  `json:"before"`
 	Sha      string  `json:"after"`
+	Commits  []struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	} `json:"commits"`
+}
+
+// ReleaseHook is a gitlab-specific release webhook body
+// GitLab does not report who created the release
+type ReleaseHook struct {
+	Action          string  `json:"action"`
+	Tag             string  `json:"tag"`
+	Name            string  `json:"name"`
+	Description     string  `json:"description"`
+	URL             string  `json:"url"`
+	UpcomingRelease bool    `json:"upcoming_release"`
+	Project         Project `json:"project"`
+	Commit          struct {
+		ID string `json:"id"`
+	} `json:"commit"`
 }
 
 // NoteHook is a gitlab-specific issue comment webhook body
@@ -39,7 +85,7 @@ type NoteHook struct {
 		UpdatedAt gitlabTime `json:"updated_at"`
 	} `json:"object_attributes"`
 	MergeRequest struct {
-		ID           int    `json:"id"`
+		IID          int    `json:"iid"`
 		Title        string `json:"title"`
 		State        string `json:"state"`
 		URL          string `json:"url"`
@@ -49,6 +95,9 @@ type NoteHook struct {
 		LastCommit   struct {
 			ID string `json:"id"`
 		} `json:"last_commit"`
+		Labels []Label `json:"labels"`
+		Draft  bool    `json:"draft"`
+		WIP    bool    `json:"work_in_progress"`
 	} `json:"merge_request"`
 }
 
@@ -59,32 +108,75 @@ type Project struct {
 }
 
 // User is a user who triggered merge request event
+// Name is a display name, which can be set to anything by the user. Use UserName to identify the user
 type User struct {
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	UserName string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// MergeRequestLabelsBody is a request body for adding or removing labels of a merge request
+type MergeRequestLabelsBody struct {
+	AddLabels    string `json:"add_labels,omitempty"`
+	RemoveLabels string `json:"remove_labels,omitempty"`
 }
 
 // RegistrationWebhookBody is a body for requesting webhook registration for the remote git server
 type RegistrationWebhookBody struct {
-	EnableSSLVerification   bool   `json:"enable_ssl_verification"`
-	ConfidentialIssueEvents bool   `json:"confidential_issues_events"`
-	ConfidentialNoteEvents  bool   `json:"confidential_note_events"`
-	DeploymentEvents        bool   `json:"deployment_events"`
-	ID                      string `json:"id"`
-	IssueEvents             bool   `json:"issues_events"`
-	JobEvents               bool   `json:"job_events"`
-	MergeRequestEvents      bool   `json:"merge_requests_events"`
-	NoteEvents              bool   `json:"note_events"`
-	PipeLineEvents          bool   `json:"pipeline_events"`
-	PushEvents              bool   `json:"push_events"`
-	TagPushEvents           bool   `json:"tag_push_events"`
-	WikiPageEvents          bool   `json:"wiki_page_events"`
-	URL                     string `json:"url"`
-	Token                   string `json:"token"`
+	WebhookEvents         `json:",inline"`
+	EnableSSLVerification bool   `json:"enable_ssl_verification"`
+	ID                    string `json:"id"`
+	URL                   string `json:"url"`
+	Token                 string `json:"token"`
+}
+
+// WebhookEvents are the events which a webhook is subscribed to
+type WebhookEvents struct {
+	ConfidentialIssueEvents bool `json:"confidential_issues_events"`
+	ConfidentialNoteEvents  bool `json:"confidential_note_events"`
+	DeploymentEvents        bool `json:"deployment_events"`
+	IssueEvents             bool `json:"issues_events"`
+	JobEvents               bool `json:"job_events"`
+	MergeRequestEvents      bool `json:"merge_requests_events"`
+	NoteEvents              bool `json:"note_events"`
+	PipeLineEvents          bool `json:"pipeline_events"`
+	PushEvents              bool `json:"push_events"`
+	ReleasesEvents          bool `json:"releases_events"`
+	TagPushEvents           bool `json:"tag_push_events"`
+	WikiPageEvents          bool `json:"wiki_page_events"`
+}
+
+// Enabled returns the names of the enabled events
+func (e *WebhookEvents) Enabled() []string {
+	events := []string{}
+	for _, ev := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"confidential_issues_events", e.ConfidentialIssueEvents},
+		{"confidential_note_events", e.ConfidentialNoteEvents},
+		{"deployment_events", e.DeploymentEvents},
+		{"issues_events", e.IssueEvents},
+		{"job_events", e.JobEvents},
+		{"merge_requests_events", e.MergeRequestEvents},
+		{"note_events", e.NoteEvents},
+		{"pipeline_events", e.PipeLineEvents},
+		{"push_events", e.PushEvents},
+		{"releases_events", e.ReleasesEvents},
+		{"tag_push_events", e.TagPushEvents},
+		{"wiki_page_events", e.WikiPageEvents},
+	} {
+		if ev.enabled {
+			events = append(events, ev.name)
+		}
+	}
+	return events
 }
 
 // WebhookEntry is a body of list of registered webhooks
 type WebhookEntry struct {
-	ID  int    `json:"id"`
-	URL string `json:"url"`
+	WebhookEvents `json:",inline"`
+	ID            int    `json:"id"`
+	URL           string `json:"url"`
 }
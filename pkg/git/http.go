@@ -2,14 +2,227 @@ package git
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Default values for HTTPClient
+const (
+	defaultHTTPTimeout  = 30 * time.Second
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = time.Second
+	defaultMaxRetryWait = 10 * time.Second
+
+	// maxPages is the maximum number of pages followed by RequestAll and RequestPages
+	maxPages = 100
+
+	// maxETagCacheEntries is the maximum number of responses cached by a HTTPClient
+	maxETagCacheEntries = 500
 )
 
-// RequestHTTP requests api call
+// HTTPError is an error response of the API server
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("error requesting api, code %d, msg %s", e.StatusCode, e.Body)
+}
+
+// IsNotFound reports if the error is a 404 response of the API server
+func IsNotFound(err error) bool {
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+}
+
+// RetryableError is an error of a request which can be retried after RetryAfter (e.g., network errors, 5xx responses
+// for idempotent requests and rate-limited requests)
+// It's returned if the retries in place are exhausted, or the request should wait longer than MaxRetryWait, so the
+// callers should retry them later (e.g., requeueing)
+type RetryableError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryableError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", e.Err.Error(), e.RetryAfter)
+}
+
+// Unwrap returns the error of the request
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter returns the duration to wait before retrying the request, if the error (or the error it wraps) is retryable
+func RetryAfter(err error) (time.Duration, bool) {
+	var retryable *RetryableError
+	if !errors.As(err, &retryable) {
+		return 0, false
+	}
+	return retryable.RetryAfter, true
+}
+
+// HTTPClient is a http client for the API servers of remote git servers
+// It retries failed requests with backoff, respects rate limits, follows pagination and caches responses using ETags
+type HTTPClient struct {
+	client *http.Client
+
+	// MaxRetries is the maximum number of retries in place for a request
+	MaxRetries int
+	// RetryBackoff is the initial backoff of the retries, doubled for every retry, if the API server does not tell it
+	RetryBackoff time.Duration
+	// MaxRetryWait is the maximum duration to wait in place before a retry. Requests which should wait longer
+	// (e.g., until the rate limit is reset) are not retried in place, but reported as RetryableError
+	MaxRetryWait time.Duration
+
+	lock sync.Mutex
+	// rateLimitResetAt is when the exhausted rate limits are reset, keyed by the host and the credential
+	rateLimitResetAt map[string]time.Time
+	etagCache        map[string]*etagCacheEntry
+}
+
+// etagCacheEntry is a cached response for the ETag
+type etagCacheEntry struct {
+	etag   string
+	body   []byte
+	header http.Header
+}
+
+// NewHTTPClient is a constructor of HTTPClient
+func NewHTTPClient(c *http.Client) *HTTPClient {
+	return &HTTPClient{
+		client:           c,
+		MaxRetries:       defaultMaxRetries,
+		RetryBackoff:     defaultRetryBackoff,
+		MaxRetryWait:     defaultMaxRetryWait,
+		rateLimitResetAt: map[string]time.Time{},
+		etagCache:        map[string]*etagCacheEntry{},
+	}
+}
+
+// defaultHTTPClient is used for the IntegrationConfigs without HTTP configurations
+var defaultHTTPClient = NewHTTPClient(&http.Client{Timeout: defaultHTTPTimeout})
+
+// httpClients caches HTTPClients for each IntegrationConfig, to reuse connections and ETag caches
+var httpClients = map[types.NamespacedName]*cachedHTTPClient{}
+var httpClientsLock sync.Mutex
+
+// cachedHTTPClient is a HTTPClient and the fingerprint of the configuration it's built from
+type cachedHTTPClient struct {
+	fingerprint string
+	client      *HTTPClient
+}
+
+// GetHTTPClient returns a HTTPClient for the IntegrationConfig, configured by its spec.git.http
+func GetHTTPClient(ic *cicdv1.IntegrationConfig, k8sClient client.Client) (*HTTPClient, error) {
+	cfg := ic.Spec.Git.HTTP
+	if cfg == nil {
+		EvictHTTPClient(types.NamespacedName{Name: ic.Name, Namespace: ic.Namespace})
+		return defaultHTTPClient, nil
+	}
+
+	// Get CA bundle
+	var caBundle []byte
+	if cfg.CABundle != nil {
+		cm := &corev1.ConfigMap{}
+		if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: cfg.CABundle.Name, Namespace: ic.Namespace}, cm); err != nil {
+			return nil, err
+		}
+		ca, exist := cm.Data[cfg.CABundle.Key]
+		if !exist && (cfg.CABundle.Optional == nil || !*cfg.CABundle.Optional) {
+			return nil, fmt.Errorf("ca bundle configmap/key %s/%s not valid", cfg.CABundle.Name, cfg.CABundle.Key)
+		}
+		caBundle = []byte(ca)
+	}
+
+	timeout := defaultHTTPTimeout
+	if cfg.Timeout != nil && cfg.Timeout.Duration > 0 {
+		timeout = cfg.Timeout.Duration
+	}
+
+	key := types.NamespacedName{Name: ic.Name, Namespace: ic.Namespace}
+	fingerprint := fmt.Sprintf("%t|%s|%s|%x", cfg.InsecureSkipVerify, cfg.ProxyURL, timeout, sha256.Sum256(caBundle))
+
+	httpClientsLock.Lock()
+	defer httpClientsLock.Unlock()
+
+	if cached, exist := httpClients[key]; exist && cached.fingerprint == fingerprint {
+		return cached.client, nil
+	}
+
+	transport, err := newTransport(cfg, caBundle)
+	if err != nil {
+		return nil, err
+	}
+	c := NewHTTPClient(&http.Client{Transport: transport, Timeout: timeout})
+	httpClients[key] = &cachedHTTPClient{fingerprint: fingerprint, client: c}
+
+	return c, nil
+}
+
+// EvictHTTPClient removes the cached HTTPClient of the IntegrationConfig (e.g., when it's deleted)
+func EvictHTTPClient(key types.NamespacedName) {
+	httpClientsLock.Lock()
+	defer httpClientsLock.Unlock()
+	delete(httpClients, key)
+}
+
+// newTransport generates a transport with the TLS/proxy configurations
+func newTransport(cfg *cicdv1.GitHTTPConfig, caBundle []byte) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if len(caBundle) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("ca bundle does not contain any valid PEM-encoded certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return transport, nil
+}
+
+// RequestHTTP requests api call, using the default HTTPClient
 func RequestHTTP(method string, uri string, header map[string]string, data interface{}) ([]byte, http.Header, error) {
+	return defaultHTTPClient.Request(method, uri, header, data)
+}
+
+// Request requests api call
+// Failed requests are retried in place with backoff, and GET requests are cached using ETags
+// Failed requests which can be retried later are reported as RetryableError
+func (c *HTTPClient) Request(method string, uri string, header map[string]string, data interface{}) ([]byte, http.Header, error) {
 	var jsonBytes []byte
 	var err error
 
@@ -20,19 +233,127 @@ func RequestHTTP(method string, uri string, header map[string]string, data inter
 		}
 	}
 
-	req, err := http.NewRequest(method, uri, bytes.NewBuffer(jsonBytes))
-	if err != nil {
-		return nil, nil, err
+	cacheKey := ""
+	if method == http.MethodGet {
+		cacheKey = etagCacheKey(uri, header)
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(method, uri, bytes.NewBuffer(jsonBytes))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for k, v := range header {
+			req.Header.Add(k, v)
+		}
+
+		// Requests are not sent until the rate limit of the credential is reset
+		limitKey := rateLimitKey(req.URL.Host, header)
+		if err := c.waitRateLimit(limitKey); err != nil {
+			return nil, nil, err
+		}
+
+		cached := c.getETagCache(cacheKey)
+		if cached != nil {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+
+		body, respHeader, statusCode, err := c.do(req, limitKey)
+		if err != nil {
+			// Only idempotent requests can be retried, as the request may have been processed
+			if !isIdempotent(method) {
+				return nil, nil, err
+			}
+			wait := c.backoff(attempt)
+			if attempt < c.MaxRetries {
+				time.Sleep(wait)
+				continue
+			}
+			return nil, nil, &RetryableError{Err: err, RetryAfter: wait}
+		}
+
+		if statusCode == http.StatusNotModified && cached != nil {
+			return cached.body, cached.header, nil
+		}
+
+		// Check additional response header
+		if statusCode < 200 || statusCode > 299 {
+			httpErr := &HTTPError{StatusCode: statusCode, Body: string(body)}
+			wait, retryable := c.retryAfter(method, statusCode, respHeader, attempt)
+			if !retryable {
+				return body, respHeader, httpErr
+			}
+			if attempt < c.MaxRetries && wait <= c.MaxRetryWait {
+				time.Sleep(wait)
+				continue
+			}
+			return body, respHeader, &RetryableError{Err: httpErr, RetryAfter: wait}
+		}
+
+		if etag := respHeader.Get("ETag"); cacheKey != "" && etag != "" {
+			c.setETagCache(cacheKey, &etagCacheEntry{etag: etag, body: body, header: respHeader})
+		}
+
+		return body, respHeader, nil
 	}
+}
+
+// RequestAll requests api call, following the pages by Link header or X-Next-Page header
+// Response of each page should be a JSON array, and they are merged into a single JSON array
+func (c *HTTPClient) RequestAll(method string, uri string, header map[string]string, data interface{}) ([]byte, http.Header, error) {
+	return c.RequestPages(method, uri, header, data, func(current string, body []byte, header http.Header) ([]json.RawMessage, string, error) {
+		var items []json.RawMessage
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, "", err
+		}
+		next, err := nextPageURL(current, header)
+		return items, next, err
+	})
+}
+
+// PageFunc parses a page of the response, for the git servers paginating the responses in their own ways
+// It returns the items of the page and the next page's url, which is empty if it's the last page
+type PageFunc func(current string, body []byte, header http.Header) ([]json.RawMessage, string, error)
+
+// RequestPages requests api call, following the pages by the PageFunc
+// Items of the pages are merged into a single JSON array
+func (c *HTTPClient) RequestPages(method string, uri string, header map[string]string, data interface{}, pageFunc PageFunc) ([]byte, http.Header, error) {
+	items := []json.RawMessage{}
+	var respHeader http.Header
+
+	next := uri
+	for page := 0; next != ""; page++ {
+		if page >= maxPages {
+			return nil, nil, fmt.Errorf("too many pages for %s", uri)
+		}
 
-	for k, v := range header {
-		req.Header.Add(k, v)
+		body, h, err := c.Request(method, next, header, data)
+		if err != nil {
+			return body, h, err
+		}
+		respHeader = h
+
+		var pageItems []json.RawMessage
+		pageItems, next, err = pageFunc(next, body, h)
+		if err != nil {
+			return nil, nil, err
+		}
+		items = append(items, pageItems...)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	merged, err := json.Marshal(items)
 	if err != nil {
 		return nil, nil, err
 	}
+	return merged, respHeader, nil
+}
+
+func (c *HTTPClient) do(req *http.Request, limitKey string) ([]byte, http.Header, int, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, 0, err
+	}
 
 	defer func() {
 		_ = resp.Body.Close()
@@ -40,14 +361,233 @@ func RequestHTTP(method string, uri string, header map[string]string, data inter
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, 0, err
 	}
 
-	// Check additional response header
-	var newErr error
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		newErr = fmt.Errorf("error requesting api, code %d, msg %s", resp.StatusCode, string(body))
+	c.updateRateLimit(limitKey, resp.Header)
+
+	return body, resp.Header, resp.StatusCode, nil
+}
+
+// retryAfter decides if the failed request can be retried, and how long to wait before retrying
+// Retry-After header is honored, otherwise it waits for the backoff of the attempt
+func (c *HTTPClient) retryAfter(method string, statusCode int, header http.Header, attempt int) (time.Duration, bool) {
+	// Rate limited requests are not processed, so they can be retried regardless of the method
+	if statusCode == http.StatusTooManyRequests || (statusCode == http.StatusForbidden && isRateLimited(header)) {
+		if retryAfter, ok := parseRetryAfter(header); ok {
+			return retryAfter, true
+		}
+		if resetAt, ok := parseRateLimitReset(header); ok {
+			return time.Until(resetAt), true
+		}
+		return c.backoff(attempt), true
+	}
+
+	// Server errors
+	if isIdempotent(method) && (statusCode == http.StatusInternalServerError || statusCode == http.StatusBadGateway ||
+		statusCode == http.StatusServiceUnavailable || statusCode == http.StatusGatewayTimeout) {
+		if retryAfter, ok := parseRetryAfter(header); ok {
+			return retryAfter, true
+		}
+		return c.backoff(attempt), true
+	}
+
+	return 0, false
+}
+
+func (c *HTTPClient) backoff(attempt int) time.Duration {
+	return c.RetryBackoff * time.Duration(1<<uint(attempt))
+}
+
+// waitRateLimit waits until the rate limit of the key is reset, if it's exhausted
+// A RetryableError is returned without waiting, if it's reset later than MaxRetryWait
+func (c *HTTPClient) waitRateLimit(key string) error {
+	c.lock.Lock()
+	resetAt := c.rateLimitResetAt[key]
+	c.lock.Unlock()
+
+	wait := time.Until(resetAt)
+	if wait <= 0 {
+		return nil
+	}
+	if wait > c.MaxRetryWait {
+		return &RetryableError{Err: fmt.Errorf("rate limit is exceeded, it is reset at %s", resetAt.Format(time.RFC3339)), RetryAfter: wait}
 	}
+	time.Sleep(wait)
+	return nil
+}
 
-	return body, resp.Header, newErr
+// updateRateLimit remembers when the rate limit of the key is reset, if it's exhausted
+// The rate limits already reset are forgotten
+func (c *HTTPClient) updateRateLimit(key string, header http.Header) {
+	if !isRateLimitExhausted(header) {
+		return
+	}
+	resetAt, ok := parseRateLimitReset(header)
+	if !ok {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for k, t := range c.rateLimitResetAt {
+		if time.Now().After(t) {
+			delete(c.rateLimitResetAt, k)
+		}
+	}
+	c.rateLimitResetAt[key] = resetAt
+}
+
+// rateLimitKey generates a key of the rate limit for the request, as the rate limits are applied for each credential
+// Credential headers are hashed, not to keep the credentials in the memory
+func rateLimitKey(host string, header map[string]string) string {
+	h := sha256.New()
+	_, _ = h.Write([]byte(host))
+	for _, k := range []string{"Authorization", "PRIVATE-TOKEN"} {
+		_, _ = h.Write([]byte("\n" + k + ":" + header[k]))
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func (c *HTTPClient) getETagCache(key string) *etagCacheEntry {
+	if key == "" {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.etagCache[key]
+}
+
+func (c *HTTPClient) setETagCache(key string, entry *etagCacheEntry) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// Evict an arbitrary entry if the cache is full
+	if _, exist := c.etagCache[key]; !exist && len(c.etagCache) >= maxETagCacheEntries {
+		for k := range c.etagCache {
+			delete(c.etagCache, k)
+			break
+		}
+	}
+	c.etagCache[key] = entry
+}
+
+// etagCacheKey generates a cache key of the request
+// Request headers are included, so that the responses for different credentials are not shared
+func etagCacheKey(uri string, header map[string]string) string {
+	var keys []string
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	_, _ = h.Write([]byte(uri))
+	for _, k := range keys {
+		_, _ = h.Write([]byte("\n" + k + ":" + header[k]))
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// SetQuery returns the url whose query parameter is set to the value, for following the pages by query parameters
+func SetQuery(uri, key, value string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set(key, value)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// nextPageURL returns the next page's url from the Link header (rel="next") or X-Next-Page header
+// Empty string is returned if it's the last page
+func nextPageURL(current string, header http.Header) (string, error) {
+	if link := header.Get("Link"); link != "" {
+		for _, l := range strings.Split(link, ",") {
+			parts := strings.Split(l, ";")
+			if len(parts) < 2 {
+				continue
+			}
+			isNext := false
+			for _, p := range parts[1:] {
+				if strings.TrimSpace(p) == `rel="next"` {
+					isNext = true
+				}
+			}
+			if !isNext {
+				continue
+			}
+			target := strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			base, err := url.Parse(current)
+			if err != nil {
+				return "", err
+			}
+			ref, err := url.Parse(target)
+			if err != nil {
+				return "", err
+			}
+			return base.ResolveReference(ref).String(), nil
+		}
+		return "", nil
+	}
+
+	if nextPage := header.Get("X-Next-Page"); nextPage != "" {
+		u, err := url.Parse(current)
+		if err != nil {
+			return "", err
+		}
+		q := u.Query()
+		q.Set("page", nextPage)
+		u.RawQuery = q.Encode()
+		return u.String(), nil
+	}
+
+	return "", nil
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// isRateLimited checks if the response is rejected due to the rate limit
+func isRateLimited(header http.Header) bool {
+	return isRateLimitExhausted(header) || header.Get("Retry-After") != ""
+}
+
+// isRateLimitExhausted checks X-RateLimit-Remaining (github, gitea) or RateLimit-Remaining (gitlab) header
+func isRateLimitExhausted(header http.Header) bool {
+	return header.Get("X-RateLimit-Remaining") == "0" || header.Get("RateLimit-Remaining") == "0"
+}
+
+// parseRateLimitReset parses X-RateLimit-Reset (github, gitea) or RateLimit-Reset (gitlab) header, in unix epoch seconds
+func parseRateLimitReset(header http.Header) (time.Time, bool) {
+	for _, key := range []string{"X-RateLimit-Reset", "RateLimit-Reset"} {
+		if v := header.Get(key); v != "" {
+			epoch, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				continue
+			}
+			return time.Unix(epoch, 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseRetryAfter parses Retry-After header, either in seconds or in HTTP date
+func parseRetryAfter(header http.Header) (time.Duration, bool) {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
 }
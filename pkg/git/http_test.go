@@ -0,0 +1,301 @@
+package git
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testHTTPClient() *HTTPClient {
+	return NewHTTPClient(&http.Client{Timeout: 5 * time.Second})
+}
+
+func TestHTTPClient_RequestAll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/link":
+			// Link header (github, gitea)
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if page < 2 {
+				w.Header().Set("Link", fmt.Sprintf(`</link?page=%d>; rel="next", </link?page=2>; rel="last"`, page+1))
+			}
+			_, _ = fmt.Fprintf(w, `[{"page": %d}]`, page)
+		case "/next-page":
+			// X-Next-Page header (gitlab)
+			page := r.URL.Query().Get("page")
+			if page == "" {
+				w.Header().Set("X-Next-Page", "2")
+			}
+			_, _ = fmt.Fprintf(w, `[{"page": "%s"}]`, page)
+		}
+	}))
+	defer srv.Close()
+
+	c := testHTTPClient()
+
+	data, _, err := c.RequestAll(http.MethodGet, srv.URL+"/link", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `[{"page":0},{"page":1},{"page":2}]`, string(data))
+
+	data, _, err = c.RequestAll(http.MethodGet, srv.URL+"/next-page?per_page=100", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `[{"page":""},{"page":"2"}]`, string(data))
+}
+
+func TestHTTPClient_RequestPages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Paged by start query parameter, with the next start in the body (bitbucket server)
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		_, _ = fmt.Fprintf(w, `{"values": [{"start": %d}], "isLastPage": %t, "nextPageStart": %d}`, start, start >= 2, start+1)
+	}))
+	defer srv.Close()
+
+	c := testHTTPClient()
+
+	data, _, err := c.RequestPages(http.MethodGet, srv.URL+"/paged?limit=1", nil, nil, func(current string, body []byte, _ http.Header) ([]json.RawMessage, string, error) {
+		page := &struct {
+			Values        []json.RawMessage `json:"values"`
+			IsLastPage    bool              `json:"isLastPage"`
+			NextPageStart int               `json:"nextPageStart"`
+		}{}
+		if err := json.Unmarshal(body, page); err != nil {
+			return nil, "", err
+		}
+		if page.IsLastPage {
+			return page.Values, "", nil
+		}
+		next, err := SetQuery(current, "start", strconv.Itoa(page.NextPageStart))
+		return page.Values, next, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `[{"start":0},{"start":1},{"start":2}]`, string(data))
+}
+
+func TestHTTPClient_RequestRetryable(t *testing.T) {
+	count := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		switch r.URL.Path {
+		case "/ok":
+			_, _ = w.Write([]byte("ok"))
+		case "/flaky":
+			// Succeeds on the third attempt
+			if count < 3 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte("ok"))
+		case "/rate-limited":
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/rate-limit-exhausted":
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+		case "/not-found":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	c := testHTTPClient()
+	c.RetryBackoff = time.Millisecond
+
+	// Server errors are retried in place, and reported as retryable after the retries are exhausted
+	_, _, err := c.Request(http.MethodGet, srv.URL+"/fail", nil, nil)
+	retryAfter, retryable := RetryAfter(err)
+	assert.Equal(t, true, retryable)
+	assert.Equal(t, 8*time.Millisecond, retryAfter)
+	assert.Equal(t, 4, count)
+
+	// Wrapped errors are also retryable
+	_, retryable = RetryAfter(fmt.Errorf("cannot list branches: %w", err))
+	assert.Equal(t, true, retryable)
+
+	// Retry-After is honored
+	count = 0
+	data, _, err := c.Request(http.MethodGet, srv.URL+"/flaky", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "ok", string(data))
+	assert.Equal(t, 3, count)
+
+	// Non-idempotent requests are not retried for server errors
+	count = 0
+	_, _, err = c.Request(http.MethodPost, srv.URL+"/fail", nil, nil)
+	assert.NotEqual(t, nil, err)
+	_, retryable = RetryAfter(err)
+	assert.Equal(t, false, retryable)
+	assert.Equal(t, 1, count)
+
+	// Client errors are not retried
+	count = 0
+	_, _, err = c.Request(http.MethodGet, srv.URL+"/not-found", nil, nil)
+	_, retryable = RetryAfter(err)
+	assert.Equal(t, false, retryable)
+	assert.Equal(t, true, IsNotFound(err))
+	assert.Equal(t, 1, count)
+
+	// Rate limited requests waiting longer than MaxRetryWait are not retried in place
+	count = 0
+	_, _, err = c.Request(http.MethodPost, srv.URL+"/rate-limited", nil, nil)
+	retryAfter, retryable = RetryAfter(err)
+	assert.Equal(t, true, retryable)
+	assert.Equal(t, 30*time.Second, retryAfter)
+	assert.Equal(t, 1, count)
+
+	// Rate limited requests are retryable after the rate limit is reset
+	count = 0
+	tokenA := map[string]string{"Authorization": "token a"}
+	_, _, err = c.Request(http.MethodGet, srv.URL+"/rate-limit-exhausted", tokenA, nil)
+	retryAfter, retryable = RetryAfter(err)
+	assert.Equal(t, true, retryable)
+	assert.Equal(t, true, retryAfter > 59*time.Minute)
+	assert.Equal(t, 1, count)
+
+	// Further requests of the credential are rejected until the rate limit is reset
+	count = 0
+	_, _, err = c.Request(http.MethodGet, srv.URL+"/ok", tokenA, nil)
+	retryAfter, retryable = RetryAfter(err)
+	assert.Equal(t, true, retryable)
+	assert.Equal(t, true, retryAfter > 59*time.Minute)
+	assert.Equal(t, 0, count)
+
+	// Requests of the other credentials are not affected
+	data, _, err = c.Request(http.MethodGet, srv.URL+"/ok", map[string]string{"Authorization": "token b"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "ok", string(data))
+	assert.Equal(t, 1, count)
+}
+
+func TestHTTPClient_RequestETag(t *testing.T) {
+	count := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"version": 1}`))
+	}))
+	defer srv.Close()
+
+	c := testHTTPClient()
+
+	for i := 0; i < 2; i++ {
+		data, _, err := c.Request(http.MethodGet, srv.URL, map[string]string{"Authorization": "token a"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, `{"version": 1}`, string(data))
+	}
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 1, len(c.etagCache))
+
+	// Responses are not shared between credentials
+	_, _, err := c.Request(http.MethodGet, srv.URL, map[string]string{"Authorization": "token b"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(c.etagCache))
+}
+
+func TestGetHTTPClient(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	ic := &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Git: cicdv1.GitConfig{APIUrl: srv.URL},
+		},
+	}
+
+	// Default client
+	c, err := GetHTTPClient(ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, defaultHTTPClient, c)
+
+	// Insecure skip verify
+	ic.Spec.Git.HTTP = &cicdv1.GitHTTPConfig{InsecureSkipVerify: true}
+	c, err = GetHTTPClient(ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _, err := c.Request(http.MethodGet, srv.URL, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "ok", string(data))
+
+	// Cached client is reused
+	cached, err := GetHTTPClient(ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, c, cached)
+
+	// Evicted client is not reused
+	EvictHTTPClient(types.NamespacedName{Name: "test-ic", Namespace: "default"})
+	evicted, err := GetHTTPClient(ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, true, c != evicted)
+
+	// CA bundle
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "git-ca", Namespace: "default"},
+		Data:       map[string]string{"ca.crt": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))},
+	}
+	fakeCli := fake.NewFakeClientWithScheme(scheme.Scheme, cm)
+	ic.Spec.Git.HTTP = &cicdv1.GitHTTPConfig{
+		CABundle: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "git-ca"}, Key: "ca.crt"},
+		Timeout:  &metav1.Duration{Duration: 10 * time.Second},
+	}
+	c, err = GetHTTPClient(ic, fakeCli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 10*time.Second, c.client.Timeout)
+	data, _, err = c.Request(http.MethodGet, srv.URL, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "ok", string(data))
+
+	// Invalid CA bundle key
+	ic.Spec.Git.HTTP.CABundle.Key = "not-exist"
+	_, err = GetHTTPClient(ic, fakeCli)
+	assert.NotEqual(t, nil, err)
+}
@@ -18,6 +18,14 @@ const (
 	EventTypeIssueComment             = EventType("issue_comment")
 	EventTypePullRequestReview        = EventType("pull_request_review")
 	EventTypePullRequestReviewComment = EventType("pull_request_review_comment")
+	EventTypeRelease                  = EventType("release")
+)
+
+// Webhook token is a webhook secret carried by the webhook url, for the git servers which can neither sign the payloads
+// nor set headers (e.g., gerrit). The webhook server passes the token to the git clients by WebhookTokenHeader header
+const (
+	WebhookTokenQuery  = "token"
+	WebhookTokenHeader = "X-Cicd-Webhook-Token"
 )
 
 // Pull Request states
@@ -32,6 +40,10 @@ const (
 	PullRequestActionOpen        = PullRequestAction("opened")
 	PullRequestActionClose       = PullRequestAction("closed")
 	PullRequestActionSynchronize = PullRequestAction("synchronize")
+	PullRequestActionLabeled     = PullRequestAction("labeled")
+	PullRequestActionUnlabeled   = PullRequestAction("unlabeled")
+	// PullRequestActionReadyForReview is an action of a draft pull request being marked as ready for review
+	PullRequestActionReadyForReview = PullRequestAction("ready_for_review")
 )
 
 // Webhook is a common structure for git webhooks
@@ -40,16 +52,73 @@ type Webhook struct {
 	EventType EventType
 	Repo      Repository
 
+	// DeliveryID is a unique ID of the webhook delivery, which is kept the same for the redeliveries
+	// Empty if the git server does not send it
+	DeliveryID string
+
 	Push         *Push
 	PullRequest  *PullRequest
 	IssueComment *IssueComment
+	Release      *Release
+
+	// Pushes are all the refs updated by a push event, for the git servers which update multiple refs by an event
+	// (e.g., bitbucket, bitbucket server, azure devops). Push is the first of them. Nil if only a ref is updated
+	Pushes []Push
+}
+
+// Split splits the webhook of a push event updating multiple refs into the webhooks of each ref, so that each ref is
+// handled as a separate push event
+func (w *Webhook) Split() []*Webhook {
+	if w.EventType != EventTypePush || len(w.Pushes) < 2 {
+		return []*Webhook{w}
+	}
+	var result []*Webhook
+	for i := range w.Pushes {
+		wh := *w
+		push := w.Pushes[i]
+		wh.Push = &push
+		wh.Pushes = nil
+		result = append(result, &wh)
+	}
+	return result
+}
+
+// NewPushWebhook returns a webhook of a push event, updating the refs of the pushes. Nil is returned if no ref is updated
+func NewPushWebhook(repo Repository, pushes []Push) *Webhook {
+	if len(pushes) == 0 {
+		return nil
+	}
+	wh := &Webhook{EventType: EventTypePush, Repo: repo, Push: &pushes[0]}
+	if len(pushes) > 1 {
+		wh.Pushes = pushes
+	}
+	return wh
 }
 
 // Push is a common structure for push events
 type Push struct {
 	Sender User
 	Ref    string
+	// Before is the sha of the ref before the push, empty or all-zero if the ref is newly created
+	Before string
 	Sha    string
+	// Message is a message of the head commit. Empty if the git server does not send it
+	Message string
+}
+
+// Release is a common structure for release events
+type Release struct {
+	Sender User
+	// Tag is the name of the tag the release is created for
+	Tag  string
+	Name string
+	// Notes is a description of the release
+	Notes string
+	// Prerelease reports if the release is a pre-release (GitHub) or an upcoming release (GitLab)
+	Prerelease bool
+	// Sha is the sha of the tagged commit
+	Sha string
+	URL string
 }
 
 // PullRequest is a common structure for pull request events
@@ -59,9 +128,25 @@ type PullRequest struct {
 	State  PullRequestState
 	Action PullRequestAction
 	Sender User
+	// Author is who opened the pull request, which may differ from the Sender of the event
+	// Empty if it's unknown, then the Sender is regarded as the author
+	Author User
 	URL    string
 	Base   Base
 	Head   Head
+
+	// Draft reports if the pull request is a draft (or a work in progress)
+	Draft bool
+
+	// Labels are the labels of the pull request
+	Labels []IssueLabel
+	// LabelChanged are the labels added or removed by the labeled/unlabeled action
+	LabelChanged []IssueLabel
+}
+
+// IssueLabel is a label of an issue or a pull request
+type IssueLabel struct {
+	Name string
 }
 
 // IssueComment is a common structure for issue comment
@@ -87,6 +172,9 @@ type Issue struct {
 type Repository struct {
 	Name string
 	URL  string
+
+	// CloneURL is set only if the repository cannot be cloned from <server url>/<name>
+	CloneURL string
 }
 
 // User is who triggered the event
@@ -109,6 +197,17 @@ type Head struct {
 
 // WebhookEntry is a body of registered webhook list
 type WebhookEntry struct {
-	ID  int
+	// ID is an identifier of the webhook, passed to DeleteWebhook
+	// It is a string for all the git servers, as some of them identify webhooks by UUIDs (bitbucket), not by numbers
+	ID  string
 	URL string
+
+	// Events are the events the webhook is subscribed to. Nil if the git server does not report them
+	Events []string
+	// ContentType is a content type of the payloads. Empty if the git server does not report it
+	ContentType string
+	// SecretConfigured reports if a secret is configured for the webhook. Nil if the git server does not report it
+	SecretConfigured *bool
+	// Active reports if the webhook is active. Nil if the git server does not report it
+	Active *bool
 }
@@ -0,0 +1,221 @@
+package periodic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/internal/utils"
+	"github.com/tmax-cloud/cicd-operator/pkg/dispatcher"
+	"gopkg.in/robfig/cron.v2"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var log = logf.Log.WithName("periodic-trigger")
+
+// syncPeriod is a period of syncing the cron entries with the periodic jobs of the IntegrationConfigs
+const syncPeriod = 10 * time.Second
+
+// Trigger is an interface of periodic trigger
+type Trigger interface {
+	Start(stopCh <-chan struct{}) error
+	NeedLeaderElection() bool
+}
+
+// trigger creates IntegrationJobs for the periodic jobs of the IntegrationConfigs, on their cron schedules
+type trigger struct {
+	client client.Client
+
+	cron      *cron.Cron
+	schedules map[types.NamespacedName]*schedule
+}
+
+// schedule is a set of the cron entries registered for an IntegrationConfig
+type schedule struct {
+	// signature identifies the (cron, branch) pairs of the periodic jobs
+	signature string
+	entries   []cron.EntryID
+}
+
+// target is a (cron, branch) pair of the periodic jobs, which run together in an IntegrationJob
+type target struct {
+	cron   string
+	branch string
+}
+
+// New is a constructor of trigger
+func New(c client.Client) *trigger {
+	return &trigger{
+		client:    c,
+		cron:      cron.New(),
+		schedules: map[types.NamespacedName]*schedule{},
+	}
+}
+
+// Start starts the trigger, until the stop channel is closed
+func (t *trigger) Start(stopCh <-chan struct{}) error {
+	log.Info("Starting periodic trigger")
+	t.cron.Start()
+	defer t.cron.Stop()
+
+	ticker := time.NewTicker(syncPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := t.sync(); err != nil {
+				if _, ok := err.(*cache.ErrCacheNotStarted); !ok {
+					log.Error(err, "")
+				}
+			}
+		case <-stopCh:
+			return nil
+		}
+	}
+}
+
+// NeedLeaderElection returns true, as the periodic jobs should be created only once even if there are multiple replicas
+func (t *trigger) NeedLeaderElection() bool {
+	return true
+}
+
+// sync registers/removes the cron entries, as the periodic jobs of the IntegrationConfigs are changed
+func (t *trigger) sync() error {
+	cfgList := &cicdv1.IntegrationConfigList{}
+	if err := t.client.List(context.Background(), cfgList); err != nil {
+		return err
+	}
+
+	scheduled := map[types.NamespacedName]bool{}
+	for i := range cfgList.Items {
+		cfg := &cfgList.Items[i]
+		if len(cfg.Spec.Jobs.Periodic) == 0 || cfg.DeletionTimestamp != nil {
+			continue
+		}
+		key := types.NamespacedName{Name: cfg.Name, Namespace: cfg.Namespace}
+		scheduled[key] = true
+
+		targets := getTargets(cfg.Spec.Jobs.Periodic)
+		signature := getSignature(targets)
+		if s, exist := t.schedules[key]; exist {
+			if s.signature == signature {
+				continue
+			}
+			t.unschedule(key)
+		}
+
+		s := &schedule{signature: signature}
+		for _, tg := range targets {
+			key, tg := key, tg
+			id, err := t.cron.AddFunc(tg.cron, func() { t.run(key, tg) })
+			if err != nil {
+				log.Error(err, fmt.Sprintf("cannot schedule periodic jobs of %s/%s for %s (cron: %s)", cfg.Namespace, cfg.Name, tg.branch, tg.cron))
+				continue
+			}
+			s.entries = append(s.entries, id)
+		}
+		t.schedules[key] = s
+		log.Info(fmt.Sprintf("Scheduled %d periodic target(s) of %s/%s", len(s.entries), cfg.Namespace, cfg.Name))
+	}
+
+	// Remove the entries of deleted IntegrationConfigs, or the ones without periodic jobs
+	for key := range t.schedules {
+		if !scheduled[key] {
+			t.unschedule(key)
+		}
+	}
+
+	return nil
+}
+
+func (t *trigger) unschedule(key types.NamespacedName) {
+	s, exist := t.schedules[key]
+	if !exist {
+		return
+	}
+	for _, id := range s.entries {
+		t.cron.Remove(id)
+	}
+	delete(t.schedules, key)
+}
+
+// run creates an IntegrationJob of the periodic jobs of the target, for the head commit of the target branch
+func (t *trigger) run(key types.NamespacedName, tg target) {
+	if err := t.createJob(key, tg); err != nil {
+		log.Error(err, fmt.Sprintf("cannot run periodic jobs of %s/%s for %s", key.Namespace, key.Name, tg.branch))
+	}
+}
+
+func (t *trigger) createJob(key types.NamespacedName, tg target) error {
+	cfg := &cicdv1.IntegrationConfig{}
+	if err := t.client.Get(context.Background(), key, cfg); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	var jobs []cicdv1.Job
+	for _, job := range cfg.Spec.Jobs.Periodic {
+		if job.Cron == tg.cron && job.Branch == tg.branch {
+			jobs = append(jobs, job.Job)
+		}
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	gitCli, err := utils.GetGitCli(cfg, t.client)
+	if err != nil {
+		return err
+	}
+	branch, err := gitCli.GetBranch(tg.branch)
+	if err != nil {
+		return err
+	}
+	if branch == nil {
+		return fmt.Errorf("branch %s does not exist", tg.branch)
+	}
+	sha := branch.Sha
+	repo, err := gitCli.GetRepository()
+	if err != nil {
+		return err
+	}
+
+	job := dispatcher.GeneratePeriodic(tg.branch, sha, jobs, repo, cfg)
+	if job == nil {
+		return nil
+	}
+	dispatcher.IdentifyRandomly(job, cfg)
+	log.Info(fmt.Sprintf("Creating periodic IntegrationJob %s/%s for %s (%s)", job.Namespace, job.Name, tg.branch, sha))
+	return t.client.Create(context.Background(), job)
+}
+
+// getTargets returns the distinct (cron, branch) pairs of the periodic jobs, in the order of the jobs
+func getTargets(jobs cicdv1.PeriodicJobs) []target {
+	var targets []target
+	exist := map[target]bool{}
+	for _, job := range jobs {
+		tg := target{cron: job.Cron, branch: job.Branch}
+		if exist[tg] {
+			continue
+		}
+		exist[tg] = true
+		targets = append(targets, tg)
+	}
+	return targets
+}
+
+func getSignature(targets []target) string {
+	var parts []string
+	for _, tg := range targets {
+		parts = append(parts, tg.cron+"@"+tg.branch)
+	}
+	return strings.Join(parts, ",")
+}
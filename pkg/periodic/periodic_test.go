@@ -0,0 +1,125 @@
+package periodic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	gitfake "github.com/tmax-cloud/cicd-operator/pkg/git/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testMasterSha = "0123456789012345678901234567890123456789"
+
+func testConfig(apiURL string) *cicdv1.IntegrationConfig {
+	return &cicdv1.IntegrationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ic", Namespace: "default"},
+		Spec: cicdv1.IntegrationConfigSpec{
+			Git: cicdv1.GitConfig{
+				Type:       cicdv1.GitTypeGitHub,
+				Repository: "tmax-cloud/cicd-operator",
+				APIUrl:     apiURL,
+				Token:      cicdv1.GitToken{Value: "test-token"},
+			},
+			Jobs: cicdv1.IntegrationConfigJobs{
+				Periodic: cicdv1.PeriodicJobs{
+					{Job: cicdv1.Job{Container: corev1.Container{Name: "nightly-test"}}, Cron: "0 0 * * *", Branch: "master"},
+					{Job: cicdv1.Job{Container: corev1.Container{Name: "nightly-e2e"}, After: []string{"nightly-test"}}, Cron: "0 0 * * *", Branch: "master"},
+					{Job: cicdv1.Job{Container: corev1.Container{Name: "dependency-scan"}, After: []string{"nightly-test"}}, Cron: "@weekly", Branch: "master"},
+				},
+			},
+		},
+	}
+}
+
+func TestTrigger_sync(t *testing.T) {
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+
+	cfg := testConfig("http://localhost")
+	cli := fake.NewFakeClientWithScheme(s, cfg)
+	tr := New(cli)
+	key := types.NamespacedName{Name: cfg.Name, Namespace: cfg.Namespace}
+
+	// Register
+	assert.Equal(t, nil, tr.sync())
+	assert.Equal(t, 2, len(tr.schedules[key].entries))
+	assert.Equal(t, 2, len(tr.cron.Entries()))
+	entries := tr.schedules[key].entries
+
+	// Not changed
+	assert.Equal(t, nil, tr.sync())
+	assert.Equal(t, entries, tr.schedules[key].entries)
+
+	// Changed, with an invalid cron spec
+	original := cfg.DeepCopy()
+	cfg.Spec.Jobs.Periodic[2].Cron = "invalid"
+	assert.Equal(t, nil, cli.Patch(context.Background(), cfg, client.MergeFrom(original)))
+	assert.Equal(t, nil, tr.sync())
+	assert.Equal(t, 1, len(tr.schedules[key].entries))
+	assert.Equal(t, 1, len(tr.cron.Entries()))
+
+	// Deleted
+	assert.Equal(t, nil, cli.Delete(context.Background(), cfg))
+	assert.Equal(t, nil, tr.sync())
+	assert.Equal(t, 0, len(tr.schedules))
+	assert.Equal(t, 0, len(tr.cron.Entries()))
+}
+
+func TestTrigger_createJob(t *testing.T) {
+	srv := gitfake.NewGitHubServer(&gitfake.Repository{
+		Name:     "tmax-cloud/cicd-operator",
+		Branches: []git.Ref{{Name: "master", Sha: testMasterSha}},
+	}, "test-token")
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	utilruntime.Must(cicdv1.AddToScheme(s))
+
+	cfg := testConfig(srv.URL)
+	cli := fake.NewFakeClientWithScheme(s, cfg)
+	tr := New(cli)
+	key := types.NamespacedName{Name: cfg.Name, Namespace: cfg.Namespace}
+
+	assert.Equal(t, nil, tr.createJob(key, target{cron: "0 0 * * *", branch: "master"}))
+	assert.Equal(t, nil, tr.createJob(key, target{cron: "@weekly", branch: "master"}))
+	assert.T(t, tr.createJob(key, target{cron: "@weekly", branch: "not-exist"}) == nil) // No jobs for the target
+	assert.T(t, tr.createJob(types.NamespacedName{Name: "not-exist", Namespace: "default"}, target{cron: "@weekly", branch: "master"}) == nil)
+
+	ijList := &cicdv1.IntegrationJobList{}
+	assert.Equal(t, nil, cli.List(context.Background(), ijList))
+	assert.Equal(t, 2, len(ijList.Items))
+
+	jobs := map[string]*cicdv1.IntegrationJob{}
+	for i := range ijList.Items {
+		jobs[ijList.Items[i].Spec.Jobs[0].Name] = &ijList.Items[i]
+	}
+
+	nightly := jobs["nightly-test"]
+	assert.Equal(t, cicdv1.JobTypePeriodic, nightly.Spec.ConfigRef.Type)
+	assert.Equal(t, string(cicdv1.JobTypePeriodic), nightly.Labels[cicdv1.JobLabelType])
+	assert.Equal(t, "refs/heads/master", nightly.Spec.Refs.Base.Ref)
+	assert.Equal(t, testMasterSha, nightly.Spec.Refs.Base.Sha)
+	assert.T(t, nightly.Spec.Refs.Pull == nil)
+	assert.Equal(t, 2, len(nightly.Spec.Jobs))
+	assert.Equal(t, []string{"nightly-test"}, nightly.Spec.Jobs[1].After)
+
+	// After jobs in the other targets are ignored
+	scan := jobs["dependency-scan"]
+	assert.Equal(t, 1, len(scan.Spec.Jobs))
+	assert.T(t, scan.Spec.Jobs[0].After == nil)
+
+	// Branch not found
+	original := cfg.DeepCopy()
+	cfg.Spec.Jobs.Periodic[2].Branch = "not-exist"
+	assert.Equal(t, nil, cli.Patch(context.Background(), cfg, client.MergeFrom(original)))
+	assert.T(t, tr.createJob(key, target{cron: "@weekly", branch: "not-exist"}) != nil)
+}
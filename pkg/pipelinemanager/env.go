@@ -6,6 +6,8 @@ import (
 	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
 	corev1 "k8s.io/api/core/v1"
 	"net/url"
+	"strconv"
+	"strings"
 )
 
 func fillDefaultEnvs(tasks []tektonv1beta1.PipelineTask, job *cicdv1.IntegrationJob) error {
@@ -34,6 +36,11 @@ func generateDefaultEnvs(job *cicdv1.IntegrationJob) ([]corev1.EnvVar, error) {
 	if err != nil {
 		return nil, err
 	}
+	serverURL := fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+	repositoryURL := jobSpec.Refs.CloneURL
+	if repositoryURL == "" {
+		repositoryURL = fmt.Sprintf("%s/%s", serverURL, jobSpec.Refs.Repository)
+	}
 	defaultEnvs := []corev1.EnvVar{
 		{Name: "CI", Value: "true"},
 		{Name: "CI_CONFIG_NAME", Value: jobSpec.ConfigRef.Name},
@@ -41,15 +48,17 @@ func generateDefaultEnvs(job *cicdv1.IntegrationJob) ([]corev1.EnvVar, error) {
 		{Name: "CI_REPOSITORY", Value: jobSpec.Refs.Repository},
 		{Name: "CI_EVENT_TYPE", Value: string(jobSpec.ConfigRef.Type)},
 		{Name: "CI_WORKSPACE", Value: DefaultWorkingDir},
-		{Name: "CI_SERVER_URL", Value: fmt.Sprintf("%s://%s", u.Scheme, u.Host)},
+		{Name: "CI_SERVER_URL", Value: serverURL},
+		{Name: "CI_REPOSITORY_URL", Value: repositoryURL},
 	}
 
 	refs := jobSpec.Refs
 	if refs.Pull == nil {
 		// Push event
-		defaultEnvs = append(defaultEnvs, corev1.EnvVar{
-			Name: "CI_HEAD_REF", Value: refs.Base.Ref,
-		})
+		defaultEnvs = append(defaultEnvs, []corev1.EnvVar{
+			{Name: "CI_HEAD_SHA", Value: refs.Base.Sha},
+			{Name: "CI_HEAD_REF", Value: refs.Base.Ref},
+		}...)
 	} else {
 		// Pull Request event
 		defaultEnvs = append(defaultEnvs, []corev1.EnvVar{
@@ -59,5 +68,22 @@ func generateDefaultEnvs(job *cicdv1.IntegrationJob) ([]corev1.EnvVar, error) {
 		}...)
 	}
 
+	// Tag push or release event
+	if strings.HasPrefix(refs.Base.Ref, "refs/tags/") {
+		defaultEnvs = append(defaultEnvs, corev1.EnvVar{Name: "CI_TAG", Value: strings.TrimPrefix(refs.Base.Ref, "refs/tags/")})
+	}
+	if refs.Release != nil {
+		defaultEnvs = append(defaultEnvs, []corev1.EnvVar{
+			{Name: "CI_RELEASE_NAME", Value: refs.Release.Name},
+			{Name: "CI_RELEASE_PRERELEASE", Value: strconv.FormatBool(refs.Release.Prerelease)},
+			{Name: "CI_RELEASE_URL", Value: refs.Release.Link},
+		}...)
+	}
+
+	// Parameters of the manually-run IntegrationJob
+	for _, p := range jobSpec.Parameters {
+		defaultEnvs = append(defaultEnvs, corev1.EnvVar{Name: p.Name, Value: p.Value})
+	}
+
 	return defaultEnvs, nil
 }
@@ -120,6 +120,10 @@ func getSpecFromStatus(jobStatus *cicdv1.JobStatus, t cicdv1.JobType, cfg *cicdv
 		jobs = cfg.Spec.Jobs.PreSubmit
 	case cicdv1.JobTypePostSubmit:
 		jobs = cfg.Spec.Jobs.PostSubmit
+	case cicdv1.JobTypePeriodic:
+		jobs = cfg.Spec.Jobs.Periodic.GetJobs()
+	case cicdv1.JobTypeRelease:
+		jobs = cfg.Spec.Jobs.Release
 	default:
 		return nil
 	}
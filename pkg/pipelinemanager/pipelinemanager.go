@@ -365,9 +365,18 @@ func (p *PipelineManager) updateGitCommitStatus(cfg *cicdv1.IntegrationConfig, j
 		return err
 	}
 
+	// Use check runs if possible
+	checkRunCli, ok := gitCli.(git.CheckRunClient)
+	useCheckRun := ok && checkRunCli.CheckRunEnabled()
+
 	// If state is changed, update git commit status
 	for i, j := range job.Status.Jobs {
-		if stateChanged[i] {
+		if stateChanged[i] && useCheckRun {
+			log.Info(fmt.Sprintf("Setting check run %s to %s", j.State, cfg.Spec.Git.Repository))
+			if err := checkRunCli.SetCheckRun(job, &job.Status.Jobs[i], job.GetReportServerAddress(j.Name)); err != nil {
+				log.Error(err, "")
+			}
+		} else if stateChanged[i] {
 			msg := j.Message
 			if len(msg) > 140 {
 				msg = msg[:139]
@@ -24,7 +24,7 @@ set -e
 git config --global user.email "bot@cicd.tmax.io"
 git config --global user.name "tmax-cicd-bot"
 
-CHECKOUT_URL="$CI_SERVER_URL/$CI_REPOSITORY"
+CHECKOUT_URL="$CI_REPOSITORY_URL"
 if [ "$CI_BASE_REF" = "" ]; then
     CHECKOUT_REF="$CI_HEAD_REF"
     CHECKOUT_SHA="$CI_HEAD_SHA"
@@ -35,6 +35,9 @@ fi
 git init
 git fetch "$CHECKOUT_URL" "$CHECKOUT_REF"
 git checkout FETCH_HEAD
+if [ "$CI_BASE_REF" = "" ] && [ "$CHECKOUT_SHA" != "" ]; then
+    git checkout "$CHECKOUT_SHA"
+fi
 if [ "$CI_BASE_REF" != "" ]; then
     git fetch "$CHECKOUT_URL" "$CI_HEAD_REF"
     git merge --no-ff "$CI_HEAD_SHA"
@@ -0,0 +1,359 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	cicdv1 "github.com/tmax-cloud/cicd-operator/api/v1"
+	"github.com/tmax-cloud/cicd-operator/internal/utils"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var log = logf.Log.WithName("poller")
+
+const (
+	// checkPeriod is a period of checking if IntegrationConfigs need to be polled
+	checkPeriod = 10 * time.Second
+
+	// pollSender is a sender of the polled events, as the users who pushed the commits cannot be known by polling
+	pollSender = "cicd-operator"
+)
+
+// HandleFunc handles the events detected by polling
+type HandleFunc func(*git.Webhook, *cicdv1.IntegrationConfig) []error
+
+// Poller is an interface of poller
+type Poller interface {
+	Start(stopCh <-chan struct{}) error
+	NeedLeaderElection() bool
+}
+
+// poller polls the remote git servers of the IntegrationConfigs whose trigger is poll
+// It detects new commits of branches, tags and open pull requests and handles them as if they are webhook events
+// The last polled states are stored in the status of the IntegrationConfigs, so they survive the restarts of the operator
+type poller struct {
+	client client.Client
+	handle HandleFunc
+}
+
+// repoState is a last polled state of a repository
+type repoState struct {
+	polledAt time.Time
+
+	branches     map[string]string
+	tags         map[string]string
+	pullRequests map[int]git.PullRequest
+}
+
+// New is a constructor of poller
+func New(c client.Client, handle HandleFunc) *poller {
+	return &poller{
+		client: c,
+		handle: handle,
+	}
+}
+
+// Start starts the poller, until the stop channel is closed
+func (p *poller) Start(stopCh <-chan struct{}) error {
+	log.Info("Starting poller")
+	ticker := time.NewTicker(checkPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.pollAll(); err != nil {
+				log.Error(err, "")
+			}
+		case <-stopCh:
+			return nil
+		}
+	}
+}
+
+// NeedLeaderElection returns true, as the events should be handled only once even if there are multiple replicas
+func (p *poller) NeedLeaderElection() bool {
+	return true
+}
+
+// pollAll polls the IntegrationConfigs whose poll interval is elapsed
+func (p *poller) pollAll() error {
+	cfgList := &cicdv1.IntegrationConfigList{}
+	if err := p.client.List(context.Background(), cfgList); err != nil {
+		return err
+	}
+
+	for i := range cfgList.Items {
+		cfg := &cfgList.Items[i]
+		if !cfg.Spec.Git.IsPolling() || cfg.DeletionTimestamp != nil {
+			continue
+		}
+
+		state := cfg.Status.PollState
+		if state != nil && time.Since(state.PolledAt.Time) < cfg.Spec.Git.GetPollInterval() {
+			continue
+		}
+		if err := p.poll(cfg); err != nil {
+			log.Error(err, fmt.Sprintf("cannot poll %s/%s", cfg.Namespace, cfg.Name))
+		}
+	}
+
+	return nil
+}
+
+// poll polls a remote git server, handles the changes since the last poll and stores the new state
+// The first poll is only for building the baseline state, so no events are handled
+// The state is stored after the events are handled, so the events are handled again if the operator stops in between
+// The refs and the pull requests whose events failed to be handled are not advanced, so they are handled again on the next poll
+func (p *poller) poll(cfg *cicdv1.IntegrationConfig) error {
+	gitCli, err := utils.GetGitCli(cfg, p.client)
+	if err != nil {
+		return err
+	}
+
+	newState, err := getRepoState(gitCli)
+	if err != nil {
+		return err
+	}
+
+	var failed []*git.Webhook
+	if cfg.Status.PollState != nil {
+		oldState := stateFromStatus(cfg.Status.PollState)
+		failed, err = p.handleEvents(gitCli, cfg, diffStates(oldState, newState))
+		if err != nil {
+			return err
+		}
+		newState = keepFailed(oldState, newState, failed)
+	}
+
+	original := cfg.DeepCopy()
+	cfg.Status.PollState = newState.toStatus()
+	if err := p.client.Status().Patch(context.Background(), cfg, client.MergeFrom(original)); err != nil {
+		return err
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d polled event(s) failed to be handled, they are handled again on the next poll", len(failed))
+	}
+	return nil
+}
+
+// handleEvents handles the events, and returns the events which failed to be handled by any plugin
+func (p *poller) handleEvents(gitCli git.Client, cfg *cicdv1.IntegrationConfig, events []*git.Webhook) ([]*git.Webhook, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	repo, err := gitCli.GetRepository()
+	if err != nil {
+		return nil, err
+	}
+	var failed []*git.Webhook
+	for _, ev := range events {
+		ev.Repo = *repo
+		log.Info(fmt.Sprintf("Handling polled %s event for %s/%s", ev.EventType, cfg.Namespace, cfg.Name))
+		errs := p.handle(ev, cfg)
+		for _, err := range errs {
+			log.Error(err, "")
+		}
+		if len(errs) > 0 {
+			failed = append(failed, ev)
+		}
+	}
+	return failed, nil
+}
+
+func getRepoState(gitCli git.Client) (*repoState, error) {
+	branches, err := gitCli.ListBranches()
+	if err != nil {
+		return nil, err
+	}
+	tags, err := gitCli.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	prs, err := gitCli.ListOpenPullRequests()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &repoState{
+		polledAt:     time.Now(),
+		branches:     map[string]string{},
+		tags:         map[string]string{},
+		pullRequests: map[int]git.PullRequest{},
+	}
+	for _, b := range branches {
+		state.branches[b.Name] = b.Sha
+	}
+	for _, t := range tags {
+		state.tags[t.Name] = t.Sha
+	}
+	for _, pr := range prs {
+		state.pullRequests[pr.ID] = pr
+	}
+	return state, nil
+}
+
+// keepFailed returns the new state, whose refs and pull requests of the failed events are kept as the old state
+func keepFailed(oldState, newState *repoState, failed []*git.Webhook) *repoState {
+	state := &repoState{
+		polledAt:     newState.polledAt,
+		branches:     map[string]string{},
+		tags:         map[string]string{},
+		pullRequests: map[int]git.PullRequest{},
+	}
+	for name, sha := range newState.branches {
+		state.branches[name] = sha
+	}
+	for name, sha := range newState.tags {
+		state.tags[name] = sha
+	}
+	for id, pr := range newState.pullRequests {
+		state.pullRequests[id] = pr
+	}
+
+	for _, ev := range failed {
+		switch {
+		case ev.Push != nil && strings.HasPrefix(ev.Push.Ref, "refs/heads/"):
+			keepRef(oldState.branches, state.branches, strings.TrimPrefix(ev.Push.Ref, "refs/heads/"))
+		case ev.Push != nil && strings.HasPrefix(ev.Push.Ref, "refs/tags/"):
+			keepRef(oldState.tags, state.tags, strings.TrimPrefix(ev.Push.Ref, "refs/tags/"))
+		case ev.PullRequest != nil:
+			if pr, exist := oldState.pullRequests[ev.PullRequest.ID]; exist {
+				state.pullRequests[pr.ID] = pr
+			} else {
+				delete(state.pullRequests, ev.PullRequest.ID)
+			}
+		}
+	}
+	return state
+}
+
+func keepRef(oldRefs, refs map[string]string, name string) {
+	if sha, exist := oldRefs[name]; exist {
+		refs[name] = sha
+	} else {
+		delete(refs, name)
+	}
+}
+
+// stateFromStatus restores the repoState stored in the IntegrationConfig status
+func stateFromStatus(status *cicdv1.PollState) *repoState {
+	state := &repoState{
+		polledAt:     status.PolledAt.Time,
+		branches:     map[string]string{},
+		tags:         map[string]string{},
+		pullRequests: map[int]git.PullRequest{},
+	}
+	for name, sha := range status.Branches {
+		state.branches[name] = sha
+	}
+	for name, sha := range status.Tags {
+		state.tags[name] = sha
+	}
+	for _, pr := range status.PullRequests {
+		state.pullRequests[pr.ID] = git.PullRequest{
+			ID:    pr.ID,
+			State: git.PullRequestStateOpen,
+			Head:  git.Head{Ref: pr.Head, Sha: pr.Sha},
+			Base:  git.Base{Ref: pr.Base},
+		}
+	}
+	return state
+}
+
+// toStatus converts the repoState to be stored in the IntegrationConfig status
+// Only the fields required for detecting the changes are stored
+func (s *repoState) toStatus() *cicdv1.PollState {
+	status := &cicdv1.PollState{
+		PolledAt: metav1.NewTime(s.polledAt),
+		Branches: map[string]string{},
+		Tags:     map[string]string{},
+	}
+	for name, sha := range s.branches {
+		status.Branches[name] = sha
+	}
+	for name, sha := range s.tags {
+		status.Tags[name] = sha
+	}
+	var ids []int
+	for id := range s.pullRequests {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		pr := s.pullRequests[id]
+		status.PullRequests = append(status.PullRequests, cicdv1.PolledPullRequest{ID: pr.ID, Head: pr.Head.Ref, Base: pr.Base.Ref, Sha: pr.Head.Sha})
+	}
+	return status
+}
+
+// diffStates generates events from the difference between the states
+// Repo field of the events is not set
+func diffStates(oldState, newState *repoState) []*git.Webhook {
+	var events []*git.Webhook
+
+	// Push events for new/updated branches and tags
+	events = append(events, diffRefs(oldState.branches, newState.branches, "refs/heads/")...)
+	events = append(events, diffRefs(oldState.tags, newState.tags, "refs/tags/")...)
+
+	// Pull request events for opened/updated pull requests
+	var ids []int
+	for id := range newState.pullRequests {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		pr := newState.pullRequests[id]
+		old, exist := oldState.pullRequests[id]
+		switch {
+		case !exist:
+			pr.Action = git.PullRequestActionOpen
+		case old.Head.Sha != pr.Head.Sha:
+			pr.Action = git.PullRequestActionSynchronize
+		default:
+			continue
+		}
+		events = append(events, &git.Webhook{EventType: git.EventTypePullRequest, PullRequest: &pr})
+	}
+
+	// Pull request events for closed pull requests
+	ids = nil
+	for id := range oldState.pullRequests {
+		if _, exist := newState.pullRequests[id]; !exist {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		pr := oldState.pullRequests[id]
+		pr.State = git.PullRequestStateClosed
+		pr.Action = git.PullRequestActionClose
+		pr.Sender = git.User{Name: pollSender}
+		events = append(events, &git.Webhook{EventType: git.EventTypePullRequest, PullRequest: &pr})
+	}
+
+	return events
+}
+
+func diffRefs(oldRefs, newRefs map[string]string, prefix string) []*git.Webhook {
+	var names []string
+	for name, sha := range newRefs {
+		if oldRefs[name] != sha {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var events []*git.Webhook
+	for _, name := range names {
+		events = append(events, &git.Webhook{EventType: git.EventTypePush, Push: &git.Push{Sender: git.User{Name: pollSender}, Ref: prefix + name, Before: oldRefs[name], Sha: newRefs[name]}})
+	}
+	return events
+}
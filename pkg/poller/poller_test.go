@@ -0,0 +1,123 @@
+package poller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
+)
+
+func TestDiffStates(t *testing.T) {
+	oldState := &repoState{
+		branches: map[string]string{"master": "aaa", "feat": "bbb"},
+		tags:     map[string]string{"v0.1.0": "ccc"},
+		pullRequests: map[int]git.PullRequest{
+			1: {ID: 1, Head: git.Head{Ref: "feat", Sha: "bbb"}},
+			2: {ID: 2, Head: git.Head{Ref: "feat2", Sha: "ddd"}},
+			3: {ID: 3, Head: git.Head{Ref: "feat3", Sha: "eee"}},
+		},
+	}
+	newState := &repoState{
+		branches: map[string]string{"master": "fff", "feat": "bbb", "new-feat": "ggg"},
+		tags:     map[string]string{"v0.1.0": "ccc", "v0.2.0": "fff"},
+		pullRequests: map[int]git.PullRequest{
+			1: {ID: 1, Head: git.Head{Ref: "feat", Sha: "bbb"}},
+			2: {ID: 2, Head: git.Head{Ref: "feat2", Sha: "hhh"}},
+			4: {ID: 4, Head: git.Head{Ref: "new-feat", Sha: "ggg"}},
+		},
+	}
+
+	events := diffStates(oldState, newState)
+	assert.Equal(t, 6, len(events))
+
+	// Branches
+	assert.Equal(t, git.EventTypePush, events[0].EventType)
+	assert.Equal(t, "refs/heads/master", events[0].Push.Ref)
+	assert.Equal(t, "aaa", events[0].Push.Before)
+	assert.Equal(t, "fff", events[0].Push.Sha)
+	assert.Equal(t, pollSender, events[0].Push.Sender.Name)
+	assert.Equal(t, "refs/heads/new-feat", events[1].Push.Ref)
+
+	// Tags
+	assert.Equal(t, "refs/tags/v0.2.0", events[2].Push.Ref)
+
+	// Pull requests
+	assert.Equal(t, git.EventTypePullRequest, events[3].EventType)
+	assert.Equal(t, 2, events[3].PullRequest.ID)
+	assert.Equal(t, git.PullRequestActionSynchronize, events[3].PullRequest.Action)
+	assert.Equal(t, 4, events[4].PullRequest.ID)
+	assert.Equal(t, git.PullRequestActionOpen, events[4].PullRequest.Action)
+	assert.Equal(t, 3, events[5].PullRequest.ID)
+	assert.Equal(t, git.PullRequestActionClose, events[5].PullRequest.Action)
+	assert.Equal(t, git.PullRequestStateClosed, events[5].PullRequest.State)
+	assert.Equal(t, pollSender, events[5].PullRequest.Sender.Name)
+
+	// No changes
+	assert.Equal(t, 0, len(diffStates(newState, newState)))
+}
+
+func TestStateFromStatus(t *testing.T) {
+	state := &repoState{
+		polledAt: time.Now().Truncate(time.Second),
+		branches: map[string]string{"master": "aaa"},
+		tags:     map[string]string{"v0.1.0": "bbb"},
+		pullRequests: map[int]git.PullRequest{
+			2: {ID: 2, Title: "feat2", Head: git.Head{Ref: "feat2", Sha: "ccc"}, Base: git.Base{Ref: "master"}},
+			1: {ID: 1, Title: "feat", Head: git.Head{Ref: "feat", Sha: "ddd"}, Base: git.Base{Ref: "master"}},
+		},
+	}
+
+	status := state.toStatus()
+	assert.Equal(t, 2, len(status.PullRequests))
+	assert.Equal(t, 1, status.PullRequests[0].ID)
+	assert.Equal(t, "ddd", status.PullRequests[0].Sha)
+
+	restored := stateFromStatus(status)
+	assert.Equal(t, state.polledAt.Unix(), restored.polledAt.Unix())
+	assert.Equal(t, state.branches, restored.branches)
+	assert.Equal(t, state.tags, restored.tags)
+	assert.Equal(t, "feat2", restored.pullRequests[2].Head.Ref)
+	assert.Equal(t, "master", restored.pullRequests[2].Base.Ref)
+
+	// The restored state is compared with the next poll without any changes
+	assert.Equal(t, 0, len(diffStates(restored, state)))
+}
+
+func TestKeepFailed(t *testing.T) {
+	oldState := &repoState{
+		branches: map[string]string{"master": "aaa"},
+		tags:     map[string]string{},
+		pullRequests: map[int]git.PullRequest{
+			1: {ID: 1, Head: git.Head{Ref: "feat", Sha: "bbb"}},
+			2: {ID: 2, Head: git.Head{Ref: "feat2", Sha: "ccc"}},
+		},
+	}
+	newState := &repoState{
+		branches: map[string]string{"master": "ddd", "new-feat": "eee"},
+		tags:     map[string]string{"v0.1.0": "ddd"},
+		pullRequests: map[int]git.PullRequest{
+			1: {ID: 1, Head: git.Head{Ref: "feat", Sha: "fff"}},
+			3: {ID: 3, Head: git.Head{Ref: "new-feat", Sha: "eee"}},
+		},
+	}
+	events := diffStates(oldState, newState)
+
+	// Every event except the push of new-feat and the open of #3 failed
+	var failed []*git.Webhook
+	for _, ev := range events {
+		if (ev.Push != nil && ev.Push.Ref == "refs/heads/new-feat") || (ev.PullRequest != nil && ev.PullRequest.ID == 3) {
+			continue
+		}
+		failed = append(failed, ev)
+	}
+	state := keepFailed(oldState, newState, failed)
+	assert.Equal(t, map[string]string{"master": "aaa", "new-feat": "eee"}, state.branches)
+	assert.Equal(t, map[string]string{}, state.tags)
+	assert.Equal(t, "bbb", state.pullRequests[1].Head.Sha)
+	assert.Equal(t, "ccc", state.pullRequests[2].Head.Sha)
+	assert.Equal(t, "eee", state.pullRequests[3].Head.Sha)
+
+	// Failed events are detected again on the next poll
+	assert.Equal(t, len(failed), len(diffStates(state, newState)))
+}
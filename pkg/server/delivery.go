@@ -0,0 +1,85 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// deliveryTTL is how long a webhook delivery is remembered, to drop its replays
+	deliveryTTL = 1 * time.Hour
+	// maxDeliveries is the maximum number of the remembered deliveries. The oldest ones are forgotten first
+	maxDeliveries = 10000
+)
+
+// deliveryStore remembers the webhook deliveries for a while, to drop the retried or duplicated ones
+type deliveryStore struct {
+	lock sync.Mutex
+
+	ttl     time.Duration
+	maxSize int
+
+	// expiry is the expiry time of each delivery
+	expiry map[string]time.Time
+	// queue is the deliveries in the order they are stored. As the ttl is the same, it's also the order of expiry
+	queue []string
+
+	now func() time.Time
+}
+
+func newDeliveryStore(ttl time.Duration, maxSize int) *deliveryStore {
+	return &deliveryStore{
+		ttl:     ttl,
+		maxSize: maxSize,
+		expiry:  map[string]time.Time{},
+		now:     time.Now,
+	}
+}
+
+// seen reports if the delivery is already stored and not expired. If not, the delivery is stored
+func (s *deliveryStore) seen(id string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	s.evictExpired(now)
+
+	if _, exist := s.expiry[id]; exist {
+		return true
+	}
+	for len(s.queue) >= s.maxSize {
+		s.evictOldest()
+	}
+	s.expiry[id] = now.Add(s.ttl)
+	s.queue = append(s.queue, id)
+	return false
+}
+
+// forget forgets the delivery, so that its replays are handled again (e.g., when handling it failed)
+func (s *deliveryStore) forget(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, exist := s.expiry[id]; !exist {
+		return
+	}
+	delete(s.expiry, id)
+	for i, q := range s.queue {
+		if q == id {
+			s.queue = append(s.queue[:i:i], s.queue[i+1:]...)
+			break
+		}
+	}
+}
+
+// evictExpired forgets the expired deliveries
+func (s *deliveryStore) evictExpired(now time.Time) {
+	for len(s.queue) > 0 && !now.Before(s.expiry[s.queue[0]]) {
+		s.evictOldest()
+	}
+}
+
+func (s *deliveryStore) evictOldest() {
+	delete(s.expiry, s.queue[0])
+	s.queue = s.queue[1:]
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestDeliveryStore_seen(t *testing.T) {
+	now := time.Now()
+	s := newDeliveryStore(time.Hour, 2)
+	s.now = func() time.Time { return now }
+
+	assert.Equal(t, false, s.seen("a"))
+	assert.Equal(t, true, s.seen("a"))
+
+	// Expiry
+	now = now.Add(30 * time.Minute)
+	assert.Equal(t, false, s.seen("b"))
+	now = now.Add(30 * time.Minute)
+	assert.Equal(t, false, s.seen("a"))
+	assert.Equal(t, true, s.seen("b"))
+
+	// Maximum size
+	assert.Equal(t, false, s.seen("c"))
+	assert.Equal(t, 2, len(s.queue))
+	assert.Equal(t, false, s.seen("b"))
+	assert.Equal(t, true, s.seen("c"))
+}
+
+func TestDeliveryStore_forget(t *testing.T) {
+	s := newDeliveryStore(time.Hour, 2)
+
+	assert.Equal(t, false, s.seen("a"))
+	assert.Equal(t, false, s.seen("b"))
+	s.forget("a")
+	assert.Equal(t, 1, len(s.queue))
+
+	// Forgotten delivery is handled again
+	assert.Equal(t, false, s.seen("a"))
+	assert.Equal(t, true, s.seen("a"))
+	assert.Equal(t, true, s.seen("b"))
+	assert.Equal(t, []string{"b", "a"}, s.queue)
+
+	// Unknown delivery
+	s.forget("c")
+	assert.Equal(t, 2, len(s.queue))
+}
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"path"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/tmax-cloud/cicd-operator/internal/utils"
+	"github.com/tmax-cloud/cicd-operator/pkg/git"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -18,6 +21,9 @@ var webhookPath = fmt.Sprintf("/webhook/{%s}/{%s}", paramKeyNamespace, paramKeyC
 
 type webhookHandler struct {
 	k8sClient client.Client
+
+	// deliveries are the recent webhook deliveries, to drop the replays of them
+	deliveries *deliveryStore
 }
 
 func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -56,8 +62,18 @@ func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Pass the webhook token of the url to the git client. Tokens set by the request header are not trusted
+	r.Header.Set(git.WebhookTokenHeader, r.URL.Query().Get(git.WebhookTokenQuery))
+
 	// Convert webhook
 	wh, err := gitCli.ParseWebhook(r.Header, body)
+	if retryAfter, retryable := git.RetryAfter(err); retryable {
+		// Let the git server redeliver it later, if it supports
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+		_ = utils.RespondError(w, http.StatusServiceUnavailable, fmt.Sprintf("req: %s, cannot parse webhook body, retry later", reqID))
+		log.Info("Cannot parse webhook", "error", err.Error())
+		return
+	}
 	if err != nil {
 		_ = utils.RespondError(w, http.StatusInternalServerError, fmt.Sprintf("req: %s, cannot parse webhook body", reqID))
 		log.Info("Cannot parse webhook", "error", err.Error())
@@ -68,11 +84,33 @@ func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Call plugin functions
-	plugins := getPlugins(wh.EventType)
-	for _, p := range plugins {
-		if err := p.Handle(wh, config); err != nil {
-			log.Error(err, "")
+	// Drop the replays (retries of the git server or duplicates by proxies) of a delivery
+	// The delivery is stored before it's handled, to drop the concurrent duplicates as well
+	deliveryKey := path.Join(ns, configName, wh.DeliveryID)
+	if wh.DeliveryID != "" && h.deliveries.seen(deliveryKey) {
+		log.Info("Dropping a replayed webhook delivery", "delivery", wh.DeliveryID)
+		return
+	}
+
+	// Call plugin functions, for each ref if multiple refs are pushed
+	var errs []error
+	for _, split := range wh.Split() {
+		errs = append(errs, HandleEvent(split, config)...)
+	}
+	retryable := false
+	for _, err := range errs {
+		log.Error(err, "")
+		if _, ok := git.RetryAfter(err); ok {
+			retryable = true
+		}
+	}
+
+	// Forget the delivery failed by transient errors, so that the retries of the git server are handled
+	// Other failures are only logged, as they would fail again
+	if retryable {
+		if wh.DeliveryID != "" {
+			h.deliveries.forget(deliveryKey)
 		}
+		_ = utils.RespondError(w, http.StatusInternalServerError, fmt.Sprintf("req: %s, cannot handle webhook", reqID))
 	}
 }
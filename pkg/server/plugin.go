@@ -13,10 +13,12 @@ type Plugin interface {
 var plugins = map[git.EventType][]Plugin{}
 
 // AddPlugin adds handler for specific events
+// Webhooks are subscribed only to the events having plugins
 func AddPlugin(events []git.EventType, p Plugin) {
 	for _, ev := range events {
 		addPlugin(ev, p)
 	}
+	git.AddConsumedEvents(events...)
 }
 
 func addPlugin(ev git.EventType, p Plugin) {
@@ -30,3 +32,15 @@ func addPlugin(ev git.EventType, p Plugin) {
 func getPlugins(ev git.EventType) []Plugin {
 	return plugins[ev]
 }
+
+// HandleEvent calls the plugins registered for the event
+// It is also used for the events which are not from webhooks (e.g., polled events)
+func HandleEvent(wh *git.Webhook, config *cicdv1.IntegrationConfig) []error {
+	var errs []error
+	for _, p := range getPlugins(wh.EventType) {
+		if err := p.Handle(wh, config); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
@@ -49,7 +49,7 @@ func New(c client.Client, cfg *rest.Config) *server {
 	}
 
 	// Add webhook handler
-	r.Methods(http.MethodPost).Subrouter().Handle(webhookPath, &webhookHandler{k8sClient: c})
+	r.Methods(http.MethodPost).Subrouter().Handle(webhookPath, &webhookHandler{k8sClient: c, deliveries: newDeliveryStore(deliveryTTL, maxDeliveries)})
 
 	// Add report handler
 	r.Methods(http.MethodGet).Subrouter().Handle(reportPath, &reportHandler{k8sClient: c, clientSet: clientSet})